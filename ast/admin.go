@@ -0,0 +1,438 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/kyleconroy/sqlparse/auth"
+	"github.com/kyleconroy/sqlparse/format"
+	"github.com/kyleconroy/sqlparse/model"
+)
+
+var (
+	_ StmtNode          = &InstallPluginStmt{}
+	_ StmtNode          = &UninstallPluginStmt{}
+	_ StmtNode          = &InstallComponentStmt{}
+	_ StmtNode          = &UninstallComponentStmt{}
+	_ SensitiveStmtNode = &CloneStmt{}
+	_ StmtNode          = &HelpStmt{}
+
+	_ DDLNode = &CreateResourceGroupStmt{}
+	_ DDLNode = &AlterResourceGroupStmt{}
+	_ DDLNode = &DropResourceGroupStmt{}
+)
+
+// InstallPluginStmt is a statement to load a server plugin.
+// See https://dev.mysql.com/doc/refman/8.0/en/install-plugin.html
+type InstallPluginStmt struct {
+	stmtNode
+
+	Name string
+	// Library is the shared library the plugin is in.
+	Library string
+}
+
+// Restore implements Node interface.
+func (n *InstallPluginStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("INSTALL PLUGIN ")
+	ctx.WriteName(n.Name)
+	ctx.WriteKeyWord(" SONAME ")
+	ctx.WriteString(n.Library)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *InstallPluginStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*InstallPluginStmt)
+	return v.Leave(n)
+}
+
+// UninstallPluginStmt is a statement to remove a server plugin.
+// See https://dev.mysql.com/doc/refman/8.0/en/uninstall-plugin.html
+type UninstallPluginStmt struct {
+	stmtNode
+
+	Name string
+}
+
+// Restore implements Node interface.
+func (n *UninstallPluginStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("UNINSTALL PLUGIN ")
+	ctx.WriteName(n.Name)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *UninstallPluginStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*UninstallPluginStmt)
+	return v.Leave(n)
+}
+
+func restoreComponents(ctx *format.RestoreCtx, components []string) {
+	for i, component := range components {
+		if i > 0 {
+			ctx.WritePlain(", ")
+		}
+		ctx.WriteString(component)
+	}
+}
+
+// InstallComponentStmt is a statement to load server components.
+// See https://dev.mysql.com/doc/refman/8.0/en/install-component.html
+type InstallComponentStmt struct {
+	stmtNode
+
+	// Components are the URNs of the components, like
+	// 'file://component_validate_password'.
+	Components []string
+}
+
+// Restore implements Node interface.
+func (n *InstallComponentStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("INSTALL COMPONENT ")
+	restoreComponents(ctx, n.Components)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *InstallComponentStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*InstallComponentStmt)
+	return v.Leave(n)
+}
+
+// UninstallComponentStmt is a statement to remove server components.
+// See https://dev.mysql.com/doc/refman/8.0/en/uninstall-component.html
+type UninstallComponentStmt struct {
+	stmtNode
+
+	Components []string
+}
+
+// Restore implements Node interface.
+func (n *UninstallComponentStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("UNINSTALL COMPONENT ")
+	restoreComponents(ctx, n.Components)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *UninstallComponentStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*UninstallComponentStmt)
+	return v.Leave(n)
+}
+
+// CloneSSL is the REQUIRE [NO] SSL option of CLONE INSTANCE.
+type CloneSSL int
+
+// CloneSSL values.
+const (
+	CloneSSLDefault CloneSSL = iota
+	CloneSSLRequired
+	CloneSSLNotRequired
+)
+
+// CloneStmt is a statement to clone the data of the local server or of a
+// donor server.
+// See https://dev.mysql.com/doc/refman/8.0/en/clone.html
+type CloneStmt struct {
+	stmtNode
+
+	// Local is set for CLONE LOCAL, which only has a DataDirectory.
+	Local bool
+	// User, Port and Password identify the donor of CLONE INSTANCE.
+	User     *auth.UserIdentity
+	Port     uint64
+	Password string
+	// DataDirectory is where the data is cloned to, empty for CLONE INSTANCE
+	// replacing the data of the recipient.
+	DataDirectory string
+	RequireSSL    CloneSSL
+}
+
+// Restore implements Node interface.
+func (n *CloneStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	if n.Local {
+		ctx.WriteKeyWord("CLONE LOCAL DATA DIRECTORY ")
+		ctx.WritePlain("= ")
+		ctx.WriteString(n.DataDirectory)
+		return nil
+	}
+	ctx.WriteKeyWord("CLONE INSTANCE FROM ")
+	if err := n.User.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CloneStmt.User")
+	}
+	ctx.WritePlainf(":%d", n.Port)
+	ctx.WriteKeyWord(" IDENTIFIED BY ")
+	ctx.WriteString(n.Password)
+	if n.DataDirectory != "" {
+		ctx.WriteKeyWord(" DATA DIRECTORY ")
+		ctx.WritePlain("= ")
+		ctx.WriteString(n.DataDirectory)
+	}
+	switch n.RequireSSL {
+	case CloneSSLRequired:
+		ctx.WriteKeyWord(" REQUIRE SSL")
+	case CloneSSLNotRequired:
+		ctx.WriteKeyWord(" REQUIRE NO SSL")
+	}
+	return nil
+}
+
+// SecureText implements SensitiveStmtNode interface.
+func (n *CloneStmt) SecureText() string {
+	redactedStmt := *n
+	if !n.Local {
+		redactedStmt.Password = "***"
+	}
+	var sb strings.Builder
+	_ = redactedStmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb))
+	return sb.String()
+}
+
+// Accept implements Node Accept interface.
+func (n *CloneStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CloneStmt)
+	return v.Leave(n)
+}
+
+// HelpStmt is a statement to look up a topic in the help tables.
+// See https://dev.mysql.com/doc/refman/8.0/en/help.html
+type HelpStmt struct {
+	stmtNode
+
+	Topic string
+}
+
+// Restore implements Node interface.
+func (n *HelpStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("HELP ")
+	ctx.WriteString(n.Topic)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *HelpStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*HelpStmt)
+	return v.Leave(n)
+}
+
+// ResourceGroupType is the kind of threads a resource group is for.
+type ResourceGroupType int
+
+// ResourceGroupType values.
+const (
+	ResourceGroupUser ResourceGroupType = iota
+	ResourceGroupSystem
+)
+
+// String implements fmt.Stringer interface.
+func (t ResourceGroupType) String() string {
+	if t == ResourceGroupSystem {
+		return "SYSTEM"
+	}
+	return "USER"
+}
+
+// ResourceGroupState is the ENABLE or DISABLE option of a resource group.
+type ResourceGroupState int
+
+// ResourceGroupState values.
+const (
+	ResourceGroupStateDefault ResourceGroupState = iota
+	ResourceGroupEnable
+	ResourceGroupDisable
+)
+
+// ResourceGroupVCPU is a CPU, or a range of CPUs, a resource group can use.
+type ResourceGroupVCPU struct {
+	// Start and End are the same for a single CPU.
+	Start uint64
+	End   uint64
+}
+
+// ResourceGroupOptions are the options of CREATE and ALTER RESOURCE GROUP.
+type ResourceGroupOptions struct {
+	VCPUs []ResourceGroupVCPU
+	// ThreadPriority is nil if it is not given.
+	ThreadPriority *int64
+	State          ResourceGroupState
+}
+
+// Restore writes the options to ctx.
+func (n *ResourceGroupOptions) Restore(ctx *format.RestoreCtx) error {
+	if len(n.VCPUs) > 0 {
+		ctx.WriteKeyWord(" VCPU ")
+		ctx.WritePlain("= ")
+		for i, vcpu := range n.VCPUs {
+			if i > 0 {
+				ctx.WritePlain(", ")
+			}
+			ctx.WritePlainf("%d", vcpu.Start)
+			if vcpu.End != vcpu.Start {
+				ctx.WritePlainf("-%d", vcpu.End)
+			}
+		}
+	}
+	if n.ThreadPriority != nil {
+		ctx.WriteKeyWord(" THREAD_PRIORITY ")
+		ctx.WritePlainf("= %d", *n.ThreadPriority)
+	}
+	switch n.State {
+	case ResourceGroupEnable:
+		ctx.WriteKeyWord(" ENABLE")
+	case ResourceGroupDisable:
+		ctx.WriteKeyWord(" DISABLE")
+	}
+	return nil
+}
+
+// CreateResourceGroupStmt is a statement to create a resource group.
+// See https://dev.mysql.com/doc/refman/8.0/en/create-resource-group.html
+type CreateResourceGroupStmt struct {
+	ddlNode
+
+	Name model.CIStr
+	Type ResourceGroupType
+	ResourceGroupOptions
+}
+
+// Restore implements Node interface.
+func (n *CreateResourceGroupStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("CREATE RESOURCE GROUP ")
+	ctx.WriteName(n.Name.O)
+	ctx.WriteKeyWord(" TYPE ")
+	ctx.WritePlain("= ")
+	ctx.WriteKeyWord(n.Type.String())
+	if err := n.ResourceGroupOptions.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateResourceGroupStmt.ResourceGroupOptions")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateResourceGroupStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateResourceGroupStmt)
+	return v.Leave(n)
+}
+
+// AlterResourceGroupStmt is a statement to change a resource group.
+// See https://dev.mysql.com/doc/refman/8.0/en/alter-resource-group.html
+type AlterResourceGroupStmt struct {
+	ddlNode
+
+	Name model.CIStr
+	ResourceGroupOptions
+	// Force moves the threads of a disabled group to the default group.
+	Force bool
+}
+
+// Restore implements Node interface.
+func (n *AlterResourceGroupStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("ALTER RESOURCE GROUP ")
+	ctx.WriteName(n.Name.O)
+	if err := n.ResourceGroupOptions.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore AlterResourceGroupStmt.ResourceGroupOptions")
+	}
+	if n.Force {
+		ctx.WriteKeyWord(" FORCE")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *AlterResourceGroupStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*AlterResourceGroupStmt)
+	return v.Leave(n)
+}
+
+// DropResourceGroupStmt is a statement to drop a resource group.
+// See https://dev.mysql.com/doc/refman/8.0/en/drop-resource-group.html
+type DropResourceGroupStmt struct {
+	ddlNode
+
+	Name  model.CIStr
+	Force bool
+}
+
+// Restore implements Node interface.
+func (n *DropResourceGroupStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("DROP RESOURCE GROUP ")
+	ctx.WriteName(n.Name.O)
+	if n.Force {
+		ctx.WriteKeyWord(" FORCE")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DropResourceGroupStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropResourceGroupStmt)
+	return v.Leave(n)
+}
@@ -32,6 +32,8 @@ type IndexAdviseStmt struct {
 
 // Restore implements Node Accept interface.
 func (n *IndexAdviseStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("INDEX ADVISE ")
 	if n.IsLocal {
 		ctx.WriteKeyWord("LOCAL ")
@@ -17,6 +17,7 @@ package ast
 
 import (
 	"io"
+	"strings"
 
 	"github.com/kyleconroy/sqlparse/format"
 	"github.com/kyleconroy/sqlparse/model"
@@ -45,6 +46,33 @@ type Node interface {
 	SetOriginTextPosition(offset int)
 	// OriginTextPosition get the start offset of this node in the origin text.
 	OriginTextPosition() int
+	// Span returns the part of the origin text the node was parsed from. It
+	// is empty unless the parser was set to keep the spans.
+	Span() Span
+	// SetSpan sets the part of the origin text the node was parsed from.
+	SetSpan(span Span)
+}
+
+// Pos is a position in the origin text.
+type Pos struct {
+	// Line is the line number, starting at 1.
+	Line int
+	// Col is the column in characters, starting at 1.
+	Col int
+	// Offset is the byte offset, starting at 0.
+	Offset int
+}
+
+// Span is a part of the origin text. End is the position following the last
+// character. The span of a node which was not parsed from text is empty.
+type Span struct {
+	Start Pos
+	End   Pos
+}
+
+// IsEmpty returns true if the span covers no text.
+func (s Span) IsEmpty() bool {
+	return s.End.Offset <= s.Start.Offset
 }
 
 // Flags indicates whether an expression contains certain types of expression.
@@ -98,6 +126,33 @@ type FuncNode interface {
 type StmtNode interface {
 	Node
 	statement()
+	// Comments returns the comments attached to the statement.
+	Comments() []Comment
+	// SetComments attaches comments to the statement.
+	SetComments(comments []Comment)
+}
+
+// Comment is a comment of the SQL text. The parser attaches comments to
+// statements when it is asked to keep them.
+type Comment struct {
+	// Text is the comment with its delimiters, like `-- note` or `/* note */`.
+	Text string
+	// Offset is the position of the comment in the SQL text.
+	Offset int
+	// Node is the node of the statement nearest to a comment inside the
+	// statement, it's nil for the comments around the statement.
+	Node Node
+	// Trailing is true if the comment follows its node, or its statement
+	// on the same line if Node is nil.
+	Trailing bool
+	// OwnLine is true for a comment following the last statement on a line
+	// of its own, it is a trailing comment of the statement.
+	OwnLine bool
+}
+
+// IsLineComment returns true for comments ending at the end of the line.
+func (c *Comment) IsLineComment() bool {
+	return strings.HasPrefix(c.Text, "--") || strings.HasPrefix(c.Text, "#")
 }
 
 // DDLNode represents DDL statement node.
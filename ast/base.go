@@ -14,6 +14,10 @@
 package ast
 
 import (
+	"sort"
+	"strings"
+
+	"github.com/kyleconroy/sqlparse/format"
 	"github.com/kyleconroy/sqlparse/types"
 )
 
@@ -22,6 +26,23 @@ import (
 type node struct {
 	text   string
 	offset int
+	span   *Span
+}
+
+// Span implements Node interface.
+func (n *node) Span() Span {
+	if n.span == nil {
+		return Span{}
+	}
+	return *n.span
+}
+
+// SetSpan implements Node interface.
+func (n *node) SetSpan(span Span) {
+	if n.span == nil {
+		n.span = new(Span)
+	}
+	*n.span = span
 }
 
 // SetOriginTextPosition implements Node interface.
@@ -48,11 +69,174 @@ func (n *node) Text() string {
 // Statement implementations should embed it in.
 type stmtNode struct {
 	node
+	comments []Comment
 }
 
 // statement implements StmtNode interface.
 func (sn *stmtNode) statement() {}
 
+// Comments implements StmtNode interface.
+func (sn *stmtNode) Comments() []Comment {
+	return sn.comments
+}
+
+// SetComments implements StmtNode interface.
+func (sn *stmtNode) SetComments(comments []Comment) {
+	sn.comments = comments
+}
+
+// restoreComments writes the comments of the statement if ctx has the
+// RestoreWithComments flag. The text the statement writes until the returned
+// function is called gets the comments of its nodes around their text, and is
+// written after the leading comments of the statement and before the trailing
+// ones. A comment whose node isn't restored is written as a leading comment.
+// Line comments following a node or the statement are written as block
+// comments, so that more text can follow on the same line, except the ones on
+// their own lines, which stay there.
+func (sn *stmtNode) restoreComments(ctx *format.RestoreCtx) func() {
+	if !ctx.Flags.HasWithCommentsFlag() || len(sn.comments) == 0 {
+		return func() {}
+	}
+	edges := make([][]Node, len(sn.comments))
+	for i, c := range sn.comments {
+		if c.Node == nil {
+			continue
+		}
+		edges[i] = commentNodes(c)
+		for _, n := range edges[i] {
+			ctx.TrackNodes(n)
+		}
+	}
+	end := ctx.Capture()
+	return func() {
+		text := end()
+		var (
+			leading []Comment
+			inserts []commentInsert
+		)
+		for i, c := range sn.comments {
+			if c.Node == nil {
+				if !c.Trailing {
+					leading = append(leading, c)
+				}
+				continue
+			}
+			insert, ok := findCommentNode(ctx, edges[i], c)
+			if !ok {
+				leading = append(leading, c)
+				continue
+			}
+			inserts = append(inserts, insert)
+		}
+		for _, c := range leading {
+			ctx.WritePlain(c.Text)
+			ctx.WritePlain("\n")
+		}
+		sort.SliceStable(inserts, func(i, j int) bool { return inserts[i].offset < inserts[j].offset })
+		last := 0
+		for _, insert := range inserts {
+			ctx.WritePlain(text[last:insert.offset])
+			ctx.WritePlain(insert.text)
+			last = insert.offset
+		}
+		ctx.WritePlain(text[last:])
+		for _, c := range sn.comments {
+			if c.Node != nil || !c.Trailing {
+				continue
+			}
+			if c.OwnLine {
+				ctx.WritePlain("\n")
+				ctx.WritePlain(c.Text)
+				if c.IsLineComment() {
+					ctx.WritePlain("\n")
+				}
+				continue
+			}
+			ctx.WritePlain(" ")
+			if inline, ok := c.inline(); ok {
+				ctx.WritePlain(inline)
+			} else {
+				ctx.WritePlain(c.Text)
+				ctx.WritePlain("\n")
+			}
+		}
+	}
+}
+
+// commentInsert is the text of a comment to write at an offset of the
+// restored statement.
+type commentInsert struct {
+	offset int
+	text   string
+}
+
+// commentNodes returns the node of a comment and its inner nodes starting, or
+// ending for a trailing comment, at the same place. A node may be restored by
+// its parent without its own `Restore`, like the fields of a SELECT, so the
+// comment is written next to the first of them which is restored.
+func commentNodes(c Comment) []Node {
+	edge := &edgeNodeCollector{trailing: c.Trailing, offset: c.Node.Span().Start.Offset}
+	if c.Trailing {
+		edge.offset = c.Node.Span().End.Offset
+	}
+	c.Node.Accept(edge)
+	return edge.nodes
+}
+
+// findCommentNode returns where to write a comment in the restored statement,
+// before or after the text of the first of nodes restored by ctx. It returns
+// false if none is restored.
+func findCommentNode(ctx *format.RestoreCtx, nodes []Node, c Comment) (commentInsert, bool) {
+	inline, ok := c.inline()
+	if !ok {
+		return commentInsert{}, false
+	}
+	for _, n := range nodes {
+		start, end, ok := ctx.NodeOffsets(n)
+		if !ok || start == end {
+			continue
+		}
+		if c.Trailing {
+			return commentInsert{offset: end, text: " " + inline}, true
+		}
+		return commentInsert{offset: start, text: inline + " "}, true
+	}
+	return commentInsert{}, false
+}
+
+// edgeNodeCollector collects the nodes starting at offset, or ending there if
+// trailing is set, outer nodes first.
+type edgeNodeCollector struct {
+	trailing bool
+	offset   int
+	nodes    []Node
+}
+
+func (v *edgeNodeCollector) Enter(n Node) (Node, bool) {
+	span := n.Span()
+	if !v.trailing && span.Start.Offset == v.offset || v.trailing && span.End.Offset == v.offset {
+		v.nodes = append(v.nodes, n)
+	}
+	return n, false
+}
+
+func (v *edgeNodeCollector) Leave(n Node) (Node, bool) {
+	return n, true
+}
+
+// inline returns the comment as a block comment, or false if it is a line
+// comment which can't be one.
+func (c *Comment) inline() (string, bool) {
+	if !c.IsLineComment() {
+		return c.Text, true
+	}
+	text := strings.TrimSpace(strings.TrimLeft(c.Text, "-#"))
+	if strings.Contains(text, "*/") {
+		return "", false
+	}
+	return "/* " + text + " */", true
+}
+
 // ddlNode implements DDLNode interface.
 // DDL implementations should embed it in.
 type ddlNode struct {
@@ -110,6 +110,8 @@ type CreateDatabaseStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateDatabaseStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("CREATE DATABASE ")
 	if n.IfNotExists {
 		ctx.WriteKeyWord("IF NOT EXISTS ")
@@ -147,6 +149,8 @@ type AlterDatabaseStmt struct {
 
 // Restore implements Node interface.
 func (n *AlterDatabaseStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("ALTER DATABASE")
 	if !n.AlterDefaultDatabase {
 		ctx.WritePlain(" ")
@@ -183,6 +187,8 @@ type DropDatabaseStmt struct {
 
 // Restore implements Node interface.
 func (n *DropDatabaseStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("DROP DATABASE ")
 	if n.IfExists {
 		ctx.WriteKeyWord("IF EXISTS ")
@@ -212,6 +218,7 @@ type IndexPartSpecification struct {
 
 // Restore implements Node interface.
 func (n *IndexPartSpecification) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if n.Expr != nil {
 		ctx.WritePlain("(")
 		if err := n.Expr.Restore(ctx); err != nil {
@@ -277,6 +284,7 @@ type ReferenceDef struct {
 
 // Restore implements Node interface.
 func (n *ReferenceDef) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if n.Table != nil {
 		ctx.WriteKeyWord("REFERENCES ")
 		if err := n.Table.Restore(ctx); err != nil {
@@ -395,6 +403,7 @@ type OnDeleteOpt struct {
 
 // Restore implements Node interface.
 func (n *OnDeleteOpt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if n.ReferOpt != ReferOptionNoOption {
 		ctx.WriteKeyWord("ON DELETE ")
 		ctx.WriteKeyWord(n.ReferOpt.String())
@@ -420,6 +429,7 @@ type OnUpdateOpt struct {
 
 // Restore implements Node interface.
 func (n *OnUpdateOpt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if n.ReferOpt != ReferOptionNoOption {
 		ctx.WriteKeyWord("ON UPDATE ")
 		ctx.WriteKeyWord(n.ReferOpt.String())
@@ -459,6 +469,7 @@ const (
 	ColumnOptionColumnFormat
 	ColumnOptionStorage
 	ColumnOptionAutoRandom
+	ColumnOptionSRID
 )
 
 var (
@@ -488,10 +499,14 @@ type ColumnOption struct {
 	Enforced bool
 	// Name is only used for Check Constraint name.
 	ConstraintName string
+	// SRID is only used for ColumnOptionSRID, it's the spatial reference
+	// system of the values of a spatial column.
+	SRID uint32
 }
 
 // Restore implements Node interface.
 func (n *ColumnOption) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	switch n.Tp {
 	case ColumnOptionNoOption:
 		return nil
@@ -572,6 +587,9 @@ func (n *ColumnOption) Restore(ctx *format.RestoreCtx) error {
 		if n.AutoRandomBitLength != types.UnspecifiedLength {
 			ctx.WritePlainf("(%d)", n.AutoRandomBitLength)
 		}
+	case ColumnOptionSRID:
+		ctx.WriteKeyWord("SRID ")
+		ctx.WritePlainf("%d", n.SRID)
 	default:
 		return errors.New("An error occurred while splicing ColumnOption")
 	}
@@ -623,6 +641,7 @@ type IndexOption struct {
 
 // Restore implements Node interface.
 func (n *IndexOption) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	hasPrevOption := false
 	if n.KeyBlockSize > 0 {
 		ctx.WriteKeyWord("KEY_BLOCK_SIZE")
@@ -696,6 +715,7 @@ const (
 	ConstraintForeignKey
 	ConstraintFulltext
 	ConstraintCheck
+	ConstraintSpatial
 )
 
 // Constraint is constraint for table definition.
@@ -727,6 +747,7 @@ type Constraint struct {
 
 // Restore implements Node interface.
 func (n *Constraint) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	switch n.Tp {
 	case ConstraintNoConstraint:
 		return nil
@@ -750,6 +771,8 @@ func (n *Constraint) Restore(ctx *format.RestoreCtx) error {
 		ctx.WriteKeyWord("UNIQUE INDEX")
 	case ConstraintFulltext:
 		ctx.WriteKeyWord("FULLTEXT")
+	case ConstraintSpatial:
+		ctx.WriteKeyWord("SPATIAL")
 	case ConstraintCheck:
 		if n.Name != "" {
 			ctx.WriteKeyWord("CONSTRAINT ")
@@ -862,6 +885,7 @@ type ColumnDef struct {
 
 // Restore implements Node interface.
 func (n *ColumnDef) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.Name.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while splicing ColumnDef Name")
 	}
@@ -938,6 +962,8 @@ type CreateTableStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateTableStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	if n.IsTemporary {
 		ctx.WriteKeyWord("CREATE TEMPORARY TABLE ")
 	} else {
@@ -1076,6 +1102,8 @@ type DropTableStmt struct {
 
 // Restore implements Node interface.
 func (n *DropTableStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	if n.IsView {
 		ctx.WriteKeyWord("DROP VIEW ")
 	} else {
@@ -1128,6 +1156,8 @@ type DropSequenceStmt struct {
 
 // Restore implements Node interface.
 func (n *DropSequenceStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("DROP SEQUENCE ")
 	if n.IfExists {
 		ctx.WriteKeyWord("IF EXISTS ")
@@ -1171,6 +1201,8 @@ type RenameTableStmt struct {
 
 // Restore implements Node interface.
 func (n *RenameTableStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("RENAME TABLE ")
 	for index, table2table := range n.TableToTables {
 		if index != 0 {
@@ -1211,6 +1243,7 @@ type TableToTable struct {
 
 // Restore implements Node interface.
 func (n *TableToTable) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.OldTable.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore TableToTable.OldTable")
 	}
@@ -1259,6 +1292,8 @@ type CreateViewStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateViewStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("CREATE ")
 	if n.OrReplace {
 		ctx.WriteKeyWord("OR REPLACE ")
@@ -1347,6 +1382,8 @@ type CreateSequenceStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateSequenceStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("CREATE ")
 	ctx.WriteKeyWord("SEQUENCE ")
 	if n.IfNotExists {
@@ -1395,6 +1432,7 @@ type IndexLockAndAlgorithm struct {
 
 // Restore implements Node interface.
 func (n *IndexLockAndAlgorithm) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	hasPrevOption := false
 	if n.AlgorithmTp != AlgorithmTypeDefault {
 		ctx.WriteKeyWord("ALGORITHM")
@@ -1454,6 +1492,8 @@ type CreateIndexStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateIndexStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("CREATE ")
 	switch n.KeyType {
 	case IndexKeyTypeUnique:
@@ -1550,6 +1590,8 @@ type DropIndexStmt struct {
 
 // Restore implements Node interface.
 func (n *DropIndexStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("DROP INDEX ")
 	if n.IfExists {
 		ctx.WriteKeyWord("IF EXISTS ")
@@ -1625,6 +1667,8 @@ func (n *LockTablesStmt) Accept(v Visitor) (Node, bool) {
 
 // Restore implements Node interface.
 func (n *LockTablesStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("LOCK TABLES ")
 	for i, tl := range n.TableLocks {
 		if i != 0 {
@@ -1651,6 +1695,8 @@ func (n *UnlockTablesStmt) Accept(v Visitor) (Node, bool) {
 
 // Restore implements Node interface.
 func (n *UnlockTablesStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("UNLOCK TABLES")
 	return nil
 }
@@ -1681,6 +1727,8 @@ func (n *CleanupTableLockStmt) Accept(v Visitor) (Node, bool) {
 
 // Restore implements Node interface.
 func (n *CleanupTableLockStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("ADMIN CLEANUP TABLE LOCK ")
 	for i, v := range n.Tables {
 		if i != 0 {
@@ -1722,6 +1770,8 @@ func (n *RepairTableStmt) Accept(v Visitor) (Node, bool) {
 
 // Restore implements Node interface.
 func (n *RepairTableStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("ADMIN REPAIR TABLE ")
 	if err := n.Table.Restore(ctx); err != nil {
 		return errors.Annotatef(err, "An error occurred while restore RepairTableStmt.table : [%v]", n.Table)
@@ -2109,6 +2159,7 @@ type ColumnPosition struct {
 
 // Restore implements Node interface.
 func (n *ColumnPosition) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	switch n.Tp {
 	case ColumnPositionNone:
 		// do nothing
@@ -2323,6 +2374,7 @@ func (n *AlterOrderItem) Restore(ctx *format.RestoreCtx) error {
 
 // Restore implements Node interface.
 func (n *AlterTableSpec) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	switch n.Tp {
 	case AlterTableSetTiFlashReplica:
 		ctx.WriteKeyWord("SET TIFLASH REPLICA ")
@@ -2889,6 +2941,8 @@ type AlterTableStmt struct {
 
 // Restore implements Node interface.
 func (n *AlterTableStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("ALTER TABLE ")
 	if err := n.Table.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore AlterTableStmt.Table")
@@ -2938,6 +2992,8 @@ type TruncateTableStmt struct {
 
 // Restore implements Node interface.
 func (n *TruncateTableStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("TRUNCATE TABLE ")
 	if err := n.Table.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore TruncateTableStmt.Table")
@@ -3385,6 +3441,7 @@ func (n *PartitionOptions) Validate() error {
 }
 
 func (n *PartitionOptions) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("PARTITION BY ")
 	if err := n.PartitionMethod.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore PartitionOptions.PartitionMethod")
@@ -3454,6 +3511,8 @@ type RecoverTableStmt struct {
 
 // Restore implements Node interface.
 func (n *RecoverTableStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("RECOVER TABLE ")
 	if n.JobID != 0 {
 		ctx.WriteKeyWord("BY JOB ")
@@ -3497,6 +3556,8 @@ type FlashBackTableStmt struct {
 
 // Restore implements Node interface.
 func (n *FlashBackTableStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("FLASHBACK TABLE ")
 	if err := n.Table.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while splicing RecoverTableStmt Table")
@@ -3572,6 +3633,7 @@ func (n *PlacementSpec) restoreRole(ctx *format.RestoreCtx) error {
 }
 
 func (n *PlacementSpec) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	switch n.Tp {
 	case PlacementAdd:
 		ctx.WriteKeyWord("ADD PLACEMENT POLICY ")
@@ -3621,6 +3683,8 @@ type AlterSequenceStmt struct {
 }
 
 func (n *AlterSequenceStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("ALTER SEQUENCE ")
 	if n.IfExists {
 		ctx.WriteKeyWord("IF EXISTS ")
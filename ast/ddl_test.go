@@ -233,6 +233,7 @@ func (ts *testDDLSuite) TestDDLColumnOptionRestore(c *C) {
 		{"STORAGE MEMORY", "STORAGE MEMORY"},
 		{"AUTO_RANDOM (3)", "AUTO_RANDOM(3)"},
 		{"AUTO_RANDOM", "AUTO_RANDOM"},
+		{"SRID 4326", "SRID 4326"},
 	}
 	extractNodeFunc := func(node Node) Node {
 		return node.(*CreateTableStmt).Cols[0].Options[0]
@@ -20,6 +20,7 @@ import (
 	"github.com/kyleconroy/sqlparse/format"
 	"github.com/kyleconroy/sqlparse/model"
 	"github.com/kyleconroy/sqlparse/mysql"
+	"github.com/kyleconroy/sqlparse/types"
 )
 
 var (
@@ -46,6 +47,9 @@ var (
 	_ Node = &TableName{}
 	_ Node = &TableRefsClause{}
 	_ Node = &TableSource{}
+	_ Node = &JSONTable{}
+	_ Node = &JSONTableColumn{}
+	_ Node = &LateralTable{}
 	_ Node = &SetOprSelectList{}
 	_ Node = &WildCardField{}
 	_ Node = &WindowSpec{}
@@ -145,6 +149,7 @@ func NewCrossJoin(left, right ResultSetNode) (n *Join) {
 
 // Restore implements Node interface.
 func (n *Join) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if ctx.JoinLevel != 0 {
 		ctx.WritePlain("(")
 		defer ctx.WritePlain(")")
@@ -157,19 +162,20 @@ func (n *Join) Restore(ctx *format.RestoreCtx) error {
 	if n.Right == nil {
 		return nil
 	}
+	ctx.WriteLineBreak(" ")
 	if n.NaturalJoin {
-		ctx.WriteKeyWord(" NATURAL")
+		ctx.WriteKeyWord("NATURAL ")
 	}
 	switch n.Tp {
 	case LeftJoin:
-		ctx.WriteKeyWord(" LEFT")
+		ctx.WriteKeyWord("LEFT ")
 	case RightJoin:
-		ctx.WriteKeyWord(" RIGHT")
+		ctx.WriteKeyWord("RIGHT ")
 	}
 	if n.StraightJoin {
-		ctx.WriteKeyWord(" STRAIGHT_JOIN ")
+		ctx.WriteKeyWord("STRAIGHT_JOIN ")
 	} else {
-		ctx.WriteKeyWord(" JOIN ")
+		ctx.WriteKeyWord("JOIN ")
 	}
 	ctx.JoinLevel++
 	if err := n.Right.Restore(ctx); err != nil {
@@ -282,6 +288,7 @@ func (n *TableName) restoreIndexHints(ctx *format.RestoreCtx) error {
 }
 
 func (n *TableName) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	n.restoreName(ctx)
 	n.restorePartitions(ctx)
 	if err := n.restoreIndexHints(ctx); err != nil {
@@ -390,6 +397,7 @@ type DeleteTableList struct {
 
 // Restore implements Node interface.
 func (n *DeleteTableList) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	for i, t := range n.Tables {
 		if i != 0 {
 			ctx.WritePlain(",")
@@ -429,6 +437,7 @@ type OnCondition struct {
 
 // Restore implements Node interface.
 func (n *OnCondition) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("ON ")
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore OnCondition.Expr")
@@ -465,6 +474,7 @@ type TableSource struct {
 
 // Restore implements Node interface.
 func (n *TableSource) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	needParen := false
 	switch n.Source.(type) {
 	case *SelectStmt, *SetOprStmt:
@@ -499,11 +509,15 @@ func (n *TableSource) Restore(ctx *format.RestoreCtx) error {
 	} else {
 		if needParen {
 			ctx.WritePlain("(")
+			ctx.Indent()
+			ctx.WriteLineBreak("")
 		}
 		if err := n.Source.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore TableSource.Source")
 		}
 		if needParen {
+			ctx.Dedent()
+			ctx.WriteLineBreak("")
 			ctx.WritePlain(")")
 		}
 		if asName := n.AsName.String(); asName != "" {
@@ -530,6 +544,225 @@ func (n *TableSource) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// JSONTable is the JSON_TABLE table function, which extracts the rows of a
+// table from a JSON document. It is the Source of a TableSource.
+// See https://dev.mysql.com/doc/refman/8.0/en/json-table-functions.html
+type JSONTable struct {
+	node
+	resultSetNode
+
+	// Expr is the JSON document.
+	Expr ExprNode
+	// Path is the JSON path of the rows in the document.
+	Path    string
+	Columns []*JSONTableColumn
+}
+
+// Restore implements Node interface.
+func (n *JSONTable) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	ctx.WriteKeyWord("JSON_TABLE")
+	ctx.WritePlain("(")
+	if err := n.Expr.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore JSONTable.Expr")
+	}
+	ctx.WritePlain(", ")
+	ctx.WriteString(n.Path)
+	ctx.WritePlain(" ")
+	if err := restoreJSONTableColumns(ctx, n.Columns); err != nil {
+		return errors.Annotate(err, "An error occurred while restore JSONTable.Columns")
+	}
+	ctx.WritePlain(")")
+	return nil
+}
+
+func restoreJSONTableColumns(ctx *format.RestoreCtx, cols []*JSONTableColumn) error {
+	ctx.WriteKeyWord("COLUMNS")
+	ctx.WritePlain("(")
+	for i, col := range cols {
+		if i > 0 {
+			ctx.WritePlain(", ")
+		}
+		if err := col.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore JSONTableColumn: [%v]", i)
+		}
+	}
+	ctx.WritePlain(")")
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *JSONTable) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*JSONTable)
+	node, ok := n.Expr.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Expr = node.(ExprNode)
+	for i, col := range n.Columns {
+		node, ok := col.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Columns[i] = node.(*JSONTableColumn)
+	}
+	return v.Leave(n)
+}
+
+// JSONTableColumnType is the kind of a JSON_TABLE column.
+type JSONTableColumnType int
+
+// JSON_TABLE column kinds.
+const (
+	// JSONTableColumnPath is a column which takes the value at a path.
+	JSONTableColumnPath JSONTableColumnType = iota
+	// JSONTableColumnExists is a column which is 1 if a path exists, else 0.
+	JSONTableColumnExists
+	// JSONTableColumnOrdinality is a row counter column.
+	JSONTableColumnOrdinality
+	// JSONTableColumnNested flattens a nested array into the columns of its own.
+	JSONTableColumnNested
+)
+
+// JSONTableOnResponseType is what a JSON_TABLE column gives when its path
+// matches nothing, or when the value can't be converted.
+type JSONTableOnResponseType int
+
+// JSON_TABLE column response types.
+const (
+	// JSONTableOnResponseNone means the clause is not given.
+	JSONTableOnResponseNone JSONTableOnResponseType = iota
+	JSONTableOnResponseNull
+	JSONTableOnResponseError
+	JSONTableOnResponseDefault
+)
+
+// JSONTableOnResponse is the ON EMPTY or ON ERROR clause of a JSON_TABLE column.
+type JSONTableOnResponse struct {
+	Tp JSONTableOnResponseType
+	// Default is the JSON value of DEFAULT.
+	Default string
+}
+
+func (n JSONTableOnResponse) restore(ctx *format.RestoreCtx, event string) {
+	switch n.Tp {
+	case JSONTableOnResponseNone:
+		return
+	case JSONTableOnResponseNull:
+		ctx.WriteKeyWord(" NULL")
+	case JSONTableOnResponseError:
+		ctx.WriteKeyWord(" ERROR")
+	case JSONTableOnResponseDefault:
+		ctx.WriteKeyWord(" DEFAULT ")
+		ctx.WriteString(n.Default)
+	}
+	ctx.WriteKeyWord(" ON " + event)
+}
+
+// JSONTableColumn is a column definition of JSON_TABLE.
+type JSONTableColumn struct {
+	node
+
+	Tp JSONTableColumnType
+	// Name is empty for a nested path.
+	Name model.CIStr
+	// FieldType is nil for an ordinality column and a nested path.
+	FieldType *types.FieldType
+	Path      string
+	OnEmpty   JSONTableOnResponse
+	OnError   JSONTableOnResponse
+	// Columns are the columns of a nested path.
+	Columns []*JSONTableColumn
+}
+
+// Restore implements Node interface.
+func (n *JSONTableColumn) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	switch n.Tp {
+	case JSONTableColumnNested:
+		ctx.WriteKeyWord("NESTED PATH ")
+		ctx.WriteString(n.Path)
+		ctx.WritePlain(" ")
+		return restoreJSONTableColumns(ctx, n.Columns)
+	case JSONTableColumnOrdinality:
+		ctx.WriteName(n.Name.O)
+		ctx.WriteKeyWord(" FOR ORDINALITY")
+		return nil
+	}
+	ctx.WriteName(n.Name.O)
+	ctx.WritePlain(" ")
+	if err := n.FieldType.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore JSONTableColumn.FieldType")
+	}
+	if n.Tp == JSONTableColumnExists {
+		ctx.WriteKeyWord(" EXISTS")
+	}
+	ctx.WriteKeyWord(" PATH ")
+	ctx.WriteString(n.Path)
+	n.OnEmpty.restore(ctx, "EMPTY")
+	n.OnError.restore(ctx, "ERROR")
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *JSONTableColumn) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*JSONTableColumn)
+	for i, col := range n.Columns {
+		node, ok := col.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Columns[i] = node.(*JSONTableColumn)
+	}
+	return v.Leave(n)
+}
+
+// LateralTable is a LATERAL derived table, whose query can refer to the
+// tables preceding it in the FROM clause. It is the Source of a TableSource.
+// See https://dev.mysql.com/doc/refman/8.0/en/lateral-derived-tables.html
+type LateralTable struct {
+	node
+	resultSetNode
+
+	// Query is a SelectStmt or a SetOprStmt.
+	Query ResultSetNode
+}
+
+// Restore implements Node interface.
+func (n *LateralTable) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	ctx.WriteKeyWord("LATERAL ")
+	ctx.WritePlain("(")
+	if err := n.Query.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore LateralTable.Query")
+	}
+	ctx.WritePlain(")")
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *LateralTable) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*LateralTable)
+	node, ok := n.Query.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Query = node.(ResultSetNode)
+	return v.Leave(n)
+}
+
 // SelectLockType is the lock type for SelectStmt.
 type SelectLockType int
 
@@ -545,9 +778,64 @@ const (
 	SelectLockForShareSkipLocked
 )
 
+// SelectLockInfo is a locking clause of SelectStmt.
 type SelectLockInfo struct {
 	LockType SelectLockType
 	WaitSec  uint64
+	// Tables are the tables of FOR UPDATE OF and FOR SHARE OF. The clause
+	// locks all the tables of the query when it is empty.
+	Tables []*TableName
+	// Next is the next locking clause of the query, if any.
+	Next *SelectLockInfo
+}
+
+// IsForUpdate checks whether the lock type is one of FOR UPDATE.
+func (n SelectLockType) IsForUpdate() bool {
+	switch n {
+	case SelectLockForUpdate, SelectLockForUpdateNoWait, SelectLockForUpdateWaitN, SelectLockForUpdateSkipLocked:
+		return true
+	}
+	return false
+}
+
+// Restore writes the locking clause, followed by the next ones.
+func (n *SelectLockInfo) Restore(ctx *format.RestoreCtx) error {
+	for info := n; info != nil; info = info.Next {
+		if info.LockType == SelectLockNone {
+			ctx.WritePlain(" ")
+			continue
+		}
+		ctx.WriteLineBreak(" ")
+		switch info.LockType {
+		case SelectLockForShare, SelectLockForShareNoWait, SelectLockForShareSkipLocked:
+			ctx.WriteKeyWord("FOR SHARE")
+		default:
+			if !info.LockType.IsForUpdate() {
+				return errors.Errorf("unsupported select lock type: %d", info.LockType)
+			}
+			ctx.WriteKeyWord("FOR UPDATE")
+		}
+		for i, tbl := range info.Tables {
+			if i == 0 {
+				ctx.WriteKeyWord(" OF ")
+			} else {
+				ctx.WritePlain(", ")
+			}
+			if err := tbl.Restore(ctx); err != nil {
+				return errors.Annotatef(err, "An error occurred while restore SelectLockInfo.Tables[%d]", i)
+			}
+		}
+		switch info.LockType {
+		case SelectLockForUpdateNoWait, SelectLockForShareNoWait:
+			ctx.WriteKeyWord(" NOWAIT")
+		case SelectLockForUpdateWaitN:
+			ctx.WriteKeyWord(" WAIT")
+			ctx.WritePlainf(" %d", info.WaitSec)
+		case SelectLockForUpdateSkipLocked, SelectLockForShareSkipLocked:
+			ctx.WriteKeyWord(" SKIP LOCKED")
+		}
+	}
+	return nil
 }
 
 // String implements fmt.Stringer.
@@ -583,6 +871,7 @@ type WildCardField struct {
 
 // Restore implements Node interface.
 func (n *WildCardField) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if schema := n.Schema.String(); schema != "" {
 		ctx.WriteName(schema)
 		ctx.WritePlain(".")
@@ -627,6 +916,7 @@ type SelectField struct {
 
 // Restore implements Node interface.
 func (n *SelectField) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if n.WildCard != nil {
 		if err := n.WildCard.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore SelectField.WildCard")
@@ -670,15 +960,13 @@ type FieldList struct {
 
 // Restore implements Node interface.
 func (n *FieldList) Restore(ctx *format.RestoreCtx) error {
-	for i, v := range n.Fields {
-		if i != 0 {
-			ctx.WritePlain(", ")
-		}
-		if err := v.Restore(ctx); err != nil {
+	defer ctx.Track(n)()
+	return ctx.WriteList(len(n.Fields), ", ", func(i int) error {
+		if err := n.Fields[i].Restore(ctx); err != nil {
 			return errors.Annotatef(err, "An error occurred while restore FieldList.Fields[%d]", i)
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 // Accept implements Node Accept interface.
@@ -707,6 +995,7 @@ type TableRefsClause struct {
 
 // Restore implements Node interface.
 func (n *TableRefsClause) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.TableRefs.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore TableRefsClause.TableRefs")
 	}
@@ -739,6 +1028,7 @@ type ByItem struct {
 
 // Restore implements Node interface.
 func (n *ByItem) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore ByItem.Expr")
 	}
@@ -771,16 +1061,14 @@ type GroupByClause struct {
 
 // Restore implements Node interface.
 func (n *GroupByClause) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("GROUP BY ")
-	for i, v := range n.Items {
-		if i != 0 {
-			ctx.WritePlain(",")
-		}
-		if err := v.Restore(ctx); err != nil {
+	return ctx.WriteList(len(n.Items), ",", func(i int) error {
+		if err := n.Items[i].Restore(ctx); err != nil {
 			return errors.Annotatef(err, "An error occurred while restore GroupByClause.Items[%d]", i)
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 // Accept implements Node Accept interface.
@@ -808,6 +1096,7 @@ type HavingClause struct {
 
 // Restore implements Node interface.
 func (n *HavingClause) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("HAVING ")
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore HavingClause.Expr")
@@ -839,16 +1128,14 @@ type OrderByClause struct {
 
 // Restore implements Node interface.
 func (n *OrderByClause) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("ORDER BY ")
-	for i, item := range n.Items {
-		if i != 0 {
-			ctx.WritePlain(",")
-		}
-		if err := item.Restore(ctx); err != nil {
+	return ctx.WriteList(len(n.Items), ",", func(i int) error {
+		if err := n.Items[i].Restore(ctx); err != nil {
 			return errors.Annotatef(err, "An error occurred while restore OrderByClause.Items[%d]", i)
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 // Accept implements Node Accept interface.
@@ -894,6 +1181,7 @@ type TableSample struct {
 }
 
 func (s *TableSample) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(s)()
 	ctx.WriteKeyWord("TABLESAMPLE ")
 	switch s.SampleMethod {
 	case SampleMethodTypeBernoulli:
@@ -972,6 +1260,129 @@ func (s *SelectStmtKind) String() string {
 	return ""
 }
 
+// CommonTableExpression is a named subquery of a WITH clause.
+// See https://dev.mysql.com/doc/refman/8.0/en/with.html
+type CommonTableExpression struct {
+	node
+
+	Name        model.CIStr
+	Query       *SubqueryExpr
+	ColNameList []model.CIStr
+	// IsRecursive indicates whether the CTE belongs to a WITH RECURSIVE clause,
+	// so its query may refer to its own name.
+	IsRecursive bool
+}
+
+// Restore implements Node interface.
+func (n *CommonTableExpression) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	ctx.WriteName(n.Name.O)
+	if len(n.ColNameList) > 0 {
+		ctx.WritePlain(" (")
+		for i, name := range n.ColNameList {
+			if i != 0 {
+				ctx.WritePlain(", ")
+			}
+			ctx.WriteName(name.O)
+		}
+		ctx.WritePlain(")")
+	}
+	ctx.WriteKeyWord(" AS ")
+	if err := n.Query.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CommonTableExpression.Query")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *CommonTableExpression) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CommonTableExpression)
+	node, ok := n.Query.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Query = node.(*SubqueryExpr)
+	return v.Leave(n)
+}
+
+// WithClause is the WITH clause in front of a SELECT, UPDATE or DELETE
+// statement or a set operation.
+// See https://dev.mysql.com/doc/refman/8.0/en/with.html
+type WithClause struct {
+	node
+
+	IsRecursive bool
+	CTEs        []*CommonTableExpression
+}
+
+// Restore implements Node interface.
+func (n *WithClause) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	ctx.WriteKeyWord("WITH ")
+	if n.IsRecursive {
+		ctx.WriteKeyWord("RECURSIVE ")
+	}
+	// The queries indent themselves, so with a Layout each CTE starts a line
+	// at the level of the statement rather than being a wrapped list item.
+	for i, cte := range n.CTEs {
+		if i != 0 {
+			ctx.WritePlain(",")
+			ctx.WriteLineBreak(" ")
+		}
+		if err := cte.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore WithClause.CTEs[%d]", i)
+		}
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *WithClause) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*WithClause)
+	for i, cte := range n.CTEs {
+		node, ok := cte.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.CTEs[i] = node.(*CommonTableExpression)
+	}
+	return v.Leave(n)
+}
+
+// restoreWith restores the WITH clause in front of a statement.
+func restoreWith(ctx *format.RestoreCtx, with *WithClause) error {
+	if with == nil {
+		return nil
+	}
+	if err := with.Restore(ctx); err != nil {
+		return errors.Trace(err)
+	}
+	ctx.WriteLineBreak(" ")
+	return nil
+}
+
+// acceptWith visits the WITH clause of a statement, it returns false if the
+// visit is aborted.
+func acceptWith(v Visitor, with **WithClause) bool {
+	if *with == nil {
+		return true
+	}
+	node, ok := (*with).Accept(v)
+	if !ok {
+		return false
+	}
+	*with = node.(*WithClause)
+	return true
+}
+
 // SelectStmt represents a select/table/values query node.
 // See https://dev.mysql.com/doc/refman/5.7/en/select.html
 type SelectStmt struct {
@@ -1014,16 +1425,23 @@ type SelectStmt struct {
 	Kind SelectStmtKind
 	// Lists is filled only when Kind == SelectStmtKindValues
 	Lists []*RowExpr
+	// With is the WITH clause in front of the query.
+	With *WithClause
 }
 
 // Restore implements Node interface.
 func (n *SelectStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	if n.IsInBraces {
 		ctx.WritePlain("(")
 		defer func() {
 			ctx.WritePlain(")")
 		}()
 	}
+	if err := restoreWith(ctx, n.With); err != nil {
+		return errors.Annotate(err, "An error occurred while restore SelectStmt.With")
+	}
 	ctx.WriteKeyWord(n.Kind.String())
 	ctx.WritePlain(" ")
 	switch n.Kind {
@@ -1075,102 +1493,109 @@ func (n *SelectStmt) Restore(ctx *format.RestoreCtx) error {
 			ctx.WriteKeyWord("STRAIGHT_JOIN ")
 		}
 		if n.Fields != nil {
-			for i, field := range n.Fields.Fields {
-				if i != 0 {
-					ctx.WritePlain(",")
-				}
-				if err := field.Restore(ctx); err != nil {
+			err := ctx.WriteList(len(n.Fields.Fields), ",", func(i int) error {
+				if err := n.Fields.Fields[i].Restore(ctx); err != nil {
 					return errors.Annotatef(err, "An error occurred while restore SelectStmt.Fields[%d]", i)
 				}
+				return nil
+			})
+			if err != nil {
+				return err
 			}
 		}
 
 		if n.From != nil {
-			ctx.WriteKeyWord(" FROM ")
+			ctx.WriteLineBreak(" ")
+			ctx.WriteKeyWord("FROM ")
 			if err := n.From.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore SelectStmt.From")
 			}
 		}
 
 		if n.From == nil && n.Where != nil {
-			ctx.WriteKeyWord(" FROM DUAL")
+			ctx.WriteLineBreak(" ")
+			ctx.WriteKeyWord("FROM DUAL")
 		}
 
 		if n.Where != nil {
-			ctx.WriteKeyWord(" WHERE ")
+			ctx.WriteLineBreak(" ")
+			ctx.WriteKeyWord("WHERE ")
 			if err := n.Where.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore SelectStmt.Where")
 			}
 		}
 
 		if n.GroupBy != nil {
-			ctx.WritePlain(" ")
+			ctx.WriteLineBreak(" ")
 			if err := n.GroupBy.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore SelectStmt.GroupBy")
 			}
 		}
 
 		if n.Having != nil {
-			ctx.WritePlain(" ")
+			ctx.WriteLineBreak(" ")
 			if err := n.Having.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore SelectStmt.Having")
 			}
 		}
 
 		if n.WindowSpecs != nil {
-			ctx.WriteKeyWord(" WINDOW ")
-			for i, windowsSpec := range n.WindowSpecs {
-				if i != 0 {
-					ctx.WritePlain(",")
-				}
-				if err := windowsSpec.Restore(ctx); err != nil {
+			ctx.WriteLineBreak(" ")
+			ctx.WriteKeyWord("WINDOW ")
+			err := ctx.WriteList(len(n.WindowSpecs), ",", func(i int) error {
+				if err := n.WindowSpecs[i].Restore(ctx); err != nil {
 					return errors.Annotatef(err, "An error occurred while restore SelectStmt.WindowSpec[%d]", i)
 				}
+				return nil
+			})
+			if err != nil {
+				return err
 			}
 		}
 	case SelectStmtKindTable:
-		if err := n.From.Restore(ctx); err != nil {
+		// The table name of TABLE can't be in parentheses, even when the
+		// statement is nested in a join.
+		joinLevel := ctx.JoinLevel
+		ctx.JoinLevel = 0
+		err := n.From.Restore(ctx)
+		ctx.JoinLevel = joinLevel
+		if err != nil {
 			return errors.Annotate(err, "An error occurred while restore SelectStmt.From")
 		}
 	case SelectStmtKindValues:
-		for i, v := range n.Lists {
-			if err := v.Restore(ctx); err != nil {
+		err := ctx.WriteList(len(n.Lists), ", ", func(i int) error {
+			if err := n.Lists[i].Restore(ctx); err != nil {
 				return errors.Annotatef(err, "An error occurred while restore SelectStmt.Lists[%d]", i)
 			}
-			if i != len(n.Lists)-1 {
-				ctx.WritePlain(", ")
-			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 	}
 
 	if n.OrderBy != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.OrderBy.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore SelectStmt.OrderBy")
 		}
 	}
 
 	if n.Limit != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.Limit.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore SelectStmt.Limit")
 		}
 	}
 
 	if n.LockInfo != nil {
-		ctx.WritePlain(" ")
-		switch n.LockInfo.LockType {
-		case SelectLockNone:
-		case SelectLockForUpdateWaitN:
-			ctx.WriteKeyWord(n.LockInfo.LockType.String())
-			ctx.WritePlainf(" %d", n.LockInfo.WaitSec)
-		default:
-			ctx.WriteKeyWord(n.LockInfo.LockType.String())
+		if err := n.LockInfo.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore SelectStmt.LockInfo")
 		}
 	}
 
 	if n.SelectIntoOpt != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.SelectIntoOpt.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore SelectStmt.SelectIntoOpt")
 		}
@@ -1186,6 +1611,9 @@ func (n *SelectStmt) Accept(v Visitor) (Node, bool) {
 	}
 
 	n = newNode.(*SelectStmt)
+	if !acceptWith(v, &n.With) {
+		return n, false
+	}
 	if n.TableHints != nil && len(n.TableHints) != 0 {
 		newHints := make([]*TableOptimizerHint, len(n.TableHints))
 		for i, hint := range n.TableHints {
@@ -1270,6 +1698,24 @@ func (n *SelectStmt) Accept(v Visitor) (Node, bool) {
 		n.Limit = node.(*Limit)
 	}
 
+	for info := n.LockInfo; info != nil; info = info.Next {
+		for i, tbl := range info.Tables {
+			node, ok := tbl.Accept(v)
+			if !ok {
+				return n, false
+			}
+			info.Tables[i] = node.(*TableName)
+		}
+	}
+
+	if n.SelectIntoOpt != nil {
+		node, ok := n.SelectIntoOpt.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.SelectIntoOpt = node.(*SelectIntoOption)
+	}
+
 	return v.Leave(n)
 }
 
@@ -1283,18 +1729,23 @@ type SetOprSelectList struct {
 
 // Restore implements Node interface.
 func (n *SetOprSelectList) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	for i, stmt := range n.Selects {
 		switch selectStmt := stmt.(type) {
 		case *SelectStmt:
 			if i != 0 {
-				ctx.WriteKeyWord(" " + selectStmt.AfterSetOperator.String() + " ")
+				ctx.WriteLineBreak(" ")
+				ctx.WriteKeyWord(selectStmt.AfterSetOperator.String())
+				ctx.WriteLineBreak(" ")
 			}
 			if err := selectStmt.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore SetOprSelectList.SelectStmt")
 			}
 		case *SetOprSelectList:
 			if i != 0 {
-				ctx.WriteKeyWord(" " + selectStmt.AfterSetOperator.String() + " ")
+				ctx.WriteLineBreak(" ")
+				ctx.WriteKeyWord(selectStmt.AfterSetOperator.String())
+				ctx.WriteLineBreak(" ")
 			}
 			ctx.WritePlain("(")
 			err := selectStmt.Restore(ctx)
@@ -1364,23 +1815,29 @@ type SetOprStmt struct {
 	SelectList *SetOprSelectList
 	OrderBy    *OrderByClause
 	Limit      *Limit
+	With       *WithClause
 }
 
 // Restore implements Node interface.
 func (n *SetOprStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	if err := restoreWith(ctx, n.With); err != nil {
+		return errors.Annotate(err, "An error occurred while restore SetOprStmt.With")
+	}
 	if err := n.SelectList.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore SetOprStmt.SelectList")
 	}
 
 	if n.OrderBy != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.OrderBy.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore SetOprStmt.OrderBy")
 		}
 	}
 
 	if n.Limit != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.Limit.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore SetOprStmt.Limit")
 		}
@@ -1395,6 +1852,9 @@ func (n *SetOprStmt) Accept(v Visitor) (Node, bool) {
 		return v.Leave(newNode)
 	}
 	n = newNode.(*SetOprStmt)
+	if !acceptWith(v, &n.With) {
+		return n, false
+	}
 	if n.SelectList != nil {
 		node, ok := n.SelectList.Accept(v)
 		if !ok {
@@ -1430,6 +1890,7 @@ type Assignment struct {
 
 // Restore implements Node interface.
 func (n *Assignment) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.Column.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore Assignment.Column")
 	}
@@ -1467,6 +1928,7 @@ type ColumnNameOrUserVar struct {
 }
 
 func (n *ColumnNameOrUserVar) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if n.ColumnName != nil {
 		if err := n.ColumnName.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore ColumnNameOrUserVar.ColumnName")
@@ -1523,6 +1985,8 @@ type LoadDataStmt struct {
 
 // Restore implements Node interface.
 func (n *LoadDataStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("LOAD DATA ")
 	if n.IsLocal {
 		ctx.WriteKeyWord("LOCAL ")
@@ -1691,6 +2155,8 @@ type CallStmt struct {
 
 // Restore implements Node interface.
 func (n *CallStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("CALL ")
 
 	if err := n.Procedure.Restore(ctx); err != nil {
@@ -1742,6 +2208,8 @@ type InsertStmt struct {
 
 // Restore implements Node interface.
 func (n *InsertStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	if n.IsReplace {
 		ctx.WriteKeyWord("REPLACE ")
 	} else {
@@ -1787,36 +2255,37 @@ func (n *InsertStmt) Restore(ctx *format.RestoreCtx) error {
 	}
 	if n.Columns != nil {
 		ctx.WritePlain(" (")
-		for i, v := range n.Columns {
-			if i != 0 {
-				ctx.WritePlain(",")
-			}
-			if err := v.Restore(ctx); err != nil {
+		err := ctx.WriteList(len(n.Columns), ",", func(i int) error {
+			if err := n.Columns[i].Restore(ctx); err != nil {
 				return errors.Annotatef(err, "An error occurred while restore InsertStmt.Columns[%d]", i)
 			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 		ctx.WritePlain(")")
 	}
 	if n.Lists != nil {
-		ctx.WriteKeyWord(" VALUES ")
-		for i, row := range n.Lists {
-			if i != 0 {
-				ctx.WritePlain(",")
-			}
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("VALUES ")
+		err := ctx.WriteList(len(n.Lists), ",", func(i int) error {
 			ctx.WritePlain("(")
-			for j, v := range row {
-				if j != 0 {
-					ctx.WritePlain(",")
-				}
-				if err := v.Restore(ctx); err != nil {
+			err := ctx.WriteList(len(n.Lists[i]), ",", func(j int) error {
+				if err := n.Lists[i][j].Restore(ctx); err != nil {
 					return errors.Annotatef(err, "An error occurred while restore InsertStmt.Lists[%d][%d]", i, j)
 				}
-			}
+				return nil
+			})
 			ctx.WritePlain(")")
+			return err
+		})
+		if err != nil {
+			return err
 		}
 	}
 	if n.Select != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		switch v := n.Select.(type) {
 		case *SelectStmt, *SetOprStmt:
 			if err := v.Restore(ctx); err != nil {
@@ -1827,25 +2296,29 @@ func (n *InsertStmt) Restore(ctx *format.RestoreCtx) error {
 		}
 	}
 	if n.Setlist != nil {
-		ctx.WriteKeyWord(" SET ")
-		for i, v := range n.Setlist {
-			if i != 0 {
-				ctx.WritePlain(",")
-			}
-			if err := v.Restore(ctx); err != nil {
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("SET ")
+		err := ctx.WriteList(len(n.Setlist), ",", func(i int) error {
+			if err := n.Setlist[i].Restore(ctx); err != nil {
 				return errors.Annotatef(err, "An error occurred while restore InsertStmt.Setlist[%d]", i)
 			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 	}
 	if n.OnDuplicate != nil {
-		ctx.WriteKeyWord(" ON DUPLICATE KEY UPDATE ")
-		for i, v := range n.OnDuplicate {
-			if i != 0 {
-				ctx.WritePlain(",")
-			}
-			if err := v.Restore(ctx); err != nil {
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("ON DUPLICATE KEY UPDATE ")
+		err := ctx.WriteList(len(n.OnDuplicate), ",", func(i int) error {
+			if err := n.OnDuplicate[i].Restore(ctx); err != nil {
 				return errors.Annotatef(err, "An error occurred while restore InsertStmt.OnDuplicate[%d]", i)
 			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 	}
 
@@ -1926,10 +2399,16 @@ type DeleteStmt struct {
 	BeforeFrom   bool
 	// TableHints represents the table level Optimizer Hint for join type.
 	TableHints []*TableOptimizerHint
+	With       *WithClause
 }
 
 // Restore implements Node interface.
 func (n *DeleteStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	if err := restoreWith(ctx, n.With); err != nil {
+		return errors.Annotate(err, "An error occurred while restore DeleteStmt.With")
+	}
 	ctx.WriteKeyWord("DELETE ")
 
 	if n.TableHints != nil && len(n.TableHints) != 0 {
@@ -1964,7 +2443,8 @@ func (n *DeleteStmt) Restore(ctx *format.RestoreCtx) error {
 				return errors.Annotate(err, "An error occurred while restore DeleteStmt.Tables")
 			}
 
-			ctx.WriteKeyWord(" FROM ")
+			ctx.WriteLineBreak(" ")
+			ctx.WriteKeyWord("FROM ")
 			if err := n.TableRefs.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore DeleteStmt.TableRefs")
 			}
@@ -1974,7 +2454,8 @@ func (n *DeleteStmt) Restore(ctx *format.RestoreCtx) error {
 				return errors.Annotate(err, "An error occurred while restore DeleteStmt.Tables")
 			}
 
-			ctx.WriteKeyWord(" USING ")
+			ctx.WriteLineBreak(" ")
+			ctx.WriteKeyWord("USING ")
 			if err := n.TableRefs.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore DeleteStmt.TableRefs")
 			}
@@ -1988,21 +2469,22 @@ func (n *DeleteStmt) Restore(ctx *format.RestoreCtx) error {
 	}
 
 	if n.Where != nil {
-		ctx.WriteKeyWord(" WHERE ")
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("WHERE ")
 		if err := n.Where.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore DeleteStmt.Where")
 		}
 	}
 
 	if n.Order != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.Order.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore DeleteStmt.Order")
 		}
 	}
 
 	if n.Limit != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.Limit.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore DeleteStmt.Limit")
 		}
@@ -2019,6 +2501,9 @@ func (n *DeleteStmt) Accept(v Visitor) (Node, bool) {
 	}
 
 	n = newNode.(*DeleteStmt)
+	if !acceptWith(v, &n.With) {
+		return n, false
+	}
 	node, ok := n.TableRefs.Accept(v)
 	if !ok {
 		return n, false
@@ -2071,10 +2556,16 @@ type UpdateStmt struct {
 	IgnoreErr     bool
 	MultipleTable bool
 	TableHints    []*TableOptimizerHint
+	With          *WithClause
 }
 
 // Restore implements Node interface.
 func (n *UpdateStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	if err := restoreWith(ctx, n.With); err != nil {
+		return errors.Annotate(err, "An error occurred while restore UpdateStmt.With")
+	}
 	ctx.WriteKeyWord("UPDATE ")
 
 	if n.TableHints != nil && len(n.TableHints) != 0 {
@@ -2104,12 +2595,10 @@ func (n *UpdateStmt) Restore(ctx *format.RestoreCtx) error {
 		return errors.Annotate(err, "An error occur while restore UpdateStmt.TableRefs")
 	}
 
-	ctx.WriteKeyWord(" SET ")
-	for i, assignment := range n.List {
-		if i != 0 {
-			ctx.WritePlain(", ")
-		}
-
+	ctx.WriteLineBreak(" ")
+	ctx.WriteKeyWord("SET ")
+	err := ctx.WriteList(len(n.List), ", ", func(i int) error {
+		assignment := n.List[i]
 		if err := assignment.Column.Restore(ctx); err != nil {
 			return errors.Annotatef(err, "An error occur while restore UpdateStmt.List[%d].Column", i)
 		}
@@ -2119,24 +2608,29 @@ func (n *UpdateStmt) Restore(ctx *format.RestoreCtx) error {
 		if err := assignment.Expr.Restore(ctx); err != nil {
 			return errors.Annotatef(err, "An error occur while restore UpdateStmt.List[%d].Expr", i)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	if n.Where != nil {
-		ctx.WriteKeyWord(" WHERE ")
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("WHERE ")
 		if err := n.Where.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occur while restore UpdateStmt.Where")
 		}
 	}
 
 	if n.Order != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.Order.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occur while restore UpdateStmt.Order")
 		}
 	}
 
 	if n.Limit != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.Limit.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occur while restore UpdateStmt.Limit")
 		}
@@ -2152,6 +2646,9 @@ func (n *UpdateStmt) Accept(v Visitor) (Node, bool) {
 		return v.Leave(newNode)
 	}
 	n = newNode.(*UpdateStmt)
+	if !acceptWith(v, &n.With) {
+		return n, false
+	}
 	node, ok := n.TableRefs.Accept(v)
 	if !ok {
 		return n, false
@@ -2198,6 +2695,7 @@ type Limit struct {
 
 // Restore implements Node interface.
 func (n *Limit) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("LIMIT ")
 	if n.Offset != nil {
 		if err := n.Offset.Restore(ctx); err != nil {
@@ -2331,6 +2829,8 @@ type ShowStmt struct {
 
 // Restore implements Node interface.
 func (n *ShowStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	restoreOptFull := func() {
 		if n.Full {
 			ctx.WriteKeyWord("FULL ")
@@ -2660,6 +3160,7 @@ type WindowSpec struct {
 
 // Restore implements Node interface.
 func (n *WindowSpec) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if name := n.Name.String(); name != "" {
 		ctx.WriteName(name)
 		if n.OnlyAlias {
@@ -2744,10 +3245,27 @@ type SelectIntoOption struct {
 	FileName   string
 	FieldsInfo *FieldsClause
 	LinesInfo  *LinesClause
+	// Vars are the variables of SELECT ... INTO var_list: user variables,
+	// as VariableExpr, and local variables of a stored program, as
+	// ColumnNameExpr.
+	Vars []ExprNode
 }
 
 // Restore implements Node interface.
 func (n *SelectIntoOption) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	if n.Tp == SelectIntoVars {
+		ctx.WriteKeyWord("INTO ")
+		for i, v := range n.Vars {
+			if i != 0 {
+				ctx.WritePlain(", ")
+			}
+			if err := v.Restore(ctx); err != nil {
+				return errors.Annotatef(err, "An error occurred while restore SelectInto.Vars[%d]", i)
+			}
+		}
+		return nil
+	}
 	if n.Tp != SelectIntoOutfile {
 		// only support SELECT/TABLE/VALUES ... INTO OUTFILE statement now
 		return errors.New("Unsupported SelectionInto type")
@@ -2774,6 +3292,14 @@ func (n *SelectIntoOption) Accept(v Visitor) (Node, bool) {
 	if skipChildren {
 		return v.Leave(newNode)
 	}
+	n = newNode.(*SelectIntoOption)
+	for i, val := range n.Vars {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Vars[i] = node.(ExprNode)
+	}
 	return v.Leave(n)
 }
 
@@ -2786,6 +3312,7 @@ type PartitionByClause struct {
 
 // Restore implements Node interface.
 func (n *PartitionByClause) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("PARTITION BY ")
 	for i, v := range n.Items {
 		if i != 0 {
@@ -2836,6 +3363,7 @@ type FrameClause struct {
 
 // Restore implements Node interface.
 func (n *FrameClause) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	switch n.Type {
 	case Rows:
 		ctx.WriteKeyWord("ROWS")
@@ -2906,6 +3434,7 @@ type FrameBound struct {
 
 // Restore implements Node interface.
 func (n *FrameBound) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if n.UnBounded {
 		ctx.WriteKeyWord("UNBOUNDED")
 	}
@@ -2976,6 +3505,8 @@ type SplitSyntaxOption struct {
 }
 
 func (n *SplitRegionStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("SPLIT ")
 	if n.SplitSyntaxOpt != nil {
 		if n.SplitSyntaxOpt.HasRegionFor {
@@ -14,8 +14,12 @@
 package ast_test
 
 import (
+	"strings"
+
 	. "github.com/pingcap/check"
+	"github.com/kyleconroy/sqlparse"
 	. "github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/format"
 )
 
 var _ = Suite(&testDMLSuite{})
@@ -188,6 +192,8 @@ func (tc *testDMLSuite) TestTableSourceRestore(c *C) {
 		{"tbl as t", "`tbl` AS `t`"},
 		{"(select * from tbl) as t", "(SELECT * FROM `tbl`) AS `t`"},
 		{"(select * from a union select * from b) as t", "(SELECT * FROM `a` UNION SELECT * FROM `b`) AS `t`"},
+		{"lateral (select * from tbl) as t", "LATERAL (SELECT * FROM `tbl`) AS `t`"},
+		{"json_table('[]', '$' columns (a int path '$.a' default '1' on empty, nested path '$.b' columns (b for ordinality))) t", "JSON_TABLE(_UTF8MB4'[]', '$' COLUMNS(`a` INT PATH '$.a' DEFAULT '1' ON EMPTY, NESTED PATH '$.b' COLUMNS(`b` FOR ORDINALITY))) AS `t`"},
 	}
 	extractNodeFunc := func(node Node) Node {
 		return node.(*SelectStmt).From.TableRefs.Left
@@ -408,3 +414,102 @@ func (ts *testDMLSuite) TestFulltextSearchModifier(c *C) {
 	c.Assert(FulltextSearchModifier(FulltextSearchModifierNaturalLanguageMode).IsNaturalLanguageMode(), IsTrue)
 	c.Assert(FulltextSearchModifier(FulltextSearchModifierNaturalLanguageMode).WithQueryExpansion(), IsFalse)
 }
+
+func (ts *testDMLSuite) TestRestoreLayout(c *C) {
+	testCases := []struct {
+		sourceSQL string
+		expectSQL string
+	}{
+		{
+			"select a, case when a > 1 then 1 else 0 end as c from t1 left join t2 on t1.id = t2.id where a in (select id from u where v = 1) group by a having count(*) > 1 order by a desc limit 10",
+			"SELECT `a`,\n" +
+				"  CASE\n" +
+				"    WHEN `a`>1 THEN 1\n" +
+				"    ELSE 0\n" +
+				"  END AS `c`\n" +
+				"FROM `t1`\n" +
+				"LEFT JOIN `t2` ON `t1`.`id`=`t2`.`id`\n" +
+				"WHERE `a` IN (\n" +
+				"  SELECT `id`\n" +
+				"  FROM `u`\n" +
+				"  WHERE `v`=1\n" +
+				")\n" +
+				"GROUP BY `a`\n" +
+				"HAVING COUNT(1)>1\n" +
+				"ORDER BY `a` DESC\n" +
+				"LIMIT 10",
+		},
+		{
+			"select aaaaaaaaaa, bbbbbbbbbbbbbb, cccccccccccccc, dddddddddddddddd, eeeeeeeeeeeeee from t",
+			"SELECT `aaaaaaaaaa`, `bbbbbbbbbbbbbb`, `cccccccccccccc`,\n" +
+				"  `dddddddddddddddd`, `eeeeeeeeeeeeee`\n" +
+				"FROM `t`",
+		},
+		{
+			"select * from (select a from t union all select b from u) as x",
+			"SELECT *\n" +
+				"FROM (\n" +
+				"  SELECT `a`\n" +
+				"  FROM (`t`)\n" +
+				"  UNION ALL\n" +
+				"  SELECT `b`\n" +
+				"  FROM (`u`)\n" +
+				") AS `x`",
+		},
+		{
+			"insert into t (a, b) values (1, 2), (3, 4) on duplicate key update a = 1",
+			"INSERT INTO `t` (`a`, `b`)\n" +
+				"VALUES (1, 2), (3, 4)\n" +
+				"ON DUPLICATE KEY UPDATE `a`=1",
+		},
+		{
+			"update t set a = 1, b = 2 where c = 3 order by d limit 1",
+			"UPDATE `t`\n" +
+				"SET `a`=1, `b`=2\n" +
+				"WHERE `c`=3\n" +
+				"ORDER BY `d`\n" +
+				"LIMIT 1",
+		},
+		{
+			"delete t1 from t1 join t2 using (id) where t2.x = 1",
+			"DELETE `t1`\n" +
+				"FROM `t1`\n" +
+				"JOIN `t2` USING (`id`)\n" +
+				"WHERE `t2`.`x`=1",
+		},
+		{
+			"with recursive cte (n) as (select 1 union all select n + 1 from cte where n < 5), c2 as (select 2) select * from cte, c2",
+			"WITH RECURSIVE `cte` (`n`) AS (\n" +
+				"  SELECT 1\n" +
+				"  UNION ALL\n" +
+				"  SELECT `n`+1\n" +
+				"  FROM `cte`\n" +
+				"  WHERE `n`<5\n" +
+				"),\n" +
+				"`c2` AS (\n" +
+				"  SELECT 2\n" +
+				")\n" +
+				"SELECT *\n" +
+				"FROM (`cte`)\n" +
+				"JOIN `c2`",
+		},
+	}
+	p := parser.New()
+	for _, testCase := range testCases {
+		comment := Commentf("source %#v", testCase.sourceSQL)
+		stmt, err := p.ParseOneStmt(testCase.sourceSQL, "", "")
+		c.Assert(err, IsNil, comment)
+		var sb strings.Builder
+		ctx := format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)
+		ctx.Layout = &format.Layout{Indent: "  ", Width: 60}
+		c.Assert(stmt.Restore(ctx), IsNil, comment)
+		c.Assert(sb.String(), Equals, testCase.expectSQL, comment)
+
+		// The layout only changes whitespace.
+		stmt2, err := p.ParseOneStmt(sb.String(), "", "")
+		c.Assert(err, IsNil, comment)
+		CleanNodeText(stmt)
+		CleanNodeText(stmt2)
+		c.Assert(stmt2, DeepEquals, stmt, comment)
+	}
+}
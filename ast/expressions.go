@@ -86,6 +86,7 @@ type BetweenExpr struct {
 
 // Restore implements Node interface.
 func (n *BetweenExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore BetweenExpr.Expr")
 	}
@@ -173,6 +174,7 @@ func restoreBinaryOpWithSpacesAround(ctx *format.RestoreCtx, op opcode.Op) error
 
 // Restore implements Node interface.
 func (n *BinaryOperationExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.L.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred when restore BinaryOperationExpr.L")
 	}
@@ -229,6 +231,7 @@ type WhenClause struct {
 
 // Restore implements Node interface.
 func (n *WhenClause) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("WHEN ")
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore WhenClauses.Expr")
@@ -275,6 +278,7 @@ type CaseExpr struct {
 
 // Restore implements Node interface.
 func (n *CaseExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("CASE")
 	if n.Value != nil {
 		ctx.WritePlain(" ")
@@ -282,19 +286,23 @@ func (n *CaseExpr) Restore(ctx *format.RestoreCtx) error {
 			return errors.Annotate(err, "An error occurred while restore CaseExpr.Value")
 		}
 	}
+	ctx.Indent()
 	for _, clause := range n.WhenClauses {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := clause.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore CaseExpr.WhenClauses")
 		}
 	}
 	if n.ElseClause != nil {
-		ctx.WriteKeyWord(" ELSE ")
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("ELSE ")
 		if err := n.ElseClause.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore CaseExpr.ElseClause")
 		}
 	}
-	ctx.WriteKeyWord(" END")
+	ctx.Dedent()
+	ctx.WriteLineBreak(" ")
+	ctx.WriteKeyWord("END")
 
 	return nil
 }
@@ -366,10 +374,15 @@ type SubqueryExpr struct {
 
 // Restore implements Node interface.
 func (n *SubqueryExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WritePlain("(")
+	ctx.Indent()
+	ctx.WriteLineBreak("")
 	if err := n.Query.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore SubqueryExpr.Query")
 	}
+	ctx.Dedent()
+	ctx.WriteLineBreak("")
 	ctx.WritePlain(")")
 	return nil
 }
@@ -412,6 +425,7 @@ type CompareSubqueryExpr struct {
 
 // Restore implements Node interface.
 func (n *CompareSubqueryExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.L.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore CompareSubqueryExpr.L")
 	}
@@ -464,6 +478,7 @@ type TableNameExpr struct {
 
 // Restore implements Node interface.
 func (n *TableNameExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.Name.Restore(ctx); err != nil {
 		return errors.Trace(err)
 	}
@@ -505,6 +520,7 @@ type ColumnName struct {
 
 // Restore implements Node interface.
 func (n *ColumnName) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if n.Schema.O != "" {
 		ctx.WriteName(n.Schema.O)
 		ctx.WritePlain(".")
@@ -567,6 +583,7 @@ type ColumnNameExpr struct {
 
 // Restore implements Node interface.
 func (n *ColumnNameExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.Name.Restore(ctx); err != nil {
 		return errors.Trace(err)
 	}
@@ -603,6 +620,7 @@ type DefaultExpr struct {
 
 // Restore implements Node interface.
 func (n *DefaultExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("DEFAULT")
 	if n.Name != nil {
 		ctx.WritePlain("(")
@@ -641,6 +659,7 @@ type ExistsSubqueryExpr struct {
 
 // Restore implements Node interface.
 func (n *ExistsSubqueryExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if n.Not {
 		ctx.WriteKeyWord("NOT EXISTS ")
 	} else {
@@ -687,6 +706,7 @@ type PatternInExpr struct {
 
 // Restore implements Node interface.
 func (n *PatternInExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore PatternInExpr.Expr")
 	}
@@ -771,6 +791,7 @@ type IsNullExpr struct {
 
 // Restore implements Node interface.
 func (n *IsNullExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Trace(err)
 	}
@@ -820,6 +841,7 @@ type IsTruthExpr struct {
 
 // Restore implements Node interface.
 func (n *IsTruthExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Trace(err)
 	}
@@ -884,6 +906,7 @@ type PatternLikeExpr struct {
 
 // Restore implements Node interface.
 func (n *PatternLikeExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore PatternLikeExpr.Expr")
 	}
@@ -962,6 +985,7 @@ type ParenthesesExpr struct {
 
 // Restore implements Node interface.
 func (n *ParenthesesExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WritePlain("(")
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred when restore ParenthesesExpr.Expr")
@@ -1009,6 +1033,7 @@ type PositionExpr struct {
 
 // Restore implements Node interface.
 func (n *PositionExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WritePlainf("%d", n.N)
 	return nil
 }
@@ -1053,6 +1078,7 @@ type PatternRegexpExpr struct {
 
 // Restore implements Node interface.
 func (n *PatternRegexpExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore PatternRegexpExpr.Expr")
 	}
@@ -1111,6 +1137,7 @@ type RowExpr struct {
 
 // Restore implements Node interface.
 func (n *RowExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("ROW")
 	ctx.WritePlain("(")
 	for i, v := range n.Values {
@@ -1158,6 +1185,7 @@ type UnaryOperationExpr struct {
 
 // Restore implements Node interface.
 func (n *UnaryOperationExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.Op.Restore(ctx); err != nil {
 		return errors.Trace(err)
 	}
@@ -1197,6 +1225,7 @@ type ValuesExpr struct {
 
 // Restore implements Node interface.
 func (n *ValuesExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("VALUES")
 	ctx.WritePlain("(")
 	if err := n.Column.Restore(ctx); err != nil {
@@ -1246,6 +1275,7 @@ type VariableExpr struct {
 
 // Restore implements Node interface.
 func (n *VariableExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if n.IsSystem {
 		ctx.WritePlain("@@")
 		if n.ExplicitScope {
@@ -1302,6 +1332,7 @@ type MaxValueExpr struct {
 
 // Restore implements Node interface.
 func (n *MaxValueExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("MAXVALUE")
 	return nil
 }
@@ -1332,6 +1363,7 @@ type MatchAgainst struct {
 }
 
 func (n *MatchAgainst) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord("MATCH")
 	ctx.WritePlain(" (")
 	for i, v := range n.ColumnNames {
@@ -1411,6 +1443,7 @@ type SetCollationExpr struct {
 
 // Restore implements Node interface.
 func (n *SetCollationExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Trace(err)
 	}
@@ -364,6 +364,7 @@ type FuncCallExpr struct {
 
 // Restore implements Node interface.
 func (n *FuncCallExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	var specialLiteral string
 	switch n.FnName.L {
 	case DateLiteral:
@@ -550,6 +551,7 @@ type FuncCastExpr struct {
 
 // Restore implements Node interface.
 func (n *FuncCastExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	switch n.FunctionType {
 	case CastFunction:
 		ctx.WriteKeyWord("CAST")
@@ -651,6 +653,7 @@ type TrimDirectionExpr struct {
 
 // Restore implements Node interface.
 func (n *TrimDirectionExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord(n.Direction.String())
 	return nil
 }
@@ -739,6 +742,7 @@ type AggregateFuncExpr struct {
 
 // Restore implements Node interface.
 func (n *AggregateFuncExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord(n.F)
 	ctx.WritePlain("(")
 	if n.Distinct {
@@ -855,6 +859,7 @@ type WindowFuncExpr struct {
 
 // Restore implements Node interface.
 func (n *WindowFuncExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord(n.F)
 	ctx.WritePlain("(")
 	for i, v := range n.Args {
@@ -1038,6 +1043,7 @@ type TimeUnitExpr struct {
 
 // Restore implements Node interface.
 func (n *TimeUnitExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord(n.Unit.String())
 	return nil
 }
@@ -1091,6 +1097,7 @@ func (selector GetFormatSelectorType) String() string {
 
 // Restore implements Node interface.
 func (n *GetFormatSelectorExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord(n.Selector.String())
 	return nil
 }
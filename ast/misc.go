@@ -40,7 +40,9 @@ var (
 	_ StmtNode = &ExplainStmt{}
 	_ StmtNode = &GrantStmt{}
 	_ StmtNode = &PrepareStmt{}
+	_ StmtNode = &ReleaseSavepointStmt{}
 	_ StmtNode = &RollbackStmt{}
+	_ StmtNode = &SavepointStmt{}
 	_ StmtNode = &SetPwdStmt{}
 	_ StmtNode = &SetRoleStmt{}
 	_ StmtNode = &SetDefaultRoleStmt{}
@@ -51,9 +53,11 @@ var (
 	_ StmtNode = &CreateBindingStmt{}
 	_ StmtNode = &DropBindingStmt{}
 	_ StmtNode = &ShutdownStmt{}
+	_ StmtNode = &XAStmt{}
 
 	_ Node = &PrivElem{}
 	_ Node = &VariableAssignment{}
+	_ Node = &XID{}
 )
 
 // Isolation level constants.
@@ -134,6 +138,8 @@ type TraceStmt struct {
 
 // Restore implements Node interface.
 func (n *TraceStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("TRACE ")
 	if n.Format != "row" {
 		ctx.WriteKeyWord("FORMAT")
@@ -174,6 +180,8 @@ type ExplainForStmt struct {
 
 // Restore implements Node interface.
 func (n *ExplainForStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("EXPLAIN ")
 	ctx.WriteKeyWord("FORMAT ")
 	ctx.WritePlain("= ")
@@ -208,6 +216,8 @@ type ExplainStmt struct {
 
 // Restore implements Node interface.
 func (n *ExplainStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	if showStmt, ok := n.Stmt.(*ShowStmt); ok {
 		ctx.WriteKeyWord("DESC ")
 		if err := showStmt.Table.Restore(ctx); err != nil {
@@ -264,6 +274,8 @@ type PrepareStmt struct {
 
 // Restore implements Node interface.
 func (n *PrepareStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("PREPARE ")
 	ctx.WriteName(n.Name)
 	ctx.WriteKeyWord(" FROM ")
@@ -307,6 +319,8 @@ type DeallocateStmt struct {
 
 // Restore implements Node interface.
 func (n *DeallocateStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("DEALLOCATE PREPARE ")
 	ctx.WriteName(n.Name)
 	return nil
@@ -347,6 +361,8 @@ type ExecuteStmt struct {
 
 // Restore implements Node interface.
 func (n *ExecuteStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("EXECUTE ")
 	ctx.WriteName(n.Name)
 	if len(n.UsingVars) > 0 {
@@ -391,6 +407,8 @@ type BeginStmt struct {
 
 // Restore implements Node interface.
 func (n *BeginStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	if n.Mode == "" {
 		if n.ReadOnly {
 			ctx.WriteKeyWord("START TRANSACTION READ ONLY")
@@ -449,6 +467,8 @@ type BinlogStmt struct {
 
 // Restore implements Node interface.
 func (n *BinlogStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("BINLOG ")
 	ctx.WriteString(n.Str)
 	return nil
@@ -496,6 +516,8 @@ type CommitStmt struct {
 
 // Restore implements Node interface.
 func (n *CommitStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("COMMIT")
 	if err := n.CompletionType.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore CommitStmt.CompletionType")
@@ -519,11 +541,21 @@ type RollbackStmt struct {
 	stmtNode
 	// CompletionType overwrites system variable `completion_type` within transaction
 	CompletionType CompletionType
+	// SavepointName is the savepoint of ROLLBACK TO SAVEPOINT.
+	// The transaction is rolled back to the savepoint only, and goes on.
+	SavepointName string
 }
 
 // Restore implements Node interface.
 func (n *RollbackStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("ROLLBACK")
+	if n.SavepointName != "" {
+		ctx.WriteKeyWord(" TO SAVEPOINT ")
+		ctx.WriteName(n.SavepointName)
+		return nil
+	}
 	if err := n.CompletionType.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore RollbackStmt.CompletionType")
 	}
@@ -540,6 +572,204 @@ func (n *RollbackStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// SavepointStmt is a statement to set a savepoint in the current transaction.
+// See https://dev.mysql.com/doc/refman/5.7/en/savepoint.html
+type SavepointStmt struct {
+	stmtNode
+
+	Name string
+}
+
+// Restore implements Node interface.
+func (n *SavepointStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("SAVEPOINT ")
+	ctx.WriteName(n.Name)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *SavepointStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*SavepointStmt)
+	return v.Leave(n)
+}
+
+// ReleaseSavepointStmt is a statement to remove a savepoint of the current
+// transaction.
+// See https://dev.mysql.com/doc/refman/5.7/en/savepoint.html
+type ReleaseSavepointStmt struct {
+	stmtNode
+
+	Name string
+}
+
+// Restore implements Node interface.
+func (n *ReleaseSavepointStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("RELEASE SAVEPOINT ")
+	ctx.WriteName(n.Name)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *ReleaseSavepointStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ReleaseSavepointStmt)
+	return v.Leave(n)
+}
+
+// XID identifies an XA transaction.
+// See https://dev.mysql.com/doc/refman/5.7/en/xa-statements.html
+type XID struct {
+	node
+
+	GTRID string
+	BQual string
+	// FormatID is 1 unless given.
+	FormatID uint64
+}
+
+// Restore implements Node interface.
+func (n *XID) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	restoreXIDPart(ctx, n.GTRID)
+	if n.BQual == "" && n.FormatID == 1 {
+		return nil
+	}
+	ctx.WritePlain(",")
+	restoreXIDPart(ctx, n.BQual)
+	if n.FormatID != 1 {
+		ctx.WritePlainf(",%d", n.FormatID)
+	}
+	return nil
+}
+
+// restoreXIDPart writes a part of an XID as a string, or as a hexadecimal
+// literal when it holds other bytes than printable ASCII characters.
+func restoreXIDPart(ctx *format.RestoreCtx, s string) {
+	for i := 0; i < len(s); i++ {
+		if s[i] < ' ' || s[i] > '~' {
+			ctx.WritePlainf("0x%x", s)
+			return
+		}
+	}
+	ctx.WriteString(s)
+}
+
+// Accept implements Node Accept interface.
+func (n *XID) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*XID)
+	return v.Leave(n)
+}
+
+// XAStmtType is the type of an XA statement.
+type XAStmtType int
+
+// XA statement types.
+const (
+	XAStart XAStmtType = iota
+	XAEnd
+	XAPrepare
+	XACommit
+	XARollback
+	XARecover
+)
+
+// XAStmt is a statement to control an XA transaction.
+// See https://dev.mysql.com/doc/refman/5.7/en/xa-statements.html
+type XAStmt struct {
+	stmtNode
+
+	Tp XAStmtType
+	// XID is nil for XA RECOVER.
+	XID *XID
+
+	// Join and Resume are the options of XA START.
+	Join   bool
+	Resume bool
+	// Suspend and ForMigrate are the options of XA END.
+	Suspend    bool
+	ForMigrate bool
+	// OnePhase is the option of XA COMMIT.
+	OnePhase bool
+	// ConvertXID is the option of XA RECOVER.
+	ConvertXID bool
+}
+
+// Restore implements Node interface.
+func (n *XAStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("XA ")
+	switch n.Tp {
+	case XAStart:
+		ctx.WriteKeyWord("START ")
+	case XAEnd:
+		ctx.WriteKeyWord("END ")
+	case XAPrepare:
+		ctx.WriteKeyWord("PREPARE ")
+	case XACommit:
+		ctx.WriteKeyWord("COMMIT ")
+	case XARollback:
+		ctx.WriteKeyWord("ROLLBACK ")
+	case XARecover:
+		ctx.WriteKeyWord("RECOVER")
+		if n.ConvertXID {
+			ctx.WriteKeyWord(" CONVERT XID")
+		}
+		return nil
+	default:
+		return errors.New("Unsupported XA statement type")
+	}
+	if err := n.XID.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore XAStmt.XID")
+	}
+	switch {
+	case n.Join:
+		ctx.WriteKeyWord(" JOIN")
+	case n.Resume:
+		ctx.WriteKeyWord(" RESUME")
+	case n.Suspend:
+		ctx.WriteKeyWord(" SUSPEND")
+		if n.ForMigrate {
+			ctx.WriteKeyWord(" FOR MIGRATE")
+		}
+	case n.OnePhase:
+		ctx.WriteKeyWord(" ONE PHASE")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *XAStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*XAStmt)
+	if n.XID != nil {
+		node, ok := n.XID.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.XID = node.(*XID)
+	}
+	return v.Leave(n)
+}
+
 // UseStmt is a statement to use the DBName database as the current database.
 // See https://dev.mysql.com/doc/refman/5.7/en/use.html
 type UseStmt struct {
@@ -550,6 +780,8 @@ type UseStmt struct {
 
 // Restore implements Node interface.
 func (n *UseStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("USE ")
 	ctx.WriteName(n.DBName)
 	return nil
@@ -580,6 +812,12 @@ type VariableAssignment struct {
 	Value    ExprNode
 	IsGlobal bool
 	IsSystem bool
+	// IsLocal indicates the name refers to a local variable or a parameter
+	// of a stored program.
+	IsLocal bool
+	// TriggerRow is set when the name is a column of the NEW or OLD row in
+	// the body of a trigger.
+	TriggerRow TriggerRow
 
 	// ExtendValue is a way to store extended info.
 	// VariableAssignment should be able to store information for SetCharset/SetPWD Stmt.
@@ -590,6 +828,19 @@ type VariableAssignment struct {
 
 // Restore implements Node interface.
 func (n *VariableAssignment) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	if n.TriggerRow != TriggerRowNone {
+		ctx.WriteKeyWord(n.TriggerRow.String())
+		ctx.WritePlain(".")
+		ctx.WriteName(n.Name)
+		ctx.WritePlain("=")
+		return errors.Annotate(n.Value.Restore(ctx), "An error occurred while restore VariableAssignment.Value")
+	}
+	if n.IsLocal {
+		ctx.WriteName(n.Name)
+		ctx.WritePlain("=")
+		return errors.Annotate(n.Value.Restore(ctx), "An error occurred while restore VariableAssignment.Value")
+	}
 	if n.IsSystem {
 		ctx.WritePlain("@@")
 		if n.IsGlobal {
@@ -676,6 +927,8 @@ type FlushStmt struct {
 
 // Restore implements Node interface.
 func (n *FlushStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("FLUSH ")
 	if n.NoWriteToBinLog {
 		ctx.WriteKeyWord("NO_WRITE_TO_BINLOG ")
@@ -767,6 +1020,8 @@ type KillStmt struct {
 
 // Restore implements Node interface.
 func (n *KillStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("KILL")
 	if n.TiDBExtension {
 		ctx.WriteKeyWord(" TIDB")
@@ -797,6 +1052,8 @@ type SetStmt struct {
 
 // Restore implements Node interface.
 func (n *SetStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("SET ")
 	for i, v := range n.Variables {
 		if i != 0 {
@@ -837,6 +1094,8 @@ type SetConfigStmt struct {
 }
 
 func (n *SetConfigStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("SET CONFIG ")
 	if n.Type != "" {
 		ctx.WriteKeyWord(n.Type)
@@ -895,6 +1154,8 @@ type SetPwdStmt struct {
 
 // Restore implements Node interface.
 func (n *SetPwdStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("SET PASSWORD")
 	if n.User != nil {
 		ctx.WriteKeyWord(" FOR ")
@@ -932,6 +1193,8 @@ type ChangeStmt struct {
 
 // Restore implements Node interface.
 func (n *ChangeStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("CHANGE ")
 	ctx.WriteKeyWord(n.NodeType)
 	ctx.WriteKeyWord(" TO NODE_STATE ")
@@ -977,6 +1240,8 @@ type SetRoleStmt struct {
 }
 
 func (n *SetRoleStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("SET ROLE")
 	switch n.SetRoleOpt {
 	case SetRoleDefault:
@@ -1020,6 +1285,8 @@ type SetDefaultRoleStmt struct {
 }
 
 func (n *SetDefaultRoleStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("SET DEFAULT ROLE")
 	switch n.SetRoleOpt {
 	case SetRoleNone:
@@ -1237,6 +1504,8 @@ type CreateUserStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateUserStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	if n.IsCreateRole {
 		ctx.WriteKeyWord("CREATE ROLE ")
 	} else {
@@ -1323,6 +1592,8 @@ type AlterUserStmt struct {
 
 // Restore implements Node interface.
 func (n *AlterUserStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("ALTER USER ")
 	if n.IfExists {
 		ctx.WriteKeyWord("IF EXISTS ")
@@ -1408,6 +1679,8 @@ type AlterInstanceStmt struct {
 
 // Restore implements Node interface.
 func (n *AlterInstanceStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("ALTER INSTANCE")
 	if n.ReloadTLS {
 		ctx.WriteKeyWord(" RELOAD TLS")
@@ -1440,6 +1713,8 @@ type DropUserStmt struct {
 
 // Restore implements Node interface.
 func (n *DropUserStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	if n.IsDropRole {
 		ctx.WriteKeyWord("DROP ROLE ")
 	} else {
@@ -1479,6 +1754,8 @@ type CreateBindingStmt struct {
 }
 
 func (n *CreateBindingStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("CREATE ")
 	if n.GlobalScope {
 		ctx.WriteKeyWord("GLOBAL ")
@@ -1525,6 +1802,8 @@ type DropBindingStmt struct {
 }
 
 func (n *DropBindingStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("DROP ")
 	if n.GlobalScope {
 		ctx.WriteKeyWord("GLOBAL ")
@@ -1596,6 +1875,8 @@ type CreateStatisticsStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateStatisticsStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("CREATE STATISTICS ")
 	if n.IfNotExists {
 		ctx.WriteKeyWord("IF NOT EXISTS ")
@@ -1660,6 +1941,8 @@ type DropStatisticsStmt struct {
 
 // Restore implements Node interface.
 func (n *DropStatisticsStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("DROP STATISTICS ")
 	ctx.WriteName(n.StatsName)
 	return nil
@@ -1684,6 +1967,8 @@ type DoStmt struct {
 
 // Restore implements Node interface.
 func (n *DoStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("DO ")
 	for i, v := range n.Exprs {
 		if i != 0 {
@@ -1822,6 +2107,8 @@ type AdminStmt struct {
 
 // Restore implements Node interface.
 func (n *AdminStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	restoreTables := func() error {
 		for i, v := range n.Tables {
 			if i != 0 {
@@ -2028,6 +2315,7 @@ type PrivElem struct {
 
 // Restore implements Node interface.
 func (n *PrivElem) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	if n.Priv == mysql.AllPriv {
 		ctx.WriteKeyWord("ALL")
 	} else if n.Priv == mysql.ExtendedPriv {
@@ -2158,6 +2446,8 @@ type RevokeStmt struct {
 
 // Restore implements Node interface.
 func (n *RevokeStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("REVOKE ")
 	for i, v := range n.Privs {
 		if i != 0 {
@@ -2216,6 +2506,8 @@ type RevokeRoleStmt struct {
 
 // Restore implements Node interface.
 func (n *RevokeRoleStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("REVOKE ")
 	for i, role := range n.Roles {
 		if i != 0 {
@@ -2261,6 +2553,8 @@ type GrantStmt struct {
 
 // Restore implements Node interface.
 func (n *GrantStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("GRANT ")
 	for i, v := range n.Privs {
 		if i != 0 && v.Priv != 0 {
@@ -2359,6 +2653,8 @@ func (n *GrantProxyStmt) Accept(v Visitor) (Node, bool) {
 
 // Restore implements Node interface.
 func (n *GrantProxyStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("GRANT PROXY ON ")
 	if err := n.LocalUser.Restore(ctx); err != nil {
 		return errors.Annotatef(err, "An error occurred while restore GrantProxyStmt.LocalUser")
@@ -2398,6 +2694,8 @@ func (n *GrantRoleStmt) Accept(v Visitor) (Node, bool) {
 
 // Restore implements Node interface.
 func (n *GrantRoleStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("GRANT ")
 	if len(n.Roles) > 0 {
 		for i, role := range n.Roles {
@@ -2440,6 +2738,8 @@ type ShutdownStmt struct {
 
 // Restore implements Node interface.
 func (n *ShutdownStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("SHUTDOWN")
 	return nil
 }
@@ -2615,6 +2915,8 @@ func (n *BRIEStmt) Accept(v Visitor) (Node, bool) {
 }
 
 func (n *BRIEStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord(n.Kind.String())
 
 	switch {
@@ -2736,6 +3038,8 @@ func (n *PurgeImportStmt) Accept(v Visitor) (Node, bool) {
 }
 
 func (n *PurgeImportStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WritePlainf("PURGE IMPORT %d", n.TaskID)
 	return nil
 }
@@ -2842,6 +3146,7 @@ func (ht *HintTable) Restore(ctx *format.RestoreCtx) {
 
 // Restore implements Node interface.
 func (n *TableOptimizerHint) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WriteKeyWord(n.HintName.String())
 	ctx.WritePlain("(")
 	if n.QBName.L != "" {
@@ -2865,7 +3170,7 @@ func (n *TableOptimizerHint) Restore(ctx *format.RestoreCtx) error {
 		ctx.WritePlainf("%d", n.HintData.(uint64))
 	case "nth_plan":
 		ctx.WritePlainf("%d", n.HintData.(int64))
-	case "tidb_hj", "tidb_smj", "tidb_inlj", "hash_join", "merge_join", "inl_join", "broadcast_join", "broadcast_join_local", "inl_hash_join", "inl_merge_join":
+	case "tidb_hj", "tidb_smj", "tidb_inlj", "hash_join", "merge_join", "inl_join", "broadcast_join", "broadcast_join_local", "inl_hash_join", "inl_merge_join", "leading":
 		for i, table := range n.Tables {
 			if i != 0 {
 				ctx.WritePlain(", ")
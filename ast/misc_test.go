@@ -152,6 +152,9 @@ func (ts *testMiscSuite) TestSensitiveStatement(c *C) {
 		&CreateUserStmt{},
 		&AlterUserStmt{},
 		&GrantStmt{},
+		&ChangeReplicationSourceStmt{},
+		&StartReplicaStmt{},
+		&CloneStmt{},
 	}
 	for i, stmt := range positive {
 		_, ok := stmt.(SensitiveStmtNode)
@@ -170,6 +173,8 @@ func (ts *testMiscSuite) TestSensitiveStatement(c *C) {
 		&DropTableStmt{},
 		&RenameTableStmt{},
 		&TruncateTableStmt{},
+		&StopReplicaStmt{},
+		&InstallPluginStmt{},
 	}
 	for _, stmt := range negative {
 		_, ok := stmt.(SensitiveStmtNode)
@@ -177,6 +182,24 @@ func (ts *testMiscSuite) TestSensitiveStatement(c *C) {
 	}
 }
 
+func (ts *testMiscSuite) TestReplicationSecureText(c *C) {
+	testCases := []struct {
+		sql    string
+		secure string
+	}{
+		{"change master to master_host='h1', master_password='secret'", "CHANGE MASTER TO MASTER_HOST = 'h1', MASTER_PASSWORD = '***'"},
+		{"change replication source to source_password='secret' for channel 'c1'", "CHANGE REPLICATION SOURCE TO SOURCE_PASSWORD = '***' FOR CHANNEL 'c1'"},
+		{"start replica user='u' password='secret'", "START REPLICA USER = 'u' PASSWORD = '***'"},
+		{"clone instance from 'u'@'h':3306 identified by 'secret'", "CLONE INSTANCE FROM `u`@`h`:3306 IDENTIFIED BY '***'"},
+	}
+	p := parser.New()
+	for _, tc := range testCases {
+		stmt, err := p.ParseOneStmt(tc.sql, "", "")
+		c.Assert(err, IsNil)
+		c.Assert(stmt.(SensitiveStmtNode).SecureText(), Equals, tc.secure)
+	}
+}
+
 func (ts *testMiscSuite) TestUserSpec(c *C) {
 	hashString := "*3D56A309CD04FA2EEF181462E59011F075C89548"
 	u := UserSpec{
@@ -235,6 +258,7 @@ func (ts *testMiscSuite) TestTableOptimizerHintRestore(c *C) {
 		{"BROADCAST_JOIN(t1,t2)", "BROADCAST_JOIN(`t1`, `t2`)"},
 		{"INL_HASH_JOIN(t1,t2)", "INL_HASH_JOIN(`t1`, `t2`)"},
 		{"INL_MERGE_JOIN(t1,t2)", "INL_MERGE_JOIN(`t1`, `t2`)"},
+		{"LEADING(t2, t1@qb)", "LEADING(`t2`, `t1`@`qb`)"},
 		{"INL_JOIN(t1,t2)", "INL_JOIN(`t1`, `t2`)"},
 		{"HASH_JOIN(t1,t2)", "HASH_JOIN(`t1`, `t2`)"},
 		{"MAX_EXECUTION_TIME(3000)", "MAX_EXECUTION_TIME(3000)"},
@@ -288,6 +312,22 @@ func (ts *testMiscSuite) TestChangeStmtRestore(c *C) {
 	RunNodeRestoreTest(c, testCases, "%s", extractNodeFunc)
 }
 
+func (ts *testMiscSuite) TestTransactionStmtRestore(c *C) {
+	testCases := []NodeRestoreTestCase{
+		{"savepoint sp", "SAVEPOINT `sp`"},
+		{"rollback work to sp", "ROLLBACK TO SAVEPOINT `sp`"},
+		{"release savepoint sp", "RELEASE SAVEPOINT `sp`"},
+		{"xa begin 'gt', 'bq', 2 join", "XA START 'gt','bq',2 JOIN"},
+		{"xa end 'gt' suspend for migrate", "XA END 'gt' SUSPEND FOR MIGRATE"},
+		{"xa commit 'gt', '' one phase", "XA COMMIT 'gt' ONE PHASE"},
+		{"xa recover convert xid", "XA RECOVER CONVERT XID"},
+	}
+	extractNodeFunc := func(node Node) Node {
+		return node
+	}
+	RunNodeRestoreTest(c, testCases, "%s", extractNodeFunc)
+}
+
 func (ts *testMiscSuite) TestBRIESecureText(c *C) {
 	testCases := []struct {
 		input   string
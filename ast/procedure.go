@@ -0,0 +1,1163 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/kyleconroy/sqlparse/auth"
+	"github.com/kyleconroy/sqlparse/format"
+	"github.com/kyleconroy/sqlparse/model"
+	"github.com/kyleconroy/sqlparse/types"
+)
+
+var (
+	_ DDLNode = &CreateRoutineStmt{}
+	_ DDLNode = &DropRoutineStmt{}
+	_ DDLNode = &AlterRoutineStmt{}
+
+	_ StmtNode = &BlockStmt{}
+	_ StmtNode = &DeclareVarStmt{}
+	_ StmtNode = &DeclareConditionStmt{}
+	_ StmtNode = &DeclareCursorStmt{}
+	_ StmtNode = &DeclareHandlerStmt{}
+	_ StmtNode = &IfStmt{}
+	_ StmtNode = &CaseStmt{}
+	_ StmtNode = &LoopStmt{}
+	_ StmtNode = &WhileStmt{}
+	_ StmtNode = &RepeatStmt{}
+	_ StmtNode = &LeaveStmt{}
+	_ StmtNode = &IterateStmt{}
+	_ StmtNode = &ReturnStmt{}
+	_ StmtNode = &OpenCursorStmt{}
+	_ StmtNode = &CloseCursorStmt{}
+	_ StmtNode = &FetchCursorStmt{}
+	_ StmtNode = &SignalStmt{}
+
+	_ Node = &RoutineParam{}
+)
+
+// RoutineType is the type of a stored routine.
+type RoutineType int
+
+// RoutineType values.
+const (
+	RoutineProcedure RoutineType = iota
+	RoutineFunction
+)
+
+// String implements fmt.Stringer interface.
+func (t RoutineType) String() string {
+	if t == RoutineFunction {
+		return "FUNCTION"
+	}
+	return "PROCEDURE"
+}
+
+// ParamMode is the direction of a parameter of a stored procedure.
+type ParamMode int
+
+// ParamMode values.
+const (
+	ParamModeIn ParamMode = iota
+	ParamModeOut
+	ParamModeInOut
+)
+
+// RoutineParam is a parameter of a stored routine.
+type RoutineParam struct {
+	node
+
+	Mode ParamMode
+	Name model.CIStr
+	Tp   *types.FieldType
+}
+
+// Restore implements Node interface.
+func (n *RoutineParam) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	switch n.Mode {
+	case ParamModeOut:
+		ctx.WriteKeyWord("OUT ")
+	case ParamModeInOut:
+		ctx.WriteKeyWord("INOUT ")
+	}
+	ctx.WriteName(n.Name.O)
+	ctx.WritePlain(" ")
+	return errors.Annotate(n.Tp.Restore(ctx), "An error occurred while restore RoutineParam.Tp")
+}
+
+// Accept implements Node Accept interface.
+func (n *RoutineParam) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*RoutineParam)
+	return v.Leave(n)
+}
+
+// RoutineCharacteristicType is the type of a characteristic of a stored routine.
+type RoutineCharacteristicType int
+
+// RoutineCharacteristicType values.
+const (
+	RoutineCharacteristicComment RoutineCharacteristicType = iota
+	RoutineCharacteristicLanguageSQL
+	RoutineCharacteristicDeterministic
+	RoutineCharacteristicNotDeterministic
+	RoutineCharacteristicContainsSQL
+	RoutineCharacteristicNoSQL
+	RoutineCharacteristicReadsSQLData
+	RoutineCharacteristicModifiesSQLData
+	RoutineCharacteristicSQLSecurity
+)
+
+// RoutineCharacteristic is a characteristic of a stored routine, such as
+// its comment or the kind of data it accesses.
+// See https://dev.mysql.com/doc/refman/8.0/en/create-procedure.html
+type RoutineCharacteristic struct {
+	Tp       RoutineCharacteristicType
+	Comment  string
+	Security model.ViewSecurity
+}
+
+// Restore implements Node interface.
+func (n *RoutineCharacteristic) Restore(ctx *format.RestoreCtx) error {
+	switch n.Tp {
+	case RoutineCharacteristicComment:
+		ctx.WriteKeyWord("COMMENT ")
+		ctx.WriteString(n.Comment)
+	case RoutineCharacteristicLanguageSQL:
+		ctx.WriteKeyWord("LANGUAGE SQL")
+	case RoutineCharacteristicDeterministic:
+		ctx.WriteKeyWord("DETERMINISTIC")
+	case RoutineCharacteristicNotDeterministic:
+		ctx.WriteKeyWord("NOT DETERMINISTIC")
+	case RoutineCharacteristicContainsSQL:
+		ctx.WriteKeyWord("CONTAINS SQL")
+	case RoutineCharacteristicNoSQL:
+		ctx.WriteKeyWord("NO SQL")
+	case RoutineCharacteristicReadsSQLData:
+		ctx.WriteKeyWord("READS SQL DATA")
+	case RoutineCharacteristicModifiesSQLData:
+		ctx.WriteKeyWord("MODIFIES SQL DATA")
+	case RoutineCharacteristicSQLSecurity:
+		ctx.WriteKeyWord("SQL SECURITY ")
+		ctx.WriteKeyWord(n.Security.String())
+	default:
+		return errors.Errorf("invalid RoutineCharacteristicType: %d", n.Tp)
+	}
+	return nil
+}
+
+func restoreCharacteristics(ctx *format.RestoreCtx, chars []*RoutineCharacteristic) error {
+	for i, char := range chars {
+		ctx.WritePlain(" ")
+		if err := char.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore Characteristics[%d]", i)
+		}
+	}
+	return nil
+}
+
+// restoreDefiner restores the DEFINER clause of a stored program, if any.
+func restoreDefiner(ctx *format.RestoreCtx, definer *auth.UserIdentity) error {
+	if definer == nil {
+		return nil
+	}
+	ctx.WriteKeyWord("DEFINER")
+	ctx.WritePlain(" = ")
+	if err := definer.Restore(ctx); err != nil {
+		return err
+	}
+	ctx.WritePlain(" ")
+	return nil
+}
+
+// CreateRoutineStmt is a statement to create a stored procedure or function.
+// See https://dev.mysql.com/doc/refman/8.0/en/create-procedure.html
+type CreateRoutineStmt struct {
+	ddlNode
+
+	Tp          RoutineType
+	OrReplace   bool
+	Definer     *auth.UserIdentity
+	IfNotExists bool
+	Name        *TableName
+	Params      []*RoutineParam
+	// Returns is the type of the value a function returns.
+	Returns         *types.FieldType
+	Characteristics []*RoutineCharacteristic
+	Body            StmtNode
+}
+
+// Restore implements Node interface.
+func (n *CreateRoutineStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("CREATE ")
+	if n.OrReplace {
+		ctx.WriteKeyWord("OR REPLACE ")
+	}
+	if err := restoreDefiner(ctx, n.Definer); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateRoutineStmt.Definer")
+	}
+	ctx.WriteKeyWord(n.Tp.String())
+	ctx.WritePlain(" ")
+	if n.IfNotExists {
+		ctx.WriteKeyWord("IF NOT EXISTS ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateRoutineStmt.Name")
+	}
+	ctx.WritePlain("(")
+	for i, param := range n.Params {
+		if i != 0 {
+			ctx.WritePlain(", ")
+		}
+		if err := param.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore CreateRoutineStmt.Params[%d]", i)
+		}
+	}
+	ctx.WritePlain(")")
+	if n.Returns != nil {
+		ctx.WriteKeyWord(" RETURNS ")
+		if err := n.Returns.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore CreateRoutineStmt.Returns")
+		}
+	}
+	if err := restoreCharacteristics(ctx, n.Characteristics); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateRoutineStmt")
+	}
+	ctx.WriteLineBreak(" ")
+	if err := n.Body.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateRoutineStmt.Body")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateRoutineStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateRoutineStmt)
+	node, ok := n.Name.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Name = node.(*TableName)
+	for i, param := range n.Params {
+		node, ok = param.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Params[i] = node.(*RoutineParam)
+	}
+	node, ok = n.Body.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Body = node.(StmtNode)
+	return v.Leave(n)
+}
+
+// DropRoutineStmt is a statement to drop a stored procedure or function.
+// See https://dev.mysql.com/doc/refman/8.0/en/drop-procedure.html
+type DropRoutineStmt struct {
+	ddlNode
+
+	Tp       RoutineType
+	IfExists bool
+	Name     *TableName
+}
+
+// Restore implements Node interface.
+func (n *DropRoutineStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("DROP ")
+	ctx.WriteKeyWord(n.Tp.String())
+	ctx.WritePlain(" ")
+	if n.IfExists {
+		ctx.WriteKeyWord("IF EXISTS ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore DropRoutineStmt.Name")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DropRoutineStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropRoutineStmt)
+	node, ok := n.Name.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Name = node.(*TableName)
+	return v.Leave(n)
+}
+
+// AlterRoutineStmt is a statement to change the characteristics of a stored
+// procedure or function.
+// See https://dev.mysql.com/doc/refman/8.0/en/alter-procedure.html
+type AlterRoutineStmt struct {
+	ddlNode
+
+	Tp              RoutineType
+	Name            *TableName
+	Characteristics []*RoutineCharacteristic
+}
+
+// Restore implements Node interface.
+func (n *AlterRoutineStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("ALTER ")
+	ctx.WriteKeyWord(n.Tp.String())
+	ctx.WritePlain(" ")
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore AlterRoutineStmt.Name")
+	}
+	return errors.Annotate(restoreCharacteristics(ctx, n.Characteristics), "An error occurred while restore AlterRoutineStmt")
+}
+
+// Accept implements Node Accept interface.
+func (n *AlterRoutineStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*AlterRoutineStmt)
+	node, ok := n.Name.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Name = node.(*TableName)
+	return v.Leave(n)
+}
+
+// restoreStmtList restores the statements of a compound statement, each one
+// on its own line and followed by a semicolon.
+func restoreStmtList(ctx *format.RestoreCtx, stmts []StmtNode) error {
+	ctx.Indent()
+	defer ctx.Dedent()
+	for i, stmt := range stmts {
+		ctx.WriteLineBreak(" ")
+		if err := stmt.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore Stmts[%d]", i)
+		}
+		ctx.WritePlain(";")
+	}
+	return nil
+}
+
+// acceptStmtList visits the statements of a compound statement, it returns
+// false if the visit is aborted.
+func acceptStmtList(v Visitor, stmts []StmtNode) bool {
+	for i, stmt := range stmts {
+		node, ok := stmt.Accept(v)
+		if !ok {
+			return false
+		}
+		stmts[i] = node.(StmtNode)
+	}
+	return true
+}
+
+func acceptExpr(v Visitor, expr *ExprNode) bool {
+	if *expr == nil {
+		return true
+	}
+	node, ok := (*expr).Accept(v)
+	if !ok {
+		return false
+	}
+	*expr = node.(ExprNode)
+	return true
+}
+
+func restoreBeginLabel(ctx *format.RestoreCtx, label model.CIStr) {
+	if label.O != "" {
+		ctx.WriteName(label.O)
+		ctx.WritePlain(": ")
+	}
+}
+
+func restoreEndLabel(ctx *format.RestoreCtx, label model.CIStr) {
+	if label.O != "" {
+		ctx.WritePlain(" ")
+		ctx.WriteName(label.O)
+	}
+}
+
+// BlockStmt is a BEGIN ... END compound statement.
+// See https://dev.mysql.com/doc/refman/8.0/en/begin-end.html
+type BlockStmt struct {
+	stmtNode
+
+	Label model.CIStr
+	Stmts []StmtNode
+}
+
+// Restore implements Node interface.
+func (n *BlockStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	restoreBeginLabel(ctx, n.Label)
+	ctx.WriteKeyWord("BEGIN")
+	if err := restoreStmtList(ctx, n.Stmts); err != nil {
+		return errors.Annotate(err, "An error occurred while restore BlockStmt")
+	}
+	ctx.WriteLineBreak(" ")
+	ctx.WriteKeyWord("END")
+	restoreEndLabel(ctx, n.Label)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *BlockStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*BlockStmt)
+	if !acceptStmtList(v, n.Stmts) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// DeclareVarStmt declares local variables of a stored program.
+// See https://dev.mysql.com/doc/refman/8.0/en/declare-local-variable.html
+type DeclareVarStmt struct {
+	stmtNode
+
+	Names   []model.CIStr
+	Tp      *types.FieldType
+	Default ExprNode
+}
+
+// Restore implements Node interface.
+func (n *DeclareVarStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("DECLARE ")
+	for i, name := range n.Names {
+		if i != 0 {
+			ctx.WritePlain(", ")
+		}
+		ctx.WriteName(name.O)
+	}
+	ctx.WritePlain(" ")
+	if err := n.Tp.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore DeclareVarStmt.Tp")
+	}
+	if n.Default != nil {
+		ctx.WriteKeyWord(" DEFAULT ")
+		if err := n.Default.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore DeclareVarStmt.Default")
+		}
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DeclareVarStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DeclareVarStmt)
+	if !acceptExpr(v, &n.Default) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// ConditionValueType is the type of a condition value.
+type ConditionValueType int
+
+// ConditionValueType values.
+const (
+	ConditionErrorCode ConditionValueType = iota
+	ConditionSQLState
+	ConditionName
+	ConditionSQLWarning
+	ConditionNotFound
+	ConditionSQLException
+)
+
+// ConditionValue is a condition a handler is declared for, or the condition
+// a SIGNAL statement raises.
+// See https://dev.mysql.com/doc/refman/8.0/en/declare-handler.html
+type ConditionValue struct {
+	Tp        ConditionValueType
+	ErrorCode uint64
+	SQLState  string
+	Name      model.CIStr
+}
+
+// Restore implements Node interface.
+func (n *ConditionValue) Restore(ctx *format.RestoreCtx) error {
+	switch n.Tp {
+	case ConditionErrorCode:
+		ctx.WritePlainf("%d", n.ErrorCode)
+	case ConditionSQLState:
+		ctx.WriteKeyWord("SQLSTATE ")
+		ctx.WriteString(n.SQLState)
+	case ConditionName:
+		ctx.WriteName(n.Name.O)
+	case ConditionSQLWarning:
+		ctx.WriteKeyWord("SQLWARNING")
+	case ConditionNotFound:
+		ctx.WriteKeyWord("NOT FOUND")
+	case ConditionSQLException:
+		ctx.WriteKeyWord("SQLEXCEPTION")
+	default:
+		return errors.Errorf("invalid ConditionValueType: %d", n.Tp)
+	}
+	return nil
+}
+
+// DeclareConditionStmt names an error condition.
+// See https://dev.mysql.com/doc/refman/8.0/en/declare-condition.html
+type DeclareConditionStmt struct {
+	stmtNode
+
+	Name  model.CIStr
+	Value *ConditionValue
+}
+
+// Restore implements Node interface.
+func (n *DeclareConditionStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("DECLARE ")
+	ctx.WriteName(n.Name.O)
+	ctx.WriteKeyWord(" CONDITION FOR ")
+	return errors.Annotate(n.Value.Restore(ctx), "An error occurred while restore DeclareConditionStmt.Value")
+}
+
+// Accept implements Node Accept interface.
+func (n *DeclareConditionStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DeclareConditionStmt)
+	return v.Leave(n)
+}
+
+// DeclareCursorStmt declares a cursor.
+// See https://dev.mysql.com/doc/refman/8.0/en/declare-cursor.html
+type DeclareCursorStmt struct {
+	stmtNode
+
+	Name  model.CIStr
+	Query ResultSetNode
+}
+
+// Restore implements Node interface.
+func (n *DeclareCursorStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("DECLARE ")
+	ctx.WriteName(n.Name.O)
+	ctx.WriteKeyWord(" CURSOR FOR ")
+	return errors.Annotate(n.Query.Restore(ctx), "An error occurred while restore DeclareCursorStmt.Query")
+}
+
+// Accept implements Node Accept interface.
+func (n *DeclareCursorStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DeclareCursorStmt)
+	node, ok := n.Query.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Query = node.(ResultSetNode)
+	return v.Leave(n)
+}
+
+// HandlerAction is what happens after a handler runs.
+type HandlerAction int
+
+// HandlerAction values.
+const (
+	HandlerContinue HandlerAction = iota
+	HandlerExit
+	HandlerUndo
+)
+
+// String implements fmt.Stringer interface.
+func (a HandlerAction) String() string {
+	switch a {
+	case HandlerExit:
+		return "EXIT"
+	case HandlerUndo:
+		return "UNDO"
+	}
+	return "CONTINUE"
+}
+
+// DeclareHandlerStmt declares a handler for conditions.
+// See https://dev.mysql.com/doc/refman/8.0/en/declare-handler.html
+type DeclareHandlerStmt struct {
+	stmtNode
+
+	Action     HandlerAction
+	Conditions []*ConditionValue
+	Stmt       StmtNode
+}
+
+// Restore implements Node interface.
+func (n *DeclareHandlerStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("DECLARE ")
+	ctx.WriteKeyWord(n.Action.String())
+	ctx.WriteKeyWord(" HANDLER FOR ")
+	for i, cond := range n.Conditions {
+		if i != 0 {
+			ctx.WritePlain(", ")
+		}
+		if err := cond.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore DeclareHandlerStmt.Conditions[%d]", i)
+		}
+	}
+	ctx.WritePlain(" ")
+	return errors.Annotate(n.Stmt.Restore(ctx), "An error occurred while restore DeclareHandlerStmt.Stmt")
+}
+
+// Accept implements Node Accept interface.
+func (n *DeclareHandlerStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DeclareHandlerStmt)
+	node, ok := n.Stmt.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Stmt = node.(StmtNode)
+	return v.Leave(n)
+}
+
+// IfBranch is the IF or an ELSEIF branch of an IF statement.
+type IfBranch struct {
+	Cond  ExprNode
+	Stmts []StmtNode
+}
+
+// IfStmt is an IF statement of a stored program.
+// See https://dev.mysql.com/doc/refman/8.0/en/if.html
+type IfStmt struct {
+	stmtNode
+
+	Branches []*IfBranch
+	// Else is nil if there is no ELSE branch.
+	Else []StmtNode
+}
+
+// Restore implements Node interface.
+func (n *IfStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	for i, branch := range n.Branches {
+		if i == 0 {
+			ctx.WriteKeyWord("IF ")
+		} else {
+			ctx.WriteLineBreak(" ")
+			ctx.WriteKeyWord("ELSEIF ")
+		}
+		if err := branch.Cond.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore IfStmt.Branches[%d].Cond", i)
+		}
+		ctx.WriteKeyWord(" THEN")
+		if err := restoreStmtList(ctx, branch.Stmts); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore IfStmt.Branches[%d]", i)
+		}
+	}
+	if n.Else != nil {
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("ELSE")
+		if err := restoreStmtList(ctx, n.Else); err != nil {
+			return errors.Annotate(err, "An error occurred while restore IfStmt.Else")
+		}
+	}
+	ctx.WriteLineBreak(" ")
+	ctx.WriteKeyWord("END IF")
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *IfStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*IfStmt)
+	for _, branch := range n.Branches {
+		if !acceptExpr(v, &branch.Cond) || !acceptStmtList(v, branch.Stmts) {
+			return n, false
+		}
+	}
+	if !acceptStmtList(v, n.Else) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// CaseStmtWhen is a WHEN branch of a CASE statement.
+type CaseStmtWhen struct {
+	Expr  ExprNode
+	Stmts []StmtNode
+}
+
+// CaseStmt is a CASE statement of a stored program. Value is nil for a
+// searched CASE statement, whose WHEN branches are conditions.
+// See https://dev.mysql.com/doc/refman/8.0/en/case.html
+type CaseStmt struct {
+	stmtNode
+
+	Value       ExprNode
+	WhenClauses []*CaseStmtWhen
+	// Else is nil if there is no ELSE branch.
+	Else []StmtNode
+}
+
+// Restore implements Node interface.
+func (n *CaseStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("CASE")
+	if n.Value != nil {
+		ctx.WritePlain(" ")
+		if err := n.Value.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore CaseStmt.Value")
+		}
+	}
+	ctx.Indent()
+	for i, when := range n.WhenClauses {
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("WHEN ")
+		if err := when.Expr.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore CaseStmt.WhenClauses[%d].Expr", i)
+		}
+		ctx.WriteKeyWord(" THEN")
+		if err := restoreStmtList(ctx, when.Stmts); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore CaseStmt.WhenClauses[%d]", i)
+		}
+	}
+	if n.Else != nil {
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("ELSE")
+		if err := restoreStmtList(ctx, n.Else); err != nil {
+			return errors.Annotate(err, "An error occurred while restore CaseStmt.Else")
+		}
+	}
+	ctx.Dedent()
+	ctx.WriteLineBreak(" ")
+	ctx.WriteKeyWord("END CASE")
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *CaseStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CaseStmt)
+	if !acceptExpr(v, &n.Value) {
+		return n, false
+	}
+	for _, when := range n.WhenClauses {
+		if !acceptExpr(v, &when.Expr) || !acceptStmtList(v, when.Stmts) {
+			return n, false
+		}
+	}
+	if !acceptStmtList(v, n.Else) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// LoopStmt is a LOOP statement.
+// See https://dev.mysql.com/doc/refman/8.0/en/loop.html
+type LoopStmt struct {
+	stmtNode
+
+	Label model.CIStr
+	Stmts []StmtNode
+}
+
+// Restore implements Node interface.
+func (n *LoopStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	restoreBeginLabel(ctx, n.Label)
+	ctx.WriteKeyWord("LOOP")
+	if err := restoreStmtList(ctx, n.Stmts); err != nil {
+		return errors.Annotate(err, "An error occurred while restore LoopStmt")
+	}
+	ctx.WriteLineBreak(" ")
+	ctx.WriteKeyWord("END LOOP")
+	restoreEndLabel(ctx, n.Label)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *LoopStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*LoopStmt)
+	if !acceptStmtList(v, n.Stmts) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// WhileStmt is a WHILE statement.
+// See https://dev.mysql.com/doc/refman/8.0/en/while.html
+type WhileStmt struct {
+	stmtNode
+
+	Label model.CIStr
+	Cond  ExprNode
+	Stmts []StmtNode
+}
+
+// Restore implements Node interface.
+func (n *WhileStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	restoreBeginLabel(ctx, n.Label)
+	ctx.WriteKeyWord("WHILE ")
+	if err := n.Cond.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore WhileStmt.Cond")
+	}
+	ctx.WriteKeyWord(" DO")
+	if err := restoreStmtList(ctx, n.Stmts); err != nil {
+		return errors.Annotate(err, "An error occurred while restore WhileStmt")
+	}
+	ctx.WriteLineBreak(" ")
+	ctx.WriteKeyWord("END WHILE")
+	restoreEndLabel(ctx, n.Label)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *WhileStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*WhileStmt)
+	if !acceptExpr(v, &n.Cond) || !acceptStmtList(v, n.Stmts) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// RepeatStmt is a REPEAT statement.
+// See https://dev.mysql.com/doc/refman/8.0/en/repeat.html
+type RepeatStmt struct {
+	stmtNode
+
+	Label model.CIStr
+	Stmts []StmtNode
+	Until ExprNode
+}
+
+// Restore implements Node interface.
+func (n *RepeatStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	restoreBeginLabel(ctx, n.Label)
+	ctx.WriteKeyWord("REPEAT")
+	if err := restoreStmtList(ctx, n.Stmts); err != nil {
+		return errors.Annotate(err, "An error occurred while restore RepeatStmt")
+	}
+	ctx.WriteLineBreak(" ")
+	ctx.WriteKeyWord("UNTIL ")
+	if err := n.Until.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore RepeatStmt.Until")
+	}
+	ctx.WriteLineBreak(" ")
+	ctx.WriteKeyWord("END REPEAT")
+	restoreEndLabel(ctx, n.Label)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *RepeatStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*RepeatStmt)
+	if !acceptStmtList(v, n.Stmts) || !acceptExpr(v, &n.Until) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// LeaveStmt exits the labeled statement.
+// See https://dev.mysql.com/doc/refman/8.0/en/leave.html
+type LeaveStmt struct {
+	stmtNode
+
+	Label model.CIStr
+}
+
+// Restore implements Node interface.
+func (n *LeaveStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("LEAVE ")
+	ctx.WriteName(n.Label.O)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *LeaveStmt) Accept(v Visitor) (Node, bool) {
+	newNode, _ := v.Enter(n)
+	return v.Leave(newNode)
+}
+
+// IterateStmt starts the next iteration of the labeled loop.
+// See https://dev.mysql.com/doc/refman/8.0/en/iterate.html
+type IterateStmt struct {
+	stmtNode
+
+	Label model.CIStr
+}
+
+// Restore implements Node interface.
+func (n *IterateStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("ITERATE ")
+	ctx.WriteName(n.Label.O)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *IterateStmt) Accept(v Visitor) (Node, bool) {
+	newNode, _ := v.Enter(n)
+	return v.Leave(newNode)
+}
+
+// ReturnStmt returns the value of a stored function.
+// See https://dev.mysql.com/doc/refman/8.0/en/return.html
+type ReturnStmt struct {
+	stmtNode
+
+	Expr ExprNode
+}
+
+// Restore implements Node interface.
+func (n *ReturnStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("RETURN ")
+	return errors.Annotate(n.Expr.Restore(ctx), "An error occurred while restore ReturnStmt.Expr")
+}
+
+// Accept implements Node Accept interface.
+func (n *ReturnStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ReturnStmt)
+	if !acceptExpr(v, &n.Expr) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// OpenCursorStmt opens a cursor.
+// See https://dev.mysql.com/doc/refman/8.0/en/open.html
+type OpenCursorStmt struct {
+	stmtNode
+
+	Name model.CIStr
+}
+
+// Restore implements Node interface.
+func (n *OpenCursorStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("OPEN ")
+	ctx.WriteName(n.Name.O)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *OpenCursorStmt) Accept(v Visitor) (Node, bool) {
+	newNode, _ := v.Enter(n)
+	return v.Leave(newNode)
+}
+
+// CloseCursorStmt closes a cursor.
+// See https://dev.mysql.com/doc/refman/8.0/en/close.html
+type CloseCursorStmt struct {
+	stmtNode
+
+	Name model.CIStr
+}
+
+// Restore implements Node interface.
+func (n *CloseCursorStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("CLOSE ")
+	ctx.WriteName(n.Name.O)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *CloseCursorStmt) Accept(v Visitor) (Node, bool) {
+	newNode, _ := v.Enter(n)
+	return v.Leave(newNode)
+}
+
+// FetchCursorStmt fetches the next row of a cursor into variables.
+// See https://dev.mysql.com/doc/refman/8.0/en/fetch.html
+type FetchCursorStmt struct {
+	stmtNode
+
+	Name model.CIStr
+	Into []model.CIStr
+}
+
+// Restore implements Node interface.
+func (n *FetchCursorStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("FETCH ")
+	ctx.WriteName(n.Name.O)
+	ctx.WriteKeyWord(" INTO ")
+	for i, name := range n.Into {
+		if i != 0 {
+			ctx.WritePlain(", ")
+		}
+		ctx.WriteName(name.O)
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *FetchCursorStmt) Accept(v Visitor) (Node, bool) {
+	newNode, _ := v.Enter(n)
+	return v.Leave(newNode)
+}
+
+// SignalItem sets a condition information item of a SIGNAL statement, such
+// as MESSAGE_TEXT.
+type SignalItem struct {
+	// Name is the upper case name of the item.
+	Name  string
+	Value ExprNode
+}
+
+// SignalStmt is a SIGNAL or RESIGNAL statement. Condition is nil for a
+// RESIGNAL statement which passes on the current condition.
+// See https://dev.mysql.com/doc/refman/8.0/en/signal.html
+type SignalStmt struct {
+	stmtNode
+
+	IsResignal bool
+	Condition  *ConditionValue
+	Items      []*SignalItem
+}
+
+// Restore implements Node interface.
+func (n *SignalStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	if n.IsResignal {
+		ctx.WriteKeyWord("RESIGNAL")
+	} else {
+		ctx.WriteKeyWord("SIGNAL")
+	}
+	if n.Condition != nil {
+		ctx.WritePlain(" ")
+		if err := n.Condition.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore SignalStmt.Condition")
+		}
+	}
+	for i, item := range n.Items {
+		if i == 0 {
+			ctx.WriteKeyWord(" SET ")
+		} else {
+			ctx.WritePlain(", ")
+		}
+		ctx.WriteKeyWord(item.Name)
+		ctx.WritePlain(" = ")
+		if err := item.Value.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore SignalStmt.Items[%d]", i)
+		}
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *SignalStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*SignalStmt)
+	for _, item := range n.Items {
+		if !acceptExpr(v, &item.Value) {
+			return n, false
+		}
+	}
+	return v.Leave(n)
+}
+
+// SignalItemNames are the condition information items a SIGNAL statement may set.
+var SignalItemNames = []string{
+	"CLASS_ORIGIN", "SUBCLASS_ORIGIN", "MESSAGE_TEXT", "MYSQL_ERRNO",
+	"CONSTRAINT_CATALOG", "CONSTRAINT_SCHEMA", "CONSTRAINT_NAME",
+	"CATALOG_NAME", "SCHEMA_NAME", "TABLE_NAME", "COLUMN_NAME", "CURSOR_NAME",
+}
+
+// IsSignalItemName reports whether name is one of SignalItemNames, ignoring case.
+func IsSignalItemName(name string) bool {
+	for _, item := range SignalItemNames {
+		if strings.EqualFold(item, name) {
+			return true
+		}
+	}
+	return false
+}
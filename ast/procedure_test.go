@@ -0,0 +1,111 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast_test
+
+import (
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/kyleconroy/sqlparse"
+	. "github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/format"
+)
+
+var _ = Suite(&testProcedureSuite{})
+
+type testProcedureSuite struct {
+}
+
+func (ts *testProcedureSuite) TestRoutineRestore(c *C) {
+	testCases := []NodeRestoreTestCase{
+		{"create procedure p(out a int) set a = 1", "CREATE PROCEDURE `p`(OUT `a` INT) SET `a`=1"},
+		{"create function f(a int) returns int deterministic begin declare b int default a; return b; end", "CREATE FUNCTION `f`(`a` INT) RETURNS INT DETERMINISTIC BEGIN DECLARE `b` INT DEFAULT `a`; RETURN `b`; END"},
+		{"create procedure p() l: begin leave l; end l", "CREATE PROCEDURE `p`() `l`: BEGIN LEAVE `l`; END `l`"},
+		{"drop procedure if exists p", "DROP PROCEDURE IF EXISTS `p`"},
+		{"alter function f comment 'x'", "ALTER FUNCTION `f` COMMENT 'x'"},
+	}
+	extractNodeFunc := func(node Node) Node {
+		return node
+	}
+	RunNodeRestoreTest(c, testCases, "%s", extractNodeFunc)
+}
+
+func (ts *testProcedureSuite) TestRoutineRestoreLayout(c *C) {
+	src := "create procedure p(n int) begin declare i int default 0; while i < n do if i % 2 = 0 then select i; end if; set i = i + 1; end while; end"
+	expect := "CREATE PROCEDURE `p`(`n` INT)\n" +
+		"BEGIN\n" +
+		"  DECLARE `i` INT DEFAULT 0;\n" +
+		"  WHILE `i`<`n` DO\n" +
+		"    IF `i`%2=0 THEN\n" +
+		"      SELECT `i`;\n" +
+		"    END IF;\n" +
+		"    SET `i`=`i`+1;\n" +
+		"  END WHILE;\n" +
+		"END"
+	p := parser.New()
+	stmt, err := p.ParseOneStmt(src, "", "")
+	c.Assert(err, IsNil)
+	var sb strings.Builder
+	ctx := format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)
+	ctx.Layout = &format.Layout{Indent: "  ", Width: 60}
+	c.Assert(stmt.Restore(ctx), IsNil)
+	c.Assert(sb.String(), Equals, expect)
+
+	stmt2, err := p.ParseOneStmt(sb.String(), "", "")
+	c.Assert(err, IsNil)
+	CleanNodeText(stmt)
+	CleanNodeText(stmt2)
+	c.Assert(stmt2, DeepEquals, stmt)
+}
+
+type columnCollector struct {
+	names []string
+}
+
+func (v *columnCollector) Enter(n Node) (Node, bool) {
+	if col, ok := n.(*ColumnNameExpr); ok {
+		v.names = append(v.names, col.Name.Name.L)
+	}
+	return n, false
+}
+
+func (v *columnCollector) Leave(n Node) (Node, bool) {
+	return n, true
+}
+
+func (ts *testProcedureSuite) TestRoutineVisitor(c *C) {
+	src := "create function p(a int) returns int begin declare cur cursor for select b from t; declare exit handler for sqlexception signal sqlstate '45000' set message_text = c; " +
+		"if d then select e; elseif f then select g; else select h; end if; case i when j then select k; end case; " +
+		"l: repeat select m; until n end repeat; while o do select q; end while; return r; end"
+	stmt, err := parser.New().ParseOneStmt(src, "", "")
+	c.Assert(err, IsNil)
+	v := &columnCollector{}
+	stmt.Accept(v)
+	c.Assert(strings.Join(v.names, " "), Equals, "b c d e f g h i j k m n o q r")
+}
+
+func (ts *testProcedureSuite) TestTriggerAndEventRestore(c *C) {
+	testCases := []NodeRestoreTestCase{
+		{"create trigger tr before insert on t for each row set new.a = old.b", "CREATE TRIGGER `tr` BEFORE INSERT ON `t` FOR EACH ROW SET NEW.`a`=OLD.`b`"},
+		{"create trigger tr after update on t for each row follows tr0 insert into log values (new.id)", "CREATE TRIGGER `tr` AFTER UPDATE ON `t` FOR EACH ROW FOLLOWS `tr0` INSERT INTO `log` VALUES (NEW.`id`)"},
+		{"drop trigger if exists tr", "DROP TRIGGER IF EXISTS `tr`"},
+		{"create event e on schedule every 1 hour starts now() do select 1", "CREATE EVENT `e` ON SCHEDULE EVERY 1 HOUR STARTS NOW() DO SELECT 1"},
+		{"alter event e rename to e2 enable", "ALTER EVENT `e` RENAME TO `e2` ENABLE"},
+		{"drop event e", "DROP EVENT `e`"},
+	}
+	extractNodeFunc := func(node Node) Node {
+		return node
+	}
+	RunNodeRestoreTest(c, testCases, "%s", extractNodeFunc)
+}
@@ -0,0 +1,429 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/kyleconroy/sqlparse/format"
+)
+
+var (
+	_ SensitiveStmtNode = &ChangeReplicationSourceStmt{}
+	_ SensitiveStmtNode = &StartReplicaStmt{}
+	_ StmtNode          = &StopReplicaStmt{}
+	_ StmtNode          = &ResetMasterStmt{}
+	_ StmtNode          = &ResetReplicaStmt{}
+	_ StmtNode          = &PurgeBinaryLogsStmt{}
+)
+
+// ReplicationOptionType is the kind of value of a replication option.
+type ReplicationOptionType int
+
+// ReplicationOptionType values.
+const (
+	// ReplicationOptionNone is an option without value, like SQL_AFTER_MTS_GAPS.
+	ReplicationOptionNone ReplicationOptionType = iota
+	ReplicationOptionString
+	ReplicationOptionUint
+	// ReplicationOptionDecimal keeps the text of the number in StrValue.
+	ReplicationOptionDecimal
+	// ReplicationOptionKeyword is a value like ON, OFF or NULL.
+	ReplicationOptionKeyword
+	// ReplicationOptionIDList is a list of server IDs, like the value of
+	// IGNORE_SERVER_IDS.
+	ReplicationOptionIDList
+)
+
+// ReplicationOption is a NAME = value option of CHANGE REPLICATION SOURCE TO,
+// or of the UNTIL clause and the connection options of START REPLICA.
+type ReplicationOption struct {
+	// Name is the upper-case name of the option, with the MASTER or SOURCE
+	// spelling it is written with.
+	Name      string
+	Tp        ReplicationOptionType
+	StrValue  string
+	UintValue uint64
+	IDs       []uint64
+}
+
+// Restore writes the option to ctx.
+func (n *ReplicationOption) Restore(ctx *format.RestoreCtx) error {
+	ctx.WriteKeyWord(n.Name)
+	switch n.Tp {
+	case ReplicationOptionNone:
+		return nil
+	case ReplicationOptionString:
+		ctx.WritePlain(" = ")
+		ctx.WriteString(n.StrValue)
+	case ReplicationOptionUint:
+		ctx.WritePlainf(" = %d", n.UintValue)
+	case ReplicationOptionDecimal:
+		ctx.WritePlain(" = ")
+		ctx.WritePlain(n.StrValue)
+	case ReplicationOptionKeyword:
+		ctx.WritePlain(" = ")
+		ctx.WriteKeyWord(n.StrValue)
+	case ReplicationOptionIDList:
+		ctx.WritePlain(" = (")
+		for i, id := range n.IDs {
+			if i > 0 {
+				ctx.WritePlain(", ")
+			}
+			ctx.WritePlainf("%d", id)
+		}
+		ctx.WritePlain(")")
+	default:
+		return errors.Errorf("invalid ReplicationOption: %d", n.Tp)
+	}
+	return nil
+}
+
+// IsPassword checks whether the value of the option is a password.
+func (n *ReplicationOption) IsPassword() bool {
+	return n.Name == "PASSWORD" || strings.HasSuffix(n.Name, "_PASSWORD")
+}
+
+func restoreReplicationOptions(ctx *format.RestoreCtx, opts []*ReplicationOption, sep string) error {
+	for i, opt := range opts {
+		if i > 0 {
+			ctx.WritePlain(sep)
+		}
+		if err := opt.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore %s option", opt.Name)
+		}
+	}
+	return nil
+}
+
+// redactReplicationOptions returns a copy of opts with the passwords hidden.
+func redactReplicationOptions(opts []*ReplicationOption) []*ReplicationOption {
+	redacted := make([]*ReplicationOption, len(opts))
+	for i, opt := range opts {
+		redacted[i] = opt
+		if opt.IsPassword() {
+			redacted[i] = &ReplicationOption{Name: opt.Name, Tp: ReplicationOptionString, StrValue: "***"}
+		}
+	}
+	return redacted
+}
+
+func restoreChannel(ctx *format.RestoreCtx, channel string) {
+	if channel != "" {
+		ctx.WriteKeyWord(" FOR CHANNEL ")
+		ctx.WriteString(channel)
+	}
+}
+
+func restoreReplicaKeyword(ctx *format.RestoreCtx, legacy bool) {
+	if legacy {
+		ctx.WriteKeyWord("SLAVE")
+	} else {
+		ctx.WriteKeyWord("REPLICA")
+	}
+}
+
+// ChangeReplicationSourceStmt is a statement to configure how the server
+// replicates from its source.
+// See https://dev.mysql.com/doc/refman/8.0/en/change-replication-source-to.html
+type ChangeReplicationSourceStmt struct {
+	stmtNode
+
+	// Legacy is set for the CHANGE MASTER TO spelling.
+	Legacy  bool
+	Options []*ReplicationOption
+	Channel string
+}
+
+// Restore implements Node interface.
+func (n *ChangeReplicationSourceStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	if n.Legacy {
+		ctx.WriteKeyWord("CHANGE MASTER TO ")
+	} else {
+		ctx.WriteKeyWord("CHANGE REPLICATION SOURCE TO ")
+	}
+	if err := restoreReplicationOptions(ctx, n.Options, ", "); err != nil {
+		return errors.Annotate(err, "An error occurred while restore ChangeReplicationSourceStmt.Options")
+	}
+	restoreChannel(ctx, n.Channel)
+	return nil
+}
+
+// SecureText implements SensitiveStmtNode interface.
+func (n *ChangeReplicationSourceStmt) SecureText() string {
+	redactedStmt := &ChangeReplicationSourceStmt{
+		Legacy:  n.Legacy,
+		Options: redactReplicationOptions(n.Options),
+		Channel: n.Channel,
+	}
+	var sb strings.Builder
+	_ = redactedStmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb))
+	return sb.String()
+}
+
+// Accept implements Node Accept interface.
+func (n *ChangeReplicationSourceStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ChangeReplicationSourceStmt)
+	return v.Leave(n)
+}
+
+// ReplicaThread is a replication thread START REPLICA and STOP REPLICA act on.
+type ReplicaThread int
+
+// ReplicaThread values.
+const (
+	ReplicaIOThread ReplicaThread = iota
+	ReplicaSQLThread
+)
+
+// String implements fmt.Stringer interface.
+func (t ReplicaThread) String() string {
+	if t == ReplicaSQLThread {
+		return "SQL_THREAD"
+	}
+	return "IO_THREAD"
+}
+
+func restoreReplicaThreads(ctx *format.RestoreCtx, threads []ReplicaThread) {
+	for i, thread := range threads {
+		if i > 0 {
+			ctx.WritePlain(",")
+		}
+		ctx.WritePlain(" ")
+		ctx.WriteKeyWord(thread.String())
+	}
+}
+
+// StartReplicaStmt is a statement to start the replication threads.
+// See https://dev.mysql.com/doc/refman/8.0/en/start-replica.html
+type StartReplicaStmt struct {
+	stmtNode
+
+	// Legacy is set for the START SLAVE spelling.
+	Legacy bool
+	// Threads are the threads to start, all of them if empty.
+	Threads []ReplicaThread
+	Until   []*ReplicationOption
+	// Connection holds the USER, PASSWORD, DEFAULT_AUTH and PLUGIN_DIR
+	// options.
+	Connection []*ReplicationOption
+	Channel    string
+}
+
+// Restore implements Node interface.
+func (n *StartReplicaStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("START ")
+	restoreReplicaKeyword(ctx, n.Legacy)
+	restoreReplicaThreads(ctx, n.Threads)
+	if len(n.Until) > 0 {
+		ctx.WriteKeyWord(" UNTIL ")
+		if err := restoreReplicationOptions(ctx, n.Until, ", "); err != nil {
+			return errors.Annotate(err, "An error occurred while restore StartReplicaStmt.Until")
+		}
+	}
+	if len(n.Connection) > 0 {
+		ctx.WritePlain(" ")
+		if err := restoreReplicationOptions(ctx, n.Connection, " "); err != nil {
+			return errors.Annotate(err, "An error occurred while restore StartReplicaStmt.Connection")
+		}
+	}
+	restoreChannel(ctx, n.Channel)
+	return nil
+}
+
+// SecureText implements SensitiveStmtNode interface.
+func (n *StartReplicaStmt) SecureText() string {
+	redactedStmt := &StartReplicaStmt{
+		Legacy:     n.Legacy,
+		Threads:    n.Threads,
+		Until:      n.Until,
+		Connection: redactReplicationOptions(n.Connection),
+		Channel:    n.Channel,
+	}
+	var sb strings.Builder
+	_ = redactedStmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb))
+	return sb.String()
+}
+
+// Accept implements Node Accept interface.
+func (n *StartReplicaStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*StartReplicaStmt)
+	return v.Leave(n)
+}
+
+// StopReplicaStmt is a statement to stop the replication threads.
+// See https://dev.mysql.com/doc/refman/8.0/en/stop-replica.html
+type StopReplicaStmt struct {
+	stmtNode
+
+	// Legacy is set for the STOP SLAVE spelling.
+	Legacy bool
+	// Threads are the threads to stop, all of them if empty.
+	Threads []ReplicaThread
+	Channel string
+}
+
+// Restore implements Node interface.
+func (n *StopReplicaStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("STOP ")
+	restoreReplicaKeyword(ctx, n.Legacy)
+	restoreReplicaThreads(ctx, n.Threads)
+	restoreChannel(ctx, n.Channel)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *StopReplicaStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*StopReplicaStmt)
+	return v.Leave(n)
+}
+
+// ResetMasterStmt is a statement to delete the binary logs and reset the
+// executed GTID set.
+// See https://dev.mysql.com/doc/refman/8.0/en/reset-master.html
+type ResetMasterStmt struct {
+	stmtNode
+
+	// To is the number of the first binary log file after the reset, 0 if
+	// it is not given.
+	To uint64
+}
+
+// Restore implements Node interface.
+func (n *ResetMasterStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("RESET MASTER")
+	if n.To > 0 {
+		ctx.WriteKeyWord(" TO ")
+		ctx.WritePlainf("%d", n.To)
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *ResetMasterStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ResetMasterStmt)
+	return v.Leave(n)
+}
+
+// ResetReplicaStmt is a statement to make the replica forget its position in
+// the binary log of the source.
+// See https://dev.mysql.com/doc/refman/8.0/en/reset-replica.html
+type ResetReplicaStmt struct {
+	stmtNode
+
+	// Legacy is set for the RESET SLAVE spelling.
+	Legacy bool
+	// All also clears the connection parameters.
+	All     bool
+	Channel string
+}
+
+// Restore implements Node interface.
+func (n *ResetReplicaStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("RESET ")
+	restoreReplicaKeyword(ctx, n.Legacy)
+	if n.All {
+		ctx.WriteKeyWord(" ALL")
+	}
+	restoreChannel(ctx, n.Channel)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *ResetReplicaStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ResetReplicaStmt)
+	return v.Leave(n)
+}
+
+// PurgeBinaryLogsStmt is a statement to delete the binary logs before a log
+// file or a point in time.
+// See https://dev.mysql.com/doc/refman/8.0/en/purge-binary-logs.html
+type PurgeBinaryLogsStmt struct {
+	stmtNode
+
+	// Legacy is set for the PURGE MASTER LOGS spelling.
+	Legacy bool
+	// Either To is the name of the log file, or Before is the time.
+	To     string
+	Before ExprNode
+}
+
+// Restore implements Node interface.
+func (n *PurgeBinaryLogsStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	if n.Legacy {
+		ctx.WriteKeyWord("PURGE MASTER LOGS ")
+	} else {
+		ctx.WriteKeyWord("PURGE BINARY LOGS ")
+	}
+	if n.Before == nil {
+		ctx.WriteKeyWord("TO ")
+		ctx.WriteString(n.To)
+		return nil
+	}
+	ctx.WriteKeyWord("BEFORE ")
+	if err := n.Before.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore PurgeBinaryLogsStmt.Before")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *PurgeBinaryLogsStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*PurgeBinaryLogsStmt)
+	if n.Before != nil {
+		node, ok := n.Before.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Before = node.(ExprNode)
+	}
+	return v.Leave(n)
+}
@@ -93,6 +93,8 @@ type AnalyzeOpt struct {
 
 // Restore implements Node interface.
 func (n *AnalyzeTableStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	if n.Incremental {
 		ctx.WriteKeyWord("ANALYZE INCREMENTAL TABLE ")
 	} else {
@@ -179,6 +181,8 @@ type DropStatsStmt struct {
 
 // Restore implements Node interface.
 func (n *DropStatsStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("DROP STATS ")
 	if err := n.Table.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while add table")
@@ -211,6 +215,8 @@ type LoadStatsStmt struct {
 
 // Restore implements Node interface.
 func (n *LoadStatsStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
 	ctx.WriteKeyWord("LOAD STATS ")
 	ctx.WriteString(n.Path)
 	return nil
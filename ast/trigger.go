@@ -0,0 +1,574 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pingcap/errors"
+	"github.com/kyleconroy/sqlparse/auth"
+	"github.com/kyleconroy/sqlparse/format"
+	"github.com/kyleconroy/sqlparse/model"
+)
+
+var (
+	_ DDLNode = &CreateTriggerStmt{}
+	_ DDLNode = &DropTriggerStmt{}
+	_ DDLNode = &CreateEventStmt{}
+	_ DDLNode = &AlterEventStmt{}
+	_ DDLNode = &DropEventStmt{}
+
+	_ ExprNode = &TriggerFieldExpr{}
+
+	_ Node = &EventSchedule{}
+)
+
+// TriggerTime is the action time of a trigger.
+type TriggerTime int
+
+// TriggerTime values.
+const (
+	TriggerBefore TriggerTime = iota
+	TriggerAfter
+)
+
+// String implements fmt.Stringer interface.
+func (t TriggerTime) String() string {
+	if t == TriggerAfter {
+		return "AFTER"
+	}
+	return "BEFORE"
+}
+
+// TriggerEvent is the kind of operation that activates a trigger.
+type TriggerEvent int
+
+// TriggerEvent values.
+const (
+	TriggerInsert TriggerEvent = iota
+	TriggerUpdate
+	TriggerDelete
+)
+
+// String implements fmt.Stringer interface.
+func (e TriggerEvent) String() string {
+	switch e {
+	case TriggerUpdate:
+		return "UPDATE"
+	case TriggerDelete:
+		return "DELETE"
+	default:
+		return "INSERT"
+	}
+}
+
+// TriggerOrder places a trigger before or after an existing trigger with the
+// same action time and event.
+type TriggerOrder int
+
+// TriggerOrder values.
+const (
+	TriggerOrderNone TriggerOrder = iota
+	TriggerFollows
+	TriggerPrecedes
+)
+
+// TriggerRow is the row a trigger body refers to with the NEW or OLD qualifier.
+type TriggerRow int
+
+// TriggerRow values.
+const (
+	TriggerRowNone TriggerRow = iota
+	TriggerRowNew
+	TriggerRowOld
+)
+
+// String implements fmt.Stringer interface.
+func (r TriggerRow) String() string {
+	switch r {
+	case TriggerRowNew:
+		return "NEW"
+	case TriggerRowOld:
+		return "OLD"
+	default:
+		return ""
+	}
+}
+
+// TriggerFieldExpr is a NEW.col or OLD.col reference in the body of a trigger.
+type TriggerFieldExpr struct {
+	exprNode
+
+	Row  TriggerRow
+	Name model.CIStr
+}
+
+// Restore implements Node interface.
+func (n *TriggerFieldExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	ctx.WriteKeyWord(n.Row.String())
+	ctx.WritePlain(".")
+	ctx.WriteName(n.Name.O)
+	return nil
+}
+
+// Format the ExprNode into a Writer.
+func (n *TriggerFieldExpr) Format(w io.Writer) {
+	fmt.Fprintf(w, "%s.`%s`", n.Row, n.Name.O)
+}
+
+// Accept implements Node Accept interface.
+func (n *TriggerFieldExpr) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*TriggerFieldExpr)
+	return v.Leave(n)
+}
+
+// CreateTriggerStmt is a statement to create a trigger.
+// See https://dev.mysql.com/doc/refman/8.0/en/create-trigger.html
+type CreateTriggerStmt struct {
+	ddlNode
+
+	OrReplace   bool
+	Definer     *auth.UserIdentity
+	IfNotExists bool
+	Name        *TableName
+	Time        TriggerTime
+	Event       TriggerEvent
+	Table       *TableName
+	// Order and OtherTrigger come from the FOLLOWS or PRECEDES clause.
+	Order        TriggerOrder
+	OtherTrigger model.CIStr
+	Body         StmtNode
+}
+
+// BodyStmts returns the statements of the trigger body. A BEGIN ... END body
+// is unwrapped into the statements it contains.
+func (n *CreateTriggerStmt) BodyStmts() []StmtNode {
+	if block, ok := n.Body.(*BlockStmt); ok && block.Label.L == "" {
+		return block.Stmts
+	}
+	return []StmtNode{n.Body}
+}
+
+// Restore implements Node interface.
+func (n *CreateTriggerStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("CREATE ")
+	if n.OrReplace {
+		ctx.WriteKeyWord("OR REPLACE ")
+	}
+	if err := restoreDefiner(ctx, n.Definer); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateTriggerStmt.Definer")
+	}
+	ctx.WriteKeyWord("TRIGGER ")
+	if n.IfNotExists {
+		ctx.WriteKeyWord("IF NOT EXISTS ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateTriggerStmt.Name")
+	}
+	ctx.WritePlain(" ")
+	ctx.WriteKeyWord(n.Time.String())
+	ctx.WritePlain(" ")
+	ctx.WriteKeyWord(n.Event.String())
+	ctx.WriteKeyWord(" ON ")
+	if err := n.Table.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateTriggerStmt.Table")
+	}
+	ctx.WriteKeyWord(" FOR EACH ROW")
+	switch n.Order {
+	case TriggerFollows:
+		ctx.WriteKeyWord(" FOLLOWS ")
+		ctx.WriteName(n.OtherTrigger.O)
+	case TriggerPrecedes:
+		ctx.WriteKeyWord(" PRECEDES ")
+		ctx.WriteName(n.OtherTrigger.O)
+	}
+	ctx.WriteLineBreak(" ")
+	if err := n.Body.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateTriggerStmt.Body")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateTriggerStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateTriggerStmt)
+	node, ok := n.Name.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Name = node.(*TableName)
+	node, ok = n.Table.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Table = node.(*TableName)
+	node, ok = n.Body.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Body = node.(StmtNode)
+	return v.Leave(n)
+}
+
+// DropTriggerStmt is a statement to drop a trigger.
+// See https://dev.mysql.com/doc/refman/8.0/en/drop-trigger.html
+type DropTriggerStmt struct {
+	ddlNode
+
+	IfExists bool
+	Name     *TableName
+}
+
+// Restore implements Node interface.
+func (n *DropTriggerStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("DROP TRIGGER ")
+	if n.IfExists {
+		ctx.WriteKeyWord("IF EXISTS ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore DropTriggerStmt.Name")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DropTriggerStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropTriggerStmt)
+	node, ok := n.Name.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Name = node.(*TableName)
+	return v.Leave(n)
+}
+
+// EventSchedule is the ON SCHEDULE clause of an event. An event either runs
+// once at the time given by At, or repeatedly every Every Unit.
+type EventSchedule struct {
+	node
+
+	At     ExprNode
+	Every  ExprNode
+	Unit   TimeUnitType
+	Starts ExprNode
+	Ends   ExprNode
+}
+
+// Restore implements Node interface.
+func (n *EventSchedule) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	if n.At != nil {
+		ctx.WriteKeyWord("AT ")
+		return errors.Annotate(n.At.Restore(ctx), "An error occurred while restore EventSchedule.At")
+	}
+	ctx.WriteKeyWord("EVERY ")
+	if err := n.Every.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore EventSchedule.Every")
+	}
+	ctx.WritePlain(" ")
+	ctx.WriteKeyWord(n.Unit.String())
+	if n.Starts != nil {
+		ctx.WriteKeyWord(" STARTS ")
+		if err := n.Starts.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore EventSchedule.Starts")
+		}
+	}
+	if n.Ends != nil {
+		ctx.WriteKeyWord(" ENDS ")
+		if err := n.Ends.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore EventSchedule.Ends")
+		}
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *EventSchedule) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*EventSchedule)
+	for _, expr := range []*ExprNode{&n.At, &n.Every, &n.Starts, &n.Ends} {
+		if !acceptExpr(v, expr) {
+			return n, false
+		}
+	}
+	return v.Leave(n)
+}
+
+// EventCompletion is the ON COMPLETION clause of an event.
+type EventCompletion int
+
+// EventCompletion values.
+const (
+	EventCompletionNone EventCompletion = iota
+	EventPreserve
+	EventNotPreserve
+)
+
+// EventStatus is the state an event is created or altered into.
+type EventStatus int
+
+// EventStatus values.
+const (
+	EventStatusNone EventStatus = iota
+	EventEnable
+	EventDisable
+	EventDisableOnSlave
+)
+
+// restoreEventOptions restores the ON COMPLETION, status and COMMENT clauses
+// shared by CREATE EVENT and ALTER EVENT, with the RENAME TO clause of ALTER
+// EVENT in between.
+func restoreEventOptions(ctx *format.RestoreCtx, completion EventCompletion, renameTo *TableName, status EventStatus, comment string) error {
+	switch completion {
+	case EventPreserve:
+		ctx.WriteKeyWord(" ON COMPLETION PRESERVE")
+	case EventNotPreserve:
+		ctx.WriteKeyWord(" ON COMPLETION NOT PRESERVE")
+	}
+	if renameTo != nil {
+		ctx.WriteKeyWord(" RENAME TO ")
+		if err := renameTo.Restore(ctx); err != nil {
+			return err
+		}
+	}
+	switch status {
+	case EventEnable:
+		ctx.WriteKeyWord(" ENABLE")
+	case EventDisable:
+		ctx.WriteKeyWord(" DISABLE")
+	case EventDisableOnSlave:
+		ctx.WriteKeyWord(" DISABLE ON SLAVE")
+	}
+	if comment != "" {
+		ctx.WriteKeyWord(" COMMENT ")
+		ctx.WriteString(comment)
+	}
+	return nil
+}
+
+// CreateEventStmt is a statement to create a scheduled event.
+// See https://dev.mysql.com/doc/refman/8.0/en/create-event.html
+type CreateEventStmt struct {
+	ddlNode
+
+	OrReplace    bool
+	Definer      *auth.UserIdentity
+	IfNotExists  bool
+	Name         *TableName
+	Schedule     *EventSchedule
+	OnCompletion EventCompletion
+	Status       EventStatus
+	Comment      string
+	Body         StmtNode
+}
+
+// Restore implements Node interface.
+func (n *CreateEventStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("CREATE ")
+	if n.OrReplace {
+		ctx.WriteKeyWord("OR REPLACE ")
+	}
+	if err := restoreDefiner(ctx, n.Definer); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateEventStmt.Definer")
+	}
+	ctx.WriteKeyWord("EVENT ")
+	if n.IfNotExists {
+		ctx.WriteKeyWord("IF NOT EXISTS ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateEventStmt.Name")
+	}
+	ctx.WriteKeyWord(" ON SCHEDULE ")
+	if err := n.Schedule.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateEventStmt.Schedule")
+	}
+	if err := restoreEventOptions(ctx, n.OnCompletion, nil, n.Status, n.Comment); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateEventStmt")
+	}
+	ctx.WriteLineBreak(" ")
+	ctx.WriteKeyWord("DO ")
+	if err := n.Body.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateEventStmt.Body")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateEventStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateEventStmt)
+	node, ok := n.Name.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Name = node.(*TableName)
+	node, ok = n.Schedule.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Schedule = node.(*EventSchedule)
+	node, ok = n.Body.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Body = node.(StmtNode)
+	return v.Leave(n)
+}
+
+// AlterEventStmt is a statement to change a scheduled event. Only the clauses
+// given in the statement are changed.
+// See https://dev.mysql.com/doc/refman/8.0/en/alter-event.html
+type AlterEventStmt struct {
+	ddlNode
+
+	Definer      *auth.UserIdentity
+	Name         *TableName
+	Schedule     *EventSchedule
+	OnCompletion EventCompletion
+	RenameTo     *TableName
+	Status       EventStatus
+	Comment      string
+	Body         StmtNode
+}
+
+// Restore implements Node interface.
+func (n *AlterEventStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("ALTER ")
+	if err := restoreDefiner(ctx, n.Definer); err != nil {
+		return errors.Annotate(err, "An error occurred while restore AlterEventStmt.Definer")
+	}
+	ctx.WriteKeyWord("EVENT ")
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore AlterEventStmt.Name")
+	}
+	if n.Schedule != nil {
+		ctx.WriteKeyWord(" ON SCHEDULE ")
+		if err := n.Schedule.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore AlterEventStmt.Schedule")
+		}
+	}
+	if err := restoreEventOptions(ctx, n.OnCompletion, n.RenameTo, n.Status, n.Comment); err != nil {
+		return errors.Annotate(err, "An error occurred while restore AlterEventStmt")
+	}
+	if n.Body != nil {
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("DO ")
+		if err := n.Body.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore AlterEventStmt.Body")
+		}
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *AlterEventStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*AlterEventStmt)
+	node, ok := n.Name.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Name = node.(*TableName)
+	if n.Schedule != nil {
+		node, ok = n.Schedule.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Schedule = node.(*EventSchedule)
+	}
+	if n.RenameTo != nil {
+		node, ok = n.RenameTo.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.RenameTo = node.(*TableName)
+	}
+	if n.Body != nil {
+		node, ok = n.Body.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Body = node.(StmtNode)
+	}
+	return v.Leave(n)
+}
+
+// DropEventStmt is a statement to drop a scheduled event.
+// See https://dev.mysql.com/doc/refman/8.0/en/drop-event.html
+type DropEventStmt struct {
+	ddlNode
+
+	IfExists bool
+	Name     *TableName
+}
+
+// Restore implements Node interface.
+func (n *DropEventStmt) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
+	defer n.restoreComments(ctx)()
+	ctx.WriteKeyWord("DROP EVENT ")
+	if n.IfExists {
+		ctx.WriteKeyWord("IF EXISTS ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore DropEventStmt.Name")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DropEventStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropEventStmt)
+	node, ok := n.Name.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Name = node.(*TableName)
+	return v.Leave(n)
+}
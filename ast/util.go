@@ -24,9 +24,8 @@ const (
 func IsReadOnly(node Node) bool {
 	switch st := node.(type) {
 	case *SelectStmt:
-		if st.LockInfo != nil {
-			switch st.LockInfo.LockType {
-			case SelectLockForUpdate, SelectLockForUpdateNoWait, SelectLockForUpdateWaitN:
+		for info := st.LockInfo; info != nil; info = info.Next {
+			if info.LockType.IsForUpdate() {
 				return false
 			}
 		}
@@ -39,7 +38,7 @@ func IsReadOnly(node Node) bool {
 		return checker.readOnly
 	case *ExplainStmt:
 		return !st.Analyze || IsReadOnly(st.Stmt)
-	case *DoStmt, *ShowStmt:
+	case *DoStmt, *ShowStmt, *HelpStmt:
 		return true
 	case *SetOprStmt:
 		for _, sel := range node.(*SetOprStmt).SelectList.Selects {
@@ -15,6 +15,7 @@ package ast_test
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	. "github.com/pingcap/check"
@@ -110,11 +111,66 @@ func (s *testCacheableSuite) TestUnionReadOnly(c *C) {
 	c.Assert(IsReadOnly(setOprStmt), IsFalse)
 }
 
+func (s *testCacheableSuite) TestSelectLockReadOnly(c *C) {
+	stmt := &SelectStmt{
+		LockInfo: &SelectLockInfo{LockType: SelectLockForShare},
+	}
+	c.Assert(IsReadOnly(stmt), IsTrue)
+
+	stmt.LockInfo.LockType = SelectLockForUpdateSkipLocked
+	c.Assert(IsReadOnly(stmt), IsFalse)
+
+	stmt.LockInfo = &SelectLockInfo{
+		LockType: SelectLockForShare,
+		Next:     &SelectLockInfo{LockType: SelectLockForUpdate},
+	}
+	c.Assert(IsReadOnly(stmt), IsFalse)
+}
+
 // CleanNodeText set the text of node and all child node empty.
 // For test only.
 func CleanNodeText(node Node) {
 	var cleaner nodeTextCleaner
 	node.Accept(&cleaner)
+	cleanPositions(reflect.ValueOf(node), make(map[uintptr]bool))
+}
+
+// cleanPositions clears the spans and the origin text positions of the nodes
+// reachable from v, including the nodes that Accept doesn't visit.
+// For test only.
+func cleanPositions(v reflect.Value, seen map[uintptr]bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		if v.CanInterface() {
+			if n, ok := v.Interface().(Node); ok {
+				n.SetSpan(Span{})
+				n.SetOriginTextPosition(0)
+			}
+		}
+		cleanPositions(v.Elem(), seen)
+	case reflect.Interface:
+		if !v.IsNil() {
+			cleanPositions(v.Elem(), seen)
+		}
+	case reflect.Struct:
+		if v.CanAddr() && v.Addr().CanInterface() {
+			if n, ok := v.Addr().Interface().(Node); ok {
+				n.SetSpan(Span{})
+				n.SetOriginTextPosition(0)
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			cleanPositions(v.Field(i), seen)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			cleanPositions(v.Index(i), seen)
+		}
+	}
 }
 
 // nodeTextCleaner clean the text of a node and it's child node.
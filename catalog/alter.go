@@ -0,0 +1,480 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"fmt"
+
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/model"
+	"github.com/kyleconroy/sqlparse/mysql"
+)
+
+// alterTable applies the specs of an `ALTER TABLE` statement in order. The
+// specs are applied to a copy of the table, so the catalog is unchanged if any
+// of them fails.
+func (c *Catalog) alterTable(stmt *ast.AlterTableStmt) error {
+	db, tbl, err := c.lookupBaseTable(stmt.Table)
+	if err != nil {
+		return err
+	}
+	tbl = cloneTable(tbl)
+	var rename *ast.TableName
+	for _, spec := range stmt.Specs {
+		if spec.Tp == ast.AlterTableRenameTable {
+			rename = spec.NewTable
+			continue
+		}
+		if err := alterTableSpec(tbl, spec); err != nil {
+			return err
+		}
+	}
+	if rename == nil {
+		replaceTable(db, tbl)
+		return nil
+	}
+
+	nc := c.Clone()
+	ndb, _ := nc.SchemaByName(db.Name)
+	replaceTable(ndb, tbl)
+	if err := nc.moveTable(&ast.TableName{Schema: db.Name, Name: stmt.Table.Name}, rename); err != nil {
+		return err
+	}
+	*c = *nc
+	return nil
+}
+
+func alterTableSpec(tbl *model.TableInfo, spec *ast.AlterTableSpec) error {
+	switch spec.Tp {
+	case ast.AlterTableOption:
+		return applyTableOptions(tbl, spec.Options)
+	case ast.AlterTableAddColumns:
+		return addColumns(tbl, spec)
+	case ast.AlterTableAddConstraint:
+		return addConstraint(tbl, spec.Constraint)
+	case ast.AlterTableDropColumn:
+		return dropColumn(tbl, spec.OldColumnName.Name, spec.IfExists)
+	case ast.AlterTableDropPrimaryKey:
+		return dropIndex(tbl, model.NewCIStr(mysql.PrimaryKeyName), false)
+	case ast.AlterTableDropIndex:
+		return dropIndex(tbl, model.NewCIStr(spec.Name), spec.IfExists)
+	case ast.AlterTableDropForeignKey:
+		return dropForeignKey(tbl, model.NewCIStr(spec.Name), spec.IfExists)
+	case ast.AlterTableModifyColumn:
+		return changeColumn(tbl, spec.NewColumns[0].Name.Name, spec.NewColumns[0], spec.Position, spec.IfExists)
+	case ast.AlterTableChangeColumn:
+		return changeColumn(tbl, spec.OldColumnName.Name, spec.NewColumns[0], spec.Position, spec.IfExists)
+	case ast.AlterTableRenameColumn:
+		return renameColumn(tbl, spec.OldColumnName.Name, spec.NewColumnName.Name)
+	case ast.AlterTableAlterColumn:
+		return alterColumnDefault(tbl, spec.NewColumns[0])
+	case ast.AlterTableRenameIndex:
+		return renameIndex(tbl, spec.FromKey, spec.ToKey)
+	case ast.AlterTableIndexInvisible:
+		idx := tbl.FindIndexByName(spec.IndexName.L)
+		if idx == nil {
+			return ErrKeyNotExists.GenWithStackByArgs(spec.IndexName.O, tbl.Name.O)
+		}
+		idx.Invisible = spec.Visibility == ast.IndexVisibilityInvisible
+	case ast.AlterTableDropCheck:
+		return dropCheck(tbl, model.NewCIStr(spec.Constraint.Name))
+	case ast.AlterTableAlterCheck:
+		cst := tbl.FindConstraintInfoByName(spec.Constraint.Name)
+		if cst == nil {
+			return ErrCantDropFieldOrKey.GenWithStackByArgs(spec.Constraint.Name)
+		}
+		cst.Enforced = spec.Constraint.Enforced
+	case ast.AlterTablePartition:
+		pi, err := buildPartitionInfo(tbl, spec.Partition)
+		if err != nil {
+			return err
+		}
+		tbl.Partition = pi
+	case ast.AlterTableRemovePartitioning:
+		if tbl.Partition == nil {
+			return ErrPartitionMgmtOnNonpartitioned
+		}
+		tbl.Partition = nil
+	case ast.AlterTableAddPartitions:
+		return addPartitions(tbl, spec)
+	case ast.AlterTableDropPartition:
+		return dropPartitions(tbl, spec.PartitionNames, spec.IfExists)
+	case ast.AlterTableCoalescePartitions:
+		if tbl.Partition == nil {
+			return ErrPartitionMgmtOnNonpartitioned
+		}
+		if spec.Num >= uint64(len(tbl.Partition.Definitions)) {
+			return ErrDropPartitionNonExistent.GenWithStackByArgs("COALESCE")
+		}
+		keep := uint64(len(tbl.Partition.Definitions)) - spec.Num
+		tbl.Partition.Definitions = tbl.Partition.Definitions[:keep]
+		tbl.Partition.Num = keep
+	case ast.AlterTableReorganizePartition:
+		return reorganizePartitions(tbl, spec)
+	case ast.AlterTableTruncatePartition, ast.AlterTableRebuildPartition, ast.AlterTableOptimizePartition,
+		ast.AlterTableRepairPartition, ast.AlterTableCheckPartitions:
+		if spec.OnAllPartitions {
+			return nil
+		}
+		_, err := findPartitions(tbl, spec.PartitionNames, "ALTER")
+		return err
+	}
+	// Other specs, such as LOCK or ALGORITHM, don't change the schema.
+	return nil
+}
+
+func addColumns(tbl *model.TableInfo, spec *ast.AlterTableSpec) error {
+	var constraints []*ast.Constraint
+	for _, colDef := range spec.NewColumns {
+		if model.FindColumnInfo(tbl.Columns, colDef.Name.Name.L) != nil {
+			if spec.IfNotExists {
+				continue
+			}
+			return ErrColumnExists.GenWithStackByArgs(colDef.Name.Name.O)
+		}
+		col, csts, err := columnDefToCol(tbl, colDef)
+		if err != nil {
+			return err
+		}
+		if err := insertColumn(tbl, col, spec.Position); err != nil {
+			return err
+		}
+		constraints = append(constraints, csts...)
+	}
+	constraints = append(constraints, spec.NewConstraints...)
+	for _, cst := range constraints {
+		if err := addConstraint(tbl, cst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertColumn adds col to the table at the given position. Without position,
+// the column is added after the last visible column.
+func insertColumn(tbl *model.TableInfo, col *model.ColumnInfo, pos *ast.ColumnPosition) error {
+	offset := 0
+	for i, c := range tbl.Columns {
+		if !c.Hidden {
+			offset = i + 1
+		}
+	}
+	if pos != nil {
+		switch pos.Tp {
+		case ast.ColumnPositionFirst:
+			offset = 0
+		case ast.ColumnPositionAfter:
+			after := model.FindColumnInfo(tbl.Columns, pos.RelativeColumn.Name.L)
+			if after == nil {
+				return ErrColumnNotExists.GenWithStackByArgs(pos.RelativeColumn.Name.O, tbl.Name.O)
+			}
+			offset = after.Offset + 1
+		}
+	}
+	tbl.Columns = append(tbl.Columns, nil)
+	copy(tbl.Columns[offset+1:], tbl.Columns[offset:])
+	tbl.Columns[offset] = col
+	adjustColumnOffsets(tbl)
+	return nil
+}
+
+func dropColumn(tbl *model.TableInfo, name model.CIStr, ifExists bool) error {
+	col := model.FindColumnInfo(tbl.Columns, name.L)
+	if col == nil || col.Hidden {
+		if ifExists {
+			return nil
+		}
+		return ErrCantDropFieldOrKey.GenWithStackByArgs(name.O)
+	}
+	visible := 0
+	for _, c := range tbl.Columns {
+		if !c.Hidden {
+			visible++
+		}
+	}
+	if visible == 1 {
+		return ErrCantRemoveAllFields
+	}
+	for _, c := range tbl.Columns {
+		if _, ok := c.Dependences[name.L]; ok && c != col {
+			return ErrDependentByGeneratedColumn.GenWithStackByArgs(name.O)
+		}
+	}
+	removeColumn(tbl, col)
+
+	// Like MySQL, remove the column from the indices which use it, and drop
+	// the indices left without any column.
+	indices := tbl.Indices[:0]
+	for _, idx := range tbl.Indices {
+		cols := idx.Columns[:0]
+		for _, ic := range idx.Columns {
+			if ic.Name.L != name.L {
+				cols = append(cols, ic)
+			}
+		}
+		idx.Columns = cols
+		if len(idx.Columns) > 0 {
+			indices = append(indices, idx)
+		}
+	}
+	tbl.Indices = indices
+	adjustColumnOffsets(tbl)
+	updateKeyFlags(tbl)
+	return nil
+}
+
+// changeColumn replaces the column oldName with the definition colDef, which
+// may have a different name.
+func changeColumn(tbl *model.TableInfo, oldName model.CIStr, colDef *ast.ColumnDef, pos *ast.ColumnPosition, ifExists bool) error {
+	old := model.FindColumnInfo(tbl.Columns, oldName.L)
+	if old == nil || old.Hidden {
+		if ifExists {
+			return nil
+		}
+		return ErrColumnNotExists.GenWithStackByArgs(oldName.O, tbl.Name.O)
+	}
+	newName := colDef.Name.Name
+	if newName.L != oldName.L && model.FindColumnInfo(tbl.Columns, newName.L) != nil {
+		return ErrColumnExists.GenWithStackByArgs(newName.O)
+	}
+	// The new column keeps the ID of the old one.
+	maxColumnID := tbl.MaxColumnID
+	col, csts, err := columnDefToCol(tbl, colDef)
+	if err != nil {
+		return err
+	}
+	tbl.MaxColumnID = maxColumnID
+	col.ID = old.ID
+	tbl.Columns[old.Offset] = col
+	if pos != nil && pos.Tp != ast.ColumnPositionNone {
+		removeColumn(tbl, col)
+		adjustColumnOffsets(tbl)
+		if err := insertColumn(tbl, col, pos); err != nil {
+			return err
+		}
+	}
+	renameColumnReferences(tbl, oldName, newName)
+	adjustColumnOffsets(tbl)
+	updateKeyFlags(tbl)
+	for _, cst := range csts {
+		if err := addConstraint(tbl, cst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renameColumn(tbl *model.TableInfo, oldName, newName model.CIStr) error {
+	col := model.FindColumnInfo(tbl.Columns, oldName.L)
+	if col == nil || col.Hidden {
+		return ErrColumnNotExists.GenWithStackByArgs(oldName.O, tbl.Name.O)
+	}
+	if newName.L != oldName.L && model.FindColumnInfo(tbl.Columns, newName.L) != nil {
+		return ErrColumnExists.GenWithStackByArgs(newName.O)
+	}
+	col.Name = newName
+	renameColumnReferences(tbl, oldName, newName)
+	return nil
+}
+
+// renameColumnReferences updates the indices and foreign keys using a renamed column.
+func renameColumnReferences(tbl *model.TableInfo, oldName, newName model.CIStr) {
+	if oldName.L == newName.L && oldName.O == newName.O {
+		return
+	}
+	for _, idx := range tbl.Indices {
+		for _, ic := range idx.Columns {
+			if ic.Name.L == oldName.L {
+				ic.Name = newName
+			}
+		}
+	}
+	for _, fk := range tbl.ForeignKeys {
+		for i, name := range fk.Cols {
+			if name.L == oldName.L {
+				fk.Cols[i] = newName
+			}
+		}
+	}
+	for _, cst := range tbl.Constraints {
+		for i, name := range cst.ConstraintCols {
+			if name.L == oldName.L {
+				cst.ConstraintCols[i] = newName
+			}
+		}
+	}
+}
+
+func alterColumnDefault(tbl *model.TableInfo, colDef *ast.ColumnDef) error {
+	col := model.FindColumnInfo(tbl.Columns, colDef.Name.Name.L)
+	if col == nil || col.Hidden {
+		return ErrColumnNotExists.GenWithStackByArgs(colDef.Name.Name.O, tbl.Name.O)
+	}
+	if len(colDef.Options) == 0 {
+		col.DefaultIsExpr = false
+		col.Flag |= mysql.NoDefaultValueFlag
+		return col.SetDefaultValue(nil)
+	}
+	col.Flag &^= mysql.NoDefaultValueFlag
+	return setDefaultValue(col, colDef.Options[0].Expr)
+}
+
+func renameIndex(tbl *model.TableInfo, from, to model.CIStr) error {
+	idx := tbl.FindIndexByName(from.L)
+	if idx == nil {
+		return ErrKeyNotExists.GenWithStackByArgs(from.O, tbl.Name.O)
+	}
+	if from.L != to.L && tbl.FindIndexByName(to.L) != nil {
+		return ErrIndexExists.GenWithStackByArgs(to.O)
+	}
+	idx.Name = to
+	return nil
+}
+
+func dropForeignKey(tbl *model.TableInfo, name model.CIStr, ifExists bool) error {
+	for i, fk := range tbl.ForeignKeys {
+		if fk.Name.L == name.L {
+			tbl.ForeignKeys = append(tbl.ForeignKeys[:i], tbl.ForeignKeys[i+1:]...)
+			return nil
+		}
+	}
+	if ifExists {
+		return nil
+	}
+	return ErrCantDropFieldOrKey.GenWithStackByArgs(name.O)
+}
+
+func dropCheck(tbl *model.TableInfo, name model.CIStr) error {
+	for i, cst := range tbl.Constraints {
+		if cst.Name.L == name.L {
+			tbl.Constraints = append(tbl.Constraints[:i], tbl.Constraints[i+1:]...)
+			return nil
+		}
+	}
+	return ErrCantDropFieldOrKey.GenWithStackByArgs(name.O)
+}
+
+func addPartitions(tbl *model.TableInfo, spec *ast.AlterTableSpec) error {
+	pi := tbl.Partition
+	if pi == nil {
+		return ErrPartitionMgmtOnNonpartitioned
+	}
+	defs := spec.PartDefinitions
+	if len(defs) == 0 && spec.Num > 0 {
+		// `ADD PARTITION PARTITIONS n` for HASH and KEY partitioning.
+		for i := uint64(0); i < spec.Num; i++ {
+			pi.Definitions = append(pi.Definitions, model.PartitionDefinition{
+				ID:   int64(len(pi.Definitions) + 1),
+				Name: model.NewCIStr(fmt.Sprintf("p%d", len(pi.Definitions))),
+			})
+		}
+		pi.Num = uint64(len(pi.Definitions))
+		return nil
+	}
+	newDefs, err := buildPartitionDefinitions(pi, defs)
+	if err != nil {
+		return err
+	}
+	for _, def := range newDefs {
+		if tbl.FindPartitionDefinitionByName(def.Name.L) != nil {
+			if spec.IfNotExists {
+				continue
+			}
+			return ErrSameNamePartition.GenWithStackByArgs(def.Name.O)
+		}
+		pi.Definitions = append(pi.Definitions, def)
+	}
+	pi.Num = uint64(len(pi.Definitions))
+	return nil
+}
+
+// findPartitions returns the offsets of the named partitions.
+func findPartitions(tbl *model.TableInfo, names []model.CIStr, operation string) ([]int, error) {
+	if tbl.Partition == nil {
+		return nil, ErrPartitionMgmtOnNonpartitioned
+	}
+	offsets := make([]int, 0, len(names))
+	for _, name := range names {
+		found := false
+		for i, def := range tbl.Partition.Definitions {
+			if def.Name.L == name.L {
+				offsets = append(offsets, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, ErrDropPartitionNonExistent.GenWithStackByArgs(operation)
+		}
+	}
+	return offsets, nil
+}
+
+func dropPartitions(tbl *model.TableInfo, names []model.CIStr, ifExists bool) error {
+	offsets, err := findPartitions(tbl, names, "DROP")
+	if err != nil {
+		if ifExists && ErrDropPartitionNonExistent.Equal(err) {
+			return nil
+		}
+		return err
+	}
+	removed := make(map[int]struct{}, len(offsets))
+	for _, offset := range offsets {
+		removed[offset] = struct{}{}
+	}
+	pi := tbl.Partition
+	defs := pi.Definitions[:0]
+	for i, def := range pi.Definitions {
+		if _, ok := removed[i]; !ok {
+			defs = append(defs, def)
+		}
+	}
+	pi.Definitions = defs
+	pi.Num = uint64(len(pi.Definitions))
+	return nil
+}
+
+// reorganizePartitions replaces the named partitions with new definitions,
+// keeping the position of the first replaced partition.
+func reorganizePartitions(tbl *model.TableInfo, spec *ast.AlterTableSpec) error {
+	offsets, err := findPartitions(tbl, spec.PartitionNames, "REORGANIZE")
+	if err != nil {
+		return err
+	}
+	pi := tbl.Partition
+	newDefs, err := buildPartitionDefinitions(pi, spec.PartDefinitions)
+	if err != nil {
+		return err
+	}
+	removed := make(map[int]struct{}, len(offsets))
+	first := len(pi.Definitions)
+	for _, offset := range offsets {
+		removed[offset] = struct{}{}
+		if offset < first {
+			first = offset
+		}
+	}
+	var defs []model.PartitionDefinition
+	for i, def := range pi.Definitions {
+		if i == first {
+			defs = append(defs, newDefs...)
+		}
+		if _, ok := removed[i]; !ok {
+			defs = append(defs, def)
+		}
+	}
+	pi.Definitions = defs
+	pi.Num = uint64(len(pi.Definitions))
+	return nil
+}
@@ -0,0 +1,506 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package catalog maintains an in-memory schema which is built by applying
+// DDL statements in order. The schema is described with the model package,
+// so it can be consumed by any tool that understands model.DBInfo and
+// model.TableInfo.
+package catalog
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/model"
+	"github.com/kyleconroy/sqlparse/mysql"
+	"github.com/kyleconroy/sqlparse/terror"
+)
+
+var (
+	// ErrNoDB is returned when an unqualified name is used without a current database.
+	ErrNoDB = terror.ClassSchema.NewStd(mysql.ErrNoDB)
+	// ErrDatabaseExists is returned when creating a database which already exists.
+	ErrDatabaseExists = terror.ClassSchema.NewStd(mysql.ErrDBCreateExists)
+	// ErrDatabaseDropExists is returned when dropping a database which doesn't exist.
+	ErrDatabaseDropExists = terror.ClassSchema.NewStd(mysql.ErrDBDropExists)
+	// ErrDatabaseNotExists is returned when a database doesn't exist.
+	ErrDatabaseNotExists = terror.ClassSchema.NewStd(mysql.ErrBadDB)
+	// ErrTableExists is returned when creating a table which already exists.
+	ErrTableExists = terror.ClassSchema.NewStd(mysql.ErrTableExists)
+	// ErrTableNotExists is returned when a table doesn't exist.
+	ErrTableNotExists = terror.ClassSchema.NewStd(mysql.ErrNoSuchTable)
+	// ErrTableDropExists is returned when dropping a table which doesn't exist.
+	ErrTableDropExists = terror.ClassSchema.NewStd(mysql.ErrBadTable)
+	// ErrWrongObject is returned when a view is used as a base table, or the other way around.
+	ErrWrongObject = terror.ClassSchema.NewStd(mysql.ErrWrongObject)
+	// ErrColumnExists is returned when adding a column which already exists.
+	ErrColumnExists = terror.ClassSchema.NewStd(mysql.ErrDupFieldName)
+	// ErrColumnNotExists is returned when a column doesn't exist.
+	ErrColumnNotExists = terror.ClassSchema.NewStd(mysql.ErrBadField)
+	// ErrCantRemoveAllFields is returned when dropping the last column of a table.
+	ErrCantRemoveAllFields = terror.ClassSchema.NewStd(mysql.ErrCantRemoveAllFields)
+	// ErrIndexExists is returned when adding an index which already exists.
+	ErrIndexExists = terror.ClassSchema.NewStd(mysql.ErrDupKeyName)
+	// ErrKeyNotExists is returned when an index doesn't exist.
+	ErrKeyNotExists = terror.ClassSchema.NewStd(mysql.ErrKeyDoesNotExist)
+	// ErrCantDropFieldOrKey is returned when dropping a key or constraint which doesn't exist.
+	ErrCantDropFieldOrKey = terror.ClassSchema.NewStd(mysql.ErrCantDropFieldOrKey)
+	// ErrDependentByGeneratedColumn is returned when dropping a column which a generated column or an expression index uses.
+	ErrDependentByGeneratedColumn = terror.ClassSchema.NewStd(mysql.ErrDependentByGeneratedColumn)
+	// ErrMultiplePriKey is returned when a table gets more than one primary key.
+	ErrMultiplePriKey = terror.ClassSchema.NewStd(mysql.ErrMultiplePriKey)
+	// ErrKeyColumnDoesNotExist is returned when an index refers to a missing column.
+	ErrKeyColumnDoesNotExist = terror.ClassSchema.NewStd(mysql.ErrKeyColumnDoesNotExits)
+	// ErrForeignKeyExists is returned when adding a foreign key which already exists.
+	ErrForeignKeyExists = terror.ClassSchema.NewStd(mysql.ErrFkDupName)
+	// ErrPartitionMgmtOnNonpartitioned is returned when managing partitions of a table without partitions.
+	ErrPartitionMgmtOnNonpartitioned = terror.ClassSchema.NewStd(mysql.ErrPartitionMgmtOnNonpartitioned)
+	// ErrDropPartitionNonExistent is returned when a partition in the list doesn't exist.
+	ErrDropPartitionNonExistent = terror.ClassSchema.NewStd(mysql.ErrDropPartitionNonExistent)
+	// ErrSameNamePartition is returned when adding a partition which already exists.
+	ErrSameNamePartition = terror.ClassSchema.NewStd(mysql.ErrSameNamePartition)
+)
+
+// Catalog is an in-memory schema. Statements are applied with Apply, and the
+// resulting schema state can be inspected with Schemas, SchemaByName and
+// TableByName. A Catalog is not safe for concurrent use.
+type Catalog struct {
+	// current is the database that unqualified names refer to.
+	current model.CIStr
+	schemas []*model.DBInfo
+	// nextID is used to allocate IDs for databases and tables.
+	nextID int64
+}
+
+// New creates an empty Catalog. If defaultSchema is not empty, the database is
+// created and used as the current database, as if `CREATE DATABASE` and `USE`
+// had been applied.
+func New(defaultSchema string) *Catalog {
+	c := &Catalog{}
+	if defaultSchema != "" {
+		db := c.newDBInfo(model.NewCIStr(defaultSchema))
+		c.schemas = append(c.schemas, db)
+		c.current = db.Name
+	}
+	return c
+}
+
+// Clone returns a deep copy of the catalog.
+func (c *Catalog) Clone() *Catalog {
+	nc := *c
+	nc.schemas = make([]*model.DBInfo, len(c.schemas))
+	for i, db := range c.schemas {
+		ndb := db.Copy()
+		ndb.Tables = make([]*model.TableInfo, len(db.Tables))
+		for j, tbl := range db.Tables {
+			ndb.Tables[j] = cloneTable(tbl)
+		}
+		nc.schemas[i] = ndb
+	}
+	return &nc
+}
+
+// CurrentSchema returns the name of the current database.
+func (c *Catalog) CurrentSchema() model.CIStr {
+	return c.current
+}
+
+// Schemas returns all databases in the order they were created.
+func (c *Catalog) Schemas() []*model.DBInfo {
+	return c.schemas
+}
+
+// SchemaByName returns the database with the given name.
+func (c *Catalog) SchemaByName(schema model.CIStr) (*model.DBInfo, bool) {
+	for _, db := range c.schemas {
+		if db.Name.L == schema.L {
+			return db, true
+		}
+	}
+	return nil, false
+}
+
+// TableByName returns the table or view with the given name. An empty schema
+// name refers to the current database.
+func (c *Catalog) TableByName(schema, table model.CIStr) (*model.TableInfo, error) {
+	db, err := c.schemaOrCurrent(schema)
+	if err != nil {
+		return nil, err
+	}
+	if tbl := findTable(db, table); tbl != nil {
+		return tbl, nil
+	}
+	return nil, ErrTableNotExists.GenWithStackByArgs(db.Name.O, table.O)
+}
+
+// ApplyAll applies the statements in order. It stops at the first error and
+// returns it annotated with the index of the failing statement.
+func (c *Catalog) ApplyAll(stmts []ast.StmtNode) error {
+	for i, stmt := range stmts {
+		if err := c.Apply(stmt); err != nil {
+			return errors.Annotatef(err, "statement %d", i)
+		}
+	}
+	return nil
+}
+
+// Apply applies a statement to the catalog. Statements which don't change the
+// schema, such as DML, are ignored. If an error is returned, the catalog is
+// left unchanged.
+func (c *Catalog) Apply(stmt ast.StmtNode) error {
+	switch x := stmt.(type) {
+	case *ast.CreateDatabaseStmt:
+		return c.createDatabase(x)
+	case *ast.AlterDatabaseStmt:
+		return c.alterDatabase(x)
+	case *ast.DropDatabaseStmt:
+		return c.dropDatabase(x)
+	case *ast.UseStmt:
+		db, ok := c.SchemaByName(model.NewCIStr(x.DBName))
+		if !ok {
+			return ErrDatabaseNotExists.GenWithStackByArgs(x.DBName)
+		}
+		c.current = db.Name
+		return nil
+	case *ast.CreateTableStmt:
+		return c.createTable(x)
+	case *ast.CreateViewStmt:
+		return c.createView(x)
+	case *ast.DropTableStmt:
+		return c.dropTable(x)
+	case *ast.RenameTableStmt:
+		return c.renameTable(x)
+	case *ast.TruncateTableStmt:
+		_, _, err := c.lookupTable(x.Table)
+		return err
+	case *ast.AlterTableStmt:
+		return c.alterTable(x)
+	case *ast.CreateIndexStmt:
+		return c.createIndex(x)
+	case *ast.DropIndexStmt:
+		return c.dropIndex(x)
+	}
+	return nil
+}
+
+func (c *Catalog) allocID() int64 {
+	c.nextID++
+	return c.nextID
+}
+
+func (c *Catalog) newDBInfo(name model.CIStr) *model.DBInfo {
+	return &model.DBInfo{
+		ID:      c.allocID(),
+		Name:    name,
+		Charset: mysql.DefaultCharset,
+		Collate: mysql.DefaultCollationName,
+		State:   model.StatePublic,
+	}
+}
+
+func (c *Catalog) schemaOrCurrent(schema model.CIStr) (*model.DBInfo, error) {
+	if schema.L == "" {
+		if c.current.L == "" {
+			return nil, ErrNoDB
+		}
+		schema = c.current
+	}
+	db, ok := c.SchemaByName(schema)
+	if !ok {
+		return nil, ErrDatabaseNotExists.GenWithStackByArgs(schema.O)
+	}
+	return db, nil
+}
+
+// lookupTable finds the database and the table referred by tn.
+func (c *Catalog) lookupTable(tn *ast.TableName) (*model.DBInfo, *model.TableInfo, error) {
+	db, err := c.schemaOrCurrent(tn.Schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	tbl := findTable(db, tn.Name)
+	if tbl == nil {
+		return nil, nil, ErrTableNotExists.GenWithStackByArgs(db.Name.O, tn.Name.O)
+	}
+	return db, tbl, nil
+}
+
+// lookupBaseTable is like lookupTable but rejects views.
+func (c *Catalog) lookupBaseTable(tn *ast.TableName) (*model.DBInfo, *model.TableInfo, error) {
+	db, tbl, err := c.lookupTable(tn)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tbl.IsView() {
+		return nil, nil, ErrWrongObject.GenWithStackByArgs(db.Name.O, tbl.Name.O, "BASE TABLE")
+	}
+	return db, tbl, nil
+}
+
+func findTable(db *model.DBInfo, name model.CIStr) *model.TableInfo {
+	for _, tbl := range db.Tables {
+		if tbl.Name.L == name.L {
+			return tbl
+		}
+	}
+	return nil
+}
+
+func removeTable(db *model.DBInfo, tbl *model.TableInfo) {
+	for i, t := range db.Tables {
+		if t == tbl {
+			db.Tables = append(db.Tables[:i], db.Tables[i+1:]...)
+			return
+		}
+	}
+}
+
+// replaceTable swaps the table having the same ID as tbl with tbl.
+func replaceTable(db *model.DBInfo, tbl *model.TableInfo) {
+	for i, t := range db.Tables {
+		if t.ID == tbl.ID {
+			db.Tables[i] = tbl
+			return
+		}
+	}
+}
+
+func (c *Catalog) createDatabase(stmt *ast.CreateDatabaseStmt) error {
+	name := model.NewCIStr(stmt.Name)
+	if _, ok := c.SchemaByName(name); ok {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return ErrDatabaseExists.GenWithStackByArgs(stmt.Name)
+	}
+	db := c.newDBInfo(name)
+	applyDatabaseOptions(db, stmt.Options)
+	c.schemas = append(c.schemas, db)
+	return nil
+}
+
+func (c *Catalog) alterDatabase(stmt *ast.AlterDatabaseStmt) error {
+	var name model.CIStr
+	if !stmt.AlterDefaultDatabase {
+		name = model.NewCIStr(stmt.Name)
+	}
+	db, err := c.schemaOrCurrent(name)
+	if err != nil {
+		return err
+	}
+	applyDatabaseOptions(db, stmt.Options)
+	return nil
+}
+
+func applyDatabaseOptions(db *model.DBInfo, options []*ast.DatabaseOption) {
+	for _, opt := range options {
+		switch opt.Tp {
+		case ast.DatabaseOptionCharset:
+			db.Charset = opt.Value
+		case ast.DatabaseOptionCollate:
+			db.Collate = opt.Value
+		}
+	}
+}
+
+func (c *Catalog) dropDatabase(stmt *ast.DropDatabaseStmt) error {
+	name := model.NewCIStr(stmt.Name)
+	for i, db := range c.schemas {
+		if db.Name.L == name.L {
+			c.schemas = append(c.schemas[:i], c.schemas[i+1:]...)
+			if c.current.L == name.L {
+				c.current = model.CIStr{}
+			}
+			return nil
+		}
+	}
+	if stmt.IfExists {
+		return nil
+	}
+	return ErrDatabaseDropExists.GenWithStackByArgs(stmt.Name)
+}
+
+func (c *Catalog) createTable(stmt *ast.CreateTableStmt) error {
+	db, err := c.schemaOrCurrent(stmt.Table.Schema)
+	if err != nil {
+		return err
+	}
+	if findTable(db, stmt.Table.Name) != nil {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return ErrTableExists.GenWithStackByArgs(stmt.Table.Name.O)
+	}
+
+	var tbl *model.TableInfo
+	if stmt.ReferTable != nil {
+		_, referTbl, err := c.lookupBaseTable(stmt.ReferTable)
+		if err != nil {
+			return err
+		}
+		tbl = referTbl.Clone()
+		tbl.Name = stmt.Table.Name
+		for _, idx := range tbl.Indices {
+			idx.Table = tbl.Name
+		}
+		for _, cst := range tbl.Constraints {
+			cst.Table = tbl.Name
+		}
+		// Foreign keys are not copied by `CREATE TABLE ... LIKE`.
+		tbl.ForeignKeys = nil
+	} else {
+		var selCols []*model.ColumnInfo
+		if stmt.Select != nil {
+			if selCols, err = c.queryColumns(stmt.Select); err != nil {
+				return err
+			}
+		}
+		tbl, err = buildTableInfo(stmt, db, selCols)
+		if err != nil {
+			return err
+		}
+	}
+	tbl.ID = c.allocID()
+	db.Tables = append(db.Tables, tbl)
+	return nil
+}
+
+func (c *Catalog) createView(stmt *ast.CreateViewStmt) error {
+	db, err := c.schemaOrCurrent(stmt.ViewName.Schema)
+	if err != nil {
+		return err
+	}
+	old := findTable(db, stmt.ViewName.Name)
+	if old != nil && !(stmt.OrReplace && old.IsView()) {
+		if old.IsView() {
+			return ErrTableExists.GenWithStackByArgs(stmt.ViewName.Name.O)
+		}
+		return ErrWrongObject.GenWithStackByArgs(db.Name.O, old.Name.O, "VIEW")
+	}
+	tbl, err := c.buildViewInfo(stmt, db)
+	if err != nil {
+		return err
+	}
+	if old != nil {
+		tbl.ID = old.ID
+		replaceTable(db, tbl)
+		return nil
+	}
+	tbl.ID = c.allocID()
+	db.Tables = append(db.Tables, tbl)
+	return nil
+}
+
+func (c *Catalog) dropTable(stmt *ast.DropTableStmt) error {
+	// Check every table before dropping any of them, so a failed statement
+	// leaves the catalog untouched.
+	type dropped struct {
+		db  *model.DBInfo
+		tbl *model.TableInfo
+	}
+	var targets []dropped
+	for _, tn := range stmt.Tables {
+		db, tbl, err := c.lookupTable(tn)
+		if err != nil {
+			if stmt.IfExists && (ErrTableNotExists.Equal(err) || ErrDatabaseNotExists.Equal(err)) {
+				continue
+			}
+			if ErrTableNotExists.Equal(err) {
+				return ErrTableDropExists.GenWithStackByArgs(tn.Schema.O + "." + tn.Name.O)
+			}
+			return err
+		}
+		if stmt.IsView && !tbl.IsView() {
+			return ErrWrongObject.GenWithStackByArgs(db.Name.O, tbl.Name.O, "VIEW")
+		}
+		if !stmt.IsView && tbl.IsView() {
+			return ErrWrongObject.GenWithStackByArgs(db.Name.O, tbl.Name.O, "BASE TABLE")
+		}
+		targets = append(targets, dropped{db, tbl})
+	}
+	for _, t := range targets {
+		removeTable(t.db, t.tbl)
+	}
+	return nil
+}
+
+func (c *Catalog) renameTable(stmt *ast.RenameTableStmt) error {
+	// Renames are applied one after another, as `RENAME TABLE a TO b, b TO a`
+	// is a valid way to swap two tables. Work on a copy so the statement is
+	// applied atomically.
+	nc := c.Clone()
+	for _, t2t := range stmt.TableToTables {
+		if err := nc.moveTable(t2t.OldTable, t2t.NewTable); err != nil {
+			return err
+		}
+	}
+	*c = *nc
+	return nil
+}
+
+// moveTable renames the table oldName to newName, possibly moving it to another database.
+func (c *Catalog) moveTable(oldName, newName *ast.TableName) error {
+	oldDB, tbl, err := c.lookupTable(oldName)
+	if err != nil {
+		return err
+	}
+	newDB, err := c.schemaOrCurrent(newName.Schema)
+	if err != nil {
+		return err
+	}
+	if findTable(newDB, newName.Name) != nil {
+		return ErrTableExists.GenWithStackByArgs(newName.Name.O)
+	}
+	removeTable(oldDB, tbl)
+	tbl.Name = newName.Name
+	for _, idx := range tbl.Indices {
+		idx.Table = tbl.Name
+	}
+	for _, cst := range tbl.Constraints {
+		cst.Table = tbl.Name
+	}
+	newDB.Tables = append(newDB.Tables, tbl)
+	return nil
+}
+
+func (c *Catalog) createIndex(stmt *ast.CreateIndexStmt) error {
+	db, tbl, err := c.lookupBaseTable(stmt.Table)
+	if err != nil {
+		return err
+	}
+	if tbl.FindIndexByName(stmt.IndexName) != nil {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return ErrIndexExists.GenWithStackByArgs(stmt.IndexName)
+	}
+	tbl = cloneTable(tbl)
+	unique := stmt.KeyType == ast.IndexKeyTypeUnique
+	if err := addIndex(tbl, stmt.IndexName, false, unique, stmt.IndexPartSpecifications, stmt.IndexOption); err != nil {
+		return err
+	}
+	replaceTable(db, tbl)
+	return nil
+}
+
+func (c *Catalog) dropIndex(stmt *ast.DropIndexStmt) error {
+	db, tbl, err := c.lookupBaseTable(stmt.Table)
+	if err != nil {
+		return err
+	}
+	tbl = cloneTable(tbl)
+	if err := dropIndex(tbl, model.NewCIStr(stmt.IndexName), stmt.IfExists); err != nil {
+		return err
+	}
+	replaceTable(db, tbl)
+	return nil
+}
@@ -0,0 +1,386 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog_test
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/kyleconroy/sqlparse"
+	. "github.com/kyleconroy/sqlparse/catalog"
+	"github.com/kyleconroy/sqlparse/model"
+	"github.com/kyleconroy/sqlparse/mysql"
+	"github.com/kyleconroy/sqlparse/terror"
+	_ "github.com/kyleconroy/sqlparse/test_driver"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testCatalogSuite{})
+
+type testCatalogSuite struct {
+}
+
+func (s *testCatalogSuite) apply(c *C, cat *Catalog, sql string) error {
+	stmts, _, err := parser.New().Parse(sql, "", "")
+	c.Assert(err, IsNil)
+	return cat.ApplyAll(stmts)
+}
+
+func (s *testCatalogSuite) mustApply(c *C, cat *Catalog, sql string) {
+	c.Assert(s.apply(c, cat, sql), IsNil, Commentf("sql: %s", sql))
+}
+
+func (s *testCatalogSuite) table(c *C, cat *Catalog, schema, name string) *model.TableInfo {
+	tbl, err := cat.TableByName(model.NewCIStr(schema), model.NewCIStr(name))
+	c.Assert(err, IsNil)
+	return tbl
+}
+
+func columnNames(tbl *model.TableInfo) []string {
+	var names []string
+	for _, col := range tbl.Columns {
+		names = append(names, col.Name.O)
+	}
+	return names
+}
+
+func indexColumns(idx *model.IndexInfo) []string {
+	var names []string
+	for _, ic := range idx.Columns {
+		names = append(names, ic.Name.O)
+	}
+	return names
+}
+
+func (s *testCatalogSuite) TestDatabase(c *C) {
+	cat := New("")
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "create table t (a int)"), ErrNoDB), IsTrue)
+
+	s.mustApply(c, cat, "create database d1 character set latin1; create database if not exists d1; use d1")
+	c.Assert(cat.CurrentSchema().O, Equals, "d1")
+	db, ok := cat.SchemaByName(model.NewCIStr("D1"))
+	c.Assert(ok, IsTrue)
+	c.Assert(db.Charset, Equals, "latin1")
+
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "create database d1"), ErrDatabaseExists), IsTrue)
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "use d2"), ErrDatabaseNotExists), IsTrue)
+
+	s.mustApply(c, cat, "alter database d1 collate utf8mb4_bin; drop database d1; drop database if exists d1")
+	c.Assert(cat.Schemas(), HasLen, 0)
+	c.Assert(cat.CurrentSchema().L, Equals, "")
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "drop database d1"), ErrDatabaseDropExists), IsTrue)
+}
+
+func (s *testCatalogSuite) TestCreateTable(c *C) {
+	cat := New("test")
+	s.mustApply(c, cat, `create table t (
+		id bigint unsigned not null auto_increment primary key,
+		name varchar(64) not null default 'x' comment 'the name',
+		email varchar(255) unique,
+		created_at timestamp default current_timestamp on update current_timestamp,
+		total int as (id + 1) stored,
+		parent_id bigint unsigned references t (id),
+		key idx_name (name(10), created_at),
+		constraint chk check (total > 0)
+	) charset utf8mb4 comment 'a table'`)
+
+	tbl := s.table(c, cat, "", "t")
+	c.Assert(columnNames(tbl), DeepEquals, []string{"id", "name", "email", "created_at", "total", "parent_id"})
+	c.Assert(tbl.Comment, Equals, "a table")
+	c.Assert(tbl.Charset, Equals, "utf8mb4")
+	c.Assert(tbl.Collate, Equals, "utf8mb4_bin")
+
+	id := tbl.Columns[0]
+	c.Assert(id.Tp, Equals, mysql.TypeLonglong)
+	c.Assert(id.Flen, Equals, 20)
+	c.Assert(mysql.HasPriKeyFlag(id.Flag), IsTrue)
+	c.Assert(mysql.HasNotNullFlag(id.Flag), IsTrue)
+	c.Assert(mysql.HasAutoIncrementFlag(id.Flag), IsTrue)
+	c.Assert(mysql.HasUnsignedFlag(id.Flag), IsTrue)
+
+	name := tbl.Columns[1]
+	c.Assert(name.GetDefaultValue(), Equals, "x")
+	c.Assert(name.DefaultIsExpr, IsFalse)
+	c.Assert(name.Comment, Equals, "the name")
+	c.Assert(mysql.HasMultipleKeyFlag(name.Flag), IsTrue)
+
+	c.Assert(mysql.HasUniKeyFlag(tbl.Columns[2].Flag), IsTrue)
+	c.Assert(tbl.Columns[3].GetDefaultValue(), Equals, "CURRENT_TIMESTAMP()")
+	c.Assert(tbl.Columns[3].DefaultIsExpr, IsTrue)
+	c.Assert(mysql.HasOnUpdateNowFlag(tbl.Columns[3].Flag), IsTrue)
+	c.Assert(tbl.Columns[4].GeneratedExprString, Equals, "`id`+1")
+	c.Assert(tbl.Columns[4].GeneratedStored, IsTrue)
+
+	c.Assert(tbl.Indices, HasLen, 3)
+	c.Assert(tbl.Indices[0].Primary, IsTrue)
+	c.Assert(tbl.Indices[0].Name.O, Equals, "PRIMARY")
+	c.Assert(tbl.Indices[1].Name.O, Equals, "email")
+	c.Assert(tbl.Indices[1].Unique, IsTrue)
+	c.Assert(tbl.Indices[2].Name.O, Equals, "idx_name")
+	c.Assert(indexColumns(tbl.Indices[2]), DeepEquals, []string{"name", "created_at"})
+	c.Assert(tbl.Indices[2].Columns[0].Length, Equals, 10)
+	c.Assert(tbl.Indices[2].Columns[1].Offset, Equals, 3)
+
+	c.Assert(tbl.ForeignKeys, HasLen, 1)
+	c.Assert(tbl.ForeignKeys[0].Name.O, Equals, "t_ibfk_1")
+	c.Assert(tbl.ForeignKeys[0].RefTable.O, Equals, "t")
+	c.Assert(tbl.ForeignKeys[0].RefCols, DeepEquals, []model.CIStr{model.NewCIStr("id")})
+
+	c.Assert(tbl.Constraints, HasLen, 1)
+	c.Assert(tbl.Constraints[0].Name.O, Equals, "chk")
+	c.Assert(tbl.Constraints[0].ExprString, Equals, "`total`>0")
+
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "create table t (a int)"), ErrTableExists), IsTrue)
+	s.mustApply(c, cat, "create table if not exists t (a int)")
+	c.Assert(s.table(c, cat, "test", "t").Columns, HasLen, 6)
+
+	s.mustApply(c, cat, "create table t2 like t")
+	t2 := s.table(c, cat, "test", "t2")
+	c.Assert(columnNames(t2), DeepEquals, columnNames(tbl))
+	c.Assert(t2.Indices[2].Table.O, Equals, "t2")
+	c.Assert(t2.ForeignKeys, HasLen, 0)
+	c.Assert(t2.ID, Not(Equals), tbl.ID)
+
+	s.mustApply(c, cat, "create table t3 as select id, name as n, id + 1 from t")
+	t3 := s.table(c, cat, "test", "t3")
+	c.Assert(columnNames(t3), DeepEquals, []string{"id", "n", "id + 1"})
+	c.Assert(t3.Columns[0].Tp, Equals, mysql.TypeLonglong)
+	c.Assert(mysql.HasPriKeyFlag(t3.Columns[0].Flag), IsFalse)
+	c.Assert(t3.Columns[1].Tp, Equals, mysql.TypeVarchar)
+	c.Assert(t3.Columns[2].Offset, Equals, 2)
+	s.mustApply(c, cat, "create table t4 (x int, n char(3), primary key (id)) as select id, n from t3")
+	t4 := s.table(c, cat, "test", "t4")
+	c.Assert(columnNames(t4), DeepEquals, []string{"x", "n", "id"})
+	c.Assert(t4.Columns[1].Tp, Equals, mysql.TypeString)
+	c.Assert(t4.Indices[0].Primary, IsTrue)
+	c.Assert(t4.Indices[0].Columns[0].Offset, Equals, 2)
+
+	errCases := []struct {
+		sql string
+		err *terror.Error
+	}{
+		{"create table e (a int, a int)", ErrColumnExists},
+		{"create table e (a int primary key, b int primary key)", ErrMultiplePriKey},
+		{"create table e (a int, key k (a), key k (a))", ErrIndexExists},
+		{"create table e (a int, key k (b))", ErrKeyColumnDoesNotExist},
+		{"create table e like missing", ErrTableNotExists},
+		{"create table missing.e (a int)", ErrDatabaseNotExists},
+		{"create table e as select id, id from t", ErrColumnExists},
+	}
+	for _, ca := range errCases {
+		err := s.apply(c, cat, ca.sql)
+		c.Assert(terror.ErrorEqual(err, ca.err), IsTrue, Commentf("sql: %s, err: %v", ca.sql, err))
+	}
+	_, err := cat.TableByName(model.NewCIStr("test"), model.NewCIStr("e"))
+	c.Assert(terror.ErrorEqual(err, ErrTableNotExists), IsTrue)
+}
+
+func (s *testCatalogSuite) TestAlterTable(c *C) {
+	cat := New("test")
+	s.mustApply(c, cat, "create table t (a int, b varchar(10), c int, index idx_bc (b, c))")
+
+	s.mustApply(c, cat, "alter table t add column d int first, add column e int after b")
+	tbl := s.table(c, cat, "test", "t")
+	c.Assert(columnNames(tbl), DeepEquals, []string{"d", "a", "b", "e", "c"})
+	c.Assert(tbl.Indices[0].Columns[1].Offset, Equals, 4)
+
+	s.mustApply(c, cat, "alter table t change column b bb varchar(20) not null after c, modify d bigint default 1")
+	tbl = s.table(c, cat, "test", "t")
+	c.Assert(columnNames(tbl), DeepEquals, []string{"d", "a", "e", "c", "bb"})
+	c.Assert(tbl.Columns[4].Flen, Equals, 20)
+	c.Assert(mysql.HasNotNullFlag(tbl.Columns[4].Flag), IsTrue)
+	c.Assert(tbl.Columns[0].Tp, Equals, mysql.TypeLonglong)
+	c.Assert(tbl.Columns[0].GetDefaultValue(), Equals, "1")
+	c.Assert(indexColumns(tbl.Indices[0]), DeepEquals, []string{"bb", "c"})
+	c.Assert(tbl.Indices[0].Columns[0].Offset, Equals, 4)
+
+	s.mustApply(c, cat, "alter table t rename column a to aa, rename index idx_bc to idx, add primary key (aa), add unique (e)")
+	tbl = s.table(c, cat, "test", "t")
+	c.Assert(tbl.Columns[1].Name.O, Equals, "aa")
+	c.Assert(tbl.FindIndexByName("idx"), NotNil)
+	c.Assert(tbl.FindIndexByName("primary"), NotNil)
+	c.Assert(tbl.FindIndexByName("e"), NotNil)
+	c.Assert(mysql.HasPriKeyFlag(tbl.Columns[1].Flag), IsTrue)
+
+	s.mustApply(c, cat, "alter table t drop column c, drop primary key, alter column d drop default, comment = 'x'")
+	tbl = s.table(c, cat, "test", "t")
+	c.Assert(columnNames(tbl), DeepEquals, []string{"d", "aa", "e", "bb"})
+	c.Assert(indexColumns(tbl.FindIndexByName("idx")), DeepEquals, []string{"bb"})
+	c.Assert(tbl.FindIndexByName("primary"), IsNil)
+	c.Assert(mysql.HasPriKeyFlag(tbl.Columns[1].Flag), IsFalse)
+	c.Assert(tbl.Columns[0].GetDefaultValue(), IsNil)
+	c.Assert(tbl.Comment, Equals, "x")
+
+	s.mustApply(c, cat, "create database other; alter table t rename to other.t2")
+	_, err := cat.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(terror.ErrorEqual(err, ErrTableNotExists), IsTrue)
+	tbl = s.table(c, cat, "other", "t2")
+	c.Assert(tbl.FindIndexByName("idx").Table.O, Equals, "t2")
+
+	errCases := []struct {
+		sql string
+		err *terror.Error
+	}{
+		{"alter table other.t2 drop column missing", ErrCantDropFieldOrKey},
+		{"alter table other.t2 modify missing int", ErrColumnNotExists},
+		{"alter table other.t2 change d aa int", ErrColumnExists},
+		{"alter table other.t2 add column d int", ErrColumnExists},
+		{"alter table other.t2 add index idx (d)", ErrIndexExists},
+		{"alter table other.t2 drop index missing", ErrCantDropFieldOrKey},
+		{"alter table other.t2 rename index missing to x", ErrKeyNotExists},
+		{"alter table other.t2 add column x int after missing", ErrColumnNotExists},
+		{"alter table other.t2 drop foreign key missing", ErrCantDropFieldOrKey},
+		{"alter table other.t2 drop partition p0", ErrPartitionMgmtOnNonpartitioned},
+		{"alter table other.missing add column x int", ErrTableNotExists},
+	}
+	for _, ca := range errCases {
+		err := s.apply(c, cat, ca.sql)
+		c.Assert(terror.ErrorEqual(err, ca.err), IsTrue, Commentf("sql: %s, err: %v", ca.sql, err))
+	}
+
+	// A failing statement leaves the table untouched.
+	c.Assert(s.apply(c, cat, "alter table other.t2 add column x int, drop column missing"), NotNil)
+	c.Assert(columnNames(s.table(c, cat, "other", "t2")), DeepEquals, []string{"d", "aa", "e", "bb"})
+
+	s.mustApply(c, cat, "create table one (a int)")
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "alter table one drop column a"), ErrCantRemoveAllFields), IsTrue)
+}
+
+func (s *testCatalogSuite) TestIndexAndForeignKey(c *C) {
+	cat := New("test")
+	s.mustApply(c, cat, `create table p (id int primary key);
+		create table t (a int, b int, constraint fk_a foreign key (a) references p (id) on delete cascade)`)
+	s.mustApply(c, cat, "create unique index ub on t (b); create index expr on t ((a + b))")
+	tbl := s.table(c, cat, "test", "t")
+	c.Assert(tbl.ForeignKeys[0].Name.O, Equals, "fk_a")
+	c.Assert(tbl.ForeignKeys[0].OnDelete, Equals, 2)
+	c.Assert(tbl.FindIndexByName("ub").Unique, IsTrue)
+	c.Assert(tbl.Columns, HasLen, 3)
+	c.Assert(tbl.Columns[2].Hidden, IsTrue)
+	c.Assert(tbl.Columns[2].GeneratedExprString, Equals, "`a`+`b`")
+	c.Assert(tbl.Columns[2].Tp, Equals, mysql.TypeLonglong)
+	c.Assert(tbl.Columns[2].Flen, Equals, 20)
+
+	// The columns an expression index uses can't be dropped.
+	err := s.apply(c, cat, "alter table t drop column b")
+	c.Assert(terror.ErrorEqual(err, ErrDependentByGeneratedColumn), IsTrue)
+	c.Assert(err, ErrorMatches, ".*Column 'b' has a generated column dependency.")
+
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "create index ub on t (a)"), ErrIndexExists), IsTrue)
+	s.mustApply(c, cat, "create index if not exists ub on t (a)")
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "alter table t add constraint fk_a foreign key (b) references p (id)"), ErrForeignKeyExists), IsTrue)
+
+	s.mustApply(c, cat, "drop index expr on t; alter table t drop foreign key fk_a")
+	tbl = s.table(c, cat, "test", "t")
+	c.Assert(tbl.Columns, HasLen, 2)
+	c.Assert(tbl.ForeignKeys, HasLen, 0)
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "drop index expr on t"), ErrCantDropFieldOrKey), IsTrue)
+	s.mustApply(c, cat, "alter table t drop column b")
+
+	// Nor the columns a generated column uses, until it is dropped.
+	s.mustApply(c, cat, "create table g (a int, b varchar(10), c int as (a + 1), d varchar(20) as (concat(b, b)) stored)")
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "alter table g drop column a"), ErrDependentByGeneratedColumn), IsTrue)
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "alter table g drop column b"), ErrDependentByGeneratedColumn), IsTrue)
+	s.mustApply(c, cat, "alter table g drop column c; alter table g drop column a")
+	c.Assert(columnNames(s.table(c, cat, "test", "g")), DeepEquals, []string{"b", "d"})
+}
+
+func (s *testCatalogSuite) TestPartition(c *C) {
+	cat := New("test")
+	s.mustApply(c, cat, `create table t (a int, d date) partition by range (a) (
+		partition p0 values less than (10),
+		partition p1 values less than (20))`)
+	tbl := s.table(c, cat, "test", "t")
+	c.Assert(tbl.Partition.Type, Equals, model.PartitionTypeRange)
+	c.Assert(tbl.Partition.Expr, Equals, "`a`")
+	c.Assert(tbl.Partition.Definitions, HasLen, 2)
+	c.Assert(tbl.Partition.Definitions[1].LessThan, DeepEquals, []string{"20"})
+
+	s.mustApply(c, cat, "alter table t add partition (partition p2 values less than maxvalue)")
+	c.Assert(s.table(c, cat, "test", "t").Partition.Definitions[2].LessThan, DeepEquals, []string{"MAXVALUE"})
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "alter table t add partition (partition p2 values less than (30))"), ErrSameNamePartition), IsTrue)
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "alter table t drop partition p9"), ErrDropPartitionNonExistent), IsTrue)
+
+	s.mustApply(c, cat, `alter table t reorganize partition p0, p1 into (partition p01 values less than (20)); alter table t drop partition p2`)
+	pi := s.table(c, cat, "test", "t").Partition
+	c.Assert(pi.Definitions, HasLen, 1)
+	c.Assert(pi.Definitions[0].Name.O, Equals, "p01")
+
+	s.mustApply(c, cat, "alter table t partition by hash (a) partitions 4")
+	pi = s.table(c, cat, "test", "t").Partition
+	c.Assert(pi.Type, Equals, model.PartitionType(model.PartitionTypeHash))
+	c.Assert(pi.Definitions, HasLen, 4)
+	c.Assert(pi.Definitions[3].Name.O, Equals, "p3")
+
+	s.mustApply(c, cat, "alter table t remove partitioning")
+	c.Assert(s.table(c, cat, "test", "t").Partition, IsNil)
+}
+
+func (s *testCatalogSuite) TestViewRenameAndDrop(c *C) {
+	cat := New("test")
+	s.mustApply(c, cat, `create table t (a int not null, b varchar(10));
+		create view v as select a, t.b as bb, a + 1 from t;
+		create view v2 (x, y) as select * from t`)
+	v := s.table(c, cat, "test", "v")
+	c.Assert(v.IsView(), IsTrue)
+	c.Assert(columnNames(v), DeepEquals, []string{"a", "bb", "a + 1"})
+	c.Assert(v.Columns[0].Tp, Equals, mysql.TypeLong)
+	c.Assert(v.Columns[1].Tp, Equals, mysql.TypeVarchar)
+	c.Assert(v.Columns[2].Tp, Equals, mysql.TypeUnspecified)
+	c.Assert(columnNames(s.table(c, cat, "test", "v2")), DeepEquals, []string{"x", "y"})
+	s.mustApply(c, cat, "create view vv as values row(1, 'a'); create view vt as table t")
+	vv := s.table(c, cat, "test", "vv")
+	c.Assert(columnNames(vv), DeepEquals, []string{"column_0", "column_1"})
+	c.Assert(vv.Columns[0].Tp, Equals, mysql.TypeLonglong)
+	c.Assert(vv.Columns[1].Tp, Equals, mysql.TypeVarString)
+	c.Assert(columnNames(s.table(c, cat, "test", "vt")), DeepEquals, []string{"a", "b"})
+	err := s.apply(c, cat, "create view vw as values row(1, 2), row(3)")
+	c.Assert(terror.ErrorEqual(err, ErrWrongValueCountOnRow), IsTrue)
+	c.Assert(err, ErrorMatches, ".*Column count doesn't match value count at row 2")
+	err = s.apply(c, cat, "create table tw as select * from (values row(1), row(2, 3)) x")
+	c.Assert(terror.ErrorEqual(err, ErrWrongValueCountOnRow), IsTrue)
+
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "create view v3 (x) as select * from t"), ErrViewWrongList), IsTrue)
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "create view t as select 1"), ErrWrongObject), IsTrue)
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "alter table v add column c int"), ErrWrongObject), IsTrue)
+	s.mustApply(c, cat, "create or replace view v as select b from t")
+	c.Assert(columnNames(s.table(c, cat, "test", "v")), DeepEquals, []string{"b"})
+
+	s.mustApply(c, cat, "create table u (c int); rename table t to tmp, u to t, tmp to u")
+	c.Assert(columnNames(s.table(c, cat, "test", "t")), DeepEquals, []string{"c"})
+	c.Assert(columnNames(s.table(c, cat, "test", "u")), DeepEquals, []string{"a", "b"})
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "rename table t to u"), ErrTableExists), IsTrue)
+
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "drop table v"), ErrWrongObject), IsTrue)
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "drop view t"), ErrWrongObject), IsTrue)
+	c.Assert(terror.ErrorEqual(s.apply(c, cat, "drop table t, missing"), ErrTableDropExists), IsTrue)
+	s.mustApply(c, cat, "drop view v, v2, vv, vt; drop table if exists t, missing")
+	db, _ := cat.SchemaByName(model.NewCIStr("test"))
+	c.Assert(db.Tables, HasLen, 1)
+	c.Assert(db.Tables[0].Name.O, Equals, "u")
+}
+
+func (s *testCatalogSuite) TestClone(c *C) {
+	cat := New("test")
+	s.mustApply(c, cat, "create table t (a int, b int check (b > 0))")
+	cloned := cat.Clone()
+	s.mustApply(c, cloned, "alter table t add column c int, rename column b to bb")
+	c.Assert(columnNames(s.table(c, cat, "test", "t")), DeepEquals, []string{"a", "b"})
+	c.Assert(s.table(c, cat, "test", "t").Constraints[0].ConstraintCols[0].O, Equals, "b")
+	c.Assert(columnNames(s.table(c, cloned, "test", "t")), DeepEquals, []string{"a", "bb", "c"})
+}
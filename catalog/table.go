@@ -0,0 +1,685 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/charset"
+	"github.com/kyleconroy/sqlparse/format"
+	"github.com/kyleconroy/sqlparse/model"
+	"github.com/kyleconroy/sqlparse/mysql"
+	"github.com/kyleconroy/sqlparse/resolver"
+	"github.com/kyleconroy/sqlparse/types"
+)
+
+// RestoreFlags are the flags used to store expressions, such as default values,
+// generated columns and check constraints, as text in the model.
+const RestoreFlags = format.DefaultRestoreFlags
+
+func restoreText(n ast.Node) (string, error) {
+	var sb strings.Builder
+	if err := n.Restore(format.NewRestoreCtx(RestoreFlags, &sb)); err != nil {
+		return "", errors.Trace(err)
+	}
+	return sb.String(), nil
+}
+
+// cloneTable is like TableInfo.Clone, but also copies the parts of the table
+// which are changed in place by the catalog.
+func cloneTable(tbl *model.TableInfo) *model.TableInfo {
+	nt := tbl.Clone()
+	nt.Constraints = make([]*model.ConstraintInfo, len(tbl.Constraints))
+	for i, cst := range tbl.Constraints {
+		nt.Constraints[i] = cst.Clone()
+	}
+	if tbl.Partition != nil {
+		pi := *tbl.Partition
+		pi.Columns = append([]model.CIStr(nil), tbl.Partition.Columns...)
+		pi.Definitions = make([]model.PartitionDefinition, len(tbl.Partition.Definitions))
+		for i, def := range tbl.Partition.Definitions {
+			pi.Definitions[i] = def.Clone()
+		}
+		nt.Partition = &pi
+	}
+	if tbl.View != nil {
+		vi := *tbl.View
+		vi.Cols = append([]model.CIStr(nil), tbl.View.Cols...)
+		nt.View = &vi
+	}
+	return nt
+}
+
+// buildTableInfo builds the table described by a `CREATE TABLE` statement.
+// selCols are the columns of the query of `CREATE TABLE ... SELECT`, which
+// follow the defined columns. As in MySQL, a query column with the name of a
+// defined column takes the definition of that column.
+func buildTableInfo(stmt *ast.CreateTableStmt, db *model.DBInfo, selCols []*model.ColumnInfo) (*model.TableInfo, error) {
+	tbl := &model.TableInfo{
+		Name:    stmt.Table.Name,
+		Charset: db.Charset,
+		Collate: db.Collate,
+		State:   model.StatePublic,
+	}
+	if err := applyTableOptions(tbl, stmt.Options); err != nil {
+		return nil, err
+	}
+
+	var constraints []*ast.Constraint
+	for _, colDef := range stmt.Cols {
+		if model.FindColumnInfo(tbl.Columns, colDef.Name.Name.L) != nil {
+			return nil, ErrColumnExists.GenWithStackByArgs(colDef.Name.Name.O)
+		}
+		col, csts, err := columnDefToCol(tbl, colDef)
+		if err != nil {
+			return nil, err
+		}
+		tbl.Columns = append(tbl.Columns, col)
+		constraints = append(constraints, csts...)
+	}
+	defined := len(tbl.Columns)
+	for _, col := range selCols {
+		if model.FindColumnInfo(tbl.Columns[:defined], col.Name.L) != nil {
+			continue
+		}
+		if model.FindColumnInfo(tbl.Columns[defined:], col.Name.L) != nil {
+			return nil, ErrColumnExists.GenWithStackByArgs(col.Name.O)
+		}
+		tbl.MaxColumnID++
+		col.ID = tbl.MaxColumnID
+		col.State = model.StatePublic
+		col.Version = model.CurrLatestColumnInfoVersion
+		tbl.Columns = append(tbl.Columns, col)
+	}
+	adjustColumnOffsets(tbl)
+
+	constraints = append(constraints, stmt.Constraints...)
+	for _, cst := range constraints {
+		if err := addConstraint(tbl, cst); err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.Partition != nil {
+		pi, err := buildPartitionInfo(tbl, stmt.Partition)
+		if err != nil {
+			return nil, err
+		}
+		tbl.Partition = pi
+	}
+	return tbl, nil
+}
+
+func applyTableOptions(tbl *model.TableInfo, options []*ast.TableOption) error {
+	for _, opt := range options {
+		switch opt.Tp {
+		case ast.TableOptionCharset:
+			if opt.Default {
+				continue
+			}
+			tbl.Charset = strings.ToLower(opt.StrValue)
+			collate, err := charset.GetDefaultCollation(tbl.Charset)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			tbl.Collate = collate
+		case ast.TableOptionCollate:
+			tbl.Collate = strings.ToLower(opt.StrValue)
+		case ast.TableOptionComment:
+			tbl.Comment = opt.StrValue
+		case ast.TableOptionAutoIncrement:
+			tbl.AutoIncID = int64(opt.UintValue)
+		case ast.TableOptionCompression:
+			tbl.Compression = opt.StrValue
+		}
+	}
+	return nil
+}
+
+// columnDefToCol converts a column definition to a column. Key options such as
+// `PRIMARY KEY` or `REFERENCES` are returned as table constraints which are
+// added after all the columns have been created.
+func columnDefToCol(tbl *model.TableInfo, colDef *ast.ColumnDef) (*model.ColumnInfo, []*ast.Constraint, error) {
+	tbl.MaxColumnID++
+	col := &model.ColumnInfo{
+		ID:        tbl.MaxColumnID,
+		Name:      colDef.Name.Name,
+		FieldType: *colDef.Tp,
+		State:     model.StatePublic,
+		Version:   model.CurrLatestColumnInfoVersion,
+	}
+	col.Elems = append([]string(nil), colDef.Tp.Elems...)
+	defaultFlen, defaultDecimal := mysql.GetDefaultFieldLengthAndDecimal(col.Tp)
+	if col.Flen == types.UnspecifiedLength {
+		col.Flen = defaultFlen
+	}
+	if col.Decimal == types.UnspecifiedLength {
+		col.Decimal = defaultDecimal
+	}
+
+	var constraints []*ast.Constraint
+	keyPart := []*ast.IndexPartSpecification{{Column: colDef.Name, Length: types.UnspecifiedLength}}
+	for _, opt := range colDef.Options {
+		switch opt.Tp {
+		case ast.ColumnOptionNotNull:
+			col.Flag |= mysql.NotNullFlag
+		case ast.ColumnOptionNull:
+			col.Flag &^= mysql.NotNullFlag
+		case ast.ColumnOptionAutoIncrement:
+			col.Flag |= mysql.AutoIncrementFlag | mysql.NotNullFlag
+		case ast.ColumnOptionPrimaryKey:
+			constraints = append(constraints, &ast.Constraint{Tp: ast.ConstraintPrimaryKey, Keys: keyPart})
+		case ast.ColumnOptionUniqKey:
+			constraints = append(constraints, &ast.Constraint{Tp: ast.ConstraintUniqKey, Keys: keyPart})
+		case ast.ColumnOptionDefaultValue:
+			if err := setDefaultValue(col, opt.Expr); err != nil {
+				return nil, nil, err
+			}
+		case ast.ColumnOptionOnUpdate:
+			col.Flag |= mysql.OnUpdateNowFlag
+		case ast.ColumnOptionComment:
+			if v, ok := opt.Expr.(ast.ValueExpr); ok {
+				col.Comment = v.GetString()
+			}
+		case ast.ColumnOptionGenerated:
+			text, err := restoreText(opt.Expr)
+			if err != nil {
+				return nil, nil, err
+			}
+			col.GeneratedExprString = text
+			col.GeneratedStored = opt.Stored
+			col.Dependences = make(map[string]struct{})
+			for _, name := range columnNamesInExpr(opt.Expr) {
+				col.Dependences[name.L] = struct{}{}
+			}
+		case ast.ColumnOptionReference:
+			constraints = append(constraints, &ast.Constraint{Tp: ast.ConstraintForeignKey, Keys: keyPart, Refer: opt.Refer})
+		case ast.ColumnOptionCollate:
+			col.Collate = strings.ToLower(opt.StrValue)
+		case ast.ColumnOptionCheck:
+			constraints = append(constraints, &ast.Constraint{
+				Tp:           ast.ConstraintCheck,
+				Name:         opt.ConstraintName,
+				Expr:         opt.Expr,
+				Enforced:     opt.Enforced,
+				InColumn:     true,
+				InColumnName: colDef.Name.Name.O,
+			})
+		}
+	}
+	return col, constraints, nil
+}
+
+// setDefaultValue stores the default value of a column. Literals are stored as
+// their string value, other expressions are stored as their restored text with
+// DefaultIsExpr set.
+func setDefaultValue(col *model.ColumnInfo, expr ast.ExprNode) error {
+	col.DefaultIsExpr = false
+	if v, ok := expr.(ast.ValueExpr); ok {
+		if v.GetValue() == nil {
+			return col.SetDefaultValue(nil)
+		}
+		if s, ok := v.GetValue().(string); ok {
+			return col.SetDefaultValue(s)
+		}
+	}
+	text, err := restoreText(expr)
+	if err != nil {
+		return err
+	}
+	if !isConstantExpr(expr) {
+		col.DefaultIsExpr = true
+	}
+	return col.SetDefaultValue(text)
+}
+
+// isConstantExpr checks whether expr is a literal, possibly with a sign.
+func isConstantExpr(expr ast.ExprNode) bool {
+	switch x := expr.(type) {
+	case ast.ValueExpr:
+		return true
+	case *ast.UnaryOperationExpr:
+		return isConstantExpr(x.V)
+	}
+	return false
+}
+
+// columnNameCollector collects the column names used in an expression.
+type columnNameCollector struct {
+	names []model.CIStr
+}
+
+// Enter implements Visitor interface.
+func (c *columnNameCollector) Enter(n ast.Node) (node ast.Node, skipChildren bool) {
+	if col, ok := n.(*ast.ColumnNameExpr); ok {
+		for _, name := range c.names {
+			if name.L == col.Name.Name.L {
+				return n, true
+			}
+		}
+		c.names = append(c.names, col.Name.Name)
+		return n, true
+	}
+	return n, false
+}
+
+// Leave implements Visitor interface.
+func (c *columnNameCollector) Leave(n ast.Node) (node ast.Node, ok bool) {
+	return n, true
+}
+
+func columnNamesInExpr(expr ast.ExprNode) []model.CIStr {
+	var c columnNameCollector
+	expr.Accept(&c)
+	return c.names
+}
+
+// adjustColumnOffsets sets the offsets of the columns and of the index columns
+// after columns have been added, removed or moved.
+func adjustColumnOffsets(tbl *model.TableInfo) {
+	for i, col := range tbl.Columns {
+		col.Offset = i
+	}
+	for _, idx := range tbl.Indices {
+		for _, ic := range idx.Columns {
+			if col := model.FindColumnInfo(tbl.Columns, ic.Name.L); col != nil {
+				ic.Offset = col.Offset
+			}
+		}
+	}
+}
+
+// updateKeyFlags recomputes the key related flags of the columns from the indices.
+func updateKeyFlags(tbl *model.TableInfo) {
+	for _, col := range tbl.Columns {
+		col.Flag &^= mysql.PriKeyFlag | mysql.UniqueKeyFlag | mysql.MultipleKeyFlag
+	}
+	for _, idx := range tbl.Indices {
+		for i, ic := range idx.Columns {
+			col := model.FindColumnInfo(tbl.Columns, ic.Name.L)
+			if col == nil {
+				continue
+			}
+			switch {
+			case idx.Primary:
+				col.Flag |= mysql.PriKeyFlag
+			case idx.Unique && len(idx.Columns) == 1:
+				col.Flag |= mysql.UniqueKeyFlag
+			case i == 0:
+				col.Flag |= mysql.MultipleKeyFlag
+			}
+		}
+	}
+}
+
+func addConstraint(tbl *model.TableInfo, cst *ast.Constraint) error {
+	switch cst.Tp {
+	case ast.ConstraintPrimaryKey:
+		return addIndex(tbl, "", true, true, cst.Keys, cst.Option)
+	case ast.ConstraintKey, ast.ConstraintIndex, ast.ConstraintFulltext, ast.ConstraintSpatial:
+		if cst.IfNotExists && tbl.FindIndexByName(cst.Name) != nil {
+			return nil
+		}
+		return addIndex(tbl, cst.Name, false, false, cst.Keys, cst.Option)
+	case ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
+		return addIndex(tbl, cst.Name, false, true, cst.Keys, cst.Option)
+	case ast.ConstraintForeignKey:
+		return addForeignKey(tbl, cst)
+	case ast.ConstraintCheck:
+		return addCheck(tbl, cst)
+	}
+	return nil
+}
+
+// indexName generates a name for an index without one, the same way MySQL does.
+func indexName(tbl *model.TableInfo, keys []*ast.IndexPartSpecification) string {
+	base := "functional_index"
+	if len(keys) > 0 && keys[0].Column != nil {
+		base = keys[0].Column.Name.O
+	}
+	name := base
+	for i := 2; tbl.FindIndexByName(name) != nil; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	return name
+}
+
+func addIndex(tbl *model.TableInfo, name string, primary, unique bool, keys []*ast.IndexPartSpecification, opt *ast.IndexOption) error {
+	if primary {
+		for _, idx := range tbl.Indices {
+			if idx.Primary {
+				return ErrMultiplePriKey
+			}
+		}
+		name = mysql.PrimaryKeyName
+	} else if name == "" {
+		name = indexName(tbl, keys)
+	}
+	if tbl.FindIndexByName(name) != nil {
+		return ErrIndexExists.GenWithStackByArgs(name)
+	}
+
+	tbl.MaxIndexID++
+	idx := &model.IndexInfo{
+		ID:      tbl.MaxIndexID,
+		Name:    model.NewCIStr(name),
+		Table:   tbl.Name,
+		State:   model.StatePublic,
+		Unique:  unique,
+		Primary: primary,
+	}
+	for i, key := range keys {
+		colName := model.CIStr{}
+		if key.Expr != nil {
+			// Expression key parts are stored as hidden generated columns.
+			col, err := addHiddenColumn(tbl, idx.Name, i, key.Expr)
+			if err != nil {
+				return err
+			}
+			colName = col.Name
+		} else {
+			colName = key.Column.Name
+		}
+		col := model.FindColumnInfo(tbl.Columns, colName.L)
+		if col == nil {
+			return ErrKeyColumnDoesNotExist.GenWithStackByArgs(colName.O)
+		}
+		if primary {
+			col.Flag |= mysql.NotNullFlag
+		}
+		length := types.UnspecifiedLength
+		if key.Length > 0 {
+			length = key.Length
+		}
+		idx.Columns = append(idx.Columns, &model.IndexColumn{
+			Name:   col.Name,
+			Offset: col.Offset,
+			Length: length,
+		})
+	}
+	if opt != nil {
+		idx.Tp = opt.Tp
+		idx.Comment = opt.Comment
+		idx.Invisible = opt.Visibility == ast.IndexVisibilityInvisible
+	}
+	tbl.Indices = append(tbl.Indices, idx)
+	updateKeyFlags(tbl)
+	return nil
+}
+
+// addHiddenColumn adds the hidden generated column storing an expression key
+// part, which has the type of the expression.
+func addHiddenColumn(tbl *model.TableInfo, idxName model.CIStr, i int, expr ast.ExprNode) (*model.ColumnInfo, error) {
+	text, err := restoreText(expr)
+	if err != nil {
+		return nil, err
+	}
+	tbl.MaxColumnID++
+	col := &model.ColumnInfo{
+		ID:                  tbl.MaxColumnID,
+		Name:                model.NewCIStr(fmt.Sprintf("_V$_%s_%d", idxName.O, i)),
+		Offset:              len(tbl.Columns),
+		FieldType:           *exprType(tbl, expr),
+		GeneratedExprString: text,
+		Dependences:         make(map[string]struct{}),
+		Hidden:              true,
+		State:               model.StatePublic,
+		Version:             model.CurrLatestColumnInfoVersion,
+	}
+	for _, name := range columnNamesInExpr(expr) {
+		col.Dependences[name.L] = struct{}{}
+	}
+	tbl.Columns = append(tbl.Columns, col)
+	return col, nil
+}
+
+// exprType returns the type of an expression over the columns of tbl. The
+// column names are bound to the columns only while the types are inferred.
+func exprType(tbl *model.TableInfo, expr ast.ExprNode) *types.FieldType {
+	binder := &columnBinder{tbl: tbl}
+	expr.Accept(binder)
+	resolver.InferTypes(expr)
+	for _, cn := range binder.bound {
+		cn.Refer = nil
+	}
+	return expr.GetType().Clone()
+}
+
+// columnBinder binds the column names of an expression to the columns of a
+// table.
+type columnBinder struct {
+	tbl   *model.TableInfo
+	bound []*ast.ColumnNameExpr
+}
+
+// Enter implements Visitor interface.
+func (b *columnBinder) Enter(n ast.Node) (node ast.Node, skipChildren bool) {
+	if cn, ok := n.(*ast.ColumnNameExpr); ok && cn.Refer == nil {
+		if col := model.FindColumnInfo(b.tbl.Columns, cn.Name.Name.L); col != nil && !col.Hidden {
+			cn.Refer = &ast.ResultField{Column: col, Table: b.tbl}
+			b.bound = append(b.bound, cn)
+		}
+	}
+	return n, false
+}
+
+// Leave implements Visitor interface.
+func (b *columnBinder) Leave(n ast.Node) (node ast.Node, ok bool) {
+	return n, true
+}
+
+// dropIndex removes an index, together with the hidden columns it owns.
+func dropIndex(tbl *model.TableInfo, name model.CIStr, ifExists bool) error {
+	for i, idx := range tbl.Indices {
+		if idx.Name.L != name.L {
+			continue
+		}
+		tbl.Indices = append(tbl.Indices[:i], tbl.Indices[i+1:]...)
+		for _, ic := range idx.Columns {
+			if col := model.FindColumnInfo(tbl.Columns, ic.Name.L); col != nil && col.Hidden {
+				removeColumn(tbl, col)
+			}
+		}
+		adjustColumnOffsets(tbl)
+		updateKeyFlags(tbl)
+		return nil
+	}
+	if ifExists {
+		return nil
+	}
+	return ErrCantDropFieldOrKey.GenWithStackByArgs(name.O)
+}
+
+func removeColumn(tbl *model.TableInfo, col *model.ColumnInfo) {
+	for i, c := range tbl.Columns {
+		if c == col {
+			tbl.Columns = append(tbl.Columns[:i], tbl.Columns[i+1:]...)
+			return
+		}
+	}
+}
+
+func addForeignKey(tbl *model.TableInfo, cst *ast.Constraint) error {
+	name := cst.Name
+	if name == "" {
+		for i := len(tbl.ForeignKeys) + 1; ; i++ {
+			name = fmt.Sprintf("%s_ibfk_%d", tbl.Name.O, i)
+			if findForeignKey(tbl, model.NewCIStr(name)) == nil {
+				break
+			}
+		}
+	}
+	if findForeignKey(tbl, model.NewCIStr(name)) != nil {
+		if cst.IfNotExists {
+			return nil
+		}
+		return ErrForeignKeyExists.GenWithStackByArgs(name)
+	}
+	fk := &model.FKInfo{
+		ID:       int64(len(tbl.ForeignKeys) + 1),
+		Name:     model.NewCIStr(name),
+		RefTable: cst.Refer.Table.Name,
+		State:    model.StatePublic,
+	}
+	for _, key := range cst.Keys {
+		if model.FindColumnInfo(tbl.Columns, key.Column.Name.L) == nil {
+			return ErrKeyColumnDoesNotExist.GenWithStackByArgs(key.Column.Name.O)
+		}
+		fk.Cols = append(fk.Cols, key.Column.Name)
+	}
+	for _, key := range cst.Refer.IndexPartSpecifications {
+		fk.RefCols = append(fk.RefCols, key.Column.Name)
+	}
+	if cst.Refer.OnDelete != nil {
+		fk.OnDelete = int(cst.Refer.OnDelete.ReferOpt)
+	}
+	if cst.Refer.OnUpdate != nil {
+		fk.OnUpdate = int(cst.Refer.OnUpdate.ReferOpt)
+	}
+	tbl.ForeignKeys = append(tbl.ForeignKeys, fk)
+	return nil
+}
+
+func findForeignKey(tbl *model.TableInfo, name model.CIStr) *model.FKInfo {
+	for _, fk := range tbl.ForeignKeys {
+		if fk.Name.L == name.L {
+			return fk
+		}
+	}
+	return nil
+}
+
+func addCheck(tbl *model.TableInfo, cst *ast.Constraint) error {
+	name := cst.Name
+	if name == "" {
+		for i := len(tbl.Constraints) + 1; ; i++ {
+			name = fmt.Sprintf("%s_chk_%d", tbl.Name.O, i)
+			if tbl.FindConstraintInfoByName(name) == nil {
+				break
+			}
+		}
+	}
+	if tbl.FindConstraintInfoByName(name) != nil {
+		return ErrCantDropFieldOrKey.GenWithStackByArgs(name)
+	}
+	text, err := restoreText(cst.Expr)
+	if err != nil {
+		return err
+	}
+	cols := columnNamesInExpr(cst.Expr)
+	for _, name := range cols {
+		if model.FindColumnInfo(tbl.Columns, name.L) == nil {
+			return ErrColumnNotExists.GenWithStackByArgs(name.O, "check constraint")
+		}
+	}
+	tbl.MaxConstraintID++
+	tbl.Constraints = append(tbl.Constraints, &model.ConstraintInfo{
+		ID:             tbl.MaxConstraintID,
+		Name:           model.NewCIStr(name),
+		Table:          tbl.Name,
+		ConstraintCols: cols,
+		Enforced:       cst.Enforced,
+		InColumn:       cst.InColumn,
+		ExprString:     text,
+		State:          model.StatePublic,
+	})
+	return nil
+}
+
+func buildPartitionInfo(tbl *model.TableInfo, opts *ast.PartitionOptions) (*model.PartitionInfo, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	pi := &model.PartitionInfo{
+		Type:   opts.Tp,
+		Enable: true,
+		Num:    opts.Num,
+	}
+	if opts.Expr != nil {
+		text, err := restoreText(opts.Expr)
+		if err != nil {
+			return nil, err
+		}
+		pi.Expr = text
+		for _, name := range columnNamesInExpr(opts.Expr) {
+			if model.FindColumnInfo(tbl.Columns, name.L) == nil {
+				return nil, ErrColumnNotExists.GenWithStackByArgs(name.O, "partition function")
+			}
+		}
+	}
+	for _, cn := range opts.ColumnNames {
+		if model.FindColumnInfo(tbl.Columns, cn.Name.L) == nil {
+			return nil, ErrColumnNotExists.GenWithStackByArgs(cn.Name.O, "partition function")
+		}
+		pi.Columns = append(pi.Columns, cn.Name)
+	}
+	defs, err := buildPartitionDefinitions(pi, opts.Definitions)
+	if err != nil {
+		return nil, err
+	}
+	pi.Definitions = defs
+	if len(pi.Definitions) == 0 && pi.Num > 0 {
+		for i := uint64(0); i < pi.Num; i++ {
+			pi.Definitions = append(pi.Definitions, model.PartitionDefinition{
+				ID:   int64(i + 1),
+				Name: model.NewCIStr(fmt.Sprintf("p%d", i)),
+			})
+		}
+	}
+	pi.Num = uint64(len(pi.Definitions))
+	return pi, nil
+}
+
+func buildPartitionDefinitions(pi *model.PartitionInfo, defs []*ast.PartitionDefinition) ([]model.PartitionDefinition, error) {
+	var result []model.PartitionDefinition
+	for _, def := range defs {
+		for _, other := range result {
+			if other.Name.L == def.Name.L {
+				return nil, ErrSameNamePartition.GenWithStackByArgs(def.Name.O)
+			}
+		}
+		pd := model.PartitionDefinition{
+			ID:   int64(len(pi.Definitions) + len(result) + 1),
+			Name: def.Name,
+		}
+		pd.Comment, _ = def.Comment()
+		switch clause := def.Clause.(type) {
+		case *ast.PartitionDefinitionClauseLessThan:
+			for _, expr := range clause.Exprs {
+				text, err := restoreText(expr)
+				if err != nil {
+					return nil, err
+				}
+				pd.LessThan = append(pd.LessThan, text)
+			}
+		case *ast.PartitionDefinitionClauseIn:
+			for _, values := range clause.Values {
+				var in []string
+				for _, expr := range values {
+					text, err := restoreText(expr)
+					if err != nil {
+						return nil, err
+					}
+					in = append(in, text)
+				}
+				pd.InValues = append(pd.InValues, in)
+			}
+		}
+		result = append(result, pd)
+	}
+	return result, nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"fmt"
+
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/model"
+	"github.com/kyleconroy/sqlparse/mysql"
+	"github.com/kyleconroy/sqlparse/terror"
+	"github.com/kyleconroy/sqlparse/types"
+)
+
+var (
+	// ErrViewWrongList is returned when the column list of a view doesn't match its query.
+	ErrViewWrongList = terror.ClassSchema.NewStd(mysql.ErrViewWrongList)
+	// ErrWrongValueCountOnRow is returned when the rows of a VALUES statement
+	// don't have the same number of columns.
+	ErrWrongValueCountOnRow = terror.ClassSchema.NewStd(mysql.ErrWrongValueCountOnRow)
+)
+
+// buildViewInfo builds the table describing a `CREATE VIEW` statement. The
+// columns of the view are derived from the select fields of its query. Columns
+// which refer to a known table copy its type, other columns have an
+// unspecified type.
+func (c *Catalog) buildViewInfo(stmt *ast.CreateViewStmt, db *model.DBInfo) (*model.TableInfo, error) {
+	text, err := restoreText(stmt.Select)
+	if err != nil {
+		return nil, err
+	}
+	tbl := &model.TableInfo{
+		Name:    stmt.ViewName.Name,
+		Charset: db.Charset,
+		Collate: db.Collate,
+		State:   model.StatePublic,
+		View: &model.ViewInfo{
+			Algorithm:   stmt.Algorithm,
+			Definer:     stmt.Definer,
+			Security:    stmt.Security,
+			SelectStmt:  text,
+			CheckOption: stmt.CheckOption,
+			Cols:        stmt.Cols,
+		},
+	}
+	cols, err := c.queryColumns(stmt.Select)
+	if err != nil {
+		return nil, err
+	}
+	if len(stmt.Cols) > 0 {
+		if len(stmt.Cols) != len(cols) {
+			return nil, ErrViewWrongList
+		}
+		for i, name := range stmt.Cols {
+			cols[i].Name = name
+		}
+	}
+	for _, col := range cols {
+		if model.FindColumnInfo(tbl.Columns, col.Name.L) != nil {
+			return nil, ErrColumnExists.GenWithStackByArgs(col.Name.O)
+		}
+		tbl.MaxColumnID++
+		col.ID = tbl.MaxColumnID
+		col.Offset = len(tbl.Columns)
+		col.State = model.StatePublic
+		tbl.Columns = append(tbl.Columns, col)
+	}
+	return tbl, nil
+}
+
+// sourceTable is a table visible in the FROM clause of a query.
+type sourceTable struct {
+	name model.CIStr
+	cols []*model.ColumnInfo
+}
+
+// queryColumns derives the output columns of a query.
+func (c *Catalog) queryColumns(node ast.Node) ([]*model.ColumnInfo, error) {
+	switch x := node.(type) {
+	case *ast.SelectStmt:
+		return c.selectColumns(x)
+	case *ast.SetOprStmt:
+		// The first query block names the columns of a set operation.
+		if x.SelectList != nil && len(x.SelectList.Selects) > 0 {
+			return c.queryColumns(x.SelectList.Selects[0])
+		}
+	case *ast.SetOprSelectList:
+		if len(x.Selects) > 0 {
+			return c.queryColumns(x.Selects[0])
+		}
+	}
+	return nil, nil
+}
+
+func (c *Catalog) selectColumns(sel *ast.SelectStmt) ([]*model.ColumnInfo, error) {
+	if sel.Kind == ast.SelectStmtKindValues {
+		return valuesColumns(sel.Lists)
+	}
+	var sources []sourceTable
+	if sel.From != nil {
+		var err error
+		if sources, err = c.collectSources(sel.From.TableRefs, sources); err != nil {
+			return nil, err
+		}
+	}
+	var cols []*model.ColumnInfo
+	for _, field := range sel.Fields.Fields {
+		if field.WildCard != nil {
+			for _, src := range sources {
+				if field.WildCard.Table.L != "" && field.WildCard.Table.L != src.name.L {
+					continue
+				}
+				for _, col := range src.cols {
+					if !col.Hidden {
+						cols = append(cols, cloneColumn(col))
+					}
+				}
+			}
+			continue
+		}
+		col := &model.ColumnInfo{FieldType: *types.NewFieldType(mysql.TypeUnspecified)}
+		if cn, ok := field.Expr.(*ast.ColumnNameExpr); ok {
+			if found := findSourceColumn(sources, cn.Name); found != nil {
+				col = cloneColumn(found)
+			}
+			col.Name = cn.Name.Name
+		} else if field.Text() != "" {
+			col.Name = model.NewCIStr(field.Text())
+		} else if text, err := restoreText(field.Expr); err == nil {
+			col.Name = model.NewCIStr(text)
+		}
+		if field.AsName.L != "" {
+			col.Name = field.AsName
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// valuesColumns returns the columns of a VALUES statement, which are named
+// column_0, column_1 and so on. A column whose value in the first row is a
+// literal has the type of the literal, other columns have an unspecified type.
+func valuesColumns(rows []*ast.RowExpr) ([]*model.ColumnInfo, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	for i, row := range rows {
+		if len(row.Values) != len(rows[0].Values) {
+			return nil, ErrWrongValueCountOnRow.GenWithStackByArgs(i + 1)
+		}
+	}
+	cols := make([]*model.ColumnInfo, len(rows[0].Values))
+	for i, value := range rows[0].Values {
+		ft := types.NewFieldType(mysql.TypeUnspecified)
+		if v, ok := value.(ast.ValueExpr); ok {
+			ft = v.GetType().Clone()
+		}
+		cols[i] = &model.ColumnInfo{
+			Name:      model.NewCIStr(fmt.Sprintf("column_%d", i)),
+			FieldType: *ft,
+		}
+	}
+	return cols, nil
+}
+
+// collectSources appends the tables visible in a FROM clause in their order of appearance.
+func (c *Catalog) collectSources(node ast.ResultSetNode, sources []sourceTable) ([]sourceTable, error) {
+	var err error
+	switch x := node.(type) {
+	case *ast.Join:
+		if x.Left != nil {
+			if sources, err = c.collectSources(x.Left, sources); err != nil {
+				return nil, err
+			}
+		}
+		if x.Right != nil {
+			if sources, err = c.collectSources(x.Right, sources); err != nil {
+				return nil, err
+			}
+		}
+	case *ast.TableSource:
+		var src sourceTable
+		switch s := x.Source.(type) {
+		case *ast.TableName:
+			src.name = s.Name
+			if tbl, err := c.TableByName(s.Schema, s.Name); err == nil {
+				src.cols = tbl.Columns
+			}
+		default:
+			if src.cols, err = c.queryColumns(s); err != nil {
+				return nil, err
+			}
+		}
+		if x.AsName.L != "" {
+			src.name = x.AsName
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+func findSourceColumn(sources []sourceTable, name *ast.ColumnName) *model.ColumnInfo {
+	for _, src := range sources {
+		if name.Table.L != "" && name.Table.L != src.name.L {
+			continue
+		}
+		if col := model.FindColumnInfo(src.cols, name.Name.L); col != nil {
+			return col
+		}
+	}
+	return nil
+}
+
+func cloneColumn(col *model.ColumnInfo) *model.ColumnInfo {
+	nc := &model.ColumnInfo{
+		Name:      col.Name,
+		FieldType: col.FieldType,
+		Comment:   col.Comment,
+		Version:   col.Version,
+	}
+	nc.Elems = append([]string(nil), col.Elems...)
+	// Key flags of the underlying table don't apply to the view.
+	nc.Flag &^= mysql.PriKeyFlag | mysql.UniqueKeyFlag | mysql.MultipleKeyFlag | mysql.AutoIncrementFlag
+	return nc
+}
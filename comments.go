@@ -0,0 +1,173 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/kyleconroy/sqlparse/ast"
+)
+
+// attachComments attaches the comments skipped by the lexer to the parsed
+// statements. A comment before a statement is a leading comment of the
+// statement, and a comment inside it is attached to its nearest node. A
+// comment following a statement on the same line, or following the last
+// statement, is a trailing comment of the statement. The comments following
+// the last statement on their own lines are marked as such.
+func (parser *Parser) attachComments() {
+	stmts, comments := parser.result, parser.lexer.comments
+	if len(stmts) == 0 || len(comments) == 0 {
+		return
+	}
+	spans := parser.stmtSpans()
+	attached := make([][]ast.Comment, len(stmts))
+	i := 0
+	for _, c := range comments {
+		// Find the first statement which does not end before the comment.
+		for i < len(stmts) && spans[i][1] <= c.Offset {
+			i++
+		}
+		switch {
+		case i < len(stmts) && spans[i][0] <= c.Offset:
+			c.Node, c.Trailing = nearestNode(stmts[i], c)
+			attached[i] = append(attached[i], c)
+		case i > 0 && (i == len(stmts) || !strings.Contains(parser.src[spans[i-1][1]:c.Offset], "\n")):
+			c.Trailing = true
+			c.OwnLine = strings.Contains(parser.src[spans[i-1][1]:c.Offset], "\n")
+			attached[i-1] = append(attached[i-1], c)
+		default:
+			attached[i] = append(attached[i], c)
+		}
+	}
+	for i, stmt := range stmts {
+		stmt.SetComments(attached[i])
+	}
+}
+
+// nearestNode returns the node of stmt nearest to a comment inside it, and
+// true if the comment follows the node. It's the widest of the nodes ending
+// last before the comment or the widest of the nodes starting first after it,
+// whichever is closer.
+func nearestNode(stmt ast.StmtNode, c ast.Comment) (ast.Node, bool) {
+	finder := &nearestNodeFinder{stmt: stmt, start: c.Offset, end: c.Offset + len(c.Text)}
+	stmt.Accept(finder)
+	prev, next := finder.prev, finder.next
+	switch {
+	case prev == nil && next == nil:
+		return nil, false
+	case next == nil:
+		return prev, true
+	case prev == nil:
+		return next, false
+	case finder.start-prev.Span().End.Offset <= next.Span().Start.Offset-finder.end:
+		return prev, true
+	default:
+		return next, false
+	}
+}
+
+// nearestNodeFinder finds the nodes before and after the comment between
+// start and end. The outer node of two with the same span is entered first,
+// so it is kept.
+type nearestNodeFinder struct {
+	stmt       ast.Node
+	start, end int
+	prev, next ast.Node
+}
+
+func (f *nearestNodeFinder) Enter(n ast.Node) (ast.Node, bool) {
+	span := n.Span()
+	if n == f.stmt || span.IsEmpty() {
+		return n, false
+	}
+	if span.End.Offset <= f.start {
+		if f.prev == nil {
+			f.prev = n
+		} else if prev := f.prev.Span(); span.End.Offset > prev.End.Offset ||
+			span.End.Offset == prev.End.Offset && span.Start.Offset < prev.Start.Offset {
+			f.prev = n
+		}
+	}
+	if span.Start.Offset >= f.end {
+		if f.next == nil {
+			f.next = n
+		} else if next := f.next.Span(); span.Start.Offset < next.Start.Offset ||
+			span.Start.Offset == next.Start.Offset && span.End.Offset > next.End.Offset {
+			f.next = n
+		}
+	}
+	return n, false
+}
+
+func (f *nearestNodeFinder) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+// stmtSpans returns the start and end offsets of the parsed statements,
+// leaving out the surrounding spaces, comments and semicolons.
+func (parser *Parser) stmtSpans() [][2]int {
+	trimmer := newStmtTrimmer(parser.src, parser.lexer.comments)
+	spans := make([][2]int, 0, len(parser.lexer.stmtRanges))
+	for _, r := range parser.lexer.stmtRanges {
+		start, end := trimmer.trim(r[0], r[1])
+		spans = append(spans, [2]int{start, end})
+	}
+	return spans
+}
+
+// stmtTrimmer trims the spaces, comments and semicolons around statements.
+type stmtTrimmer struct {
+	src string
+	// starts maps the start offsets of the comments to their end offsets,
+	// and ends maps the other way round.
+	starts map[int]int
+	ends   map[int]int
+}
+
+func newStmtTrimmer(src string, comments []ast.Comment) *stmtTrimmer {
+	t := &stmtTrimmer{
+		src:    src,
+		starts: make(map[int]int, len(comments)),
+		ends:   make(map[int]int, len(comments)),
+	}
+	for _, c := range comments {
+		t.starts[c.Offset] = c.Offset + len(c.Text)
+		t.ends[c.Offset+len(c.Text)] = c.Offset
+	}
+	return t
+}
+
+func (t *stmtTrimmer) trim(start, end int) (int, int) {
+	src := t.src
+	for start < end {
+		if next, ok := t.starts[start]; ok {
+			start = next
+		} else if src[start] == ';' || unicode.IsSpace(rune(src[start])) {
+			start++
+		} else {
+			break
+		}
+	}
+	for end > start {
+		if prev, ok := t.ends[end]; ok {
+			end = prev
+		} else if src[end-1] == ';' || unicode.IsSpace(rune(src[end-1])) {
+			end--
+		} else {
+			break
+		}
+	}
+	return start, end
+}
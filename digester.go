@@ -23,6 +23,10 @@ import (
 	"sync"
 	"unicode"
 	"unsafe"
+
+	"github.com/pingcap/errors"
+	"github.com/kyleconroy/sqlparse/ast"
+	sqlformat "github.com/kyleconroy/sqlparse/format"
 )
 
 // DigestHash generates the digest of statements.
@@ -33,9 +37,9 @@ import (
 //
 // Deprecated: It is logically consistent with NormalizeDigest.
 func DigestHash(sql string) (result string) {
-	d := digesterPool.Get().(*sqlDigester)
+	d := defaultDigester.pool.Get().(*sqlDigester)
 	result = d.doDigest(sql)
-	digesterPool.Put(d)
+	defaultDigester.pool.Put(d)
 	return
 }
 
@@ -47,10 +51,7 @@ func DigestHash(sql string) (result string) {
 // DigestNormalized should be called with a normalized SQL string (like 'select ?') generated by function Normalize.
 // do not call with SQL which is not normalized, DigestNormalized('select 1') and DigestNormalized('select 2') is not the same
 func DigestNormalized(normalized string) (result string) {
-	d := digesterPool.Get().(*sqlDigester)
-	result = d.doDigestNormalized(normalized)
-	digesterPool.Put(d)
-	return
+	return defaultDigester.DigestNormalized(normalized)
 }
 
 // Normalize generates the normalized statements.
@@ -59,27 +60,186 @@ func DigestNormalized(normalized string) (result string) {
 //
 // for example: Normalize('select 1 from b where a = 1') => 'select ? from b where a = ?'
 func Normalize(sql string) (result string) {
-	d := digesterPool.Get().(*sqlDigester)
-	result = d.doNormalize(sql)
-	digesterPool.Put(d)
-	return
+	return defaultDigester.Normalize(sql)
+}
+
+// NormalizeWithParams is Normalize which also returns the literals replaced
+// with "?", in the order of the statement text. A list of literals collapsed
+// into "..." returns all of them.
+//
+// for example: NormalizeWithParams("select * from t where a = 'x' and b in (1, -2)")
+// => "select * from t where a = ? and b in ( ... )", [x 1 -2]
+func NormalizeWithParams(sql string) (normalized string, params []NormalizedParam) {
+	return defaultDigester.NormalizeWithParams(sql)
+}
+
+// ParamKind is the kind of a literal returned by NormalizeWithParams.
+type ParamKind int
+
+// ParamKind values.
+const (
+	ParamString ParamKind = iota
+	ParamInt
+	ParamDecimal
+	ParamFloat
+	ParamHex
+	ParamBit
+)
+
+// String implements fmt.Stringer interface.
+func (k ParamKind) String() string {
+	switch k {
+	case ParamString:
+		return "string"
+	case ParamInt:
+		return "int"
+	case ParamDecimal:
+		return "decimal"
+	case ParamFloat:
+		return "float"
+	case ParamHex:
+		return "hex"
+	case ParamBit:
+		return "bit"
+	}
+	return "unknown"
+}
+
+// NormalizedParam is a literal Normalize replaces with "?".
+type NormalizedParam struct {
+	Kind ParamKind
+	// Value is the unquoted and unescaped value of a string, and the literal
+	// as written, with its sign, for the other kinds.
+	Value string
+	// Start and End are the byte offsets of the literal in the statement
+	// text, including the sign of a number.
+	Start int
+	End   int
 }
 
 // NormalizeDigest combines Normalize and DigestNormalized into one method.
 func NormalizeDigest(sql string) (normalized, digest string) {
-	d := digesterPool.Get().(*sqlDigester)
+	return defaultDigester.NormalizeDigest(sql)
+}
+
+// NormalizeDigestNode is NormalizeDigest for a statement node.
+// A statement returned by the parser keeps its text, which is normalized as
+// is, so the result is the same as NormalizeDigest on that text. Any other
+// statement, like one built by the caller, is restored first, with lowercase
+// keywords and without the default charset of the strings, and the restored
+// text is normalized: the literals become "?", the lists of literals "...".
+// Call SetText("") on a parsed statement after changing it.
+func NormalizeDigestNode(node ast.StmtNode) (normalized, digest string, err error) {
+	return defaultDigester.NormalizeDigestNode(node)
+}
+
+// digestRestoreFlags restores a statement for NormalizeDigestNode.
+const digestRestoreFlags = sqlformat.RestoreStringSingleQuotes | sqlformat.RestoreKeyWordLowercase |
+	sqlformat.RestoreNameBackQuotes | sqlformat.RestoreStringWithoutDefaultCharset
+
+// DigestOptions are the normalization rules of a Digester. The zero value
+// has the rules of Normalize and NormalizeDigest.
+type DigestOptions struct {
+	// KeepHints keeps the optimizer hints, with their spaces collapsed, the
+	// index hints and STRAIGHT_JOIN.
+	KeepHints bool
+	// KeepLists keeps each literal of a list, like the values of a row,
+	// instead of collapsing the list into "...".
+	KeepLists bool
+	// ReplacePositions replaces the column positions of ORDER BY and GROUP
+	// BY, like the 1 of "order by 1", with "?" like the other numbers.
+	ReplacePositions bool
+	// KeepIdentifierCase keeps the case of the identifiers. The words which
+	// are keywords are lowercased all the same.
+	KeepIdentifierCase bool
+	// IgnoreSchema drops the schema of the table names following FROM, JOIN,
+	// INTO, UPDATE and TABLE, and of the column names qualified with both a
+	// schema and a table.
+	IgnoreSchema bool
+	// Hash creates the hash computing the digests, SHA-256 if it is nil.
+	Hash func() hash2.Hash
+}
+
+// Digester normalizes and digests statements with its options. It is safe
+// for concurrent use.
+type Digester struct {
+	pool sync.Pool
+}
+
+// NewDigester returns a Digester with the options.
+func NewDigester(opts DigestOptions) *Digester {
+	dg := &Digester{}
+	dg.pool.New = func() interface{} {
+		hasher := sha256.New()
+		if opts.Hash != nil {
+			hasher = opts.Hash()
+		}
+		d := &sqlDigester{
+			lexer:  NewScanner(""),
+			hasher: hasher,
+			opts:   opts,
+		}
+		// The optimizer hints skipped by the lexer are kept from its comments.
+		d.lexer.keepComments = opts.KeepHints
+		return d
+	}
+	return dg
+}
+
+var defaultDigester = NewDigester(DigestOptions{})
+
+// Normalize is the Normalize function with the options of the Digester.
+func (dg *Digester) Normalize(sql string) (result string) {
+	d := dg.pool.Get().(*sqlDigester)
+	result = d.doNormalize(sql)
+	dg.pool.Put(d)
+	return
+}
+
+// DigestNormalized is the DigestNormalized function with the hash of the
+// Digester.
+func (dg *Digester) DigestNormalized(normalized string) (result string) {
+	d := dg.pool.Get().(*sqlDigester)
+	result = d.doDigestNormalized(normalized)
+	dg.pool.Put(d)
+	return
+}
+
+// NormalizeDigest is the NormalizeDigest function with the options of the
+// Digester.
+func (dg *Digester) NormalizeDigest(sql string) (normalized, digest string) {
+	d := dg.pool.Get().(*sqlDigester)
 	normalized, digest = d.doNormalizeDigest(sql)
-	digesterPool.Put(d)
+	dg.pool.Put(d)
 	return
 }
 
-var digesterPool = sync.Pool{
-	New: func() interface{} {
-		return &sqlDigester{
-			lexer:  NewScanner(""),
-			hasher: sha256.New(),
+// NormalizeWithParams is the NormalizeWithParams function with the options
+// of the Digester.
+func (dg *Digester) NormalizeWithParams(sql string) (normalized string, params []NormalizedParam) {
+	d := dg.pool.Get().(*sqlDigester)
+	d.collectParams = true
+	normalized = d.doNormalize(sql)
+	params = d.params
+	d.collectParams = false
+	d.params = nil
+	dg.pool.Put(d)
+	return
+}
+
+// NormalizeDigestNode is the NormalizeDigestNode function with the options
+// of the Digester.
+func (dg *Digester) NormalizeDigestNode(node ast.StmtNode) (normalized, digest string, err error) {
+	sql := node.Text()
+	if sql == "" {
+		var sb strings.Builder
+		if err = node.Restore(sqlformat.NewRestoreCtx(digestRestoreFlags, &sb)); err != nil {
+			return "", "", errors.Trace(err)
 		}
-	},
+		sql = sb.String()
+	}
+	normalized, digest = dg.NormalizeDigest(sql)
+	return
 }
 
 // sqlDigester is used to compute DigestHash or Normalize for sql.
@@ -88,6 +248,13 @@ type sqlDigester struct {
 	lexer  *Scanner
 	hasher hash2.Hash
 	tokens tokenDeque
+	opts   DigestOptions
+	// params are the literals replaced by normalize if collectParams is set.
+	params        []NormalizedParam
+	collectParams bool
+	// tableRefs is set in a list of table names, where IgnoreSchema drops the
+	// schema of the names.
+	tableRefs bool
 }
 
 func (d *sqlDigester) doDigestNormalized(normalized string) (result string) {
@@ -151,17 +318,24 @@ func (d *sqlDigester) normalize(sql string) {
 		if pos.Offset == len(sql) {
 			break
 		}
-		currTok := token{tok, strings.ToLower(lit)}
+		currTok := token{tok, d.lowerLit(tok, lit), pos.Offset}
 
-		if d.reduceOptimizerHint(&currTok) {
+		if d.opts.KeepHints {
+			d.keepOptimizerHints()
+		} else if d.reduceOptimizerHint(&currTok) {
 			continue
 		}
 
-		d.reduceLit(&currTok)
+		d.reduceLit(&currTok, lit)
+
+		if d.opts.IgnoreSchema {
+			d.reduceSchema(currTok)
+		}
 
 		d.tokens = append(d.tokens, currTok)
 	}
 	d.lexer.reset("")
+	d.tableRefs = false
 	for i, token := range d.tokens {
 		if token.tok == singleAtIdentifier {
 			d.buffer.WriteString("@")
@@ -174,6 +348,97 @@ func (d *sqlDigester) normalize(sql string) {
 	d.tokens = d.tokens[:0]
 }
 
+// lowerLit lowercases the literal of a token, unless it is an identifier
+// kept as is by KeepIdentifierCase.
+func (d *sqlDigester) lowerLit(tok int, lit string) string {
+	if d.opts.KeepIdentifierCase {
+		switch tok {
+		case quotedIdentifier:
+			return lit
+		case identifier:
+			if _, ok := tokenMap[strings.ToUpper(lit)]; !ok {
+				return lit
+			}
+		}
+	}
+	return strings.ToLower(lit)
+}
+
+// keepOptimizerHints adds the optimizer hints skipped by the lexer since the
+// last token, if the hints follow a keyword accepting them.
+func (d *sqlDigester) keepOptimizerHints() {
+	defer func() {
+		d.lexer.comments = d.lexer.comments[:0]
+	}()
+	last := d.tokens.back(1)
+	if last == nil {
+		return
+	}
+	if _, ok := hintedTokens[tokenMap[strings.ToUpper(last[0].lit)]]; !ok {
+		return
+	}
+	for _, comment := range d.lexer.comments {
+		if !strings.HasPrefix(comment.Text, "/*+") {
+			continue
+		}
+		lit := strings.Join(strings.Fields(strings.ToLower(comment.Text)), " ")
+		d.tokens.pushBack(token{hintComment, lit, comment.Offset})
+	}
+}
+
+// tableRefKeywords start a list of table names.
+var tableRefKeywords = map[string]struct{}{
+	"from": {}, "join": {}, "straight_join": {}, "into": {}, "update": {}, "table": {},
+}
+
+// tableRefEndKeywords end a list of table names.
+var tableRefEndKeywords = map[string]struct{}{
+	"(": {}, "where": {}, "on": {}, "using": {}, "set": {}, "values": {}, "value": {}, "select": {},
+	"group": {}, "order": {}, "having": {}, "limit": {}, "union": {}, "except": {}, "intersect": {},
+	"for": {}, "lock": {}, "window": {}, "partition": {}, "duplicate": {},
+}
+
+// reduceSchema drops the schema of the name ending with tok: "db.t.a" => "t.a"
+// anywhere and "db.t" => "t" in a list of table names.
+func (d *sqlDigester) reduceSchema(tok token) {
+	if _, ok := tableRefKeywords[tok.lit]; ok {
+		// on duplicate key update a = x
+		last := d.tokens.back(1)
+		d.tableRefs = last == nil || last[0].lit != "key"
+		return
+	}
+	if _, ok := tableRefEndKeywords[tok.lit]; ok {
+		d.tableRefs = false
+		return
+	}
+	if !d.isName(tok) && tok.lit != "*" {
+		return
+	}
+	n := len(d.tokens)
+	if n > 4 {
+		n = 4
+	}
+	last4 := d.tokens.back(n)
+	if n < 2 || last4[n-1].lit != "." || !d.isName(last4[n-2]) {
+		return
+	}
+	if n == 4 && last4[1].lit == "." && d.isName(last4[0]) {
+		// db . t . a => t . a
+		x := d.tokens.popBack(2)
+		d.tokens.popBack(2)
+		d.tokens = append(d.tokens, x...)
+		return
+	}
+	if d.tableRefs && (n < 3 || last4[n-3].lit != ".") {
+		// db . t => t
+		d.tokens.popBack(2)
+	}
+}
+
+func (d *sqlDigester) isName(t token) bool {
+	return t.tok == identifier || t.tok == quotedIdentifier
+}
+
 func (d *sqlDigester) reduceOptimizerHint(tok *token) (reduced bool) {
 	// ignore /*+..*/
 	if tok.tok == hintComment {
@@ -210,7 +475,7 @@ func (d *sqlDigester) reduceOptimizerHint(tok *token) (reduced bool) {
 	return
 }
 
-func (d *sqlDigester) reduceLit(currTok *token) {
+func (d *sqlDigester) reduceLit(currTok *token, lit string) {
 	if !d.isLit(*currTok) {
 		return
 	}
@@ -223,33 +488,65 @@ func (d *sqlDigester) reduceLit(currTok *token) {
 		return
 	}
 
+	param := NormalizedParam{Value: lit, Start: currTok.offset, End: d.lexer.r.pos().Offset}
 	// "-x" or "+x" => "x"
 	if d.isPrefixByUnary(currTok.tok) {
-		d.tokens.popBack(1)
+		sign := d.tokens.popBack(1)[0]
+		if sign.lit == "-" {
+			param.Value = "-" + param.Value
+		}
+		param.Start = sign.offset
 	}
 
 	// "?, ?, ?, ?" => "..."
 	last2 := d.tokens.back(2)
-	if d.isGenericList(last2) {
+	if !d.opts.KeepLists && d.isGenericList(last2) {
 		d.tokens.popBack(2)
+		d.addParam(currTok.tok, param)
 		currTok.tok = genericSymbolList
 		currTok.lit = "..."
 		return
 	}
 
 	// order by n => order by n
-	if currTok.tok == intLit {
+	if currTok.tok == intLit && !d.opts.ReplacePositions {
 		if d.isOrderOrGroupBy() {
 			return
 		}
 	}
 
 	// 2 => ?
+	d.addParam(currTok.tok, param)
 	currTok.tok = genericSymbol
 	currTok.lit = "?"
 	return
 }
 
+// addParam keeps the literal replaced by normalize for NormalizeWithParams.
+// Parameter markers have no value and are not kept.
+func (d *sqlDigester) addParam(tok int, param NormalizedParam) {
+	if !d.collectParams {
+		return
+	}
+	switch tok {
+	case stringLit:
+		param.Kind = ParamString
+	case intLit:
+		param.Kind = ParamInt
+	case decLit:
+		param.Kind = ParamDecimal
+	case floatLit:
+		param.Kind = ParamFloat
+	case hexLit:
+		param.Kind = ParamHex
+	case bitLit:
+		param.Kind = ParamBit
+	default:
+		return
+	}
+	d.params = append(d.params, param)
+}
+
 func (d *sqlDigester) isPrefixByUnary(currTok int) (isUnary bool) {
 	if !d.isNumLit(currTok) {
 		return
@@ -357,8 +654,9 @@ func (d *sqlDigester) isComma(tok token) (isComma bool) {
 }
 
 type token struct {
-	tok int
-	lit string
+	tok    int
+	lit    string
+	offset int
 }
 
 type tokenDeque []token
@@ -0,0 +1,193 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	io2 "io"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pingcap/errors"
+)
+
+// DigestRecord is a statement read by DigestReader.
+type DigestRecord struct {
+	// Text is the statement text, leaving out the surrounding spaces and the
+	// ";" ending it.
+	Text string
+	// Normalized and Digest are the results of NormalizeDigest on Text.
+	Normalized string
+	Digest     string
+	// Offset is the byte offset of Text in the input.
+	Offset int64
+}
+
+// digestReadSize is the size of the reads of DigestReader.
+const digestReadSize = 64 << 10
+
+// DigestReader normalizes and digests the statements read from r with the
+// default options. See (*Digester).DigestReader.
+func DigestReader(r io2.Reader, workers int, fn func(DigestRecord) error) error {
+	return defaultDigester.DigestReader(r, workers, fn)
+}
+
+// DigestReader reads statements ending with ";" from r, normalizes and
+// digests them on workers goroutines, GOMAXPROCS if workers is not positive,
+// and calls fn with the records as they are ready, in the order of the input.
+// The statements are split with the lexer, so a ";" inside a string, a quoted
+// identifier or a comment doesn't end a statement, and r is read as it goes
+// rather than at once. The statements with nothing but comments are skipped.
+// It stops at the first error of r or fn, after fn has been called with the
+// statements read before, and returns it.
+func (dg *Digester) DigestReader(r io2.Reader, workers int, fn func(DigestRecord) error) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	// The builtin close is shadowed by a token in this package, so a nil job
+	// ends jobs and pending instead.
+	var (
+		jobs = make(chan *digestJob, workers)
+		// pending keeps the jobs in the order of the input.
+		pending = make(chan *digestJob, workers*2)
+		stopped int32
+		wg      sync.WaitGroup
+		readErr error
+	)
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			d := dg.pool.Get().(*sqlDigester)
+			defer dg.pool.Put(d)
+			for job := <-jobs; job != nil; job = <-jobs {
+				job.rec.Normalized, job.rec.Digest = d.doNormalizeDigest(job.rec.Text)
+				job.ready <- struct{}{}
+			}
+		}()
+	}
+	go func() {
+		readErr = splitStmts(r, func(rec DigestRecord) bool {
+			if atomic.LoadInt32(&stopped) != 0 {
+				return false
+			}
+			job := &digestJob{rec: rec, ready: make(chan struct{}, 1)}
+			pending <- job
+			jobs <- job
+			return true
+		})
+		for i := 0; i < workers; i++ {
+			jobs <- nil
+		}
+		pending <- nil
+	}()
+
+	var err error
+	for job := <-pending; job != nil; job = <-pending {
+		if err != nil {
+			continue
+		}
+		<-job.ready
+		if job.rec.Normalized == "" {
+			continue
+		}
+		if err = fn(job.rec); err != nil {
+			atomic.StoreInt32(&stopped, 1)
+		}
+	}
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	return errors.Trace(readErr)
+}
+
+type digestJob struct {
+	rec   DigestRecord
+	ready chan struct{}
+}
+
+// splitStmts reads the statements from r and calls emit with their text and
+// offset until emit returns false.
+func splitStmts(r io2.Reader, emit func(DigestRecord) bool) error {
+	var (
+		// buf holds the input from offset on, after the last statement.
+		buf    []byte
+		offset int64
+		// scanned is the end of the last token of buf known to be complete,
+		// the next scan resumes there rather than at the start of buf.
+		scanned int
+		lexer   = NewScanner("")
+		chunk   = make([]byte, digestReadSize)
+	)
+	// split emits the statements of buf, and the rest of it at EOF.
+	split := func(eof bool) bool {
+		start, last := 0, 0
+		lexer.reset(string(buf[scanned:]))
+		for {
+			tok, pos, _ := lexer.scan()
+			// A token reaching the end of buf may go on in the next chunk,
+			// like a comment skipped before the end.
+			if tok == 0 || lexer.r.eof() && tok != ';' {
+				break
+			}
+			last = lexer.r.p.Offset
+			if tok != ';' {
+				continue
+			}
+			end := scanned + pos.Offset
+			if !emitStmt(buf, start, end, offset, emit) {
+				return false
+			}
+			start = end + 1
+		}
+		lexer.reset("")
+		if eof {
+			return emitStmt(buf, start, len(buf), offset, emit)
+		}
+		scanned += last - start
+		offset += int64(start)
+		buf = append(buf[:0], buf[start:]...)
+		return true
+	}
+	for {
+		n, err := r.Read(chunk)
+		// rescan buf only when the chunk may end a statement.
+		buf = append(buf, chunk[:n]...)
+		if n > 0 && bytes.IndexByte(chunk[:n], ';') >= 0 && !split(false) {
+			return nil
+		}
+		if err == io2.EOF {
+			split(true)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// emitStmt emits the statement of buf[start:end], if it is not blank.
+func emitStmt(buf []byte, start, end int, offset int64, emit func(DigestRecord) bool) bool {
+	text := string(buf[start:end])
+	trimmed := strings.TrimLeft(text, " \t\r\n")
+	start += len(text) - len(trimmed)
+	trimmed = strings.TrimRight(trimmed, " \t\r\n")
+	if trimmed == "" {
+		return true
+	}
+	return emit(DigestRecord{Text: trimmed, Offset: offset + int64(start)})
+}
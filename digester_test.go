@@ -14,8 +14,16 @@
 package parser_test
 
 import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"strings"
+	"testing/iotest"
+
 	. "github.com/pingcap/check"
 	"github.com/kyleconroy/sqlparse"
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/model"
 )
 
 var _ = Suite(&testSQLDigestSuite{})
@@ -23,40 +31,42 @@ var _ = Suite(&testSQLDigestSuite{})
 type testSQLDigestSuite struct {
 }
 
+// normalizeTests are the statements of TestNormalize and their normalized form.
+var normalizeTests = []struct {
+	input  string
+	expect string
+}{
+	{"SELECT 1", "select ?"},
+	{"select * from b where id = 1", "select * from b where id = ?"},
+	{"select 1 from b where id in (1, 3, '3', 1, 2, 3, 4)", "select ? from b where id in ( ... )"},
+	{"select 1 from b where id in (1, a, 4)", "select ? from b where id in ( ? , a , ? )"},
+	{"select 1 from b order by 2", "select ? from b order by 2"},
+	{"select /*+ a hint */ 1", "select ?"},
+	{"select /* a hint */ 1", "select ?"},
+	{"select truncate(1, 2)", "select truncate ( ... )"},
+	{"select -1 + - 2 + b - c + 0.2 + (-2) from c where d in (1, -2, +3)", "select ? + ? + b - c + ? + ( ? ) from c where d in ( ... )"},
+	{"select * from t where a <= -1 and b < -2 and c = -3 and c > -4 and c >= -5 and e is 1", "select * from t where a <= ? and b < ? and c = ? and c > ? and c >= ? and e is ?"},
+	{"select count(a), b from t group by 2", "select count ( a ) , b from t group by 2"},
+	{"select count(a), b, c from t group by 2, 3", "select count ( a ) , b , c from t group by 2 , 3"},
+	{"select count(a), b, c from t group by (2, 3)", "select count ( a ) , b , c from t group by ( 2 , 3 )"},
+	{"select a, b from t order by 1, 2", "select a , b from t order by 1 , 2"},
+	{"select count(*) from t", "select count ( ? ) from t"},
+	{"select * from t Force Index(kk)", "select * from t"},
+	{"select * from t USE Index(kk)", "select * from t"},
+	{"select * from t Ignore Index(kk)", "select * from t"},
+	{"select * from t1 straight_join t2 on t1.id=t2.id", "select * from t1 join t2 on t1 . id = t2 . id"},
+	// test syntax error, it will be checked by parser, but it should not make normalize dead loop.
+	{"select * from t ignore index(", "select * from t ignore index"},
+	{"select /*+ ", "select "},
+	{"select * from 🥳", "select * from"},
+	{"select 1 / 2", "select ? / ?"},
+	{"select * from t where a = 40 limit ?, ?", "select * from t where a = ? limit ..."},
+	{"select * from t where a > ?", "select * from t where a > ?"},
+	{"select @a=b from t", "select @a = b from t"},
+}
+
 func (s *testSQLDigestSuite) TestNormalize(c *C) {
-	tests := []struct {
-		input  string
-		expect string
-	}{
-		{"SELECT 1", "select ?"},
-		{"select * from b where id = 1", "select * from b where id = ?"},
-		{"select 1 from b where id in (1, 3, '3', 1, 2, 3, 4)", "select ? from b where id in ( ... )"},
-		{"select 1 from b where id in (1, a, 4)", "select ? from b where id in ( ? , a , ? )"},
-		{"select 1 from b order by 2", "select ? from b order by 2"},
-		{"select /*+ a hint */ 1", "select ?"},
-		{"select /* a hint */ 1", "select ?"},
-		{"select truncate(1, 2)", "select truncate ( ... )"},
-		{"select -1 + - 2 + b - c + 0.2 + (-2) from c where d in (1, -2, +3)", "select ? + ? + b - c + ? + ( ? ) from c where d in ( ... )"},
-		{"select * from t where a <= -1 and b < -2 and c = -3 and c > -4 and c >= -5 and e is 1", "select * from t where a <= ? and b < ? and c = ? and c > ? and c >= ? and e is ?"},
-		{"select count(a), b from t group by 2", "select count ( a ) , b from t group by 2"},
-		{"select count(a), b, c from t group by 2, 3", "select count ( a ) , b , c from t group by 2 , 3"},
-		{"select count(a), b, c from t group by (2, 3)", "select count ( a ) , b , c from t group by ( 2 , 3 )"},
-		{"select a, b from t order by 1, 2", "select a , b from t order by 1 , 2"},
-		{"select count(*) from t", "select count ( ? ) from t"},
-		{"select * from t Force Index(kk)", "select * from t"},
-		{"select * from t USE Index(kk)", "select * from t"},
-		{"select * from t Ignore Index(kk)", "select * from t"},
-		{"select * from t1 straight_join t2 on t1.id=t2.id", "select * from t1 join t2 on t1 . id = t2 . id"},
-		// test syntax error, it will be checked by parser, but it should not make normalize dead loop.
-		{"select * from t ignore index(", "select * from t ignore index"},
-		{"select /*+ ", "select "},
-		{"select * from 🥳", "select * from"},
-		{"select 1 / 2", "select ? / ?"},
-		{"select * from t where a = 40 limit ?, ?", "select * from t where a = ? limit ..."},
-		{"select * from t where a > ?", "select * from t where a > ?"},
-		{"select @a=b from t", "select @a = b from t"},
-	}
-	for _, test := range tests {
+	for _, test := range normalizeTests {
 		normalized := parser.Normalize(test.input)
 		digest := parser.DigestNormalized(normalized)
 		c.Assert(normalized, Equals, test.expect)
@@ -67,15 +77,17 @@ func (s *testSQLDigestSuite) TestNormalize(c *C) {
 	}
 }
 
+// normalizeDigestTests are statements with their normalized form and digest.
+var normalizeDigestTests = []struct {
+	sql        string
+	normalized string
+	digest     string
+}{
+	{"select 1 from b where id in (1, 3, '3', 1, 2, 3, 4)", "select ? from b where id in ( ... )", "f36161eef94dbfbd5e2f6b9a2f498a4c7facc6860621fbeb8084f63898275016"},
+}
+
 func (s *testSQLDigestSuite) TestNormalizeDigest(c *C) {
-	tests := []struct {
-		sql        string
-		normalized string
-		digest     string
-	}{
-		{"select 1 from b where id in (1, 3, '3', 1, 2, 3, 4)", "select ? from b where id in ( ... )", "f36161eef94dbfbd5e2f6b9a2f498a4c7facc6860621fbeb8084f63898275016"},
-	}
-	for _, test := range tests {
+	for _, test := range normalizeDigestTests {
 		normalized, digest := parser.NormalizeDigest(test.sql)
 		c.Assert(normalized, Equals, test.normalized)
 		c.Assert(digest, Equals, test.digest)
@@ -87,13 +99,15 @@ func (s *testSQLDigestSuite) TestNormalizeDigest(c *C) {
 	}
 }
 
+// digestHashEqGroups are groups of statements which have the same digest.
+var digestHashEqGroups = [][]string{
+	{"select * from b where id = 1", "select * from b where id = '1'", "select * from b where id =2"},
+	{"select 2 from b, c where c.id > 1", "select 4 from b, c where c.id > 23"},
+	{"Select 3", "select 1"},
+}
+
 func (s *testSQLDigestSuite) TestDigestHashEqForSimpleSQL(c *C) {
-	sqlGroups := [][]string{
-		{"select * from b where id = 1", "select * from b where id = '1'", "select * from b where id =2"},
-		{"select 2 from b, c where c.id > 1", "select 4 from b, c where c.id > 23"},
-		{"Select 3", "select 1"},
-	}
-	for _, sqlGroup := range sqlGroups {
+	for _, sqlGroup := range digestHashEqGroups {
 		var d string
 		for _, sql := range sqlGroup {
 			dig := parser.DigestHash(sql)
@@ -106,11 +120,13 @@ func (s *testSQLDigestSuite) TestDigestHashEqForSimpleSQL(c *C) {
 	}
 }
 
+// digestHashNotEqGroups are groups of statements which have different digests.
+var digestHashNotEqGroups = [][]string{
+	{"select * from b where id = 1", "select a from b where id = 1", "select * from d where bid =1"},
+}
+
 func (s *testSQLDigestSuite) TestDigestHashNotEqForSimpleSQL(c *C) {
-	sqlGroups := [][]string{
-		{"select * from b where id = 1", "select a from b where id = 1", "select * from d where bid =1"},
-	}
-	for _, sqlGroup := range sqlGroups {
+	for _, sqlGroup := range digestHashNotEqGroups {
 		var d string
 		for _, sql := range sqlGroup {
 			dig := parser.DigestHash(sql)
@@ -122,3 +138,210 @@ func (s *testSQLDigestSuite) TestDigestHashNotEqForSimpleSQL(c *C) {
 		}
 	}
 }
+
+// normalizeDigestNodeTests are statements NormalizeDigestNode digests as NormalizeDigest does.
+var normalizeDigestNodeTests = []string{
+	"SELECT 1",
+	"select * from b where id in (1, 3, '3', 1, 2, 3, 4)",
+	"select /*+ use_index(t, a) */ a as x from t where x in (1, 2) and y = 'a' order by 1",
+	"select count(*), b from t Force Index(kk) group by 2;",
+	"select a b from t1 inner join t2 on t1.a <> 1 where c = _utf8mb4'x'",
+	"insert into t (a, b) values (1, 'x'), (2, 'y')",
+	"update t set a = a + -1 where b is null limit 10",
+}
+
+func (s *testSQLDigestSuite) TestNormalizeDigestNode(c *C) {
+	p := parser.New()
+	for _, sql := range normalizeDigestNodeTests {
+		stmt, err := p.ParseOneStmt(sql, "", "")
+		c.Assert(err, IsNil)
+		normalized, digest, err := parser.NormalizeDigestNode(stmt)
+		c.Assert(err, IsNil)
+		normalized2, digest2 := parser.NormalizeDigest(sql)
+		c.Assert(normalized, Equals, normalized2, Commentf("%s", sql))
+		c.Assert(digest, Equals, digest2, Commentf("%s", sql))
+	}
+
+	// Statements without text are restored.
+	restored := []struct {
+		sql    string
+		expect string
+	}{
+		{"select a from t where x in (1, 2) and y = 'a'", "select a from t where x in ( ... ) and y = ?"},
+		{"SELECT `A` FROM `t` WHERE x = 'b' LIMIT 5", "select a from t where x = ? limit ?"},
+		{"delete from t where id = ?", "delete from t where id = ?"},
+	}
+	for _, test := range restored {
+		stmt, err := p.ParseOneStmt(test.sql, "", "")
+		c.Assert(err, IsNil)
+		stmt.SetText("")
+		normalized, digest, err := parser.NormalizeDigestNode(stmt)
+		c.Assert(err, IsNil)
+		c.Assert(normalized, Equals, test.expect)
+		c.Assert(digest, Equals, parser.DigestNormalized(test.expect))
+		normalized2, _ := parser.NormalizeDigest(test.sql)
+		c.Assert(normalized, Equals, normalized2)
+	}
+
+	// A changed statement is digested as it is now once its text is cleared.
+	stmt, err := p.ParseOneStmt("select a from t where x = 1", "", "")
+	c.Assert(err, IsNil)
+	sel := stmt.(*ast.SelectStmt)
+	sel.From.TableRefs.Left.(*ast.TableSource).Source.(*ast.TableName).Name = model.NewCIStr("t2")
+	sel.Where = nil
+	stmt.SetText("")
+	normalized, digest, err := parser.NormalizeDigestNode(stmt)
+	c.Assert(err, IsNil)
+	c.Assert(normalized, Equals, "select a from t2")
+	normalized2, digest2 := parser.NormalizeDigest("select a from t2")
+	c.Assert(normalized, Equals, normalized2)
+	c.Assert(digest, Equals, digest2)
+	_, digest3 := parser.NormalizeDigest("select a from t where x = 1")
+	c.Assert(digest, Not(Equals), digest3)
+}
+
+func (s *testSQLDigestSuite) TestNormalizeDigestNodeCorpus(c *C) {
+	// Every statement of the digest tests which parses has the same digest
+	// as a node as it has as text.
+	sqls := append([]string(nil), normalizeDigestNodeTests...)
+	for _, test := range normalizeTests {
+		sqls = append(sqls, test.input)
+	}
+	for _, test := range normalizeDigestTests {
+		sqls = append(sqls, test.sql)
+	}
+	for _, group := range append(append([][]string(nil), digestHashEqGroups...), digestHashNotEqGroups...) {
+		sqls = append(sqls, group...)
+	}
+	p := parser.New()
+	var parsed int
+	for _, sql := range sqls {
+		stmt, err := p.ParseOneStmt(sql, "", "")
+		if err != nil {
+			continue
+		}
+		parsed++
+		normalized, digest, err := parser.NormalizeDigestNode(stmt)
+		c.Assert(err, IsNil)
+		normalized2, digest2 := parser.NormalizeDigest(sql)
+		c.Assert(normalized, Equals, normalized2, Commentf("%s", sql))
+		c.Assert(digest, Equals, digest2, Commentf("%s", sql))
+	}
+	c.Assert(parsed > len(sqls)/2, IsTrue, Commentf("parsed %d of %d", parsed, len(sqls)))
+}
+
+func (s *testSQLDigestSuite) TestNormalizeWithParams(c *C) {
+	sql := "select count(*), 'a''b' from t where a = -1.5 and b in (1, +2, x'0A') and c > 1e3 and d = b'101' and e = ? order by 1 limit 10"
+	normalized, params := parser.NormalizeWithParams(sql)
+	c.Assert(normalized, Equals, parser.Normalize(sql))
+	c.Assert(normalized, Equals, "select count ( ? ) , ? from t where a = ? and b in ( ... ) and c > ? and d = ? and e = ? order by 1 limit ?")
+	expected := []struct {
+		kind  parser.ParamKind
+		value string
+		text  string
+	}{
+		{parser.ParamString, "a'b", "'a''b'"},
+		{parser.ParamDecimal, "-1.5", "-1.5"},
+		{parser.ParamInt, "1", "1"},
+		{parser.ParamInt, "2", "+2"},
+		{parser.ParamHex, "x'0A'", "x'0A'"},
+		{parser.ParamFloat, "1e3", "1e3"},
+		{parser.ParamBit, "b'101'", "b'101'"},
+		{parser.ParamInt, "10", "10"},
+	}
+	c.Assert(params, HasLen, len(expected))
+	for i, e := range expected {
+		c.Assert(params[i].Kind, Equals, e.kind, Commentf("%d", i))
+		c.Assert(params[i].Value, Equals, e.value, Commentf("%d", i))
+		c.Assert(sql[params[i].Start:params[i].End], Equals, e.text, Commentf("%d", i))
+	}
+
+	_, params = parser.NormalizeWithParams("select a from t")
+	c.Assert(params, HasLen, 0)
+}
+
+func (s *testSQLDigestSuite) TestDigesterOptions(c *C) {
+	tests := []struct {
+		opts   parser.DigestOptions
+		input  string
+		expect string
+	}{
+		{parser.DigestOptions{}, "SELECT /*+ USE_INDEX(t, a) */ A from DB.T force index(a) straight_join u where x in (1, 2)", "select a from db . t join u where x in ( ... )"},
+		{parser.DigestOptions{KeepHints: true}, "select /*+   USE_INDEX(t,  a) */ a from t force index(a) straight_join u", "select /*+ use_index(t, a) */ a from t force index ( a ) straight_join u"},
+		{parser.DigestOptions{KeepHints: true}, "select /* a comment */ a from t", "select a from t"},
+		{parser.DigestOptions{KeepLists: true}, "insert into t values (1, 'a'), (2, 'b')", "insert into t values ( ? , ? ) , ( ? , ? )"},
+		{parser.DigestOptions{ReplacePositions: true}, "select a from t group by 1 order by 2", "select a from t group by ? order by ?"},
+		{parser.DigestOptions{KeepIdentifierCase: true}, "SELECT MyCol, `Other` FROM MyTable WHERE Id = 1", "select MyCol , Other from MyTable where Id = ?"},
+		{parser.DigestOptions{IgnoreSchema: true}, "select db.t.a, t.b from db.t, db2.u join v on t.a = v.a where db.t.c = 1", "select t . a , t . b from t , u join v on t . a = v . a where t . c = ?"},
+		{parser.DigestOptions{IgnoreSchema: true}, "insert into db.t (a) values (1) on duplicate key update t.a = 2", "insert into t ( a ) values ( ? ) on duplicate key update t . a = ?"},
+		{parser.DigestOptions{IgnoreSchema: true}, "update `db`.t set t.a = 1", "update t set t . a = ?"},
+	}
+	for _, test := range tests {
+		normalized := parser.NewDigester(test.opts).Normalize(test.input)
+		c.Assert(normalized, Equals, test.expect, Commentf("%s", test.input))
+	}
+
+	sql := "select * from t where a = 1"
+	normalized, digest := parser.NewDigester(parser.DigestOptions{}).NormalizeDigest(sql)
+	expectNormalized, expectDigest := parser.NormalizeDigest(sql)
+	c.Assert(normalized, Equals, expectNormalized)
+	c.Assert(digest, Equals, expectDigest)
+
+	dg := parser.NewDigester(parser.DigestOptions{Hash: md5.New})
+	normalized, digest = dg.NormalizeDigest(sql)
+	c.Assert(normalized, Equals, expectNormalized)
+	c.Assert(digest, Equals, fmt.Sprintf("%x", md5.Sum([]byte(normalized))))
+	c.Assert(dg.DigestNormalized(normalized), Equals, digest)
+}
+
+func (s *testSQLDigestSuite) TestDigestReader(c *C) {
+	input := "select 1;\n  SELECT 'a;b' from `t;` where a in (1, 2) /* ; */ ; -- x;\ninsert into t values (3);;/* only a comment */;\nselect 'x;y', \"z;\" /* a; b */ from t;\nselect \"x\""
+	expected := []parser.DigestRecord{
+		{Text: "select 1"},
+		{Text: "SELECT 'a;b' from `t;` where a in (1, 2) /* ; */"},
+		{Text: "-- x;\ninsert into t values (3)"},
+		{Text: "select 'x;y', \"z;\" /* a; b */ from t"},
+		{Text: "select \"x\""},
+	}
+	for i := range expected {
+		expected[i].Normalized, expected[i].Digest = parser.NormalizeDigest(expected[i].Text)
+		expected[i].Offset = int64(strings.Index(input, expected[i].Text))
+	}
+	readers := []func(io.Reader) io.Reader{
+		func(r io.Reader) io.Reader { return r },
+		iotest.OneByteReader,
+		iotest.HalfReader,
+	}
+	for _, reader := range readers {
+		for _, workers := range []int{0, 1, 3} {
+			var records []parser.DigestRecord
+			err := parser.DigestReader(reader(strings.NewReader(input)), workers, func(rec parser.DigestRecord) error {
+				records = append(records, rec)
+				return nil
+			})
+			c.Assert(err, IsNil)
+			c.Assert(records, DeepEquals, expected)
+		}
+	}
+
+	var n int
+	errStop := fmt.Errorf("stop")
+	err := parser.DigestReader(strings.NewReader(strings.Repeat("select 1;", 1000)), 4, func(parser.DigestRecord) error {
+		n++
+		if n == 10 {
+			return errStop
+		}
+		return nil
+	})
+	c.Assert(err, Equals, errStop)
+	c.Assert(n, Equals, 10)
+
+	n = 0
+	r := io.MultiReader(strings.NewReader("select 1; select 2"), iotest.ErrReader(errStop))
+	err = parser.DigestReader(r, 2, func(parser.DigestRecord) error {
+		n++
+		return nil
+	})
+	c.Assert(err, ErrorMatches, "stop")
+	c.Assert(n, Equals, 1)
+}
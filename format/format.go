@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"unicode/utf8"
 )
 
 const (
@@ -220,6 +221,8 @@ const (
 	RestoreSpacesAroundBinaryOperation
 
 	RestoreStringWithoutDefaultCharset
+
+	RestoreWithComments
 )
 
 const (
@@ -284,17 +287,59 @@ func (rf RestoreFlags) HasStringWithoutDefaultCharset() bool {
 	return rf.has(RestoreStringWithoutDefaultCharset)
 }
 
+// HasWithCommentsFlag returns a boolean indicating whether `rf` has `RestoreWithComments` flag.
+func (rf RestoreFlags) HasWithCommentsFlag() bool {
+	return rf.has(RestoreWithComments)
+}
+
+// Layout describes how `Restore` lays a statement out over several lines.
+// Major clauses start new lines, subqueries and CASE branches are indented,
+// and lists are wrapped at Width.
+type Layout struct {
+	// Indent is written once for each nesting level at the start of a line.
+	Indent string
+	// Width is the column after which lists are wrapped. Zero disables wrapping.
+	Width int
+}
+
 // RestoreCtx is `Restore` context to hold flags and writer.
 type RestoreCtx struct {
 	Flags     RestoreFlags
 	In        io.Writer
 	JoinLevel int
 	DefaultDB string
+	// Layout makes `Restore` write several lines. A nil Layout writes a single line.
+	Layout *Layout
+
+	depth  int
+	column int
+	// offset is the number of bytes written to In, and tracked holds the
+	// nodes whose offsets are recorded by Track.
+	offset  int
+	tracked []*trackedNode
+}
+
+// trackedNode is where the text of a node tracked by a RestoreCtx starts and
+// ends. The offsets are -1 until the node is restored.
+type trackedNode struct {
+	node       interface{}
+	start, end int
 }
 
 // NewRestoreCtx returns a new `RestoreCtx`.
 func NewRestoreCtx(flags RestoreFlags, in io.Writer) *RestoreCtx {
-	return &RestoreCtx{flags, in, 0, ""}
+	return &RestoreCtx{Flags: flags, In: in}
+}
+
+// write writes s into writer and keeps track of the current column.
+func (ctx *RestoreCtx) write(s string) {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		ctx.column = utf8.RuneCountInString(s[i+1:])
+	} else {
+		ctx.column += utf8.RuneCountInString(s)
+	}
+	ctx.offset += len(s)
+	fmt.Fprint(ctx.In, s)
 }
 
 // WriteKeyWord writes the `keyWord` into writer.
@@ -306,7 +351,7 @@ func (ctx *RestoreCtx) WriteKeyWord(keyWord string) {
 	case ctx.Flags.HasKeyWordLowercaseFlag():
 		keyWord = strings.ToLower(keyWord)
 	}
-	fmt.Fprint(ctx.In, keyWord)
+	ctx.write(keyWord)
 }
 
 // WriteString writes the string into writer
@@ -324,7 +369,7 @@ func (ctx *RestoreCtx) WriteString(str string) {
 		str = strings.Replace(str, `"`, `""`, -1)
 		quotes = `"`
 	}
-	fmt.Fprint(ctx.In, quotes, str, quotes)
+	ctx.write(quotes + str + quotes)
 }
 
 // WriteName writes the name into writer
@@ -345,15 +390,183 @@ func (ctx *RestoreCtx) WriteName(name string) {
 		name = strings.Replace(name, "`", "``", -1)
 		quotes = "`"
 	}
-	fmt.Fprint(ctx.In, quotes, name, quotes)
+	ctx.write(quotes + name + quotes)
 }
 
 // WritePlain writes the plain text into writer without any handling.
 func (ctx *RestoreCtx) WritePlain(plainText string) {
-	fmt.Fprint(ctx.In, plainText)
+	ctx.write(plainText)
 }
 
 // WritePlainf write the plain text into writer without any handling.
 func (ctx *RestoreCtx) WritePlainf(format string, a ...interface{}) {
-	fmt.Fprintf(ctx.In, format, a...)
+	ctx.write(fmt.Sprintf(format, a...))
+}
+
+// WriteLineBreak starts a new line at the current indentation if ctx has a
+// Layout, or writes `flat` otherwise.
+func (ctx *RestoreCtx) WriteLineBreak(flat string) {
+	if ctx.Layout == nil {
+		ctx.write(flat)
+		return
+	}
+	ctx.write("\n" + strings.Repeat(ctx.Layout.Indent, ctx.depth))
+}
+
+// Indent increases the indentation of the lines started by WriteLineBreak.
+func (ctx *RestoreCtx) Indent() {
+	ctx.depth++
+}
+
+// Dedent decreases the indentation of the lines started by WriteLineBreak.
+func (ctx *RestoreCtx) Dedent() {
+	ctx.depth--
+}
+
+// WriteList calls restore for n items, writing `sep` between them. With a
+// Layout, separators are followed by a space, and an item which would end
+// after Layout.Width or spans several lines starts a new line instead. Lines
+// started inside the list are indented one level deeper. Each item is
+// restored once, the items after the first into a buffer which is written
+// once their place is known.
+func (ctx *RestoreCtx) WriteList(n int, sep string, restore func(i int) error) error {
+	if ctx.Layout == nil {
+		for i := 0; i < n; i++ {
+			if i != 0 {
+				ctx.write(sep)
+			}
+			if err := restore(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	sep = strings.TrimRight(sep, " ")
+	ctx.Indent()
+	defer ctx.Dedent()
+	for i := 0; i < n; i++ {
+		if i != 0 {
+			ctx.write(sep)
+			text, tracked, err := ctx.measure(func() error { return restore(i) })
+			if err != nil {
+				return err
+			}
+			width := utf8.RuneCountInString(text)
+			if strings.IndexByte(text, '\n') >= 0 || ctx.Layout.Width > 0 && ctx.column+1+width > ctx.Layout.Width {
+				ctx.WriteLineBreak("")
+			} else {
+				ctx.write(" ")
+			}
+			tracked.shift(ctx.offset)
+			ctx.write(text)
+			continue
+		}
+		if err := restore(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Capture makes the following writes go to a buffer until the returned
+// function is called, which returns what was written. The text is not written
+// to ctx.In, so the caller writes it, changed or not. The offsets of the nodes
+// tracked meanwhile are relative to the start of the text.
+func (ctx *RestoreCtx) Capture() func() string {
+	in, column, offset := ctx.In, ctx.column, ctx.offset
+	var sb strings.Builder
+	ctx.In, ctx.offset = &sb, 0
+	return func() string {
+		ctx.In, ctx.column, ctx.offset = in, column, offset
+		return sb.String()
+	}
+}
+
+// measure returns the text restore writes at the start of a new line. When
+// the text fits on the current line, it has no line breaks, so it's the same
+// there. The offsets of the nodes tracked meanwhile are relative to the start
+// of the text, and are returned to be shifted where the text is written.
+func (ctx *RestoreCtx) measure(restore func() error) (string, trackedOffsets, error) {
+	in, column, offset := ctx.In, ctx.column, ctx.offset
+	defer func() {
+		ctx.In, ctx.column, ctx.offset = in, column, offset
+	}()
+	var sb strings.Builder
+	ctx.In, ctx.column, ctx.offset = &sb, utf8.RuneCountInString(strings.Repeat(ctx.Layout.Indent, ctx.depth)), 0
+	tracked := ctx.trackedOffsets()
+	err := restore()
+	tracked.collect()
+	return sb.String(), tracked, err
+}
+
+// TrackNodes makes ctx record where the text of the nodes starts and ends
+// when they are restored, to be returned by NodeOffsets. The nodes are
+// compared by identity, so the text of a node is found even if the same
+// text is written for another one.
+func (ctx *RestoreCtx) TrackNodes(nodes ...interface{}) {
+	for _, n := range nodes {
+		ctx.tracked = append(ctx.tracked, &trackedNode{node: n, start: -1, end: -1})
+	}
+}
+
+// Track records where the text of n starts, if n is tracked, and returns the
+// function recording where it ends. The `Restore` methods of the nodes call
+// it first, as in `defer ctx.Track(n)()`.
+func (ctx *RestoreCtx) Track(n interface{}) func() {
+	for _, t := range ctx.tracked {
+		if t.node == n && t.start < 0 {
+			t.start = ctx.offset
+			return func() { t.end = ctx.offset }
+		}
+	}
+	return noTrack
+}
+
+func noTrack() {}
+
+// NodeOffsets returns where the text of a node tracked by TrackNodes starts
+// and ends, or false if the node hasn't been restored.
+func (ctx *RestoreCtx) NodeOffsets(n interface{}) (start, end int, ok bool) {
+	for _, t := range ctx.tracked {
+		if t.node == n && t.end >= 0 {
+			return t.start, t.end, true
+		}
+	}
+	return 0, 0, false
+}
+
+// trackedOffsets are the nodes tracked by a RestoreCtx which are restored
+// while a text is measured.
+type trackedOffsets []*trackedNode
+
+// trackedOffsets returns the tracked nodes not restored yet.
+func (ctx *RestoreCtx) trackedOffsets() trackedOffsets {
+	var tracked trackedOffsets
+	for _, t := range ctx.tracked {
+		if t.start < 0 {
+			tracked = append(tracked, t)
+		}
+	}
+	return tracked
+}
+
+// collect keeps the nodes which have been restored since.
+func (tracked *trackedOffsets) collect() {
+	kept := (*tracked)[:0]
+	for _, t := range *tracked {
+		if t.start >= 0 {
+			kept = append(kept, t)
+		}
+	}
+	*tracked = kept
+}
+
+// shift moves the offsets of the nodes to a text written at offset.
+func (tracked trackedOffsets) shift(offset int) {
+	for _, t := range tracked {
+		t.start += offset
+		if t.end >= 0 {
+			t.end += offset
+		}
+	}
 }
@@ -95,3 +95,102 @@ func (s *testRestoreCtxSuite) TestRestoreCtx(c *C) {
 		c.Assert(sb.String(), Equals, testCase.expect, Commentf("case: %#v", testCase))
 	}
 }
+
+func (s *testRestoreCtxSuite) TestRestoreCtxLayout(c *C) {
+	names := []string{"alpha", "beta", "gamma", "delta"}
+	restore := func(ctx *RestoreCtx) {
+		ctx.WriteKeyWord("select ")
+		err := ctx.WriteList(len(names), ",", func(i int) error {
+			ctx.WriteName(names[i])
+			return nil
+		})
+		c.Assert(err, IsNil)
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("from ")
+		ctx.WritePlain("(")
+		ctx.Indent()
+		ctx.WriteLineBreak("")
+		ctx.WriteKeyWord("values")
+		ctx.Dedent()
+		ctx.WriteLineBreak("")
+		ctx.WritePlain(")")
+	}
+
+	var sb strings.Builder
+	restore(NewRestoreCtx(0, &sb))
+	c.Assert(sb.String(), Equals, "select alpha,beta,gamma,delta from (values)")
+
+	sb.Reset()
+	ctx := NewRestoreCtx(0, &sb)
+	ctx.Layout = &Layout{Indent: "  "}
+	restore(ctx)
+	c.Assert(sb.String(), Equals, "select alpha, beta, gamma, delta\nfrom (\n  values\n)")
+
+	sb.Reset()
+	ctx = NewRestoreCtx(0, &sb)
+	ctx.Layout = &Layout{Indent: "\t", Width: 20}
+	restore(ctx)
+	c.Assert(sb.String(), Equals, "select alpha, beta,\n\tgamma, delta\nfrom (\n\tvalues\n)")
+
+	// Each item is restored once, however deep the lists are nested.
+	calls := 0
+	var nested func(depth int) error
+	nested = func(depth int) error {
+		calls++
+		if depth == 0 {
+			ctx.WriteName("x")
+			return nil
+		}
+		ctx.WritePlain("(")
+		defer ctx.WritePlain(")")
+		return ctx.WriteList(2, ",", func(int) error { return nested(depth - 1) })
+	}
+	sb.Reset()
+	c.Assert(nested(5), IsNil)
+	c.Assert(calls, Equals, 63)
+}
+
+func (s *testRestoreCtxSuite) TestRestoreCtxTrackNodes(c *C) {
+	names := []string{"alpha", "beta", "alpha"}
+	nodes := []*string{&names[0], &names[1], &names[2]}
+	restore := func(ctx *RestoreCtx) {
+		ctx.WriteKeyWord("select ")
+		err := ctx.WriteList(len(nodes), ",", func(i int) error {
+			defer ctx.Track(nodes[i])()
+			ctx.WriteName(*nodes[i])
+			return nil
+		})
+		c.Assert(err, IsNil)
+	}
+
+	var sb strings.Builder
+	ctx := NewRestoreCtx(0, &sb)
+	ctx.TrackNodes(nodes[2], nodes[1])
+	restore(ctx)
+	c.Assert(sb.String(), Equals, "select alpha,beta,alpha")
+	start, end, ok := ctx.NodeOffsets(nodes[2])
+	c.Assert(ok, IsTrue)
+	c.Assert(sb.String()[start:end], Equals, "alpha")
+	c.Assert(start, Equals, 18)
+	_, _, ok = ctx.NodeOffsets(nodes[0])
+	c.Assert(ok, IsFalse)
+
+	// The offsets are relative to the captured text, and follow the items
+	// a Layout moves to new lines.
+	sb.Reset()
+	ctx = NewRestoreCtx(0, &sb)
+	ctx.Layout = &Layout{Indent: "\t", Width: 16}
+	ctx.TrackNodes(nodes[2], nodes[1])
+	ctx.WritePlain("-- ")
+	captured := ctx.Capture()
+	restore(ctx)
+	text := captured()
+	c.Assert(text, Equals, "select alpha,\n\tbeta, alpha")
+	for _, n := range []*string{nodes[1], nodes[2]} {
+		start, end, ok := ctx.NodeOffsets(n)
+		c.Assert(ok, IsTrue)
+		c.Assert(text[start:end], Equals, *n)
+	}
+	start, _, _ = ctx.NodeOffsets(nodes[2])
+	c.Assert(start, Equals, 21)
+}
@@ -139,6 +139,7 @@ import (
 	"os"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/cznic/mathutil"
@@ -438,10 +439,17 @@ type %[1]sXError struct {
 	}
 	mustFormat(f, "%u}\n")
 
+	// Token literals
+	mustFormat(f, "\n%sSymLiterals = []string{%i\n", *oPref)
+	for _, v := range su {
+		mustFormat(f, "%q,\n", symLiteral(v.sym))
+	}
+	mustFormat(f, "%u}\n")
+
 	// Reduction table
-	mustFormat(f, "\n%sReductions = []struct{xsym, components int}{%i\n", *oPref)
+	mustFormat(f, "\n%sReductions = []struct {%i\nxsym, components int\ntag string\n%u}{%i\n", *oPref)
 	for _, rule := range p.Rules {
-		mustFormat(f, "{%d, %d},\n", xlat[rule.Sym.Value], len(rule.Components))
+		mustFormat(f, "{%d, %d, %q},\n", xlat[rule.Sym.Value], len(rule.Components), rule.Sym.Type)
 	}
 	mustFormat(f, "%u}\n")
 
@@ -545,6 +553,49 @@ func %[1]sSymName(c int) (s string) {
 	return __yyfmt__.Sprintf("%%d", c)
 }
 
+// %[1]sExpected returns the tokens the parser accepts with the stack
+// yyS[:yyp+1]. The row of the state on top of the stack isn't enough: the
+// default reductions and the merged states of the tables give actions to
+// tokens which can't follow, so the reductions are simulated for each token.
+func %[1]sExpected(yyS []%[1]sSymType, yyp int) []string {
+	var expected []string
+	states := make([]int, yyp+1)
+	for i := range states {
+		states[i] = yyS[i].yys
+	}
+	stack := make([]int, 0, len(states))
+	for xsym, lit := range %[1]sSymLiterals {
+		if lit != "" && %[1]sAccepts(append(stack[:0], states...), xsym) {
+			expected = append(expected, lit)
+		}
+	}
+	return expected
+}
+
+// %[1]sAccepts reports whether the token xsym is shifted, or accepted, once
+// the reductions it causes are done on the stack of states.
+func %[1]sAccepts(stack []int, xsym int) bool {
+	for len(stack) > 0 {
+		state := stack[len(stack)-1]
+		row := %[1]sParseTab[state]
+		if xsym >= len(row) || row[xsym] == 0 {
+			return state == 1
+		}
+		// A shift, or the reduction of the start rule accepting the input.
+		yyn := int(row[xsym]) + %[1]sTabOfs
+		if yyn >= 0 {
+			return true
+		}
+		x0 := %[1]sReductions[-yyn]
+		if x0.components >= len(stack) {
+			return false
+		}
+		stack = stack[:len(stack)-x0.components]
+		stack = append(stack, int(%[1]sParseTab[stack[len(stack)-1]][x0.xsym])+%[1]sTabOfs)
+	}
+	return false
+}
+
 func %[1]slex1(yylex %[1]sLexer, lval *%[1]sSymType) (n int) {
 	n = yylex.Lex(lval)
 	if n <= 0 {
@@ -662,7 +713,7 @@ yynewstate:
 				msg = "syntax error"
 			}
 			// ignore goyacc error message
-			yylex.AppendError(yylex.Errorf(""))
+			%[1]sSyntaxError(yylex, %[1]sExpected(yyS, yyp))
 			Nerrs++
 			fallthrough
 
@@ -792,6 +843,7 @@ yynewstate:
 
 
 	%[1]sSetOffset(parser.yyVAL, parser.yyVAL.offset)
+	%[1]sSetSpan(parser, yyS[yyp:yypt+1], x0.tag)
 
 	if yyEx != nil && yyEx.Reduced(r, exState, parser.yyVAL) {
 		return -1
@@ -832,6 +884,29 @@ import __yyfmt__ "fmt"
 	}
 }
 
+// symLiteral returns the text of a terminal symbol to show in error
+// messages, or "" if it has none. Only the literal string of a token and a
+// character token have a text: the name of a token is internal to the
+// grammar, like the ones of the precedence tokens.
+func symLiteral(sym *y.Symbol) string {
+	switch {
+	case !sym.IsTerminal:
+		return ""
+	case sym.Name == "$end":
+		return "EOF"
+	case sym.LiteralString != "":
+		if s, err := strconv.Unquote(sym.LiteralString); err == nil {
+			return s
+		}
+		return sym.LiteralString
+	case sym.Name[0] == '\'':
+		if s, err := strconv.Unquote(sym.Name); err == nil {
+			return s
+		}
+	}
+	return ""
+}
+
 func mustFormat(f strutil.Formatter, format string, args ...interface{}) {
 	_, err := f.Format(format, args...)
 	if err != nil {
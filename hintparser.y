@@ -104,6 +104,7 @@ import (
 	hintUseCascades           "USE_CASCADES"
 	hintNthPlan               "NTH_PLAN"
 	hintLimitToCop            "LIMIT_TO_COP"
+	hintLeading               "LEADING"
 
 	/* Other keywords */
 	hintOLAP            "OLAP"
@@ -539,6 +540,7 @@ SupportedTableLevelOptimizerHintName:
 |	"NO_SWAP_JOIN_INPUTS"
 |	"INL_MERGE_JOIN"
 |	"HASH_JOIN"
+|	"LEADING"
 
 UnsupportedIndexLevelOptimizerHintName:
 	"INDEX_MERGE"
@@ -642,6 +644,7 @@ Identifier:
 |	"TIME_RANGE"
 |	"USE_CASCADES"
 |	"NTH_PLAN"
+|	"LEADING"
 /* other keywords */
 |	"OLAP"
 |	"OLTP"
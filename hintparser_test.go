@@ -146,6 +146,19 @@ func (s *testHintParserSuite) TestParseHint(c *C) {
 				},
 			},
 		},
+		{
+			input: "LEADING(@qb1 t2, t1@qb2)",
+			output: []*ast.TableOptimizerHint{
+				{
+					HintName: model.NewCIStr("LEADING"),
+					QBName:   model.NewCIStr("qb1"),
+					Tables: []ast.HintTable{
+						{TableName: model.NewCIStr("t2")},
+						{TableName: model.NewCIStr("t1"), QBName: model.NewCIStr("qb2")},
+					},
+				},
+			},
+		},
 		{
 			input: "USE_INDEX_MERGE(@qb1 tbl1 x, y, z) IGNORE_INDEX(tbl2@qb2) USE_INDEX(tbl3 PRIMARY)",
 			output: []*ast.TableOptimizerHint{
@@ -348,3 +361,48 @@ func (s *testHintParserSuite) TestParseHint(c *C) {
 		c.Assert(output, DeepEquals, tc.output, Commentf("input = %s,\n... output = %q", tc.input, output))
 	}
 }
+
+func (s *testHintParserSuite) TestValidateHints(c *C) {
+	testCases := []struct {
+		sql   string
+		warns []string
+	}{
+		{"select /*+ USE_INDEX(t1, idx) HASH_JOIN(t1, test.t2) */ * from t1, test.t2", nil},
+		{"select /*+ USE_INDEX(t2, idx) */ * from t1", []string{".*Unresolved name `t2` for USE_INDEX hint"}},
+		{"select /*+ HASH_JOIN(t1) */ * from t1 as a", []string{".*Unresolved name `t1` for HASH_JOIN hint"}},
+		{"select /*+ HASH_JOIN(a, d) */ * from t1 a join (select 1) d", nil},
+		{"select /*+ HASH_JOIN(t1, jt) */ * from t1, json_table(t1.j, '$[*]' columns (x int path '$')) jt", nil},
+		{"select /*+ LEADING(d, t1) */ * from t1, lateral (select t1.a) d", nil},
+		{"select /*+ HASH_JOIN(t1@sel_2) */ * from t1, lateral (select t1.a from t2) d", []string{".*Unresolved name `t1`@`sel_2` for HASH_JOIN hint"}},
+		{"select /*+ HASH_JOIN(db.t1) */ * from other.t1", []string{".*Unresolved name `db`.`t1` for HASH_JOIN hint"}},
+		{"select /*+ HASH_JOIN(t2) */ * from t1 where a in (select a from t2)", []string{".*Unresolved name `t2` for HASH_JOIN hint"}},
+		{"select /*+ HASH_JOIN(t2@sel_2) */ * from t1 where a in (select a from t2)", nil},
+		{"select /*+ HASH_JOIN(@sel_2 t2) */ * from t1 where a in (select a from t2)", nil},
+		{"select /*+ HASH_AGG(@sel_3) */ * from t1 where a in (select a from t2)", []string{".*Query block name `sel_3` is not found for HASH_AGG hint"}},
+		{"select /*+ HASH_JOIN(t2@qb) */ * from t1 where a in (select /*+ QB_NAME(qb) */ a from t2)", nil},
+		{"select /*+ HASH_JOIN(t2@qb2) */ * from t1 where a in (select /*+ QB_NAME(qb) */ a from t2)", []string{
+			".*Query block name `qb2` is not found for HASH_JOIN hint",
+			".*Unresolved name `qb` for QB_NAME hint",
+		}},
+		{"select /*+ QB_NAME(qb) */ * from t1 where a in (select /*+ QB_NAME(qb) */ a from t2)", []string{
+			".*Hint QB_NAME\\(`qb`\\) is ignored as conflicting/duplicated",
+			".*Unresolved name `qb` for QB_NAME hint",
+		}},
+		{"select /*+ LEADING(t2, t1) */ * from t1 join t2", nil},
+		{"select /*+ LEADING(t2, t1) LEADING(t1, t2) */ * from t1 join t2", []string{".*Hint LEADING\\(`t1`, `t2`\\) is ignored as conflicting/duplicated"}},
+		{"select /*+ LEADING(t1, t1) */ * from t1 join t2", []string{".*Hint LEADING\\(`t1`, `t1`\\) is ignored as conflicting/duplicated"}},
+		{"update /*+ USE_INDEX(t1, idx) HASH_AGG(@upd_1) */ t1 set a = (select /*+ HASH_AGG() */ max(a) from t2)", nil},
+		{"delete /*+ USE_INDEX(t2, idx) */ from t1", []string{".*Unresolved name `t2` for USE_INDEX hint"}},
+		{"insert /*+ MEMORY_QUOTA(1 MB) */ into t1 select /*+ USE_INDEX(t2, idx) */ * from t2", nil},
+	}
+	p := parser.New()
+	for _, tc := range testCases {
+		stmt, err := p.ParseOneStmt(tc.sql, "", "")
+		c.Assert(err, IsNil, Commentf("sql = %s", tc.sql))
+		warns := parser.ValidateHints(stmt)
+		c.Assert(warns, HasLen, len(tc.warns), Commentf("sql = %s,\n... warns = %q", tc.sql, warns))
+		for i, warn := range warns {
+			c.Assert(warn, ErrorMatches, tc.warns[i], Commentf("sql = %s, i = %d", tc.sql, i))
+		}
+	}
+}
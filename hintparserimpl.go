@@ -29,15 +29,26 @@ var (
 	ErrWarnMemoryQuotaOverflow          = terror.ClassParser.NewStd(mysql.ErrWarnMemoryQuotaOverflow)
 	ErrWarnOptimizerHintParseError      = terror.ClassParser.NewStd(mysql.ErrWarnOptimizerHintParseError)
 	ErrWarnOptimizerHintInvalidInteger  = terror.ClassParser.NewStd(mysql.ErrWarnOptimizerHintInvalidInteger)
+	ErrWarnConflictingHint              = terror.ClassParser.NewStd(mysql.ErrWarnConflictingHint)
+	ErrWarnUnknownQBName                = terror.ClassParser.NewStd(mysql.ErrWarnUnknownQBName)
+	ErrUnresolvedHintName               = terror.ClassParser.NewStd(mysql.ErrUnresolvedHintName)
 )
 
 // hintScanner implements the yyhintLexer interface
 type hintScanner struct {
 	Scanner
+	// base is the position of the hint text in the sql string.
+	base Pos
 }
 
 func (hs *hintScanner) Errorf(format string, args ...interface{}) error {
 	inner := hs.Scanner.Errorf(format, args...)
+	// The message keeps the position of the hint scanner, only the fields
+	// are moved to the sql string.
+	if e, ok := inner.(*SyntaxError); ok {
+		pos := shiftPos(Pos{Line: e.Line, Col: e.Col, Offset: e.Offset}, hs.base)
+		e.Line, e.Col, e.Offset = pos.Line, pos.Col, pos.Offset
+	}
 	return ErrWarnOptimizerHintParseError.GenWithStackByArgs(inner)
 }
 
@@ -134,6 +145,8 @@ func (hp *hintParser) parse(input string, sqlMode mysql.SQLMode, initPos Pos) ([
 		Col:    initPos.Col + 3, // skipped the initial '/*+'
 		Offset: 0,
 	}
+	// initPos.Col counts from 0.
+	hp.lexer.base = Pos{Line: initPos.Line, Col: initPos.Col + 4, Offset: initPos.Offset + 3}
 	hp.lexer.inBangComment = true // skip the final '*/' (we need the '*/' for reporting warnings)
 
 	yyhintParse(&hp.lexer, hp)
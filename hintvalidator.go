@@ -0,0 +1,226 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kyleconroy/sqlparse/ast"
+	sqlformat "github.com/kyleconroy/sqlparse/format"
+	"github.com/kyleconroy/sqlparse/model"
+)
+
+// ValidateHints checks the optimizer hints of a statement against its query
+// blocks and tables, which ParseHint can't do. It returns a warning for:
+//
+//	a hint naming a table which is not in its query block,
+//	a hint naming a query block which doesn't exist,
+//	a QB_NAME which no hint refers to, or which names two query blocks,
+//	a second LEADING in a query block, or a LEADING naming a table twice.
+//
+// The query blocks are the SELECT, UPDATE and DELETE statements, in the order
+// they appear in. They may be named with QB_NAME, and are otherwise named
+// sel_N, upd_N and del_N after their position, starting at 1. The hints of an
+// INSERT apply to the table it inserts into.
+func ValidateHints(node ast.StmtNode) []error {
+	v := &hintValidator{qbNames: make(map[string]*hintBlock)}
+	node.Accept(v)
+	return v.validate()
+}
+
+// hintBlock is a query block of the statement validated by ValidateHints.
+type hintBlock struct {
+	name   string
+	hints  []*ast.TableOptimizerHint
+	tables []ast.HintTable
+	// leading is the first LEADING hint of the block.
+	leading *ast.TableOptimizerHint
+}
+
+type hintValidator struct {
+	blocks  []*hintBlock
+	stack   []*hintBlock
+	offset  int
+	qbNames map[string]*hintBlock
+	// qbUsed are the QB_NAME names the hints refer to.
+	qbUsed map[string]bool
+	warns  []error
+}
+
+// Enter implements ast.Visitor interface.
+func (v *hintValidator) Enter(in ast.Node) (ast.Node, bool) {
+	switch node := in.(type) {
+	case *ast.SelectStmt:
+		v.enterBlock("sel", node.TableHints)
+	case *ast.UpdateStmt:
+		v.enterBlock("upd", node.TableHints)
+	case *ast.DeleteStmt:
+		v.enterBlock("del", node.TableHints)
+	case *ast.InsertStmt:
+		v.enterBlock("", node.TableHints)
+	case *ast.TableSource:
+		if len(v.stack) == 0 {
+			break
+		}
+		block := v.stack[len(v.stack)-1]
+		switch source := node.Source.(type) {
+		case *ast.TableName:
+			if node.AsName.L != "" {
+				block.tables = append(block.tables, ast.HintTable{TableName: node.AsName})
+			} else {
+				block.tables = append(block.tables, ast.HintTable{DBName: source.Schema, TableName: source.Name})
+			}
+		case *ast.SelectStmt, *ast.SetOprStmt, *ast.JSONTable, *ast.LateralTable:
+			block.tables = append(block.tables, ast.HintTable{TableName: node.AsName})
+		}
+	}
+	return in, false
+}
+
+// Leave implements ast.Visitor interface.
+func (v *hintValidator) Leave(in ast.Node) (ast.Node, bool) {
+	switch in.(type) {
+	case *ast.SelectStmt, *ast.UpdateStmt, *ast.DeleteStmt, *ast.InsertStmt:
+		v.stack = v.stack[:len(v.stack)-1]
+	}
+	return in, true
+}
+
+// enterBlock starts a query block. An INSERT, with no prefix, is not counted.
+func (v *hintValidator) enterBlock(prefix string, hints []*ast.TableOptimizerHint) {
+	block := &hintBlock{hints: hints}
+	if prefix != "" {
+		v.offset++
+		block.name = fmt.Sprintf("%s_%d", prefix, v.offset)
+	}
+	v.blocks = append(v.blocks, block)
+	v.stack = append(v.stack, block)
+	for _, hint := range hints {
+		if hint.HintName.L != "qb_name" {
+			continue
+		}
+		if _, ok := v.qbNames[hint.QBName.L]; ok {
+			v.warns = append(v.warns, ErrWarnConflictingHint.GenWithStackByArgs(restoreHint(hint)))
+			continue
+		}
+		v.qbNames[hint.QBName.L] = block
+	}
+}
+
+func (v *hintValidator) validate() []error {
+	v.qbUsed = make(map[string]bool)
+	for _, block := range v.blocks {
+		for _, hint := range block.hints {
+			if hint.HintName.L == "qb_name" {
+				continue
+			}
+			hintBlock := v.lookupBlock(block, hint.QBName, hint)
+			if hintBlock == nil {
+				continue
+			}
+			for _, table := range hint.Tables {
+				tableBlock := v.lookupBlock(hintBlock, table.QBName, hint)
+				if tableBlock != nil && !tableBlock.hasTable(table) {
+					v.warns = append(v.warns, ErrUnresolvedHintName.GenWithStackByArgs(restoreHintTable(table), hintName(hint)))
+				}
+			}
+			if hint.HintName.L == "leading" {
+				v.checkLeading(hintBlock, hint)
+			}
+		}
+	}
+	for _, block := range v.blocks {
+		for _, hint := range block.hints {
+			if hint.HintName.L == "qb_name" && v.qbNames[hint.QBName.L] == block && !v.qbUsed[hint.QBName.L] {
+				v.warns = append(v.warns, ErrUnresolvedHintName.GenWithStackByArgs(quoteName(hint.QBName.O), hintName(hint)))
+			}
+		}
+	}
+	return v.warns
+}
+
+// lookupBlock returns the query block named qbName, or block if qbName is
+// empty. It warns and returns nil if there is no such block.
+func (v *hintValidator) lookupBlock(block *hintBlock, qbName model.CIStr, hint *ast.TableOptimizerHint) *hintBlock {
+	if qbName.L == "" {
+		return block
+	}
+	if named, ok := v.qbNames[qbName.L]; ok {
+		v.qbUsed[qbName.L] = true
+		return named
+	}
+	for _, b := range v.blocks {
+		if b.name == qbName.L {
+			return b
+		}
+	}
+	v.warns = append(v.warns, ErrWarnUnknownQBName.GenWithStackByArgs(quoteName(qbName.O), hintName(hint)))
+	return nil
+}
+
+// checkLeading keeps the first LEADING of a query block, which must not name
+// a table twice.
+func (v *hintValidator) checkLeading(block *hintBlock, hint *ast.TableOptimizerHint) {
+	if block.leading != nil {
+		v.warns = append(v.warns, ErrWarnConflictingHint.GenWithStackByArgs(restoreHint(hint)))
+		return
+	}
+	seen := make(map[string]bool, len(hint.Tables))
+	for _, table := range hint.Tables {
+		key := table.DBName.L + "." + table.TableName.L + "@" + table.QBName.L
+		if seen[key] {
+			v.warns = append(v.warns, ErrWarnConflictingHint.GenWithStackByArgs(restoreHint(hint)))
+			return
+		}
+		seen[key] = true
+	}
+	block.leading = hint
+}
+
+// hasTable reports whether the hint table names a table of the query block.
+// A table with an alias is only named by its alias.
+func (b *hintBlock) hasTable(table ast.HintTable) bool {
+	for _, t := range b.tables {
+		if t.TableName.L != table.TableName.L {
+			continue
+		}
+		if table.DBName.L == "" || t.DBName.L == "" || table.DBName.L == t.DBName.L {
+			return true
+		}
+	}
+	return false
+}
+
+func hintName(hint *ast.TableOptimizerHint) string {
+	return strings.ToUpper(hint.HintName.O)
+}
+
+func quoteName(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+func restoreHint(hint *ast.TableOptimizerHint) string {
+	var sb strings.Builder
+	if err := hint.Restore(sqlformat.NewRestoreCtx(sqlformat.DefaultRestoreFlags, &sb)); err != nil {
+		return hintName(hint)
+	}
+	return sb.String()
+}
+
+func restoreHintTable(table ast.HintTable) string {
+	var sb strings.Builder
+	table.Restore(sqlformat.NewRestoreCtx(sqlformat.DefaultRestoreFlags, &sb))
+	return sb.String()
+}
@@ -16,11 +16,13 @@ package parser
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
 
+	"github.com/kyleconroy/sqlparse/ast"
 	"github.com/kyleconroy/sqlparse/mysql"
 )
 
@@ -73,6 +75,23 @@ type Scanner struct {
 
 	// true if a dot follows an identifier
 	identifierDot bool
+
+	// comments records the comments skipped by scan(), if keepComments is set.
+	comments     []ast.Comment
+	keepComments bool
+	// stmtRanges records the start and end offsets of the texts returned by stmtText().
+	stmtRanges [][2]int
+	// lines records the lines of the sql string, it's built by position() on demand.
+	lines []lineInfo
+}
+
+type lineInfo struct {
+	offset int
+	ascii  bool
+	// runeOffset and runeCol are the last offset position() counted the
+	// characters up to in a line which isn't ASCII, and its column.
+	runeOffset int
+	runeCol    int
 }
 
 // Errors returns the errors and warns during a scan.
@@ -89,6 +108,64 @@ func (s *Scanner) reset(sql string) {
 	s.stmtStartPos = 0
 	s.inBangComment = false
 	s.lastKeyword = 0
+	s.comments = s.comments[:0]
+	s.stmtRanges = s.stmtRanges[:0]
+	s.lines = s.lines[:0]
+}
+
+// skipStmt skips the rest of a statement which failed to parse, up to the
+// next ';'. It returns the offset where the statement ends, and false if it
+// reaches the end of the sql string.
+func (s *Scanner) skipStmt() (end int, more bool) {
+	// The token which caused the error is the last one scanned.
+	if end = s.lastScanOffset; end >= len(s.r.s) || s.r.s[end] != ';' {
+		var v yySymType
+		for {
+			tok := s.Lex(&v)
+			if tok == 0 {
+				return len(s.r.s), false
+			}
+			if tok == ';' {
+				end = s.lastScanOffset
+				break
+			}
+		}
+	}
+	s.stmtStartPos = end + 1
+	return end, true
+}
+
+// position returns the Pos of the offset in the sql string. Lines and columns
+// start at 1, and columns are counted in characters.
+func (s *Scanner) position(offset int) Pos {
+	if len(s.lines) == 0 {
+		line := lineInfo{ascii: true, runeCol: 1}
+		for i := 0; i < len(s.r.s); i++ {
+			switch c := s.r.s[i]; {
+			case c == '\n':
+				line.runeOffset = line.offset
+				s.lines = append(s.lines, line)
+				line = lineInfo{offset: i + 1, ascii: true, runeCol: 1}
+			case c >= utf8.RuneSelf:
+				line.ascii = false
+			}
+		}
+		line.runeOffset = line.offset
+		s.lines = append(s.lines, line)
+	}
+	n := sort.Search(len(s.lines), func(i int) bool { return s.lines[i].offset > offset })
+	line := &s.lines[n-1]
+	if line.ascii {
+		return Pos{Line: n, Col: offset - line.offset + 1, Offset: offset}
+	}
+	// The offsets are mostly asked in increasing order, so the characters
+	// are counted from the last offset of the line rather than from its start.
+	if offset < line.runeOffset {
+		line.runeOffset, line.runeCol = line.offset, 1
+	}
+	line.runeCol += utf8.RuneCountInString(s.r.s[line.runeOffset:offset])
+	line.runeOffset = offset
+	return Pos{Line: n, Col: line.runeCol, Offset: offset}
 }
 
 func (s *Scanner) stmtText() string {
@@ -101,11 +178,44 @@ func (s *Scanner) stmtText() string {
 	}
 
 	text := s.r.s[s.stmtStartPos:endPos]
+	s.stmtRanges = append(s.stmtRanges, [2]int{s.stmtStartPos, endPos})
 
 	s.stmtStartPos = endPos
 	return text
 }
 
+// SyntaxError is an error found by the scanner or the parser in the sql
+// string. Its message is the one of MySQL, and the fields locate the error
+// for tools. The parser returns it without wrapping it, and StmtError wraps
+// it with Unwrap, so errors.As finds it.
+type SyntaxError struct {
+	// Line and Col locate the offending token, they start at 1, and Col is
+	// counted in characters.
+	Line int
+	Col  int
+	// Offset is the byte offset of the offending token.
+	Offset int
+	// Token is the text of the offending token, it's empty at the end of the
+	// sql string.
+	Token string
+	// Expected holds the tokens the parser would have accepted instead of
+	// Token, sorted. It's empty if the error is not a grammar mismatch.
+	Expected []string
+
+	// msgPos is the position the message reports, which follows the
+	// offending token with Col counted from 0, as MySQL reports it. near is
+	// the text from the offending token on, detail is the message of Errorf
+	// and the length of a truncated text.
+	msgPos Pos
+	near   string
+	detail string
+}
+
+// Error implements error interface.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d column %d near \"%s\"%s", e.msgPos.Line, e.msgPos.Col, e.near, e.detail)
+}
+
 // Errorf tells scanner something is wrong.
 // Scanner satisfies yyLexer interface which need this function.
 func (s *Scanner) Errorf(format string, a ...interface{}) (err error) {
@@ -116,9 +226,19 @@ func (s *Scanner) Errorf(format string, a ...interface{}) (err error) {
 		lenStr = "(total length " + strconv.Itoa(len(val)) + ")"
 		val = val[:2048]
 	}
-	err = fmt.Errorf("line %d column %d near \"%s\"%s %s",
-		s.r.p.Line, s.r.p.Col, val, str, lenStr)
-	return
+	pos := s.position(s.lastScanOffset)
+	e := &SyntaxError{
+		Line:   pos.Line,
+		Col:    pos.Col,
+		Offset: pos.Offset,
+		msgPos: s.r.p,
+		near:   val,
+		detail: str + " " + lenStr,
+	}
+	if end := s.r.p.Offset; end > s.lastScanOffset {
+		e.Token = s.r.s[s.lastScanOffset:end]
+	}
+	return e
 }
 
 // AppendError sets error into scanner.
@@ -138,6 +258,7 @@ func (s *Scanner) AppendError(err error) {
 func (s *Scanner) Lex(v *yySymType) int {
 	tok, pos, lit := s.scan()
 	s.lastScanOffset = pos.Offset
+	v.startOffset, v.endOffset = pos.Offset, s.r.pos().Offset
 	s.lastKeyword3 = s.lastKeyword2
 	s.lastKeyword2 = s.lastKeyword
 	s.lastKeyword = 0
@@ -311,9 +432,11 @@ func startWithBb(s *Scanner) (tok int, pos Pos, lit string) {
 }
 
 func startWithSharp(s *Scanner) (tok int, pos Pos, lit string) {
+	pos = s.r.pos()
 	s.r.incAsLongAs(func(ch rune) bool {
 		return ch != '\n'
 	})
+	s.addComment(pos)
 	return s.scan()
 }
 
@@ -325,6 +448,7 @@ func startWithDash(s *Scanner) (tok int, pos Pos, lit string) {
 			s.r.incAsLongAs(func(ch rune) bool {
 				return ch != '\n'
 			})
+			s.addComment(pos)
 			return s.scan()
 		}
 	}
@@ -417,6 +541,7 @@ func startWithSlash(s *Scanner) (tok int, pos Pos, lit string) {
 					s.lastHintPos = pos
 					return hintComment, pos, s.r.data(&pos)
 				} else {
+					s.addComment(pos)
 					return s.scan()
 				}
 			case 0:
@@ -435,6 +560,15 @@ func startWithSlash(s *Scanner) (tok int, pos Pos, lit string) {
 	}
 }
 
+// addComment records the comment from pos to the current position.
+func (s *Scanner) addComment(pos Pos) {
+	if !s.keepComments {
+		return
+	}
+	text := strings.TrimRight(s.r.data(&pos), "\r")
+	s.comments = append(s.comments, ast.Comment{Text: text, Offset: pos.Offset})
+}
+
 func startWithStar(s *Scanner) (tok int, pos Pos, lit string) {
 	pos = s.r.pos()
 	s.r.inc()
@@ -57,7 +57,7 @@ type testCaseItem struct {
 
 func TestSingleCharOther(t *testing.T) {
 	runTest(t, []testCaseItem{
-		{"AT", identifier},
+		{"AT", at},
 		{"?", paramMarker},
 		{"PLACEHOLDER", identifier},
 		{"=", eq},
@@ -174,6 +174,17 @@ SELECT`, selectKwd},
 		{"/*T![unsupported] '*/0 -- ' */", intLit}, // equivalent to 0
 		{"/*T![test] '*/0 -- ' */", stringLit},     // equivalent to '*/0 -- '
 	})
+
+	// The comments are only recorded when they are kept.
+	l := NewScanner("/* a */ select 1 -- b")
+	for tok, _, _ := l.scan(); tok != 0; tok, _, _ = l.scan() {
+	}
+	assert(t, len(l.comments), 0)
+	l.reset("/* a */ select 1 -- b")
+	l.keepComments = true
+	for tok, _, _ := l.scan(); tok != 0; tok, _, _ = l.scan() {
+	}
+	assert(t, len(l.comments), 2)
 }
 
 func runTest(t *testing.T, table []testCaseItem) {
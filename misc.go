@@ -163,6 +163,7 @@ var tokenMap = map[string]int{
 	"AS":                       as,
 	"ASC":                      asc,
 	"ASCII":                    ascii,
+	"AT":                       at,
 	"AUTO_ID_CACHE":            autoIdCache,
 	"AUTO_INCREMENT":           autoIncrement,
 	"AUTO_RANDOM":              autoRandom,
@@ -172,6 +173,7 @@ var tokenMap = map[string]int{
 	"BACKEND":                  backend,
 	"BACKUP":                   backup,
 	"BACKUPS":                  backups,
+	"BEFORE":                   before,
 	"BEGIN":                    begin,
 	"BETWEEN":                  between,
 	"BERNOULLI":                bernoulli,
@@ -206,6 +208,7 @@ var tokenMap = map[string]int{
 	"CAST":                     cast,
 	"CHAIN":                    chain,
 	"CHANGE":                   change,
+	"CHANNEL":                  channel,
 	"CHAR":                     charType,
 	"CHARACTER":                character,
 	"CHARSET":                  charsetKwd,
@@ -215,6 +218,8 @@ var tokenMap = map[string]int{
 	"CIPHER":                   cipher,
 	"CLEANUP":                  cleanup,
 	"CLIENT":                   client,
+	"CLONE":                    clone,
+	"CLOSE":                    close,
 	"CMSKETCH":                 cmSketch,
 	"COALESCE":                 coalesce,
 	"COLLATE":                  collate,
@@ -226,15 +231,20 @@ var tokenMap = map[string]int{
 	"COMMIT":                   commit,
 	"COMMITTED":                committed,
 	"COMPACT":                  compact,
+	"COMPLETION":               completion,
+	"COMPONENT":                component,
 	"COMPRESSED":               compressed,
 	"COMPRESSION":              compression,
 	"CONCURRENCY":              concurrency,
+	"CONDITION":                condition,
 	"CONFIG":                   config,
 	"CONNECTION":               connection,
 	"CONSISTENT":               consistent,
 	"CONSTRAINT":               constraint,
 	"CONSTRAINTS":              constraints,
+	"CONTAINS":                 contains,
 	"CONTEXT":                  context,
+	"CONTINUE":                 continueKwd,
 	"CONVERT":                  convert,
 	"COPY":                     copyKwd,
 	"CORRELATION":              correlation,
@@ -254,6 +264,7 @@ var tokenMap = map[string]int{
 	"CURRENT_TIMESTAMP":        currentTs,
 	"CURRENT_USER":             currentUser,
 	"CURRENT":                  current,
+	"CURSOR":                   cursor,
 	"CURTIME":                  curTime,
 	"CYCLE":                    cycle,
 	"DATA":                     data,
@@ -272,7 +283,9 @@ var tokenMap = map[string]int{
 	"DEALLOCATE":               deallocate,
 	"DEC":                      decimalType,
 	"DECIMAL":                  decimalType,
+	"DECLARE":                  declare,
 	"DEFAULT":                  defaultKwd,
+	"DEFAULT_AUTH":             defaultAuth,
 	"DEFINER":                  definer,
 	"DELAY_KEY_WRITE":          delayKeyWrite,
 	"DELAYED":                  delayed,
@@ -281,6 +294,7 @@ var tokenMap = map[string]int{
 	"DEPTH":                    depth,
 	"DESC":                     desc,
 	"DESCRIBE":                 describe,
+	"DETERMINISTIC":            deterministic,
 	"DIRECTORY":                directory,
 	"DISABLE":                  disable,
 	"DISCARD":                  discard,
@@ -295,11 +309,15 @@ var tokenMap = map[string]int{
 	"DUAL":                     dual,
 	"DUPLICATE":                duplicate,
 	"DYNAMIC":                  dynamic,
+	"EACH":                     each,
 	"ELSE":                     elseKwd,
+	"ELSEIF":                   elseIfKwd,
+	"EMPTY":                    empty,
 	"ENABLE":                   enable,
 	"ENCLOSED":                 enclosed,
 	"ENCRYPTION":               encryption,
 	"END":                      end,
+	"ENDS":                     ends,
 	"ENFORCED":                 enforced,
 	"ENGINE":                   engine,
 	"ENGINES":                  engines,
@@ -310,6 +328,7 @@ var tokenMap = map[string]int{
 	"ESCAPED":                  escaped,
 	"EVENT":                    event,
 	"EVENTS":                   events,
+	"EVERY":                    every,
 	"EVOLVE":                   evolve,
 	"EXACT":                    exact,
 	"EXCEPT":                   except,
@@ -317,6 +336,7 @@ var tokenMap = map[string]int{
 	"EXCLUSIVE":                exclusive,
 	"EXECUTE":                  execute,
 	"EXISTS":                   exists,
+	"EXIT":                     exit,
 	"EXPANSION":                expansion,
 	"EXPIRE":                   expire,
 	"EXPLAIN":                  explain,
@@ -335,24 +355,31 @@ var tokenMap = map[string]int{
 	"FLUSH":                    flush,
 	"FOLLOWER":                 follower,
 	"FOLLOWING":                following,
+	"FOLLOWS":                  follows,
 	"FOR":                      forKwd,
 	"FORCE":                    force,
 	"FOREIGN":                  foreign,
 	"FORMAT":                   format,
+	"FOUND":                    found,
 	"FROM":                     from,
 	"FULL":                     full,
 	"FULLTEXT":                 fulltext,
 	"FUNCTION":                 function,
 	"GENERAL":                  general,
 	"GENERATED":                generated,
+	"GEOMCOLLECTION":           geomCollection,
+	"GEOMETRY":                 geometry,
+	"GEOMETRYCOLLECTION":       geometryCollection,
 	"GET_FORMAT":               getFormat,
 	"GLOBAL":                   global,
 	"GRANT":                    grant,
 	"GRANTS":                   grants,
 	"GROUP_CONCAT":             groupConcat,
 	"GROUP":                    group,
+	"HANDLER":                  handler,
 	"HASH":                     hash,
 	"HAVING":                   having,
+	"HELP":                     help,
 	"HIGH_PRIORITY":            highPriority,
 	"HISTORY":                  history,
 	"HISTOGRAM":                histogram,
@@ -373,9 +400,11 @@ var tokenMap = map[string]int{
 	"INDEXES":                  indexes,
 	"INFILE":                   infile,
 	"INNER":                    inner,
+	"INOUT":                    inout,
 	"INPLACE":                  inplace,
 	"INSERT_METHOD":            insertMethod,
 	"INSERT":                   insert,
+	"INSTALL":                  install,
 	"INSTANCE":                 instance,
 	"INSTANT":                  instant,
 	"INT":                      intType,
@@ -392,16 +421,19 @@ var tokenMap = map[string]int{
 	"INVISIBLE":                invisible,
 	"INVOKER":                  invoker,
 	"IO":                       io,
+	"IO_THREAD":                ioThread,
 	"IPC":                      ipc,
 	"IS":                       is,
 	"ISOLATION":                isolation,
 	"ISSUER":                   issuer,
+	"ITERATE":                  iterate,
 	"JOB":                      job,
 	"JOBS":                     jobs,
 	"JOIN":                     join,
 	"JSON_ARRAYAGG":            jsonArrayagg,
 	"JSON_OBJECTAGG":           jsonObjectAgg,
 	"JSON":                     jsonType,
+	"JSON_TABLE":               jsonTable,
 	"KEY_BLOCK_SIZE":           keyBlockSize,
 	"KEY":                      key,
 	"KEYS":                     keys,
@@ -411,9 +443,11 @@ var tokenMap = map[string]int{
 	"LAST_BACKUP":              lastBackup,
 	"LAST":                     last,
 	"LASTVAL":                  lastval,
+	"LATERAL":                  lateral,
 	"LEADER":                   leader,
 	"LEADING":                  leading,
 	"LEARNER":                  learner,
+	"LEAVE":                    leave,
 	"LEFT":                     left,
 	"LESS":                     less,
 	"LEVEL":                    level,
@@ -421,6 +455,7 @@ var tokenMap = map[string]int{
 	"LIMIT":                    limit,
 	"LINEAR":                   linear,
 	"LINES":                    lines,
+	"LINESTRING":               lineString,
 	"LIST":                     list,
 	"LOAD":                     load,
 	"LOCAL":                    local,
@@ -433,6 +468,7 @@ var tokenMap = map[string]int{
 	"LONG":                     long,
 	"LONGBLOB":                 longblobType,
 	"LONGTEXT":                 longtextType,
+	"LOOP":                     loop,
 	"LOW_PRIORITY":             lowPriority,
 	"MASTER":                   master,
 	"MATCH":                    match,
@@ -452,6 +488,7 @@ var tokenMap = map[string]int{
 	"MEMORY":                   memory,
 	"MERGE":                    merge,
 	"MICROSECOND":              microsecond,
+	"MIGRATE":                  migrate,
 	"MIN_ROWS":                 minRows,
 	"MIN":                      min,
 	"MINUTE_MICROSECOND":       minuteMicrosecond,
@@ -460,12 +497,17 @@ var tokenMap = map[string]int{
 	"MINVALUE":                 minValue,
 	"MOD":                      mod,
 	"MODE":                     mode,
+	"MODIFIES":                 modifies,
 	"MODIFY":                   modify,
 	"MONTH":                    month,
+	"MULTILINESTRING":          multiLineString,
+	"MULTIPOINT":               multiPoint,
+	"MULTIPOLYGON":             multiPolygon,
 	"NAMES":                    names,
 	"NATIONAL":                 national,
 	"NATURAL":                  natural,
 	"NCHAR":                    ncharType,
+	"NESTED":                   nested,
 	"NEVER":                    never,
 	"NEXT_ROW_ID":              next_row_id,
 	"NEXT":                     next,
@@ -487,8 +529,10 @@ var tokenMap = map[string]int{
 	"NULLS":                    nulls,
 	"NUMERIC":                  numericType,
 	"NVARCHAR":                 nvarcharType,
+	"OF":                       of,
 	"OFF":                      off,
 	"OFFSET":                   offset,
+	"ONE":                      one,
 	"ON_DUPLICATE":             onDuplicate,
 	"ON":                       on,
 	"ONLINE":                   online,
@@ -502,6 +546,8 @@ var tokenMap = map[string]int{
 	"OPTIONALLY":               optionally,
 	"OR":                       or,
 	"ORDER":                    order,
+	"ORDINALITY":               ordinality,
+	"OUT":                      out,
 	"OUTER":                    outer,
 	"OUTFILE":                  outfile,
 	"PACK_KEYS":                packKeys,
@@ -512,14 +558,22 @@ var tokenMap = map[string]int{
 	"PARTITIONING":             partitioning,
 	"PARTITIONS":               partitions,
 	"PASSWORD":                 password,
+	"PATH":                     path,
 	"PERCENT":                  percent,
 	"PER_DB":                   per_db,
 	"PER_TABLE":                per_table,
 	"PESSIMISTIC":              pessimistic,
+	"PHASE":                    phase,
 	"PLACEMENT":                placement,
+	"PLUGIN":                   plugin,
 	"PLUGINS":                  plugins,
+	"PLUGIN_DIR":               pluginDir,
+	"POINT":                    point,
 	"POLICY":                   policy,
+	"POLYGON":                  polygon,
 	"POSITION":                 position,
+	"PRECEDES":                 precedes,
+	"PRESERVE":                 preserve,
 	"PRE_SPLIT_REGIONS":        preSplitRegions,
 	"PRECEDING":                preceding,
 	"PRECISION":                precisionType,
@@ -541,10 +595,12 @@ var tokenMap = map[string]int{
 	"RANGE":                    rangeKwd,
 	"RATE_LIMIT":               rateLimit,
 	"READ":                     read,
+	"READS":                    reads,
 	"REAL":                     realType,
 	"REBUILD":                  rebuild,
 	"RECENT":                   recent,
 	"RECOVER":                  recover,
+	"RECURSIVE":                recursive,
 	"REDUNDANT":                redundant,
 	"REFERENCES":               references,
 	"REGEXP":                   regexpKwd,
@@ -565,11 +621,15 @@ var tokenMap = map[string]int{
 	"REQUIRE":                  require,
 	"REQUIRED":                 required,
 	"RESET":                    reset,
+	"RESIGNAL":                 resignal,
+	"RESOURCE":                 resource,
 	"RESPECT":                  respect,
 	"RESTART":                  restart,
 	"RESTORE":                  restore,
 	"RESTORES":                 restores,
 	"RESTRICT":                 restrict,
+	"RETURN":                   returnKwd,
+	"RETURNS":                  returns,
 	"REVERSE":                  reverse,
 	"REVOKE":                   revoke,
 	"RIGHT":                    right,
@@ -586,6 +646,8 @@ var tokenMap = map[string]int{
 	"S3":                       s3,
 	"SAMPLES":                  samples,
 	"SAN":                      san,
+	"SAVEPOINT":                savepoint,
+	"SCHEDULE":                 schedule,
 	"SCHEMA":                   database,
 	"SCHEMAS":                  databases,
 	"SECOND_MICROSECOND":       secondMicrosecond,
@@ -608,6 +670,7 @@ var tokenMap = map[string]int{
 	"SHARED":                   shared,
 	"SHOW":                     show,
 	"SHUTDOWN":                 shutdown,
+	"SIGNAL":                   signal,
 	"SIGNED":                   signed,
 	"SIMPLE":                   simple,
 	"SKIP":                     skip,
@@ -617,15 +680,20 @@ var tokenMap = map[string]int{
 	"SMALLINT":                 smallIntType,
 	"SNAPSHOT":                 snapshot,
 	"SOME":                     some,
+	"SONAME":                   soname,
 	"SOURCE":                   source,
 	"SPATIAL":                  spatial,
 	"SPLIT":                    split,
+	"SQLEXCEPTION":             sqlexception,
+	"SQLSTATE":                 sqlstate,
+	"SQLWARNING":               sqlwarning,
 	"SQL_BIG_RESULT":           sqlBigResult,
 	"SQL_BUFFER_RESULT":        sqlBufferResult,
 	"SQL_CACHE":                sqlCache,
 	"SQL_CALC_FOUND_ROWS":      sqlCalcFoundRows,
 	"SQL_NO_CACHE":             sqlNoCache,
 	"SQL_SMALL_RESULT":         sqlSmallResult,
+	"SQL_THREAD":               sqlThread,
 	"SQL_TSI_DAY":              sqlTsiDay,
 	"SQL_TSI_HOUR":             sqlTsiHour,
 	"SQL_TSI_MINUTE":           sqlTsiMinute,
@@ -635,10 +703,12 @@ var tokenMap = map[string]int{
 	"SQL_TSI_WEEK":             sqlTsiWeek,
 	"SQL_TSI_YEAR":             sqlTsiYear,
 	"SQL":                      sql,
+	"SRID":                     srid,
 	"SSL":                      ssl,
 	"STALENESS":                staleness,
 	"START":                    start,
 	"STARTING":                 starting,
+	"STARTS":                   starts,
 	"STATISTICS":               statistics,
 	"STATS_AUTO_RECALC":        statsAutoRecalc,
 	"STATS_BUCKETS":            statsBuckets,
@@ -654,6 +724,7 @@ var tokenMap = map[string]int{
 	"STDDEV_POP":               stddevPop,
 	"STDDEV_SAMP":              stddevSamp,
 	"STDDEV":                   stddevPop,
+	"STOP":                     stop,
 	"STORAGE":                  storage,
 	"STORED":                   stored,
 	"STRAIGHT_JOIN":            straightJoin,
@@ -667,6 +738,7 @@ var tokenMap = map[string]int{
 	"SUBSTRING":                substring,
 	"SUM":                      sum,
 	"SUPER":                    super,
+	"SUSPEND":                  suspend,
 	"SWAPS":                    swaps,
 	"SWITCHES":                 switchesSym,
 	"SYSTEM":                   system,
@@ -684,6 +756,7 @@ var tokenMap = map[string]int{
 	"TEXT":                     textType,
 	"THAN":                     than,
 	"THEN":                     then,
+	"THREAD_PRIORITY":          threadPriority,
 	"TIDB":                     tidb,
 	"TIDB_STATS":               tidbStats,
 	"TIFLASH":                  tiFlash,
@@ -720,12 +793,15 @@ var tokenMap = map[string]int{
 	"UNBOUNDED":                unbounded,
 	"UNCOMMITTED":              uncommitted,
 	"UNDEFINED":                undefined,
+	"UNDO":                     undo,
 	"UNICODE":                  unicodeSym,
+	"UNINSTALL":                uninstall,
 	"UNION":                    union,
 	"UNIQUE":                   unique,
 	"UNKNOWN":                  unknown,
 	"UNLOCK":                   unlock,
 	"UNSIGNED":                 unsigned,
+	"UNTIL":                    until,
 	"UPDATE":                   update,
 	"USAGE":                    usage,
 	"USE":                      use,
@@ -745,6 +821,7 @@ var tokenMap = map[string]int{
 	"VARIABLES":                variables,
 	"VARIANCE":                 varPop,
 	"VARYING":                  varying,
+	"VCPU":                     vcpu,
 	"VOTER":                    voter,
 	"VIEW":                     view,
 	"VIRTUAL":                  virtual,
@@ -754,11 +831,15 @@ var tokenMap = map[string]int{
 	"WEIGHT_STRING":            weightString,
 	"WHEN":                     when,
 	"WHERE":                    where,
+	"WHILE":                    while,
 	"WIDTH":                    width,
 	"WITH":                     with,
 	"WITHOUT":                  without,
+	"WORK":                     work,
 	"WRITE":                    write,
 	"X509":                     x509,
+	"XA":                       xa,
+	"XID":                      xid,
 	"XOR":                      xor,
 	"YEAR_MONTH":               yearMonth,
 	"YEAR":                     yearType,
@@ -898,6 +979,7 @@ var hintTokenMap = map[string]int{
 	"TIME_RANGE":              hintTimeRange,
 	"USE_CASCADES":            hintUseCascades,
 	"NTH_PLAN":                hintNthPlan,
+	"LEADING":                 hintLeading,
 
 	// TiDB hint aliases
 	"TIDB_HJ":   hintHashJoin,
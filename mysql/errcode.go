@@ -894,6 +894,9 @@ const (
 	ErrGeneratedColumnNonPrior                                      = 3107
 	ErrDependentByGeneratedColumn                                   = 3108
 	ErrGeneratedColumnRefAutoInc                                    = 3109
+	ErrWarnConflictingHint                                          = 3126
+	ErrWarnUnknownQBName                                            = 3127
+	ErrUnresolvedHintName                                           = 3128
 	ErrInvalidJSONText                                              = 3140
 	ErrInvalidJSONPath                                              = 3143
 	ErrInvalidTypeForJSON                                           = 3146
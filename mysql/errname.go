@@ -897,6 +897,9 @@ var MySQLErrName = map[uint16]*ErrMessage{
 	ErrGeneratedColumnNonPrior:                               Message("Generated column can refer only to generated columns defined prior to it.", nil),
 	ErrDependentByGeneratedColumn:                            Message("Column '%s' has a generated column dependency.", nil),
 	ErrGeneratedColumnRefAutoInc:                             Message("Generated column '%s' cannot refer to auto-increment column.", nil),
+	ErrWarnConflictingHint:                                   Message("Hint %s is ignored as conflicting/duplicated", nil),
+	ErrWarnUnknownQBName:                                     Message("Query block name %s is not found for %s hint", nil),
+	ErrUnresolvedHintName:                                    Message("Unresolved name %s for %s hint", nil),
 	ErrInvalidFieldSize:                                      Message("Invalid size for column '%s'.", nil),
 	ErrIncorrectType:                                         Message("Incorrect type for argument %s in function %s.", nil),
 	ErrInvalidJSONData:                                       Message("Invalid JSON data provided to function %s: %s", nil),
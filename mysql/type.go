@@ -47,6 +47,19 @@ const (
 	TypeGeometry   byte = 0xff
 )
 
+// Geometry types. They are the subtypes of TypeGeometry, a column of a
+// subtype other than GeometryTypeGeometry only stores that kind of geometry.
+const (
+	GeometryTypeGeometry byte = iota
+	GeometryTypePoint
+	GeometryTypeLineString
+	GeometryTypePolygon
+	GeometryTypeMultiPoint
+	GeometryTypeMultiLineString
+	GeometryTypeMultiPolygon
+	GeometryTypeGeometryCollection
+)
+
 // Flag information.
 const (
 	NotNullFlag        uint = 1 << 0  /* Field can't be NULL */
@@ -26,6 +26,7 @@
 package parser
 
 import (
+	"math"
 	"strings"
 
 	"github.com/pingcap/parser/mysql"
@@ -41,6 +42,8 @@ import (
 
 %union {
 	offset int // offset
+	startOffset int // offset of the first token
+	endOffset int // offset after the last token
 	item interface{}
 	ident string
 	expr ast.ExprNode
@@ -77,6 +80,7 @@ import (
 	and               "AND"
 	as                "AS"
 	asc               "ASC"
+	before            "BEFORE"
 	between           "BETWEEN"
 	bigIntType        "BIGINT"
 	binaryType        "BINARY"
@@ -91,8 +95,10 @@ import (
 	charType          "CHAR"
 	check             "CHECK"
 	collate           "COLLATE"
+	condition         "CONDITION"
 	column            "COLUMN"
 	constraint        "CONSTRAINT"
+	continueKwd       "CONTINUE"
 	convert           "CONVERT"
 	create            "CREATE"
 	cross             "CROSS"
@@ -102,6 +108,7 @@ import (
 	currentTs         "CURRENT_TIMESTAMP"
 	currentUser       "CURRENT_USER"
 	currentRole       "CURRENT_ROLE"
+	cursor            "CURSOR"
 	database          "DATABASE"
 	databases         "DATABASES"
 	dayHour           "DAY_HOUR"
@@ -109,22 +116,27 @@ import (
 	dayMinute         "DAY_MINUTE"
 	daySecond         "DAY_SECOND"
 	decimalType       "DECIMAL"
+	declare           "DECLARE"
 	defaultKwd        "DEFAULT"
 	delayed           "DELAYED"
 	deleteKwd         "DELETE"
 	denseRank         "DENSE_RANK"
 	desc              "DESC"
 	describe          "DESCRIBE"
+	deterministic     "DETERMINISTIC"
 	distinct          "DISTINCT"
 	distinctRow       "DISTINCTROW"
 	div               "DIV"
 	doubleType        "DOUBLE"
 	drop              "DROP"
 	dual              "DUAL"
+	each              "EACH"
 	elseKwd           "ELSE"
+	elseIfKwd         "ELSEIF"
 	enclosed          "ENCLOSED"
 	escaped           "ESCAPED"
 	exists            "EXISTS"
+	exit              "EXIT"
 	explain           "EXPLAIN"
 	except            "EXCEPT"
 	falseKwd          "FALSE"
@@ -151,12 +163,14 @@ import (
 	index             "INDEX"
 	infile            "INFILE"
 	inner             "INNER"
+	inout             "INOUT"
 	integerType       "INTEGER"
 	intersect         "INTERSECT"
 	interval          "INTERVAL"
 	into              "INTO"
 	outfile           "OUTFILE"
 	is                "IS"
+	iterate           "ITERATE"
 	insert            "INSERT"
 	intType           "INT"
 	int1Type          "INT1"
@@ -165,13 +179,16 @@ import (
 	int4Type          "INT4"
 	int8Type          "INT8"
 	join              "JOIN"
+	jsonTable         "JSON_TABLE"
 	key               "KEY"
 	keys              "KEYS"
 	kill              "KILL"
 	lag               "LAG"
 	lastValue         "LAST_VALUE"
+	lateral           "LATERAL"
 	lead              "LEAD"
 	leading           "LEADING"
+	leave             "LEAVE"
 	left              "LEFT"
 	like              "LIKE"
 	limit             "LIMIT"
@@ -183,6 +200,7 @@ import (
 	lock              "LOCK"
 	longblobType      "LONGBLOB"
 	longtextType      "LONGTEXT"
+	loop              "LOOP"
 	lowPriority       "LOW_PRIORITY"
 	match             "MATCH"
 	maxValue          "MAXVALUE"
@@ -192,12 +210,14 @@ import (
 	minuteMicrosecond "MINUTE_MICROSECOND"
 	minuteSecond      "MINUTE_SECOND"
 	mod               "MOD"
+	modifies          "MODIFIES"
 	not               "NOT"
 	noWriteToBinLog   "NO_WRITE_TO_BINLOG"
 	nthValue          "NTH_VALUE"
 	ntile             "NTILE"
 	null              "NULL"
 	numericType       "NUMERIC"
+	of                "OF"
 	on                "ON"
 	optimize          "OPTIMIZE"
 	option            "OPTION"
@@ -205,6 +225,7 @@ import (
 	or                "OR"
 	order             "ORDER"
 	outer             "OUTER"
+	out               "OUT"
 	over              "OVER"
 	partition         "PARTITION"
 	percentRank       "PERCENT_RANK"
@@ -215,7 +236,9 @@ import (
 	rangeKwd          "RANGE"
 	rank              "RANK"
 	read              "READ"
+	reads             "READS"
 	realType          "REAL"
+	recursive         "RECURSIVE"
 	references        "REFERENCES"
 	regexpKwd         "REGEXP"
 	release           "RELEASE"
@@ -223,7 +246,9 @@ import (
 	repeat            "REPEAT"
 	replace           "REPLACE"
 	require           "REQUIRE"
+	resignal          "RESIGNAL"
 	restrict          "RESTRICT"
+	returnKwd         "RETURN"
 	revoke            "REVOKE"
 	right             "RIGHT"
 	rlike             "RLIKE"
@@ -234,12 +259,16 @@ import (
 	selectKwd         "SELECT"
 	set               "SET"
 	show              "SHOW"
+	signal            "SIGNAL"
 	smallIntType      "SMALLINT"
 	spatial           "SPATIAL"
 	sql               "SQL"
 	sqlBigResult      "SQL_BIG_RESULT"
 	sqlCalcFoundRows  "SQL_CALC_FOUND_ROWS"
+	sqlexception      "SQLEXCEPTION"
 	sqlSmallResult    "SQL_SMALL_RESULT"
+	sqlstate          "SQLSTATE"
+	sqlwarning        "SQLWARNING"
 	ssl               "SSL"
 	starting          "STARTING"
 	straightJoin      "STRAIGHT_JOIN"
@@ -256,6 +285,7 @@ import (
 	trailing          "TRAILING"
 	trigger           "TRIGGER"
 	trueKwd           "TRUE"
+	undo              "UNDO"
 	unique            "UNIQUE"
 	union             "UNION"
 	unlock            "UNLOCK"
@@ -276,6 +306,7 @@ import (
 	virtual           "VIRTUAL"
 	when              "WHEN"
 	where             "WHERE"
+	while             "WHILE"
 	write             "WRITE"
 	window            "WINDOW"
 	with              "WITH"
@@ -295,6 +326,7 @@ import (
 	always                "ALWAYS"
 	any                   "ANY"
 	ascii                 "ASCII"
+	at                    "AT"
 	autoIdCache           "AUTO_ID_CACHE"
 	autoIncrement         "AUTO_INCREMENT"
 	autoRandom            "AUTO_RANDOM"
@@ -319,21 +351,27 @@ import (
 	capture               "CAPTURE"
 	cascaded              "CASCADED"
 	chain                 "CHAIN"
+	channel               "CHANNEL"
 	charsetKwd            "CHARSET"
 	checkpoint            "CHECKPOINT"
 	checksum              "CHECKSUM"
 	cipher                "CIPHER"
 	cleanup               "CLEANUP"
 	client                "CLIENT"
+	clone                 "CLONE"
+	close                 "CLOSE"
 	coalesce              "COALESCE"
 	collation             "COLLATION"
 	columnFormat          "COLUMN_FORMAT"
 	columns               "COLUMNS"
+	component             "COMPONENT"
 	config                "CONFIG"
 	comment               "COMMENT"
 	commit                "COMMIT"
 	committed             "COMMITTED"
 	compact               "COMPACT"
+	completion            "COMPLETION"
+	contains              "CONTAINS"
 	compressed            "COMPRESSED"
 	compression           "COMPRESSION"
 	concurrency           "CONCURRENCY"
@@ -356,6 +394,7 @@ import (
 	dateType              "DATE"
 	day                   "DAY"
 	deallocate            "DEALLOCATE"
+	defaultAuth           "DEFAULT_AUTH"
 	definer               "DEFINER"
 	delayKeyWrite         "DELAY_KEY_WRITE"
 	directory             "DIRECTORY"
@@ -365,9 +404,11 @@ import (
 	do                    "DO"
 	duplicate             "DUPLICATE"
 	dynamic               "DYNAMIC"
+	empty                 "EMPTY"
 	enable                "ENABLE"
 	encryption            "ENCRYPTION"
 	end                   "END"
+	ends                  "ENDS"
 	enforced              "ENFORCED"
 	engine                "ENGINE"
 	engines               "ENGINES"
@@ -376,6 +417,7 @@ import (
 	escape                "ESCAPE"
 	event                 "EVENT"
 	events                "EVENTS"
+	every                 "EVERY"
 	evolve                "EVOLVE"
 	exchange              "EXCHANGE"
 	exclusive             "EXCLUSIVE"
@@ -389,14 +431,21 @@ import (
 	first                 "FIRST"
 	fixed                 "FIXED"
 	flush                 "FLUSH"
+	follows               "FOLLOWS"
 	following             "FOLLOWING"
 	format                "FORMAT"
+	found                 "FOUND"
 	full                  "FULL"
 	function              "FUNCTION"
 	general               "GENERAL"
+	geomCollection        "GEOMCOLLECTION"
+	geometry              "GEOMETRY"
+	geometryCollection    "GEOMETRYCOLLECTION"
 	global                "GLOBAL"
 	grants                "GRANTS"
 	hash                  "HASH"
+	handler               "HANDLER"
+	help                  "HELP"
 	histogram             "HISTOGRAM"
 	history               "HISTORY"
 	hosts                 "HOSTS"
@@ -409,10 +458,12 @@ import (
 	incremental           "INCREMENTAL"
 	indexes               "INDEXES"
 	insertMethod          "INSERT_METHOD"
+	install               "INSTALL"
 	instance              "INSTANCE"
 	invisible             "INVISIBLE"
 	invoker               "INVOKER"
 	io                    "IO"
+	ioThread              "IO_THREAD"
 	ipc                   "IPC"
 	isolation             "ISOLATION"
 	issuer                "ISSUER"
@@ -425,6 +476,7 @@ import (
 	lastval               "LASTVAL"
 	less                  "LESS"
 	level                 "LEVEL"
+	lineString            "LINESTRING"
 	list                  "LIST"
 	local                 "LOCAL"
 	locked                "LOCKED"
@@ -442,15 +494,20 @@ import (
 	memory                "MEMORY"
 	merge                 "MERGE"
 	microsecond           "MICROSECOND"
+	migrate               "MIGRATE"
 	minRows               "MIN_ROWS"
 	minute                "MINUTE"
 	minValue              "MINVALUE"
 	mode                  "MODE"
 	modify                "MODIFY"
 	month                 "MONTH"
+	multiLineString       "MULTILINESTRING"
+	multiPoint            "MULTIPOINT"
+	multiPolygon          "MULTIPOLYGON"
 	names                 "NAMES"
 	national              "NATIONAL"
 	ncharType             "NCHAR"
+	nested                "NESTED"
 	never                 "NEVER"
 	next                  "NEXT"
 	nextval               "NEXTVAL"
@@ -466,11 +523,13 @@ import (
 	nulls                 "NULLS"
 	off                   "OFF"
 	offset                "OFFSET"
+	one                   "ONE"
 	onDuplicate           "ON_DUPLICATE"
 	online                "ONLINE"
 	only                  "ONLY"
 	open                  "OPEN"
 	optional              "OPTIONAL"
+	ordinality            "ORDINALITY"
 	packKeys              "PACK_KEYS"
 	pageSym               "PAGE"
 	parser                "PARSER"
@@ -478,15 +537,23 @@ import (
 	partitioning          "PARTITIONING"
 	partitions            "PARTITIONS"
 	password              "PASSWORD"
+	path                  "PATH"
 	percent               "PERCENT"
 	per_db                "PER_DB"
 	per_table             "PER_TABLE"
 	pipesAsOr
+	phase                 "PHASE"
+	plugin                "PLUGIN"
 	plugins               "PLUGINS"
+	pluginDir             "PLUGIN_DIR"
+	point                 "POINT"
 	policy                "POLICY"
+	polygon               "POLYGON"
 	preSplitRegions       "PRE_SPLIT_REGIONS"
 	preceding             "PRECEDING"
+	precedes              "PRECEDES"
 	prepare               "PREPARE"
+	preserve              "PRESERVE"
 	privileges            "PRIVILEGES"
 	process               "PROCESS"
 	processlist           "PROCESSLIST"
@@ -511,12 +578,14 @@ import (
 	replicas              "REPLICAS"
 	replication           "REPLICATION"
 	required              "REQUIRED"
+	resource              "RESOURCE"
 	respect               "RESPECT"
 	restart               "RESTART"
 	restore               "RESTORE"
 	restores              "RESTORES"
 	resume                "RESUME"
 	reverse               "REVERSE"
+	returns               "RETURNS"
 	role                  "ROLE"
 	rollback              "ROLLBACK"
 	routine               "ROUTINE"
@@ -524,6 +593,8 @@ import (
 	rowFormat             "ROW_FORMAT"
 	rtree                 "RTREE"
 	san                   "SAN"
+	savepoint             "SAVEPOINT"
+	schedule              "SCHEDULE"
 	second                "SECOND"
 	secondaryEngine       "SECONDARY_ENGINE"
 	secondaryLoad         "SECONDARY_LOAD"
@@ -548,10 +619,12 @@ import (
 	slow                  "SLOW"
 	snapshot              "SNAPSHOT"
 	some                  "SOME"
+	soname                "SONAME"
 	source                "SOURCE"
 	sqlBufferResult       "SQL_BUFFER_RESULT"
 	sqlCache              "SQL_CACHE"
 	sqlNoCache            "SQL_NO_CACHE"
+	sqlThread             "SQL_THREAD"
 	sqlTsiDay             "SQL_TSI_DAY"
 	sqlTsiHour            "SQL_TSI_HOUR"
 	sqlTsiMinute          "SQL_TSI_MINUTE"
@@ -560,17 +633,21 @@ import (
 	sqlTsiSecond          "SQL_TSI_SECOND"
 	sqlTsiWeek            "SQL_TSI_WEEK"
 	sqlTsiYear            "SQL_TSI_YEAR"
+	srid                  "SRID"
 	start                 "START"
+	starts                "STARTS"
 	statsAutoRecalc       "STATS_AUTO_RECALC"
 	statsPersistent       "STATS_PERSISTENT"
 	statsSamplePages      "STATS_SAMPLE_PAGES"
 	status                "STATUS"
+	stop                  "STOP"
 	storage               "STORAGE"
 	strictFormat          "STRICT_FORMAT"
 	subject               "SUBJECT"
 	subpartition          "SUBPARTITION"
 	subpartitions         "SUBPARTITIONS"
 	super                 "SUPER"
+	suspend               "SUSPEND"
 	swaps                 "SWAPS"
 	switchesSym           "SWITCHES"
 	system                "SYSTEM"
@@ -582,6 +659,7 @@ import (
 	temptable             "TEMPTABLE"
 	textType              "TEXT"
 	than                  "THAN"
+	threadPriority        "THREAD_PRIORITY"
 	tikvImporter          "TIKV_IMPORTER"
 	timestampType         "TIMESTAMP"
 	timeType              "TIME"
@@ -595,18 +673,24 @@ import (
 	uncommitted           "UNCOMMITTED"
 	undefined             "UNDEFINED"
 	unicodeSym            "UNICODE"
+	uninstall             "UNINSTALL"
 	unknown               "UNKNOWN"
+	until                 "UNTIL"
 	user                  "USER"
 	validation            "VALIDATION"
 	value                 "VALUE"
 	variables             "VARIABLES"
+	vcpu                  "VCPU"
 	view                  "VIEW"
 	visible               "VISIBLE"
 	warnings              "WARNINGS"
 	week                  "WEEK"
 	weightString          "WEIGHT_STRING"
 	without               "WITHOUT"
+	work                  "WORK"
 	x509                  "X509"
+	xa                    "XA"
+	xid                   "XID"
 	yearType              "YEAR"
 	wait                  "WAIT"
 
@@ -772,6 +856,8 @@ import (
 %type	<expr>
 	Expression             "expression"
 	MaxValueOrExpression   "maxvalue or expression"
+	EventStartsOpt         "Optional STARTS clause of an event schedule"
+	EventEndsOpt           "Optional ENDS clause of an event schedule"
 	BoolPri                "boolean primary expression"
 	ExprOrDefault          "expression or default"
 	PredicateExpr          "Predicate expression factor"
@@ -802,85 +888,124 @@ import (
 	ProcedureCall          "Procedure call with Identifier or identifier"
 
 %type	<statement>
-	AdminStmt              "Check table statement or show ddl statement"
-	AlterDatabaseStmt      "Alter database statement"
-	AlterTableStmt         "Alter table statement"
-	AlterUserStmt          "Alter user statement"
-	AlterInstanceStmt      "Alter instance statement"
-	AlterSequenceStmt      "Alter sequence statement"
-	AnalyzeTableStmt       "Analyze table statement"
-	BeginTransactionStmt   "BEGIN TRANSACTION statement"
-	BinlogStmt             "Binlog base64 statement"
-	BRIEStmt               "BACKUP or RESTORE statement"
-	CommitStmt             "COMMIT statement"
-	CreateTableStmt        "CREATE TABLE statement"
-	CreateViewStmt         "CREATE VIEW  statement"
-	CreateUserStmt         "CREATE User statement"
-	CreateRoleStmt         "CREATE Role statement"
-	CreateDatabaseStmt     "Create Database Statement"
-	CreateIndexStmt        "CREATE INDEX statement"
-	CreateBindingStmt      "CREATE BINDING  statement"
-	CreateSequenceStmt     "CREATE SEQUENCE statement"
-	CreateStatisticsStmt   "CREATE STATISTICS statement"
-	DoStmt                 "Do statement"
-	DropDatabaseStmt       "DROP DATABASE statement"
-	DropIndexStmt          "DROP INDEX statement"
-	DropStatisticsStmt     "DROP STATISTICS statement"
-	DropStatsStmt          "DROP STATS statement"
-	DropTableStmt          "DROP TABLE statement"
-	DropSequenceStmt       "DROP SEQUENCE statement"
-	DropUserStmt           "DROP USER"
-	DropRoleStmt           "DROP ROLE"
-	DropViewStmt           "DROP VIEW statement"
-	DropBindingStmt        "DROP BINDING  statement"
-	DeallocateStmt         "Deallocate prepared statement"
-	DeleteFromStmt         "DELETE FROM statement"
-	DeleteWithoutUsingStmt "Normal DELETE statement"
-	DeleteWithUsingStmt    "DELETE USING statement"
-	EmptyStmt              "empty statement"
-	ExecuteStmt            "Execute statement"
-	ExplainStmt            "EXPLAIN statement"
-	ExplainableStmt        "explainable statement"
-	FlushStmt              "Flush statement"
-	FlashbackTableStmt     "Flashback table statement"
-	GrantStmt              "Grant statement"
-	GrantProxyStmt         "Grant proxy statement"
-	GrantRoleStmt          "Grant role statement"
-	InsertIntoStmt         "INSERT INTO statement"
-	CallStmt               "CALL statement"
-	IndexAdviseStmt        "INDEX ADVISE statement"
-	KillStmt               "Kill statement"
-	LoadDataStmt           "Load data statement"
-	LoadStatsStmt          "Load statistic statement"
-	LockTablesStmt         "Lock tables statement"
-	PreparedStmt           "PreparedStmt"
-	PurgeImportStmt        "PURGE IMPORT statement that removes a IMPORT task record"
-	SelectStmt             "SELECT statement"
-	RenameTableStmt        "rename table statement"
-	ReplaceIntoStmt        "REPLACE INTO statement"
-	RecoverTableStmt       "recover table statement"
-	RevokeStmt             "Revoke statement"
-	RevokeRoleStmt         "Revoke role statement"
-	RollbackStmt           "ROLLBACK statement"
-	SplitRegionStmt        "Split index region statement"
-	SetStmt                "Set variable statement"
-	ChangeStmt             "Change statement"
-	SetRoleStmt            "Set active role statement"
-	SetDefaultRoleStmt     "Set default statement for some user"
-	ShowStmt               "Show engines/databases/tables/user/columns/warnings/status statement"
-	Statement              "statement"
-	TraceStmt              "TRACE statement"
-	TraceableStmt          "traceable statement"
-	TruncateTableStmt      "TRUNCATE TABLE statement"
-	UnlockTablesStmt       "Unlock tables statement"
-	UpdateStmt             "UPDATE statement"
-	SetOprStmt             "Union/Except/Intersect select statement"
-	SetOprStmt1            "Union/Except/Intersect select statement1"
-	SetOprStmt2            "Union/Except/Intersect select statement2"
-	UseStmt                "USE statement"
-	ShutdownStmt           "SHUTDOWN statement"
-	CreateViewSelectOpt    "Select/Union/Except/Intersect statement in CREATE VIEW ... AS SELECT"
-	BindableStmt           "Statement that can be created binding on"
+	AdminStmt                   "Check table statement or show ddl statement"
+	AlterDatabaseStmt           "Alter database statement"
+	AlterTableStmt              "Alter table statement"
+	AlterUserStmt               "Alter user statement"
+	AlterInstanceStmt           "Alter instance statement"
+	AlterSequenceStmt           "Alter sequence statement"
+	AlterRoutineStmt            "ALTER PROCEDURE/FUNCTION statement"
+	AlterEventStmt              "ALTER EVENT statement"
+	AnalyzeTableStmt            "Analyze table statement"
+	BeginTransactionStmt        "BEGIN TRANSACTION statement"
+	StartTransactionStmt        "START TRANSACTION statement"
+	BinlogStmt                  "Binlog base64 statement"
+	BRIEStmt                    "BACKUP or RESTORE statement"
+	CommitStmt                  "COMMIT statement"
+	CreateTableStmt             "CREATE TABLE statement"
+	CreateViewStmt              "CREATE VIEW  statement"
+	CreateRoutineStmt           "CREATE PROCEDURE/FUNCTION statement"
+	CreateTriggerStmt           "CREATE TRIGGER statement"
+	CreateEventStmt             "CREATE EVENT statement"
+	CreateUserStmt              "CREATE User statement"
+	CreateRoleStmt              "CREATE Role statement"
+	CreateDatabaseStmt          "Create Database Statement"
+	CreateIndexStmt             "CREATE INDEX statement"
+	CreateBindingStmt           "CREATE BINDING  statement"
+	CreateSequenceStmt          "CREATE SEQUENCE statement"
+	CreateStatisticsStmt        "CREATE STATISTICS statement"
+	DoStmt                      "Do statement"
+	DropDatabaseStmt            "DROP DATABASE statement"
+	DropIndexStmt               "DROP INDEX statement"
+	DropStatisticsStmt          "DROP STATISTICS statement"
+	DropStatsStmt               "DROP STATS statement"
+	DropTableStmt               "DROP TABLE statement"
+	DropSequenceStmt            "DROP SEQUENCE statement"
+	DropUserStmt                "DROP USER"
+	DropRoleStmt                "DROP ROLE"
+	DropViewStmt                "DROP VIEW statement"
+	DropRoutineStmt             "DROP PROCEDURE/FUNCTION statement"
+	DropTriggerStmt             "DROP TRIGGER statement"
+	DropEventStmt               "DROP EVENT statement"
+	DropBindingStmt             "DROP BINDING  statement"
+	DeallocateStmt              "Deallocate prepared statement"
+	DeleteFromStmt              "DELETE FROM statement"
+	DeleteWithoutUsingStmt      "Normal DELETE statement"
+	DeleteWithUsingStmt         "DELETE USING statement"
+	EmptyStmt                   "empty statement"
+	ExecuteStmt                 "Execute statement"
+	ExplainStmt                 "EXPLAIN statement"
+	ExplainableStmt             "explainable statement"
+	FlushStmt                   "Flush statement"
+	FlashbackTableStmt          "Flashback table statement"
+	GrantStmt                   "Grant statement"
+	GrantProxyStmt              "Grant proxy statement"
+	GrantRoleStmt               "Grant role statement"
+	InsertIntoStmt              "INSERT INTO statement"
+	CallStmt                    "CALL statement"
+	IndexAdviseStmt             "INDEX ADVISE statement"
+	KillStmt                    "Kill statement"
+	LoadDataStmt                "Load data statement"
+	LoadStatsStmt               "Load statistic statement"
+	LockTablesStmt              "Lock tables statement"
+	PreparedStmt                "PreparedStmt"
+	ProcedureProcStmt           "statement of a stored program"
+	ProcedureStatementStmt      "SQL statement allowed in a stored program"
+	ProcedureLabelableStmt      "BEGIN/LOOP/WHILE/REPEAT statement of a stored program"
+	EventBodyOpt                "Optional DO clause of ALTER EVENT"
+	ProcedureIfStmt             "IF statement of a stored program"
+	ProcedureCaseStmt           "CASE statement of a stored program"
+	ProcedureSignalStmt         "SIGNAL/RESIGNAL statement"
+	ProcedureDecl               "DECLARE statement of a stored program"
+	PurgeImportStmt             "PURGE IMPORT statement that removes a IMPORT task record"
+	SelectStmt                  "SELECT statement"
+	RenameTableStmt             "rename table statement"
+	ReplaceIntoStmt             "REPLACE INTO statement"
+	RecoverTableStmt            "recover table statement"
+	RevokeStmt                  "Revoke statement"
+	RevokeRoleStmt              "Revoke role statement"
+	RollbackStmt                "ROLLBACK statement"
+	SavepointStmt               "SAVEPOINT statement"
+	ReleaseSavepointStmt        "RELEASE SAVEPOINT statement"
+	SplitRegionStmt             "Split index region statement"
+	SetStmt                     "Set variable statement"
+	ChangeStmt                  "Change statement"
+	SetRoleStmt                 "Set active role statement"
+	SetDefaultRoleStmt          "Set default statement for some user"
+	ShowStmt                    "Show engines/databases/tables/user/columns/warnings/status statement"
+	Statement                   "statement"
+	TraceStmt                   "TRACE statement"
+	TraceableStmt               "traceable statement"
+	TruncateTableStmt           "TRUNCATE TABLE statement"
+	UnlockTablesStmt            "Unlock tables statement"
+	UpdateStmt                  "UPDATE statement"
+	SetOprStmt                  "Union/Except/Intersect select statement"
+	SetOprStmt1                 "Union/Except/Intersect select statement1"
+	SetOprStmt2                 "Union/Except/Intersect select statement2"
+	SetOprStmtNoWith1           "Union/Except/Intersect select statement1 without WITH clause"
+	SetOprStmtNoWith2           "Union/Except/Intersect select statement2 without WITH clause"
+	UpdateStmtNoWith            "UPDATE statement without WITH clause"
+	DeleteFromStmtNoWith        "DELETE FROM statement without WITH clause"
+	UseStmt                     "USE statement"
+	ShutdownStmt                "SHUTDOWN statement"
+	XAStmt                      "XA transaction statement"
+	AlterResourceGroupStmt      "ALTER RESOURCE GROUP statement"
+	ChangeReplicationSourceStmt "CHANGE REPLICATION SOURCE statement"
+	CloneStmt                   "CLONE statement"
+	CreateResourceGroupStmt     "CREATE RESOURCE GROUP statement"
+	DropResourceGroupStmt       "DROP RESOURCE GROUP statement"
+	HelpStmt                    "HELP statement"
+	InstallComponentStmt        "INSTALL COMPONENT statement"
+	InstallPluginStmt           "INSTALL PLUGIN statement"
+	PurgeBinaryLogsStmt         "PURGE BINARY LOGS statement"
+	ResetMasterStmt             "RESET MASTER statement"
+	ResetReplicaStmt            "RESET REPLICA statement"
+	StartReplicaStmt            "START REPLICA statement"
+	StopReplicaStmt             "STOP REPLICA statement"
+	UninstallComponentStmt      "UNINSTALL COMPONENT statement"
+	UninstallPluginStmt         "UNINSTALL PLUGIN statement"
+	CreateViewSelectOpt         "Select/Union/Except/Intersect statement in CREATE VIEW ... AS SELECT"
+	BindableStmt                "Statement that can be created binding on"
 
 %type	<item>
 	AdminShowSlow                          "Admin Show Slow statement"
@@ -916,6 +1041,7 @@ import (
 	ColumnNameOrUserVarListOptWithBrackets "column name or user variable list opt with brackets"
 	ColumnSetValue                         "insert statement set value by column name"
 	ColumnSetValueList                     "insert statement set value by column name list"
+	CommonTableExpr                        "Common table expression"
 	CompareOp                              "Compare opcode"
 	ColumnOption                           "column definition option"
 	ColumnOptionList                       "column definition option list"
@@ -1042,6 +1168,39 @@ import (
 	Priority                               "Statement priority"
 	PriorityOpt                            "Statement priority option"
 	PrivElem                               "Privilege element"
+	ProcedureParam                         "Parameter of a stored procedure"
+	ProcedureParamList                     "Parameter list of a stored procedure"
+	ProcedureParamListOpt                  "Optional parameter list of a stored procedure"
+	ProcedureParamModeOpt                  "Optional IN/OUT/INOUT parameter mode"
+	FunctionParam                          "Parameter of a stored function"
+	FunctionParamList                      "Parameter list of a stored function"
+	FunctionParamListOpt                   "Optional parameter list of a stored function"
+	RoutineCharacteristic                  "Characteristic of a stored routine"
+	RoutineCharacteristicListOpt           "Optional characteristic list of a stored routine"
+	ProcedureProcStmtList                  "Statement list of a stored program"
+	ProcedureProcStmtListOpt               "Optional statement list of a stored program"
+	ProcedureDeclListOpt                   "Optional declaration list of a BEGIN ... END block"
+	ProcedureHandlerAction                 "CONTINUE/EXIT/UNDO handler action"
+	ProcedureHandlerCondition              "Condition of a handler"
+	ProcedureHandlerConditionList          "Condition list of a handler"
+	ProcedureConditionValue                "Error code or SQLSTATE value"
+	ProcedureSQLState                      "SQLSTATE value"
+	ProcedureElseIfListOpt                 "Optional ELSEIF branches of an IF statement"
+	ProcedureElseOpt                       "Optional ELSE branch of an IF or CASE statement"
+	ProcedureWhen                          "WHEN branch of a CASE statement"
+	ProcedureWhenList                      "WHEN branches of a CASE statement"
+	ProcedureSignalCondition               "Condition raised by a SIGNAL statement"
+	ProcedureSignalItem                    "Condition information item of a SIGNAL statement"
+	ProcedureSignalItemList                "Condition information item list of a SIGNAL statement"
+	ProcedureSignalItemListOpt             "Optional condition information items of a SIGNAL statement"
+	TriggerTime                            "BEFORE/AFTER action time of a trigger"
+	TriggerEvent                           "INSERT/UPDATE/DELETE event of a trigger"
+	TriggerOrderOpt                        "Optional FOLLOWS/PRECEDES clause of a trigger"
+	EventSchedule                          "Schedule of an event"
+	EventCompletionOpt                     "Optional ON COMPLETION clause of an event"
+	EventStatusOpt                         "Optional ENABLE/DISABLE clause of an event"
+	EventScheduleCompletionOpt             "Optional ON SCHEDULE and ON COMPLETION clauses of ALTER EVENT"
+	EventRenameOpt                         "Optional RENAME TO clause of ALTER EVENT"
 	PrivLevel                              "Privilege scope"
 	PrivType                               "Privilege type"
 	ReferDef                               "Reference definition"
@@ -1065,6 +1224,9 @@ import (
 	RowValue                               "Row value"
 	RowStmt                                "Row constructor"
 	SelectLockOpt                          "SELECT lock options"
+	SelectLockList                         "SELECT locking clause list"
+	SelectLock                             "SELECT locking clause"
+	SelectLockTablesOpt                    "Optional OF clause of a locking clause"
 	SelectStmtSQLCache                     "SELECT statement optional SQL_CAHCE/SQL_NO_CACHE"
 	SelectStmtFieldList                    "SELECT statement field list"
 	SelectStmtLimit                        "SELECT statement LIMIT clause"
@@ -1077,6 +1239,9 @@ import (
 	SelectStmtFromTable                    "SELECT statement from table"
 	SelectStmtGroup                        "SELECT statement optional GROUP BY clause"
 	SelectStmtIntoOption                   "SELECT statement into clause"
+	SelectIntoClause                       "INTO clause of SELECT"
+	SelectIntoVarList                      "variable list of SELECT ... INTO"
+	SelectIntoVar                          "variable of SELECT ... INTO"
 	SequenceOption                         "Create sequence option"
 	SequenceOptionList                     "Create sequence option list"
 	SetRoleOpt                             "Set role options"
@@ -1147,6 +1312,7 @@ import (
 	VariableAssignment                     "set variable value"
 	VariableAssignmentList                 "set variable value list"
 	ViewAlgorithm                          "view algorithm"
+	ViewAlgorithmDefiner                   "view algorithm and definer"
 	ViewCheckOption                        "view check option"
 	ViewDefiner                            "view definer"
 	ViewName                               "view name"
@@ -1183,6 +1349,8 @@ import (
 	WindowFrameExtent                      "WINDOW frame extent"
 	WindowFrameStart                       "WINDOW frame start"
 	WindowName                             "WINDOW name"
+	WithClause                             "WITH clause"
+	WithList                               "WITH list of common table expressions"
 	WindowNameOrSpec                       "WINDOW name or spec"
 	WindowSpec                             "WINDOW spec"
 	WindowSpecDetails                      "WINDOW spec details"
@@ -1192,6 +1360,7 @@ import (
 	LikeOrNotOp                            "Like predicate"
 	RegexpOrNotOp                          "Regexp predicate"
 	NumericType                            "Numeric types"
+	SpatialType                            "Spatial types"
 	IntegerType                            "Integer Types types"
 	BooleanType                            "Boolean Types types"
 	FixedPointType                         "Exact value types"
@@ -1241,46 +1410,82 @@ import (
 	PlacementOptions                       "Placement rules options"
 	PlacementSpec                          "Placement rules specification"
 	PlacementSpecList                      "Placement rules specifications"
+	XAIdentifier                           "XA transaction identifier"
+	JSONTableColumns                       "COLUMNS clause of JSON_TABLE"
+	JSONTableColumnList                    "JSON_TABLE column list"
+	JSONTableColumn                        "JSON_TABLE column definition"
+	JSONTableOnResponseOpt                 "Optional ON EMPTY and ON ERROR clauses of a JSON_TABLE column"
+	JSONTableOnResponse                    "NULL, ERROR or DEFAULT value of a JSON_TABLE column"
+	ReplicationSourceOptionList            "CHANGE REPLICATION SOURCE option list"
+	ReplicationSourceOption                "CHANGE REPLICATION SOURCE option"
+	ReplicationOptionValue                 "Value of a replication option"
+	ReplicationIDList                      "Server ID list"
+	SlaveOrReplica                         "SLAVE or REPLICA"
+	ReplicaThreadListOpt                   "Optional replication thread list"
+	ReplicaThreadList                      "Replication thread list"
+	ReplicaThread                          "IO_THREAD or SQL_THREAD"
+	ReplicaUntilOpt                        "Optional UNTIL clause of START REPLICA"
+	ReplicaUntilOptionList                 "UNTIL option list"
+	ReplicaUntilOption                     "UNTIL option"
+	ReplicaConnectionOptionListOpt         "Optional connection options of START REPLICA"
+	ReplicaConnectionOption                "Connection option of START REPLICA"
+	ResetMasterToOpt                       "Optional TO clause of RESET MASTER"
+	BinaryOrMaster                         "BINARY or MASTER"
+	CloneRequireSSLOpt                     "Optional REQUIRE [NO] SSL clause of CLONE"
+	ResourceGroupType                      "SYSTEM or USER"
+	ResourceGroupOptions                   "Resource group options"
+	ResourceGroupVCPUOpt                   "Optional VCPU option"
+	ResourceGroupVCPUList                  "VCPU list"
+	ResourceGroupVCPU                      "CPU or CPU range"
+	ResourceGroupPriorityOpt               "Optional THREAD_PRIORITY option"
+	ResourceGroupStateOpt                  "Optional ENABLE or DISABLE option"
+	ForceOpt                               "Optional FORCE keyword"
 
 %type	<ident>
-	AsOpt             "AS or EmptyString"
-	KeyOrIndex        "{KEY|INDEX}"
-	ColumnKeywordOpt  "Column keyword or empty"
-	PrimaryOpt        "Optional primary keyword"
-	NowSym            "CURRENT_TIMESTAMP/LOCALTIME/LOCALTIMESTAMP"
-	NowSymFunc        "CURRENT_TIMESTAMP/LOCALTIME/LOCALTIMESTAMP/NOW"
-	DefaultKwdOpt     "optional DEFAULT keyword"
-	DatabaseSym       "DATABASE or SCHEMA"
-	ExplainSym        "EXPLAIN or DESCRIBE or DESC"
-	RegexpSym         "REGEXP or RLIKE"
-	IntoOpt           "INTO or EmptyString"
-	ValueSym          "Value or Values"
-	NotSym            "Not token"
-	Char              "{CHAR|CHARACTER}"
-	NChar             "{NCHAR|NATIONAL CHARACTER|NATIONAL CHAR}"
-	Varchar           "{VARCHAR|VARCHARACTER|CHARACTER VARYING|CHAR VARYING}"
-	NVarchar          "{NATIONAL VARCHAR|NATIONAL VARCHARACTER|NVARCHAR|NCHAR VARCHAR|NATIONAL CHARACTER VARYING|NATIONAL CHAR VARYING|NCHAR VARYING}"
-	Year              "{YEAR|SQL_TSI_YEAR}"
-	DeallocateSym     "Deallocate or drop"
-	OuterOpt          "optional OUTER clause"
-	CrossOpt          "Cross join option"
-	TablesTerminalSym "{TABLE|TABLES}"
-	IsolationLevel    "Isolation level"
-	ShowIndexKwd      "Show index/indexs/key keyword"
-	DistinctKwd       "DISTINCT/DISTINCTROW keyword"
-	FromOrIn          "From or In"
-	OptTable          "Optional table keyword"
-	OptInteger        "Optional Integer keyword"
-	CharsetKw         "charset or charater set"
-	CommaOpt          "optional comma"
-	logAnd            "logical and operator"
-	logOr             "logical or operator"
-	LinearOpt         "linear or empty"
-	FieldsOrColumns   "Fields or columns"
-	StorageMedia      "{DISK|MEMORY|DEFAULT}"
-	EncryptionOpt     "Encryption option 'Y' or 'N'"
-	FirstOrNext       "FIRST or NEXT"
-	RowOrRows         "ROW or ROWS"
+	AsOpt                 "AS or EmptyString"
+	KeyOrIndex            "{KEY|INDEX}"
+	ColumnKeywordOpt      "Column keyword or empty"
+	PrimaryOpt            "Optional primary keyword"
+	NowSym                "CURRENT_TIMESTAMP/LOCALTIME/LOCALTIMESTAMP"
+	NowSymFunc            "CURRENT_TIMESTAMP/LOCALTIME/LOCALTIMESTAMP/NOW"
+	DefaultKwdOpt         "optional DEFAULT keyword"
+	DatabaseSym           "DATABASE or SCHEMA"
+	ExplainSym            "EXPLAIN or DESCRIBE or DESC"
+	RegexpSym             "REGEXP or RLIKE"
+	IntoOpt               "INTO or EmptyString"
+	ValueSym              "Value or Values"
+	NotSym                "Not token"
+	Char                  "{CHAR|CHARACTER}"
+	NChar                 "{NCHAR|NATIONAL CHARACTER|NATIONAL CHAR}"
+	Varchar               "{VARCHAR|VARCHARACTER|CHARACTER VARYING|CHAR VARYING}"
+	NVarchar              "{NATIONAL VARCHAR|NATIONAL VARCHARACTER|NVARCHAR|NCHAR VARCHAR|NATIONAL CHARACTER VARYING|NATIONAL CHAR VARYING|NCHAR VARYING}"
+	Year                  "{YEAR|SQL_TSI_YEAR}"
+	DeallocateSym         "Deallocate or drop"
+	OuterOpt              "optional OUTER clause"
+	CrossOpt              "Cross join option"
+	TablesTerminalSym     "{TABLE|TABLES}"
+	IsolationLevel        "Isolation level"
+	ShowIndexKwd          "Show index/indexs/key keyword"
+	DistinctKwd           "DISTINCT/DISTINCTROW keyword"
+	FromOrIn              "From or In"
+	OptTable              "Optional table keyword"
+	OptInteger            "Optional Integer keyword"
+	CharsetKw             "charset or charater set"
+	CommaOpt              "optional comma"
+	logAnd                "logical and operator"
+	logOr                 "logical or operator"
+	LinearOpt             "linear or empty"
+	FieldsOrColumns       "Fields or columns"
+	StorageMedia          "{DISK|MEMORY|DEFAULT}"
+	EncryptionOpt         "Encryption option 'Y' or 'N'"
+	FirstOrNext           "FIRST or NEXT"
+	RowOrRows             "ROW or ROWS"
+	ProcedureEndLabelOpt  "Optional end label of a compound statement"
+	EventCommentOpt       "Optional COMMENT clause of an event"
+	WorkOpt               "Optional WORK keyword"
+	XAStartSym            "START or BEGIN"
+	ChannelOpt            "Optional FOR CHANNEL clause"
+	CloneDataDirectoryOpt "Optional DATA DIRECTORY clause of CLONE"
 
 %type	<ident>
 	ODBCDateTimeType                "ODBC type keywords for date and time literals"
@@ -1350,6 +1555,8 @@ import (
 %precedence order
 %precedence lowerThanFunction
 %precedence function
+%precedence lowerThanInto
+%precedence into
 
 /* A dummy token to force the priority of TableRef production in a join. */
 %left tableRefPriority
@@ -2549,6 +2756,10 @@ BeginTransactionStmt:
 	{
 		$$ = &ast.BeginStmt{}
 	}
+|	"BEGIN" "WORK"
+	{
+		$$ = &ast.BeginStmt{}
+	}
 |	"BEGIN" "PESSIMISTIC"
 	{
 		$$ = &ast.BeginStmt{
@@ -2561,7 +2772,10 @@ BeginTransactionStmt:
 			Mode: ast.Optimistic,
 		}
 	}
-|	"START" "TRANSACTION"
+|	StartTransactionStmt
+
+StartTransactionStmt:
+	"START" "TRANSACTION"
 	{
 		$$ = &ast.BeginStmt{}
 	}
@@ -2733,15 +2947,19 @@ ColumnNameOrUserVarListOptWithBrackets:
 	}
 
 CommitStmt:
-	"COMMIT"
+	"COMMIT" WorkOpt
 	{
 		$$ = &ast.CommitStmt{}
 	}
-|	"COMMIT" CompletionTypeWithinTransaction
+|	"COMMIT" WorkOpt CompletionTypeWithinTransaction
 	{
-		$$ = &ast.CommitStmt{CompletionType: $2.(ast.CompletionType)}
+		$$ = &ast.CommitStmt{CompletionType: $3.(ast.CompletionType)}
 	}
 
+WorkOpt:
+	{}
+|	"WORK"
+
 PrimaryOpt:
 	{}
 |	"PRIMARY"
@@ -2896,6 +3114,15 @@ ColumnOption:
 	{
 		$$ = &ast.ColumnOption{Tp: ast.ColumnOptionAutoRandom, AutoRandomBitLength: $2.(int)}
 	}
+|	"SRID" NUM
+	{
+		srid := getUint64FromNUM($2)
+		if srid > math.MaxUint32 {
+			yylex.AppendError(yylex.Errorf("The SRID %d is out of range", srid))
+			return 1
+		}
+		$$ = &ast.ColumnOption{Tp: ast.ColumnOptionSRID, SRID: uint32(srid)}
+	}
 
 StorageMedia:
 	"DEFAULT"
@@ -2990,6 +3217,19 @@ ConstraintElem:
 		}
 		$$ = c
 	}
+|	"SPATIAL" KeyOrIndexOpt IndexName '(' IndexPartSpecificationList ')' IndexOptionList
+	{
+		c := &ast.Constraint{
+			Tp:           ast.ConstraintSpatial,
+			Keys:         $5.([]*ast.IndexPartSpecification),
+			Name:         $3.(*ast.NullString).String,
+			IsEmptyIndex: $3.(*ast.NullString).Empty,
+		}
+		if $7 != nil {
+			c.Option = $7.(*ast.IndexOption)
+		}
+		$$ = c
+	}
 |	KeyOrIndex IfNotExists IndexNameAndTypeOpt '(' IndexPartSpecificationList ')' IndexOptionList
 	{
 		c := &ast.Constraint{
@@ -3890,24 +4130,28 @@ LikeTableWithOrWithoutParen:
  *          as select Col1,Col2 from table WITH LOCAL CHECK OPTION
  *******************************************************************/
 CreateViewStmt:
-	"CREATE" OrReplace ViewAlgorithm ViewDefiner ViewSQLSecurity "VIEW" ViewName ViewFieldList "AS" CreateViewSelectOpt ViewCheckOption
+	"CREATE" OrReplace ViewAlgorithmDefiner ViewSQLSecurity "VIEW" ViewName ViewFieldList "AS" CreateViewSelectOpt ViewCheckOption
 	{
 		startOffset := parser.startOffset(&yyS[yypt-1])
-		selStmt := $10.(ast.StmtNode)
+		selStmt := $9.(ast.StmtNode)
 		selStmt.SetText(strings.TrimSpace(parser.src[startOffset:]))
+		algorithmDefiner := $3.([]interface{})
 		x := &ast.CreateViewStmt{
 			OrReplace: $2.(bool),
-			ViewName:  $7.(*ast.TableName),
+			ViewName:  $6.(*ast.TableName),
 			Select:    selStmt,
-			Algorithm: $3.(model.ViewAlgorithm),
-			Definer:   $4.(*auth.UserIdentity),
-			Security:  $5.(model.ViewSecurity),
+			Algorithm: algorithmDefiner[0].(model.ViewAlgorithm),
+			Definer:   &auth.UserIdentity{CurrentUser: true},
+			Security:  $4.(model.ViewSecurity),
 		}
-		if $8 != nil {
-			x.Cols = $8.([]model.CIStr)
+		if algorithmDefiner[1] != nil {
+			x.Definer = algorithmDefiner[1].(*auth.UserIdentity)
 		}
-		if $11 != nil {
-			x.CheckOption = $11.(model.ViewCheckOption)
+		if $7 != nil {
+			x.Cols = $7.([]model.CIStr)
+		}
+		if $10 != nil {
+			x.CheckOption = $10.(model.ViewCheckOption)
 			endOffset := parser.startOffset(&yyS[yypt])
 			selStmt.SetText(strings.TrimSpace(parser.src[startOffset:endOffset]))
 		} else {
@@ -3926,12 +4170,21 @@ OrReplace:
 		$$ = true
 	}
 
-ViewAlgorithm:
-	/* EMPTY */
+// ViewAlgorithmDefiner keeps the optional ALGORITHM of a view out of the
+// CREATE [OR REPLACE] [DEFINER = user] prefix it shares with the stored
+// routines, it is a pair of the algorithm and the definer.
+ViewAlgorithmDefiner:
+	ViewDefiner
 	{
-		$$ = model.AlgorithmUndefined
+		$$ = []interface{}{model.AlgorithmUndefined, $1}
+	}
+|	ViewAlgorithm ViewDefiner
+	{
+		$$ = []interface{}{$1, $2}
 	}
-|	"ALGORITHM" "=" "UNDEFINED"
+
+ViewAlgorithm:
+	"ALGORITHM" "=" "UNDEFINED"
 	{
 		$$ = model.AlgorithmUndefined
 	}
@@ -3947,7 +4200,7 @@ ViewAlgorithm:
 ViewDefiner:
 	/* EMPTY */
 	{
-		$$ = &auth.UserIdentity{CurrentUser: true}
+		$$ = nil
 	}
 |	"DEFINER" "=" Username
 	{
@@ -4005,278 +4258,1133 @@ ViewCheckOption:
 		$$ = model.CheckOptionLocal
 	}
 
-/******************************************************************
- * Do statement
- * See https://dev.mysql.com/doc/refman/5.7/en/do.html
- ******************************************************************/
-DoStmt:
-	"DO" ExpressionList
-	{
-		$$ = &ast.DoStmt{
-			Exprs: $2.([]ast.ExprNode),
-		}
-	}
-
 /*******************************************************************
  *
- *  Delete Statement
+ *  Create Procedure/Function Statement
  *
+ *  Example:
+ *      CREATE DEFINER = CURRENT_USER PROCEDURE p(IN a INT, OUT b INT) COMMENT 'double'
+ *      BEGIN
+ *          SET b = a * 2;
+ *      END
+ *
+ *  See https://dev.mysql.com/doc/refman/8.0/en/create-procedure.html
  *******************************************************************/
-DeleteWithoutUsingStmt:
-	"DELETE" TableOptimizerHintsOpt PriorityOpt QuickOptional IgnoreOptional "FROM" TableName PartitionNameListOpt TableAsNameOpt IndexHintListOpt WhereClauseOptional OrderByOptional LimitClause
-	{
-		// Single Table
-		tn := $7.(*ast.TableName)
-		tn.IndexHints = $10.([]*ast.IndexHint)
-		tn.PartitionNames = $8.([]model.CIStr)
-		join := &ast.Join{Left: &ast.TableSource{Source: tn, AsName: $9.(model.CIStr)}, Right: nil}
-		x := &ast.DeleteStmt{
-			TableRefs: &ast.TableRefsClause{TableRefs: join},
-			Priority:  $3.(mysql.PriorityEnum),
-			Quick:     $4.(bool),
-			IgnoreErr: $5.(bool),
-		}
-		if $2 != nil {
-			x.TableHints = $2.([]*ast.TableOptimizerHint)
-		}
-		if $11 != nil {
-			x.Where = $11.(ast.ExprNode)
+CreateRoutineStmt:
+	"CREATE" OrReplace ViewDefiner "PROCEDURE" IfNotExists TableName '(' ProcedureParamListOpt ')' RoutineCharacteristicListOpt ProcedureProcStmt
+	{
+		x := &ast.CreateRoutineStmt{
+			Tp:              ast.RoutineProcedure,
+			OrReplace:       $2.(bool),
+			IfNotExists:     $5.(bool),
+			Name:            $6.(*ast.TableName),
+			Params:          $8.([]*ast.RoutineParam),
+			Characteristics: $10.([]*ast.RoutineCharacteristic),
+			Body:            $11,
 		}
-		if $12 != nil {
-			x.Order = $12.(*ast.OrderByClause)
+		if $3 != nil {
+			x.Definer = $3.(*auth.UserIdentity)
 		}
-		if $13 != nil {
-			x.Limit = $13.(*ast.Limit)
+		if hasReturn(x.Body) {
+			yylex.AppendError(ErrSpBadReturn.GenWithStackByArgs())
+			return 1
 		}
-
+		markLocalVariables(x.Body, x.Params)
 		$$ = x
 	}
-|	"DELETE" TableOptimizerHintsOpt PriorityOpt QuickOptional IgnoreOptional TableAliasRefList "FROM" TableRefs WhereClauseOptional
+|	"CREATE" OrReplace ViewDefiner "FUNCTION" IfNotExists TableName '(' FunctionParamListOpt ')' "RETURNS" Type RoutineCharacteristicListOpt ProcedureProcStmt
 	{
-		// Multiple Table
-		x := &ast.DeleteStmt{
-			Priority:     $3.(mysql.PriorityEnum),
-			Quick:        $4.(bool),
-			IgnoreErr:    $5.(bool),
-			IsMultiTable: true,
-			BeforeFrom:   true,
-			Tables:       &ast.DeleteTableList{Tables: $6.([]*ast.TableName)},
-			TableRefs:    &ast.TableRefsClause{TableRefs: $8.(*ast.Join)},
-		}
-		if $2 != nil {
-			x.TableHints = $2.([]*ast.TableOptimizerHint)
+		x := &ast.CreateRoutineStmt{
+			Tp:              ast.RoutineFunction,
+			OrReplace:       $2.(bool),
+			IfNotExists:     $5.(bool),
+			Name:            $6.(*ast.TableName),
+			Params:          $8.([]*ast.RoutineParam),
+			Returns:         $11.(*types.FieldType),
+			Characteristics: $12.([]*ast.RoutineCharacteristic),
+			Body:            $13,
 		}
-		if $9 != nil {
-			x.Where = $9.(ast.ExprNode)
+		if $3 != nil {
+			x.Definer = $3.(*auth.UserIdentity)
 		}
+		markLocalVariables(x.Body, x.Params)
 		$$ = x
 	}
 
-DeleteWithUsingStmt:
-	"DELETE" TableOptimizerHintsOpt PriorityOpt QuickOptional IgnoreOptional "FROM" TableAliasRefList "USING" TableRefs WhereClauseOptional
+ProcedureParamListOpt:
+	/* empty */
 	{
-		// Multiple Table
-		x := &ast.DeleteStmt{
-			Priority:     $3.(mysql.PriorityEnum),
-			Quick:        $4.(bool),
-			IgnoreErr:    $5.(bool),
-			IsMultiTable: true,
-			Tables:       &ast.DeleteTableList{Tables: $7.([]*ast.TableName)},
-			TableRefs:    &ast.TableRefsClause{TableRefs: $9.(*ast.Join)},
-		}
-		if $2 != nil {
-			x.TableHints = $2.([]*ast.TableOptimizerHint)
-		}
-		if $10 != nil {
-			x.Where = $10.(ast.ExprNode)
-		}
-		$$ = x
+		$$ = []*ast.RoutineParam{}
 	}
+|	ProcedureParamList
 
-DeleteFromStmt:
-	DeleteWithoutUsingStmt
-|	DeleteWithUsingStmt
-
-DatabaseSym:
-	"DATABASE"
-
-DropDatabaseStmt:
-	"DROP" DatabaseSym IfExists DBName
+ProcedureParamList:
+	ProcedureParam
 	{
-		$$ = &ast.DropDatabaseStmt{IfExists: $3.(bool), Name: $4}
+		$$ = []*ast.RoutineParam{$1.(*ast.RoutineParam)}
 	}
-
-/******************************************************************
- * Drop Index Statement
- * See https://dev.mysql.com/doc/refman/8.0/en/drop-index.html
- *
- *  DROP INDEX index_name ON tbl_name
- *      [algorithm_option | lock_option] ...
- *
- *  algorithm_option:
- *      ALGORITHM [=] {DEFAULT|INPLACE|COPY}
- *
- *  lock_option:
- *      LOCK [=] {DEFAULT|NONE|SHARED|EXCLUSIVE}
- ******************************************************************/
-DropIndexStmt:
-	"DROP" "INDEX" IfExists Identifier "ON" TableName IndexLockAndAlgorithmOpt
+|	ProcedureParamList ',' ProcedureParam
 	{
-		var indexLockAndAlgorithm *ast.IndexLockAndAlgorithm
-		if $7 != nil {
-			indexLockAndAlgorithm = $7.(*ast.IndexLockAndAlgorithm)
-			if indexLockAndAlgorithm.LockTp == ast.LockTypeDefault && indexLockAndAlgorithm.AlgorithmTp == ast.AlgorithmTypeDefault {
-				indexLockAndAlgorithm = nil
-			}
-		}
-		$$ = &ast.DropIndexStmt{IfExists: $3.(bool), IndexName: $4, Table: $6.(*ast.TableName), LockAlg: indexLockAndAlgorithm}
+		$$ = append($1.([]*ast.RoutineParam), $3.(*ast.RoutineParam))
 	}
 
-DropTableStmt:
-	"DROP" OptTemporary TableOrTables IfExists TableNameList RestrictOrCascadeOpt
+ProcedureParam:
+	ProcedureParamModeOpt Identifier Type
 	{
-		$$ = &ast.DropTableStmt{IfExists: $4.(bool), Tables: $5.([]*ast.TableName), IsView: false, IsTemporary: $2.(bool)}
+		$$ = &ast.RoutineParam{
+			Mode: $1.(ast.ParamMode),
+			Name: model.NewCIStr($2),
+			Tp:   $3.(*types.FieldType),
+		}
 	}
 
-OptTemporary:
+ProcedureParamModeOpt:
 	/* empty */
 	{
-		$$ = false
+		$$ = ast.ParamModeIn
 	}
-|	"TEMPORARY"
+|	"IN"
 	{
-		$$ = true
-		yylex.AppendError(yylex.Errorf("TiDB doesn't support TEMPORARY TABLE, TEMPORARY will be parsed but ignored."))
-		parser.lastErrorAsWarn()
+		$$ = ast.ParamModeIn
 	}
-
-DropViewStmt:
-	"DROP" "VIEW" TableNameList RestrictOrCascadeOpt
+|	"OUT"
 	{
-		$$ = &ast.DropTableStmt{Tables: $3.([]*ast.TableName), IsView: true}
+		$$ = ast.ParamModeOut
 	}
-|	"DROP" "VIEW" "IF" "EXISTS" TableNameList RestrictOrCascadeOpt
+|	"INOUT"
 	{
-		$$ = &ast.DropTableStmt{IfExists: true, Tables: $5.([]*ast.TableName), IsView: true}
+		$$ = ast.ParamModeInOut
 	}
 
-DropUserStmt:
-	"DROP" "USER" UsernameList
+FunctionParamListOpt:
+	/* empty */
 	{
-		$$ = &ast.DropUserStmt{IsDropRole: false, IfExists: false, UserList: $3.([]*auth.UserIdentity)}
+		$$ = []*ast.RoutineParam{}
 	}
-|	"DROP" "USER" "IF" "EXISTS" UsernameList
+|	FunctionParamList
+
+FunctionParamList:
+	FunctionParam
 	{
-		$$ = &ast.DropUserStmt{IsDropRole: false, IfExists: true, UserList: $5.([]*auth.UserIdentity)}
+		$$ = []*ast.RoutineParam{$1.(*ast.RoutineParam)}
 	}
-
-DropRoleStmt:
-	"DROP" "ROLE" RolenameList
+|	FunctionParamList ',' FunctionParam
 	{
-		tmp := make([]*auth.UserIdentity, 0, 10)
-		roleList := $3.([]*auth.RoleIdentity)
-		for _, r := range roleList {
-			tmp = append(tmp, &auth.UserIdentity{Username: r.Username, Hostname: r.Hostname})
-		}
-		$$ = &ast.DropUserStmt{IsDropRole: true, IfExists: false, UserList: tmp}
+		$$ = append($1.([]*ast.RoutineParam), $3.(*ast.RoutineParam))
 	}
-|	"DROP" "ROLE" "IF" "EXISTS" RolenameList
+
+FunctionParam:
+	Identifier Type
 	{
-		tmp := make([]*auth.UserIdentity, 0, 10)
-		roleList := $5.([]*auth.RoleIdentity)
-		for _, r := range roleList {
-			tmp = append(tmp, &auth.UserIdentity{Username: r.Username, Hostname: r.Hostname})
+		$$ = &ast.RoutineParam{
+			Name: model.NewCIStr($1),
+			Tp:   $2.(*types.FieldType),
 		}
-		$$ = &ast.DropUserStmt{IsDropRole: true, IfExists: true, UserList: tmp}
 	}
 
-DropStatsStmt:
-	"DROP" "STATS" TableName
+RoutineCharacteristicListOpt:
+	/* empty */
 	{
-		$$ = &ast.DropStatsStmt{Table: $3.(*ast.TableName)}
+		$$ = []*ast.RoutineCharacteristic{}
 	}
-
-RestrictOrCascadeOpt:
-	{}
-|	"RESTRICT"
-|	"CASCADE"
-
-TableOrTables:
-	"TABLE"
-|	"TABLES"
-
-EqOpt:
-	{}
-|	eq
-
-EmptyStmt:
-	/* EMPTY */
+|	RoutineCharacteristicListOpt RoutineCharacteristic
 	{
-		$$ = nil
+		$$ = append($1.([]*ast.RoutineCharacteristic), $2.(*ast.RoutineCharacteristic))
 	}
 
-TraceStmt:
-	"TRACE" TraceableStmt
+RoutineCharacteristic:
+	"COMMENT" stringLit
 	{
-		$$ = &ast.TraceStmt{
-			Stmt:   $2,
-			Format: "row",
-		}
-		startOffset := parser.startOffset(&yyS[yypt])
-		$2.SetText(string(parser.src[startOffset:]))
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicComment, Comment: $2}
 	}
-|	"TRACE" "FORMAT" "=" stringLit TraceableStmt
+|	"LANGUAGE" "SQL"
 	{
-		$$ = &ast.TraceStmt{
-			Stmt:   $5,
-			Format: $4,
-		}
-		startOffset := parser.startOffset(&yyS[yypt])
-		$5.SetText(string(parser.src[startOffset:]))
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicLanguageSQL}
 	}
-
-ExplainSym:
-	"EXPLAIN"
-|	"DESCRIBE"
-|	"DESC"
-
-ExplainStmt:
-	ExplainSym TableName
+|	"DETERMINISTIC"
 	{
-		$$ = &ast.ExplainStmt{
-			Stmt: &ast.ShowStmt{
-				Tp:    ast.ShowColumns,
-				Table: $2.(*ast.TableName),
-			},
-		}
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicDeterministic}
 	}
-|	ExplainSym TableName ColumnName
+|	"NOT" "DETERMINISTIC"
 	{
-		$$ = &ast.ExplainStmt{
-			Stmt: &ast.ShowStmt{
-				Tp:     ast.ShowColumns,
-				Table:  $2.(*ast.TableName),
-				Column: $3.(*ast.ColumnName),
-			},
-		}
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicNotDeterministic}
 	}
-|	ExplainSym ExplainableStmt
+|	"CONTAINS" "SQL"
 	{
-		$$ = &ast.ExplainStmt{
-			Stmt:   $2,
-			Format: "row",
-		}
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicContainsSQL}
 	}
-|	ExplainSym "FOR" "CONNECTION" NUM
+|	"NO" "SQL"
 	{
-		$$ = &ast.ExplainForStmt{
-			Format:       "row",
-			ConnectionID: getUint64FromNUM($4),
-		}
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicNoSQL}
 	}
-|	ExplainSym "FORMAT" "=" stringLit "FOR" "CONNECTION" NUM
+|	"READS" "SQL" "DATA"
 	{
-		$$ = &ast.ExplainForStmt{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicReadsSQLData}
+	}
+|	"MODIFIES" "SQL" "DATA"
+	{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicModifiesSQLData}
+	}
+|	"SQL" "SECURITY" "DEFINER"
+	{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicSQLSecurity, Security: model.SecurityDefiner}
+	}
+|	"SQL" "SECURITY" "INVOKER"
+	{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicSQLSecurity, Security: model.SecurityInvoker}
+	}
+
+/*******************************************************************
+ *  Drop Procedure/Function Statement
+ *  See https://dev.mysql.com/doc/refman/8.0/en/drop-procedure.html
+ *******************************************************************/
+DropRoutineStmt:
+	"DROP" "PROCEDURE" IfExists TableName
+	{
+		$$ = &ast.DropRoutineStmt{Tp: ast.RoutineProcedure, IfExists: $3.(bool), Name: $4.(*ast.TableName)}
+	}
+|	"DROP" "FUNCTION" IfExists TableName
+	{
+		$$ = &ast.DropRoutineStmt{Tp: ast.RoutineFunction, IfExists: $3.(bool), Name: $4.(*ast.TableName)}
+	}
+
+/*******************************************************************
+ *  Alter Procedure/Function Statement
+ *  See https://dev.mysql.com/doc/refman/8.0/en/alter-procedure.html
+ *******************************************************************/
+AlterRoutineStmt:
+	"ALTER" "PROCEDURE" TableName RoutineCharacteristicListOpt
+	{
+		$$ = &ast.AlterRoutineStmt{
+			Tp:              ast.RoutineProcedure,
+			Name:            $3.(*ast.TableName),
+			Characteristics: $4.([]*ast.RoutineCharacteristic),
+		}
+	}
+|	"ALTER" "FUNCTION" TableName RoutineCharacteristicListOpt
+	{
+		$$ = &ast.AlterRoutineStmt{
+			Tp:              ast.RoutineFunction,
+			Name:            $3.(*ast.TableName),
+			Characteristics: $4.([]*ast.RoutineCharacteristic),
+		}
+	}
+
+/*******************************************************************
+ *  Create Trigger Statement
+ *  See https://dev.mysql.com/doc/refman/8.0/en/create-trigger.html
+ *******************************************************************/
+CreateTriggerStmt:
+	"CREATE" OrReplace ViewDefiner "TRIGGER" IfNotExists TableName TriggerTime TriggerEvent "ON" TableName "FOR" "EACH" "ROW" TriggerOrderOpt ProcedureProcStmt
+	{
+		x := &ast.CreateTriggerStmt{
+			OrReplace:   $2.(bool),
+			IfNotExists: $5.(bool),
+			Name:        $6.(*ast.TableName),
+			Time:        $7.(ast.TriggerTime),
+			Event:       $8.(ast.TriggerEvent),
+			Table:       $10.(*ast.TableName),
+			Body:        $15,
+		}
+		if $3 != nil {
+			x.Definer = $3.(*auth.UserIdentity)
+		}
+		if $14 != nil {
+			order := $14.([]interface{})
+			x.Order = order[0].(ast.TriggerOrder)
+			x.OtherTrigger = model.NewCIStr(order[1].(string))
+		}
+		if hasReturn(x.Body) {
+			yylex.AppendError(ErrSpBadReturn.GenWithStackByArgs())
+			return 1
+		}
+		markTriggerFields(x.Body)
+		markLocalVariables(x.Body, nil)
+		$$ = x
+	}
+
+TriggerTime:
+	"BEFORE"
+	{
+		$$ = ast.TriggerBefore
+	}
+|	"AFTER"
+	{
+		$$ = ast.TriggerAfter
+	}
+
+TriggerEvent:
+	"INSERT"
+	{
+		$$ = ast.TriggerInsert
+	}
+|	"UPDATE"
+	{
+		$$ = ast.TriggerUpdate
+	}
+|	"DELETE"
+	{
+		$$ = ast.TriggerDelete
+	}
+
+TriggerOrderOpt:
+	/* empty */
+	{
+		$$ = nil
+	}
+|	"FOLLOWS" Identifier
+	{
+		$$ = []interface{}{ast.TriggerFollows, $2}
+	}
+|	"PRECEDES" Identifier
+	{
+		$$ = []interface{}{ast.TriggerPrecedes, $2}
+	}
+
+/*******************************************************************
+ *  Drop Trigger Statement
+ *  See https://dev.mysql.com/doc/refman/8.0/en/drop-trigger.html
+ *******************************************************************/
+DropTriggerStmt:
+	"DROP" "TRIGGER" IfExists TableName
+	{
+		$$ = &ast.DropTriggerStmt{IfExists: $3.(bool), Name: $4.(*ast.TableName)}
+	}
+
+/*******************************************************************
+ *  Create Event Statement
+ *  See https://dev.mysql.com/doc/refman/8.0/en/create-event.html
+ *******************************************************************/
+CreateEventStmt:
+	"CREATE" OrReplace ViewDefiner "EVENT" IfNotExists TableName "ON" "SCHEDULE" EventSchedule EventCompletionOpt EventStatusOpt EventCommentOpt "DO" ProcedureProcStmt
+	{
+		x := &ast.CreateEventStmt{
+			OrReplace:    $2.(bool),
+			IfNotExists:  $5.(bool),
+			Name:         $6.(*ast.TableName),
+			Schedule:     $9.(*ast.EventSchedule),
+			OnCompletion: $10.(ast.EventCompletion),
+			Status:       $11.(ast.EventStatus),
+			Comment:      $12,
+			Body:         $14,
+		}
+		if $3 != nil {
+			x.Definer = $3.(*auth.UserIdentity)
+		}
+		if hasReturn(x.Body) {
+			yylex.AppendError(ErrSpBadReturn.GenWithStackByArgs())
+			return 1
+		}
+		markLocalVariables(x.Body, nil)
+		$$ = x
+	}
+
+EventSchedule:
+	"AT" Expression
+	{
+		$$ = &ast.EventSchedule{At: $2}
+	}
+|	"EVERY" Expression TimeUnit EventStartsOpt EventEndsOpt
+	{
+		$$ = &ast.EventSchedule{
+			Every:  $2,
+			Unit:   $3.(ast.TimeUnitType),
+			Starts: $4,
+			Ends:   $5,
+		}
+	}
+
+EventStartsOpt:
+	/* empty */
+	{
+		$$ = nil
+	}
+|	"STARTS" Expression
+	{
+		$$ = $2
+	}
+
+EventEndsOpt:
+	/* empty */
+	{
+		$$ = nil
+	}
+|	"ENDS" Expression
+	{
+		$$ = $2
+	}
+
+EventCompletionOpt:
+	/* empty */
+	{
+		$$ = ast.EventCompletionNone
+	}
+|	"ON" "COMPLETION" "PRESERVE"
+	{
+		$$ = ast.EventPreserve
+	}
+|	"ON" "COMPLETION" "NOT" "PRESERVE"
+	{
+		$$ = ast.EventNotPreserve
+	}
+
+EventStatusOpt:
+	/* empty */
+	{
+		$$ = ast.EventStatusNone
+	}
+|	"ENABLE"
+	{
+		$$ = ast.EventEnable
+	}
+|	"DISABLE"
+	{
+		$$ = ast.EventDisable
+	}
+|	"DISABLE" "ON" "SLAVE"
+	{
+		$$ = ast.EventDisableOnSlave
+	}
+
+EventCommentOpt:
+	/* empty */
+	{
+		$$ = ""
+	}
+|	"COMMENT" stringLit
+	{
+		$$ = $2
+	}
+
+/*******************************************************************
+ *  Alter Event Statement
+ *  See https://dev.mysql.com/doc/refman/8.0/en/alter-event.html
+ *******************************************************************/
+AlterEventStmt:
+	"ALTER" ViewDefiner "EVENT" TableName EventScheduleCompletionOpt EventRenameOpt EventStatusOpt EventCommentOpt EventBodyOpt
+	{
+		opts := $5.([]interface{})
+		x := &ast.AlterEventStmt{
+			Name:         $4.(*ast.TableName),
+			OnCompletion: opts[1].(ast.EventCompletion),
+			Status:       $7.(ast.EventStatus),
+			Comment:      $8,
+		}
+		if $2 != nil {
+			x.Definer = $2.(*auth.UserIdentity)
+		}
+		if opts[0] != nil {
+			x.Schedule = opts[0].(*ast.EventSchedule)
+		}
+		if $6 != nil {
+			x.RenameTo = $6.(*ast.TableName)
+		}
+		if $9 != nil {
+			x.Body = $9
+			if hasReturn(x.Body) {
+				yylex.AppendError(ErrSpBadReturn.GenWithStackByArgs())
+				return 1
+			}
+			markLocalVariables(x.Body, nil)
+		}
+		$$ = x
+	}
+
+EventScheduleCompletionOpt:
+	EventCompletionOpt
+	{
+		$$ = []interface{}{nil, $1}
+	}
+|	"ON" "SCHEDULE" EventSchedule EventCompletionOpt
+	{
+		$$ = []interface{}{$3, $4}
+	}
+
+EventRenameOpt:
+	/* empty */
+	{
+		$$ = nil
+	}
+|	"RENAME" "TO" TableName
+	{
+		$$ = $3
+	}
+
+EventBodyOpt:
+	/* empty */
+	{
+		$$ = nil
+	}
+|	"DO" ProcedureProcStmt
+	{
+		$$ = $2
+	}
+
+/*******************************************************************
+ *  Drop Event Statement
+ *  See https://dev.mysql.com/doc/refman/8.0/en/drop-event.html
+ *******************************************************************/
+DropEventStmt:
+	"DROP" "EVENT" IfExists TableName
+	{
+		$$ = &ast.DropEventStmt{IfExists: $3.(bool), Name: $4.(*ast.TableName)}
+	}
+
+/*******************************************************************
+ *  Statements of stored programs
+ *  See https://dev.mysql.com/doc/refman/8.0/en/sql-compound-statements.html
+ *******************************************************************/
+ProcedureProcStmt:
+	ProcedureStatementStmt
+|	ProcedureLabelableStmt
+|	identifier ':' ProcedureLabelableStmt ProcedureEndLabelOpt
+	{
+		label := model.NewCIStr($1)
+		if $4 != "" && model.NewCIStr($4).L != label.L {
+			yylex.AppendError(yylex.Errorf("End-label %s without match", $4))
+			return 1
+		}
+		switch x := $3.(type) {
+		case *ast.BlockStmt:
+			x.Label = label
+		case *ast.LoopStmt:
+			x.Label = label
+		case *ast.WhileStmt:
+			x.Label = label
+		case *ast.RepeatStmt:
+			x.Label = label
+		}
+		$$ = $3
+	}
+|	ProcedureIfStmt
+|	ProcedureCaseStmt
+|	"LEAVE" Identifier
+	{
+		$$ = &ast.LeaveStmt{Label: model.NewCIStr($2)}
+	}
+|	"ITERATE" Identifier
+	{
+		$$ = &ast.IterateStmt{Label: model.NewCIStr($2)}
+	}
+|	"RETURN" Expression
+	{
+		$$ = &ast.ReturnStmt{Expr: $2}
+	}
+|	"OPEN" Identifier
+	{
+		$$ = &ast.OpenCursorStmt{Name: model.NewCIStr($2)}
+	}
+|	"CLOSE" Identifier
+	{
+		$$ = &ast.CloseCursorStmt{Name: model.NewCIStr($2)}
+	}
+|	"FETCH" Identifier "INTO" ColumnList
+	{
+		$$ = &ast.FetchCursorStmt{Name: model.NewCIStr($2), Into: $4.([]model.CIStr)}
+	}
+|	"FETCH" "FROM" Identifier "INTO" ColumnList
+	{
+		$$ = &ast.FetchCursorStmt{Name: model.NewCIStr($3), Into: $5.([]model.CIStr)}
+	}
+|	"FETCH" "NEXT" "FROM" Identifier "INTO" ColumnList
+	{
+		$$ = &ast.FetchCursorStmt{Name: model.NewCIStr($4), Into: $6.([]model.CIStr)}
+	}
+|	ProcedureSignalStmt
+
+// ProcedureStatementStmt is a plain SQL statement allowed in a stored program.
+// A BEGIN starts a block, so a transaction starts with START TRANSACTION or
+// BEGIN WORK.
+ProcedureStatementStmt:
+	AlterDatabaseStmt
+|	AlterTableStmt
+|	AlterUserStmt
+|	AnalyzeTableStmt
+|	"BEGIN" "WORK"
+	{
+		$$ = &ast.BeginStmt{}
+	}
+|	CallStmt
+|	ChangeReplicationSourceStmt
+|	CommitStmt
+|	CreateDatabaseStmt
+|	CreateIndexStmt
+|	CreateRoleStmt
+|	CreateTableStmt
+|	CreateUserStmt
+|	CreateViewStmt
+|	DeallocateStmt
+|	DeleteFromStmt
+|	DoStmt
+|	DropDatabaseStmt
+|	DropIndexStmt
+|	DropRoleStmt
+|	DropTableStmt
+|	DropUserStmt
+|	DropViewStmt
+|	ExecuteStmt
+|	ExplainStmt
+|	FlushStmt
+|	GrantProxyStmt
+|	GrantRoleStmt
+|	GrantStmt
+|	InsertIntoStmt
+|	KillStmt
+|	LoadDataStmt
+|	LockTablesStmt
+|	PreparedStmt
+|	PurgeBinaryLogsStmt
+|	RenameTableStmt
+|	ReleaseSavepointStmt
+|	ReplaceIntoStmt
+|	ResetMasterStmt
+|	ResetReplicaStmt
+|	RevokeRoleStmt
+|	RevokeStmt
+|	RollbackStmt
+|	SavepointStmt
+|	SetDefaultRoleStmt
+|	SetOprStmt1
+|	SetRoleStmt
+|	SetStmt
+|	ShowStmt
+|	StartReplicaStmt
+|	StartTransactionStmt
+|	StopReplicaStmt
+|	TruncateTableStmt
+|	UnlockTablesStmt
+|	UpdateStmt
+
+ProcedureLabelableStmt:
+	"BEGIN" ProcedureDeclListOpt ProcedureProcStmtListOpt "END"
+	{
+		$$ = &ast.BlockStmt{Stmts: append($2.([]ast.StmtNode), $3.([]ast.StmtNode)...)}
+	}
+|	"LOOP" ProcedureProcStmtList "END" "LOOP"
+	{
+		$$ = &ast.LoopStmt{Stmts: $2.([]ast.StmtNode)}
+	}
+|	"WHILE" Expression "DO" ProcedureProcStmtList "END" "WHILE"
+	{
+		$$ = &ast.WhileStmt{Cond: $2, Stmts: $4.([]ast.StmtNode)}
+	}
+|	"REPEAT" ProcedureProcStmtList "UNTIL" Expression "END" "REPEAT"
+	{
+		$$ = &ast.RepeatStmt{Stmts: $2.([]ast.StmtNode), Until: $4}
+	}
+
+ProcedureEndLabelOpt:
+	/* empty */
+	{
+		$$ = ""
+	}
+|	identifier
+
+ProcedureProcStmtListOpt:
+	/* empty */
+	{
+		$$ = []ast.StmtNode{}
+	}
+|	ProcedureProcStmtList
+
+ProcedureProcStmtList:
+	ProcedureProcStmt ';'
+	{
+		$$ = []ast.StmtNode{$1}
+	}
+|	ProcedureProcStmtList ProcedureProcStmt ';'
+	{
+		$$ = append($1.([]ast.StmtNode), $2)
+	}
+
+ProcedureDeclListOpt:
+	/* empty */
+	{
+		$$ = []ast.StmtNode{}
+	}
+|	ProcedureDeclListOpt ProcedureDecl ';'
+	{
+		$$ = append($1.([]ast.StmtNode), $2)
+	}
+
+ProcedureDecl:
+	"DECLARE" ColumnList Type
+	{
+		$$ = &ast.DeclareVarStmt{Names: $2.([]model.CIStr), Tp: $3.(*types.FieldType)}
+	}
+|	"DECLARE" ColumnList Type "DEFAULT" Expression
+	{
+		$$ = &ast.DeclareVarStmt{Names: $2.([]model.CIStr), Tp: $3.(*types.FieldType), Default: $5}
+	}
+|	"DECLARE" Identifier "CONDITION" "FOR" ProcedureConditionValue
+	{
+		$$ = &ast.DeclareConditionStmt{Name: model.NewCIStr($2), Value: $5.(*ast.ConditionValue)}
+	}
+|	"DECLARE" Identifier "CURSOR" "FOR" SetOprStmt1
+	{
+		$$ = &ast.DeclareCursorStmt{Name: model.NewCIStr($2), Query: $5.(ast.ResultSetNode)}
+	}
+|	"DECLARE" ProcedureHandlerAction "HANDLER" "FOR" ProcedureHandlerConditionList ProcedureProcStmt
+	{
+		$$ = &ast.DeclareHandlerStmt{
+			Action:     $2.(ast.HandlerAction),
+			Conditions: $5.([]*ast.ConditionValue),
+			Stmt:       $6,
+		}
+	}
+
+ProcedureHandlerAction:
+	"CONTINUE"
+	{
+		$$ = ast.HandlerContinue
+	}
+|	"EXIT"
+	{
+		$$ = ast.HandlerExit
+	}
+|	"UNDO"
+	{
+		$$ = ast.HandlerUndo
+	}
+
+ProcedureHandlerConditionList:
+	ProcedureHandlerCondition
+	{
+		$$ = []*ast.ConditionValue{$1.(*ast.ConditionValue)}
+	}
+|	ProcedureHandlerConditionList ',' ProcedureHandlerCondition
+	{
+		$$ = append($1.([]*ast.ConditionValue), $3.(*ast.ConditionValue))
+	}
+
+ProcedureHandlerCondition:
+	ProcedureConditionValue
+|	Identifier
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionName, Name: model.NewCIStr($1)}
+	}
+|	"SQLWARNING"
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionSQLWarning}
+	}
+|	"NOT" "FOUND"
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionNotFound}
+	}
+|	"SQLEXCEPTION"
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionSQLException}
+	}
+
+ProcedureConditionValue:
+	intLit
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionErrorCode, ErrorCode: getUint64FromNUM($1)}
+	}
+|	ProcedureSQLState
+
+ProcedureSQLState:
+	"SQLSTATE" stringLit
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionSQLState, SQLState: $2}
+	}
+|	"SQLSTATE" "VALUE" stringLit
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionSQLState, SQLState: $3}
+	}
+
+ProcedureIfStmt:
+	"IF" Expression "THEN" ProcedureProcStmtList ProcedureElseIfListOpt ProcedureElseOpt "END" "IF"
+	{
+		branch := &ast.IfBranch{Cond: $2, Stmts: $4.([]ast.StmtNode)}
+		x := &ast.IfStmt{Branches: append([]*ast.IfBranch{branch}, $5.([]*ast.IfBranch)...)}
+		if $6 != nil {
+			x.Else = $6.([]ast.StmtNode)
+		}
+		$$ = x
+	}
+
+ProcedureElseIfListOpt:
+	/* empty */
+	{
+		$$ = []*ast.IfBranch{}
+	}
+|	ProcedureElseIfListOpt "ELSEIF" Expression "THEN" ProcedureProcStmtList
+	{
+		$$ = append($1.([]*ast.IfBranch), &ast.IfBranch{Cond: $3, Stmts: $5.([]ast.StmtNode)})
+	}
+
+ProcedureElseOpt:
+	/* empty */
+	{
+		$$ = nil
+	}
+|	"ELSE" ProcedureProcStmtList
+	{
+		$$ = $2
+	}
+
+ProcedureCaseStmt:
+	"CASE" ExpressionOpt ProcedureWhenList ProcedureElseOpt "END" "CASE"
+	{
+		x := &ast.CaseStmt{WhenClauses: $3.([]*ast.CaseStmtWhen)}
+		if $2 != nil {
+			x.Value = $2
+		}
+		if $4 != nil {
+			x.Else = $4.([]ast.StmtNode)
+		}
+		$$ = x
+	}
+
+ProcedureWhenList:
+	ProcedureWhen
+	{
+		$$ = []*ast.CaseStmtWhen{$1.(*ast.CaseStmtWhen)}
+	}
+|	ProcedureWhenList ProcedureWhen
+	{
+		$$ = append($1.([]*ast.CaseStmtWhen), $2.(*ast.CaseStmtWhen))
+	}
+
+ProcedureWhen:
+	"WHEN" Expression "THEN" ProcedureProcStmtList
+	{
+		$$ = &ast.CaseStmtWhen{Expr: $2, Stmts: $4.([]ast.StmtNode)}
+	}
+
+ProcedureSignalStmt:
+	"SIGNAL" ProcedureSignalCondition ProcedureSignalItemListOpt
+	{
+		$$ = &ast.SignalStmt{Condition: $2.(*ast.ConditionValue), Items: $3.([]*ast.SignalItem)}
+	}
+|	"RESIGNAL" ProcedureSignalItemListOpt
+	{
+		$$ = &ast.SignalStmt{IsResignal: true, Items: $2.([]*ast.SignalItem)}
+	}
+|	"RESIGNAL" ProcedureSignalCondition ProcedureSignalItemListOpt
+	{
+		$$ = &ast.SignalStmt{IsResignal: true, Condition: $2.(*ast.ConditionValue), Items: $3.([]*ast.SignalItem)}
+	}
+
+ProcedureSignalCondition:
+	ProcedureSQLState
+|	Identifier
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionName, Name: model.NewCIStr($1)}
+	}
+
+ProcedureSignalItemListOpt:
+	/* empty */
+	{
+		$$ = []*ast.SignalItem{}
+	}
+|	"SET" ProcedureSignalItemList
+	{
+		$$ = $2
+	}
+
+ProcedureSignalItemList:
+	ProcedureSignalItem
+	{
+		$$ = []*ast.SignalItem{$1.(*ast.SignalItem)}
+	}
+|	ProcedureSignalItemList ',' ProcedureSignalItem
+	{
+		$$ = append($1.([]*ast.SignalItem), $3.(*ast.SignalItem))
+	}
+
+ProcedureSignalItem:
+	Identifier eq Expression
+	{
+		if !ast.IsSignalItemName($1) {
+			yylex.AppendError(yylex.Errorf("Unknown condition information item %s", $1))
+			return 1
+		}
+		$$ = &ast.SignalItem{Name: strings.ToUpper($1), Value: $3}
+	}
+
+/******************************************************************
+ * Do statement
+ * See https://dev.mysql.com/doc/refman/5.7/en/do.html
+ ******************************************************************/
+DoStmt:
+	"DO" ExpressionList
+	{
+		$$ = &ast.DoStmt{
+			Exprs: $2.([]ast.ExprNode),
+		}
+	}
+
+/*******************************************************************
+ *
+ *  Delete Statement
+ *
+ *******************************************************************/
+DeleteWithoutUsingStmt:
+	"DELETE" TableOptimizerHintsOpt PriorityOpt QuickOptional IgnoreOptional "FROM" TableName PartitionNameListOpt TableAsNameOpt IndexHintListOpt WhereClauseOptional OrderByOptional LimitClause
+	{
+		// Single Table
+		tn := $7.(*ast.TableName)
+		tn.IndexHints = $10.([]*ast.IndexHint)
+		tn.PartitionNames = $8.([]model.CIStr)
+		join := &ast.Join{Left: &ast.TableSource{Source: tn, AsName: $9.(model.CIStr)}, Right: nil}
+		x := &ast.DeleteStmt{
+			TableRefs: &ast.TableRefsClause{TableRefs: join},
+			Priority:  $3.(mysql.PriorityEnum),
+			Quick:     $4.(bool),
+			IgnoreErr: $5.(bool),
+		}
+		if $2 != nil {
+			x.TableHints = $2.([]*ast.TableOptimizerHint)
+		}
+		if $11 != nil {
+			x.Where = $11.(ast.ExprNode)
+		}
+		if $12 != nil {
+			x.Order = $12.(*ast.OrderByClause)
+		}
+		if $13 != nil {
+			x.Limit = $13.(*ast.Limit)
+		}
+
+		$$ = x
+	}
+|	"DELETE" TableOptimizerHintsOpt PriorityOpt QuickOptional IgnoreOptional TableAliasRefList "FROM" TableRefs WhereClauseOptional
+	{
+		// Multiple Table
+		x := &ast.DeleteStmt{
+			Priority:     $3.(mysql.PriorityEnum),
+			Quick:        $4.(bool),
+			IgnoreErr:    $5.(bool),
+			IsMultiTable: true,
+			BeforeFrom:   true,
+			Tables:       &ast.DeleteTableList{Tables: $6.([]*ast.TableName)},
+			TableRefs:    &ast.TableRefsClause{TableRefs: $8.(*ast.Join)},
+		}
+		if $2 != nil {
+			x.TableHints = $2.([]*ast.TableOptimizerHint)
+		}
+		if $9 != nil {
+			x.Where = $9.(ast.ExprNode)
+		}
+		$$ = x
+	}
+
+DeleteWithUsingStmt:
+	"DELETE" TableOptimizerHintsOpt PriorityOpt QuickOptional IgnoreOptional "FROM" TableAliasRefList "USING" TableRefs WhereClauseOptional
+	{
+		// Multiple Table
+		x := &ast.DeleteStmt{
+			Priority:     $3.(mysql.PriorityEnum),
+			Quick:        $4.(bool),
+			IgnoreErr:    $5.(bool),
+			IsMultiTable: true,
+			Tables:       &ast.DeleteTableList{Tables: $7.([]*ast.TableName)},
+			TableRefs:    &ast.TableRefsClause{TableRefs: $9.(*ast.Join)},
+		}
+		if $2 != nil {
+			x.TableHints = $2.([]*ast.TableOptimizerHint)
+		}
+		if $10 != nil {
+			x.Where = $10.(ast.ExprNode)
+		}
+		$$ = x
+	}
+
+DeleteFromStmt:
+	DeleteFromStmtNoWith
+|	WithClause DeleteFromStmtNoWith
+	{
+		d := $2.(*ast.DeleteStmt)
+		d.With = $1.(*ast.WithClause)
+		$$ = d
+	}
+
+DeleteFromStmtNoWith:
+	DeleteWithoutUsingStmt
+|	DeleteWithUsingStmt
+
+DatabaseSym:
+	"DATABASE"
+
+DropDatabaseStmt:
+	"DROP" DatabaseSym IfExists DBName
+	{
+		$$ = &ast.DropDatabaseStmt{IfExists: $3.(bool), Name: $4}
+	}
+
+/******************************************************************
+ * Drop Index Statement
+ * See https://dev.mysql.com/doc/refman/8.0/en/drop-index.html
+ *
+ *  DROP INDEX index_name ON tbl_name
+ *      [algorithm_option | lock_option] ...
+ *
+ *  algorithm_option:
+ *      ALGORITHM [=] {DEFAULT|INPLACE|COPY}
+ *
+ *  lock_option:
+ *      LOCK [=] {DEFAULT|NONE|SHARED|EXCLUSIVE}
+ ******************************************************************/
+DropIndexStmt:
+	"DROP" "INDEX" IfExists Identifier "ON" TableName IndexLockAndAlgorithmOpt
+	{
+		var indexLockAndAlgorithm *ast.IndexLockAndAlgorithm
+		if $7 != nil {
+			indexLockAndAlgorithm = $7.(*ast.IndexLockAndAlgorithm)
+			if indexLockAndAlgorithm.LockTp == ast.LockTypeDefault && indexLockAndAlgorithm.AlgorithmTp == ast.AlgorithmTypeDefault {
+				indexLockAndAlgorithm = nil
+			}
+		}
+		$$ = &ast.DropIndexStmt{IfExists: $3.(bool), IndexName: $4, Table: $6.(*ast.TableName), LockAlg: indexLockAndAlgorithm}
+	}
+
+DropTableStmt:
+	"DROP" OptTemporary TableOrTables IfExists TableNameList RestrictOrCascadeOpt
+	{
+		$$ = &ast.DropTableStmt{IfExists: $4.(bool), Tables: $5.([]*ast.TableName), IsView: false, IsTemporary: $2.(bool)}
+	}
+
+OptTemporary:
+	/* empty */
+	{
+		$$ = false
+	}
+|	"TEMPORARY"
+	{
+		$$ = true
+		yylex.AppendError(yylex.Errorf("TiDB doesn't support TEMPORARY TABLE, TEMPORARY will be parsed but ignored."))
+		parser.lastErrorAsWarn()
+	}
+
+DropViewStmt:
+	"DROP" "VIEW" TableNameList RestrictOrCascadeOpt
+	{
+		$$ = &ast.DropTableStmt{Tables: $3.([]*ast.TableName), IsView: true}
+	}
+|	"DROP" "VIEW" "IF" "EXISTS" TableNameList RestrictOrCascadeOpt
+	{
+		$$ = &ast.DropTableStmt{IfExists: true, Tables: $5.([]*ast.TableName), IsView: true}
+	}
+
+DropUserStmt:
+	"DROP" "USER" UsernameList
+	{
+		$$ = &ast.DropUserStmt{IsDropRole: false, IfExists: false, UserList: $3.([]*auth.UserIdentity)}
+	}
+|	"DROP" "USER" "IF" "EXISTS" UsernameList
+	{
+		$$ = &ast.DropUserStmt{IsDropRole: false, IfExists: true, UserList: $5.([]*auth.UserIdentity)}
+	}
+
+DropRoleStmt:
+	"DROP" "ROLE" RolenameList
+	{
+		tmp := make([]*auth.UserIdentity, 0, 10)
+		roleList := $3.([]*auth.RoleIdentity)
+		for _, r := range roleList {
+			tmp = append(tmp, &auth.UserIdentity{Username: r.Username, Hostname: r.Hostname})
+		}
+		$$ = &ast.DropUserStmt{IsDropRole: true, IfExists: false, UserList: tmp}
+	}
+|	"DROP" "ROLE" "IF" "EXISTS" RolenameList
+	{
+		tmp := make([]*auth.UserIdentity, 0, 10)
+		roleList := $5.([]*auth.RoleIdentity)
+		for _, r := range roleList {
+			tmp = append(tmp, &auth.UserIdentity{Username: r.Username, Hostname: r.Hostname})
+		}
+		$$ = &ast.DropUserStmt{IsDropRole: true, IfExists: true, UserList: tmp}
+	}
+
+DropStatsStmt:
+	"DROP" "STATS" TableName
+	{
+		$$ = &ast.DropStatsStmt{Table: $3.(*ast.TableName)}
+	}
+
+RestrictOrCascadeOpt:
+	{}
+|	"RESTRICT"
+|	"CASCADE"
+
+TableOrTables:
+	"TABLE"
+|	"TABLES"
+
+EqOpt:
+	{}
+|	eq
+
+EmptyStmt:
+	/* EMPTY */
+	{
+		$$ = nil
+	}
+
+TraceStmt:
+	"TRACE" TraceableStmt
+	{
+		$$ = &ast.TraceStmt{
+			Stmt:   $2,
+			Format: "row",
+		}
+		startOffset := parser.startOffset(&yyS[yypt])
+		$2.SetText(string(parser.src[startOffset:]))
+	}
+|	"TRACE" "FORMAT" "=" stringLit TraceableStmt
+	{
+		$$ = &ast.TraceStmt{
+			Stmt:   $5,
+			Format: $4,
+		}
+		startOffset := parser.startOffset(&yyS[yypt])
+		$5.SetText(string(parser.src[startOffset:]))
+	}
+
+ExplainSym:
+	"EXPLAIN"
+|	"DESCRIBE"
+|	"DESC"
+
+ExplainStmt:
+	ExplainSym TableName
+	{
+		$$ = &ast.ExplainStmt{
+			Stmt: &ast.ShowStmt{
+				Tp:    ast.ShowColumns,
+				Table: $2.(*ast.TableName),
+			},
+		}
+	}
+|	ExplainSym TableName ColumnName
+	{
+		$$ = &ast.ExplainStmt{
+			Stmt: &ast.ShowStmt{
+				Tp:     ast.ShowColumns,
+				Table:  $2.(*ast.TableName),
+				Column: $3.(*ast.ColumnName),
+			},
+		}
+	}
+|	ExplainSym ExplainableStmt
+	{
+		$$ = &ast.ExplainStmt{
+			Stmt:   $2,
+			Format: "row",
+		}
+	}
+|	ExplainSym "FOR" "CONNECTION" NUM
+	{
+		$$ = &ast.ExplainForStmt{
+			Format:       "row",
+			ConnectionID: getUint64FromNUM($4),
+		}
+	}
+|	ExplainSym "FORMAT" "=" stringLit "FOR" "CONNECTION" NUM
+	{
+		$$ = &ast.ExplainForStmt{
 			Format:       $4,
 			ConnectionID: getUint64FromNUM($7),
 		}
@@ -5265,6 +6373,7 @@ UnReservedKeyword:
 |	"SAN"
 |	"COMMIT"
 |	"COMPACT"
+|	"CONTAINS"
 |	"COMPRESSED"
 |	"CONSISTENT"
 |	"CURRENT"
@@ -5295,10 +6404,12 @@ UnReservedKeyword:
 |	"FLUSH"
 |	"FOLLOWING"
 |	"FORMAT"
+|	"FOUND"
 |	"FULL"
 |	"GENERAL"
 |	"GLOBAL"
 |	"HASH"
+|	"HANDLER"
 |	"HOUR"
 |	"INSERT_METHOD"
 |	"LESS"
@@ -5341,6 +6452,7 @@ UnReservedKeyword:
 |	"TRUNCATE"
 |	"UNBOUNDED"
 |	"UNKNOWN"
+|	"UNTIL"
 |	"VALUE" %prec lowerThanValueKeyword
 |	"WARNINGS"
 |	"YEAR"
@@ -5386,6 +6498,7 @@ UnReservedKeyword:
 |	"DISABLE"
 |	"ENABLE"
 |	"REVERSE"
+|	"RETURNS"
 |	"PRIVILEGES"
 |	"NO"
 |	"BINLOG"
@@ -5425,6 +6538,7 @@ UnReservedKeyword:
 |	"MAX_USER_CONNECTIONS"
 |	"REPLICATION"
 |	"CLIENT"
+|	"CLOSE"
 |	"SLAVE"
 |	"RELOAD"
 |	"TEMPORARY"
@@ -5565,6 +6679,53 @@ UnReservedKeyword:
 |	"PURGE"
 |	"SKIP"
 |	"LOCKED"
+|	"AT"
+|	"COMPLETION"
+|	"ENDS"
+|	"EVERY"
+|	"FOLLOWS"
+|	"PRECEDES"
+|	"PRESERVE"
+|	"SCHEDULE"
+|	"STARTS"
+|	"GEOMETRY"
+|	"GEOMETRYCOLLECTION"
+|	"GEOMCOLLECTION"
+|	"LINESTRING"
+|	"MULTILINESTRING"
+|	"MULTIPOINT"
+|	"MULTIPOLYGON"
+|	"POINT"
+|	"POLYGON"
+|	"SRID"
+|	"MIGRATE"
+|	"ONE"
+|	"PHASE"
+|	"SAVEPOINT"
+|	"SUSPEND"
+|	"WORK"
+|	"XA"
+|	"XID"
+|	"EMPTY"
+|	"NESTED"
+|	"ORDINALITY"
+|	"PATH"
+|	"CHANNEL"
+|	"CLONE"
+|	"COMPONENT"
+|	"DEFAULT_AUTH"
+|	"HELP"
+|	"INSTALL"
+|	"IO_THREAD"
+|	"PLUGIN"
+|	"PLUGIN_DIR"
+|	"RESOURCE"
+|	"SONAME"
+|	"SQL_THREAD"
+|	"STOP"
+|	"THREAD_PRIORITY"
+|	"UNINSTALL"
+|	"VCPU"
 
 TiDBKeyword:
 	"ADMIN"
@@ -6367,15 +7528,22 @@ FunctionNameConflict:
 |	"DATE"
 |	"DATABASE"
 |	"DAY"
+|	"GEOMETRYCOLLECTION"
 |	"HOUR"
 |	"IF"
 |	"INTERVAL" %prec lowerThanIntervalKeyword
 |	"FORMAT"
 |	"LEFT"
+|	"LINESTRING"
 |	"MICROSECOND"
 |	"MINUTE"
 |	"MONTH"
+|	"MULTILINESTRING"
+|	"MULTIPOINT"
+|	"MULTIPOLYGON"
 |	builtinNow
+|	"POINT"
+|	"POLYGON"
 |	"QUARTER"
 |	"REPEAT"
 |	"REPLACE"
@@ -7286,11 +8454,15 @@ PriorityOpt:
 TableName:
 	Identifier
 	{
-		$$ = &ast.TableName{Name: model.NewCIStr($1)}
+		tn := &ast.TableName{Name: model.NewCIStr($1)}
+		tn.SetOriginTextPosition(parser.startOffset(&yyS[yypt]))
+		$$ = tn
 	}
 |	Identifier '.' Identifier
 	{
-		$$ = &ast.TableName{Schema: model.NewCIStr($1), Name: model.NewCIStr($3)}
+		tn := &ast.TableName{Schema: model.NewCIStr($1), Name: model.NewCIStr($3)}
+		tn.SetOriginTextPosition(parser.startOffset(&yyS[yypt-2]))
+		$$ = tn
 	}
 
 TableNameList:
@@ -7418,13 +8590,107 @@ DeallocateSym:
 |	"DROP"
 
 RollbackStmt:
-	"ROLLBACK"
+	"ROLLBACK" WorkOpt
 	{
 		$$ = &ast.RollbackStmt{}
 	}
-|	"ROLLBACK" CompletionTypeWithinTransaction
+|	"ROLLBACK" WorkOpt CompletionTypeWithinTransaction
+	{
+		$$ = &ast.RollbackStmt{CompletionType: $3.(ast.CompletionType)}
+	}
+|	"ROLLBACK" WorkOpt "TO" Identifier
+	{
+		$$ = &ast.RollbackStmt{SavepointName: $4}
+	}
+|	"ROLLBACK" WorkOpt "TO" "SAVEPOINT" Identifier
 	{
-		$$ = &ast.RollbackStmt{CompletionType: $2.(ast.CompletionType)}
+		$$ = &ast.RollbackStmt{SavepointName: $5}
+	}
+
+SavepointStmt:
+	"SAVEPOINT" Identifier
+	{
+		$$ = &ast.SavepointStmt{Name: $2}
+	}
+
+ReleaseSavepointStmt:
+	"RELEASE" "SAVEPOINT" Identifier
+	{
+		$$ = &ast.ReleaseSavepointStmt{Name: $3}
+	}
+
+/*******************************************************************
+ *
+ *  XA Transaction Statements
+ *
+ *  See https://dev.mysql.com/doc/refman/5.7/en/xa-statements.html
+ *******************************************************************/
+XAStmt:
+	"XA" XAStartSym XAIdentifier
+	{
+		$$ = &ast.XAStmt{Tp: ast.XAStart, XID: $3.(*ast.XID)}
+	}
+|	"XA" XAStartSym XAIdentifier "JOIN"
+	{
+		$$ = &ast.XAStmt{Tp: ast.XAStart, XID: $3.(*ast.XID), Join: true}
+	}
+|	"XA" XAStartSym XAIdentifier "RESUME"
+	{
+		$$ = &ast.XAStmt{Tp: ast.XAStart, XID: $3.(*ast.XID), Resume: true}
+	}
+|	"XA" "END" XAIdentifier
+	{
+		$$ = &ast.XAStmt{Tp: ast.XAEnd, XID: $3.(*ast.XID)}
+	}
+|	"XA" "END" XAIdentifier "SUSPEND"
+	{
+		$$ = &ast.XAStmt{Tp: ast.XAEnd, XID: $3.(*ast.XID), Suspend: true}
+	}
+|	"XA" "END" XAIdentifier "SUSPEND" "FOR" "MIGRATE"
+	{
+		$$ = &ast.XAStmt{Tp: ast.XAEnd, XID: $3.(*ast.XID), Suspend: true, ForMigrate: true}
+	}
+|	"XA" "PREPARE" XAIdentifier
+	{
+		$$ = &ast.XAStmt{Tp: ast.XAPrepare, XID: $3.(*ast.XID)}
+	}
+|	"XA" "COMMIT" XAIdentifier
+	{
+		$$ = &ast.XAStmt{Tp: ast.XACommit, XID: $3.(*ast.XID)}
+	}
+|	"XA" "COMMIT" XAIdentifier "ONE" "PHASE"
+	{
+		$$ = &ast.XAStmt{Tp: ast.XACommit, XID: $3.(*ast.XID), OnePhase: true}
+	}
+|	"XA" "ROLLBACK" XAIdentifier
+	{
+		$$ = &ast.XAStmt{Tp: ast.XARollback, XID: $3.(*ast.XID)}
+	}
+|	"XA" "RECOVER"
+	{
+		$$ = &ast.XAStmt{Tp: ast.XARecover}
+	}
+|	"XA" "RECOVER" "CONVERT" "XID"
+	{
+		$$ = &ast.XAStmt{Tp: ast.XARecover, ConvertXID: true}
+	}
+
+XAStartSym:
+	"START"
+|	"BEGIN"
+
+XAIdentifier:
+	TextString
+	{
+		$$ = &ast.XID{GTRID: $1, FormatID: 1}
+	}
+|	TextString ',' TextString
+	{
+		$$ = &ast.XID{GTRID: $1, BQual: $3, FormatID: 1}
+	}
+|	TextString ',' TextString ',' NUM
+	{
+		$$ = &ast.XID{GTRID: $1, BQual: $3, FormatID: getUint64FromNUM($5)}
 	}
 
 CompletionTypeWithinTransaction:
@@ -7464,17 +8730,36 @@ ShutdownStmt:
 	}
 
 SelectStmtBasic:
-	"SELECT" SelectStmtOpts SelectStmtFieldList
+	"SELECT" SelectStmtOpts SelectStmtFieldList %prec lowerThanInto
+	{
+		st := &ast.SelectStmt{
+			SelectStmtOpts: $2.(*ast.SelectStmtOpts),
+			Distinct:       $2.(*ast.SelectStmtOpts).Distinct,
+			Fields:         $3.(*ast.FieldList),
+			Kind:           ast.SelectStmtKindSelect,
+		}
+		if st.SelectStmtOpts.TableHints != nil {
+			st.TableHints = st.SelectStmtOpts.TableHints
+		}
+		$$ = st
+	}
+|	"SELECT" SelectStmtOpts SelectStmtFieldList SelectIntoClause
 	{
 		st := &ast.SelectStmt{
 			SelectStmtOpts: $2.(*ast.SelectStmtOpts),
 			Distinct:       $2.(*ast.SelectStmtOpts).Distinct,
 			Fields:         $3.(*ast.FieldList),
 			Kind:           ast.SelectStmtKindSelect,
+			SelectIntoOpt:  $4.(*ast.SelectIntoOption),
 		}
 		if st.SelectStmtOpts.TableHints != nil {
 			st.TableHints = st.SelectStmtOpts.TableHints
 		}
+		lastField := st.Fields.Fields[len(st.Fields.Fields)-1]
+		if lastField.Expr != nil && lastField.AsName.O == "" {
+			lastEnd := parser.endOffset(&yyS[yypt])
+			lastField.SetText(parser.src[lastField.Offset:lastEnd])
+		}
 		$$ = st
 	}
 
@@ -7483,7 +8768,7 @@ SelectStmtFromDualTable:
 	{
 		st := $1.(*ast.SelectStmt)
 		lastField := st.Fields.Fields[len(st.Fields.Fields)-1]
-		if lastField.Expr != nil && lastField.AsName.O == "" {
+		if lastField.Expr != nil && lastField.AsName.O == "" && st.SelectIntoOpt == nil {
 			lastEnd := yyS[yypt-1].offset - 1
 			lastField.SetText(parser.src[lastField.Offset:lastEnd])
 		}
@@ -7498,7 +8783,7 @@ SelectStmtFromTable:
 		st := $1.(*ast.SelectStmt)
 		st.From = $3.(*ast.TableRefsClause)
 		lastField := st.Fields.Fields[len(st.Fields.Fields)-1]
-		if lastField.Expr != nil && lastField.AsName.O == "" {
+		if lastField.Expr != nil && lastField.AsName.O == "" && st.SelectIntoOpt == nil {
 			lastEnd := parser.endOffset(&yyS[yypt-5])
 			lastField.SetText(parser.src[lastField.Offset:lastEnd])
 		}
@@ -7597,7 +8882,7 @@ SelectStmt:
 			st.LockInfo = $5.(*ast.SelectLockInfo)
 		}
 		lastField := st.Fields.Fields[len(st.Fields.Fields)-1]
-		if lastField.Expr != nil && lastField.AsName.O == "" {
+		if lastField.Expr != nil && lastField.AsName.O == "" && st.SelectIntoOpt == nil {
 			src := parser.src
 			var lastEnd int
 			if $2 != nil {
@@ -7628,6 +8913,10 @@ SelectStmt:
 			st.Limit = $4.(*ast.Limit)
 		}
 		if $6 != nil {
+			if st.SelectIntoOpt != nil {
+				yylex.AppendError(yylex.Errorf("Multiple INTO clauses in one query block"))
+				return 1
+			}
 			st.SelectIntoOpt = $6.(*ast.SelectIntoOption)
 		}
 		$$ = st
@@ -7645,6 +8934,10 @@ SelectStmt:
 			st.LockInfo = $4.(*ast.SelectLockInfo)
 		}
 		if $5 != nil {
+			if st.SelectIntoOpt != nil {
+				yylex.AppendError(yylex.Errorf("Multiple INTO clauses in one query block"))
+				return 1
+			}
 			st.SelectIntoOpt = $5.(*ast.SelectIntoOption)
 		}
 		$$ = st
@@ -7662,6 +8955,10 @@ SelectStmt:
 			st.Limit = $3.(*ast.Limit)
 		}
 		if $5 != nil {
+			if st.SelectIntoOpt != nil {
+				yylex.AppendError(yylex.Errorf("Multiple INTO clauses in one query block"))
+				return 1
+			}
 			st.SelectIntoOpt = $5.(*ast.SelectIntoOption)
 		}
 		$$ = st
@@ -7839,7 +9136,9 @@ WindowFrameStart:
 	}
 |	paramMarker "PRECEDING"
 	{
-		$$ = ast.FrameBound{Type: ast.Preceding, Expr: ast.NewParamMarkerExpr(yyS[yypt].offset)}
+		marker := ast.NewParamMarkerExpr(yyS[yypt-1].offset)
+		marker.SetOriginTextPosition(yyS[yypt-1].offset)
+		$$ = ast.FrameBound{Type: ast.Preceding, Expr: marker}
 	}
 |	"INTERVAL" Expression TimeUnit "PRECEDING"
 	{
@@ -7868,7 +9167,9 @@ WindowFrameBound:
 	}
 |	paramMarker "FOLLOWING"
 	{
-		$$ = ast.FrameBound{Type: ast.Following, Expr: ast.NewParamMarkerExpr(yyS[yypt].offset)}
+		marker := ast.NewParamMarkerExpr(yyS[yypt-1].offset)
+		marker.SetOriginTextPosition(yyS[yypt-1].offset)
+		$$ = ast.FrameBound{Type: ast.Following, Expr: marker}
 	}
 |	"INTERVAL" Expression TimeUnit "FOLLOWING"
 	{
@@ -8054,7 +9355,9 @@ TableFactor:
 		if $5 != nil {
 			tn.TableSample = $5.(*ast.TableSample)
 		}
-		$$ = &ast.TableSource{Source: tn, AsName: $3.(model.CIStr)}
+		ts := &ast.TableSource{Source: tn, AsName: $3.(model.CIStr)}
+		ts.SetOriginTextPosition(tn.OriginTextPosition())
+		$$ = ts
 	}
 |	'(' SetOprStmt1 ')' TableAsNameOpt
 	{
@@ -8062,7 +9365,9 @@ TableFactor:
 			endOffset := parser.endOffset(&yyS[yypt-1])
 			parser.setLastSelectFieldText(st, endOffset)
 		}
-		$$ = &ast.TableSource{Source: $2.(ast.ResultSetNode), AsName: $4.(model.CIStr)}
+		ts := &ast.TableSource{Source: $2.(ast.ResultSetNode), AsName: $4.(model.CIStr)}
+		ts.SetOriginTextPosition(parser.startOffset(&yyS[yypt-3]))
+		$$ = ts
 	}
 |	'(' TableRefs ')'
 	{
@@ -8070,6 +9375,107 @@ TableFactor:
 		j.ExplicitParens = true
 		$$ = $2
 	}
+|	"LATERAL" '(' SetOprStmt1 ')' TableAsName
+	{
+		if st, isSel := $3.(*ast.SelectStmt); isSel {
+			endOffset := parser.endOffset(&yyS[yypt-1])
+			parser.setLastSelectFieldText(st, endOffset)
+		}
+		ts := &ast.TableSource{Source: &ast.LateralTable{Query: $3.(ast.ResultSetNode)}, AsName: $5.(model.CIStr)}
+		ts.SetOriginTextPosition(parser.startOffset(&yyS[yypt-4]))
+		$$ = ts
+	}
+|	"JSON_TABLE" '(' Expression ',' stringLit JSONTableColumns ')' TableAsName
+	{
+		ts := &ast.TableSource{
+			Source: &ast.JSONTable{Expr: $3, Path: $5, Columns: $6.([]*ast.JSONTableColumn)},
+			AsName: $8.(model.CIStr),
+		}
+		ts.SetOriginTextPosition(parser.startOffset(&yyS[yypt-7]))
+		$$ = ts
+	}
+
+JSONTableColumns:
+	"COLUMNS" '(' JSONTableColumnList ')'
+	{
+		$$ = $3
+	}
+
+JSONTableColumnList:
+	JSONTableColumn
+	{
+		$$ = []*ast.JSONTableColumn{$1.(*ast.JSONTableColumn)}
+	}
+|	JSONTableColumnList ',' JSONTableColumn
+	{
+		$$ = append($1.([]*ast.JSONTableColumn), $3.(*ast.JSONTableColumn))
+	}
+
+JSONTableColumn:
+	Identifier "FOR" "ORDINALITY"
+	{
+		$$ = &ast.JSONTableColumn{Tp: ast.JSONTableColumnOrdinality, Name: model.NewCIStr($1)}
+	}
+|	Identifier Type "PATH" stringLit JSONTableOnResponseOpt
+	{
+		onResponse := $5.([]interface{})
+		$$ = &ast.JSONTableColumn{
+			Tp:        ast.JSONTableColumnPath,
+			Name:      model.NewCIStr($1),
+			FieldType: $2.(*types.FieldType),
+			Path:      $4,
+			OnEmpty:   onResponse[0].(ast.JSONTableOnResponse),
+			OnError:   onResponse[1].(ast.JSONTableOnResponse),
+		}
+	}
+|	Identifier Type "EXISTS" "PATH" stringLit
+	{
+		$$ = &ast.JSONTableColumn{
+			Tp:        ast.JSONTableColumnExists,
+			Name:      model.NewCIStr($1),
+			FieldType: $2.(*types.FieldType),
+			Path:      $5,
+		}
+	}
+|	"NESTED" "PATH" stringLit JSONTableColumns
+	{
+		$$ = &ast.JSONTableColumn{Tp: ast.JSONTableColumnNested, Path: $3, Columns: $4.([]*ast.JSONTableColumn)}
+	}
+|	"NESTED" stringLit JSONTableColumns
+	{
+		$$ = &ast.JSONTableColumn{Tp: ast.JSONTableColumnNested, Path: $2, Columns: $3.([]*ast.JSONTableColumn)}
+	}
+
+JSONTableOnResponseOpt:
+	{
+		$$ = []interface{}{ast.JSONTableOnResponse{}, ast.JSONTableOnResponse{}}
+	}
+|	JSONTableOnResponse "ON" "EMPTY"
+	{
+		$$ = []interface{}{$1, ast.JSONTableOnResponse{}}
+	}
+|	JSONTableOnResponse "ON" "ERROR"
+	{
+		$$ = []interface{}{ast.JSONTableOnResponse{}, $1}
+	}
+|	JSONTableOnResponse "ON" "EMPTY" JSONTableOnResponse "ON" "ERROR"
+	{
+		$$ = []interface{}{$1, $4}
+	}
+
+JSONTableOnResponse:
+	"NULL"
+	{
+		$$ = ast.JSONTableOnResponse{Tp: ast.JSONTableOnResponseNull}
+	}
+|	"ERROR"
+	{
+		$$ = ast.JSONTableOnResponse{Tp: ast.JSONTableOnResponseError}
+	}
+|	"DEFAULT" stringLit
+	{
+		$$ = ast.JSONTableOnResponse{Tp: ast.JSONTableOnResponseDefault, Default: $2}
+	}
 
 PartitionNameListOpt:
 	/* empty */
@@ -8253,7 +9659,9 @@ LimitOption:
 	}
 |	paramMarker
 	{
-		$$ = ast.NewParamMarkerExpr(yyS[yypt].offset)
+		marker := ast.NewParamMarkerExpr(yyS[yypt].offset)
+		marker.SetOriginTextPosition(yyS[yypt].offset)
+		$$ = marker
 	}
 
 RowOrRows:
@@ -8465,7 +9873,10 @@ SelectStmtIntoOption:
 	{
 		$$ = nil
 	}
-|	"INTO" "OUTFILE" stringLit Fields Lines
+|	SelectIntoClause
+
+SelectIntoClause:
+	"INTO" "OUTFILE" stringLit Fields Lines
 	{
 		x := &ast.SelectIntoOption{
 			Tp:       ast.SelectIntoOutfile,
@@ -8478,7 +9889,35 @@ SelectStmtIntoOption:
 			x.LinesInfo = $5.(*ast.LinesClause)
 		}
 
-		$$ = x
+		$$ = x
+	}
+|	"INTO" SelectIntoVarList
+	{
+		$$ = &ast.SelectIntoOption{
+			Tp:   ast.SelectIntoVars,
+			Vars: $2.([]ast.ExprNode),
+		}
+	}
+
+SelectIntoVarList:
+	SelectIntoVar
+	{
+		$$ = []ast.ExprNode{$1.(ast.ExprNode)}
+	}
+|	SelectIntoVarList ',' SelectIntoVar
+	{
+		$$ = append($1.([]ast.ExprNode), $3.(ast.ExprNode))
+	}
+
+// SelectIntoVar is a user variable or a local variable of a stored program.
+SelectIntoVar:
+	UserVariable
+	{
+		$$ = $1
+	}
+|	Identifier
+	{
+		$$ = &ast.ColumnNameExpr{Name: &ast.ColumnName{Name: model.NewCIStr($1)}}
 	}
 
 // See https://dev.mysql.com/doc/refman/5.7/en/subqueries.html
@@ -8516,43 +9955,84 @@ SelectLockOpt:
 	{
 		$$ = nil
 	}
-|	"FOR" "UPDATE"
+|	SelectLockList
+|	"LOCK" "IN" "SHARE" "MODE"
 	{
-		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForUpdate}
+		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForShare}
 	}
-|	"FOR" "SHARE"
+
+SelectLockList:
+	SelectLock
+|	SelectLockList SelectLock
 	{
-		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForShare}
+		last := $1.(*ast.SelectLockInfo)
+		for last.Next != nil {
+			last = last.Next
+		}
+		last.Next = $2.(*ast.SelectLockInfo)
+		$$ = $1
+	}
+
+SelectLock:
+	"FOR" "UPDATE" SelectLockTablesOpt
+	{
+		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForUpdate, Tables: $3.([]*ast.TableName)}
+	}
+|	"FOR" "SHARE" SelectLockTablesOpt
+	{
+		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForShare, Tables: $3.([]*ast.TableName)}
 	}
-|	"FOR" "UPDATE" "NOWAIT"
+|	"FOR" "UPDATE" SelectLockTablesOpt "NOWAIT"
 	{
-		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForUpdateNoWait}
+		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForUpdateNoWait, Tables: $3.([]*ast.TableName)}
 	}
-|	"FOR" "UPDATE" "WAIT" NUM
+|	"FOR" "UPDATE" SelectLockTablesOpt "WAIT" NUM
 	{
 		$$ = &ast.SelectLockInfo{
 			LockType: ast.SelectLockForUpdateWaitN,
-			WaitSec:  getUint64FromNUM($4),
+			WaitSec:  getUint64FromNUM($5),
+			Tables:   $3.([]*ast.TableName),
 		}
 	}
-|	"FOR" "SHARE" "NOWAIT"
+|	"FOR" "SHARE" SelectLockTablesOpt "NOWAIT"
 	{
-		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForShareNoWait}
+		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForShareNoWait, Tables: $3.([]*ast.TableName)}
 	}
-|	"FOR" "UPDATE" "SKIP" "LOCKED"
+|	"FOR" "UPDATE" SelectLockTablesOpt "SKIP" "LOCKED"
 	{
-		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForUpdateSkipLocked}
+		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForUpdateSkipLocked, Tables: $3.([]*ast.TableName)}
 	}
-|	"FOR" "SHARE" "SKIP" "LOCKED"
+|	"FOR" "SHARE" SelectLockTablesOpt "SKIP" "LOCKED"
 	{
-		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForShareSkipLocked}
+		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForShareSkipLocked, Tables: $3.([]*ast.TableName)}
 	}
-|	"LOCK" "IN" "SHARE" "MODE"
+
+SelectLockTablesOpt:
 	{
-		$$ = &ast.SelectLockInfo{LockType: ast.SelectLockForShare}
+		$$ = []*ast.TableName(nil)
+	}
+|	"OF" TableNameList
+	{
+		$$ = $2
 	}
 
 SetOprStmt1:
+	SetOprStmtNoWith1
+|	WithClause SetOprStmtNoWith1
+	{
+		setWithClause($2, $1.(*ast.WithClause))
+		$$ = $2
+	}
+
+SetOprStmt2:
+	SetOprStmtNoWith2
+|	WithClause SetOprStmtNoWith2
+	{
+		setWithClause($2, $1.(*ast.WithClause))
+		$$ = $2
+	}
+
+SetOprStmtNoWith1:
 	SetOprClauseList %prec lowerThanParenthese
 	{
 		setOpr := &ast.SetOprStmt{SelectList: &ast.SetOprSelectList{Selects: $1.([]ast.Node)}}
@@ -8571,7 +10051,7 @@ SetOprStmt1:
 	}
 |	SetOprStmt
 
-SetOprStmt2:
+SetOprStmtNoWith2:
 	SetOprClauseList %prec higherThanParenthese
 	{
 		setOpr := &ast.SetOprStmt{SelectList: &ast.SetOprSelectList{Selects: $1.([]ast.Node)}}
@@ -8590,6 +10070,44 @@ SetOprStmt2:
 	}
 |	SetOprStmt
 
+// See https://dev.mysql.com/doc/refman/8.0/en/with.html
+WithClause:
+	"WITH" WithList
+	{
+		$$ = &ast.WithClause{CTEs: $2.([]*ast.CommonTableExpression)}
+	}
+|	"WITH" "RECURSIVE" WithList
+	{
+		ctes := $3.([]*ast.CommonTableExpression)
+		for _, cte := range ctes {
+			cte.IsRecursive = true
+		}
+		$$ = &ast.WithClause{IsRecursive: true, CTEs: ctes}
+	}
+
+WithList:
+	CommonTableExpr
+	{
+		$$ = []*ast.CommonTableExpression{$1.(*ast.CommonTableExpression)}
+	}
+|	WithList ',' CommonTableExpr
+	{
+		$$ = append($1.([]*ast.CommonTableExpression), $3.(*ast.CommonTableExpression))
+	}
+
+CommonTableExpr:
+	Identifier ViewFieldList "AS" SubSelect
+	{
+		cte := &ast.CommonTableExpression{
+			Name:  model.NewCIStr($1),
+			Query: $4.(*ast.SubqueryExpr),
+		}
+		if $2 != nil {
+			cte.ColNameList = $2.([]model.CIStr)
+		}
+		$$ = cte
+	}
+
 // See https://dev.mysql.com/doc/refman/5.7/en/union.html
 // See https://mariadb.com/kb/en/intersect/
 // See https://mariadb.com/kb/en/except/
@@ -8640,127 +10158,393 @@ SetOprStmt:
 		setOpr.Limit = $7.(*ast.Limit)
 		$$ = setOpr
 	}
-|	'(' SetOprClauseList ')' OrderBy
+|	'(' SetOprClauseList ')' OrderBy
+	{
+		setOprList := $2.([]ast.Node)
+		if sel, isSelect := setOprList[0].(*ast.SelectStmt); isSelect && !sel.IsInBraces && len(setOprList) == 1 {
+			endOffset := parser.endOffset(&yyS[yypt-1])
+			parser.setLastSelectFieldText(sel, endOffset)
+		}
+		setOpr := &ast.SetOprStmt{SelectList: &ast.SetOprSelectList{Selects: []ast.Node{&ast.SetOprSelectList{Selects: $2.([]ast.Node)}}}}
+		setOpr.OrderBy = $4.(*ast.OrderByClause)
+		$$ = setOpr
+	}
+|	'(' SetOprClauseList ')' SelectStmtLimit
+	{
+		setOprList := $2.([]ast.Node)
+		if sel, isSelect := setOprList[0].(*ast.SelectStmt); isSelect && !sel.IsInBraces && len(setOprList) == 1 {
+			endOffset := parser.endOffset(&yyS[yypt-1])
+			parser.setLastSelectFieldText(sel, endOffset)
+		}
+		setOpr := &ast.SetOprStmt{SelectList: &ast.SetOprSelectList{Selects: []ast.Node{&ast.SetOprSelectList{Selects: setOprList}}}}
+		setOpr.Limit = $4.(*ast.Limit)
+		$$ = setOpr
+	}
+|	'(' SetOprClauseList ')' OrderBy SelectStmtLimit
+	{
+		setOprList := $2.([]ast.Node)
+		if sel, isSelect := setOprList[0].(*ast.SelectStmt); isSelect && !sel.IsInBraces && len(setOprList) == 1 {
+			endOffset := parser.endOffset(&yyS[yypt-2])
+			parser.setLastSelectFieldText(sel, endOffset)
+		}
+		setOpr := &ast.SetOprStmt{SelectList: &ast.SetOprSelectList{Selects: []ast.Node{&ast.SetOprSelectList{Selects: setOprList}}}}
+		setOpr.OrderBy = $4.(*ast.OrderByClause)
+		setOpr.Limit = $5.(*ast.Limit)
+		$$ = setOpr
+	}
+
+SetOprClauseList:
+	SetOprClause
+|	SetOprClauseList SetOpr SetOprClause
+	{
+		setOprList1 := $1.([]ast.Node)
+		setOprList2 := $3.([]ast.Node)
+		if sel, isSelect := setOprList1[len(setOprList1)-1].(*ast.SelectStmt); isSelect && !sel.IsInBraces {
+			endOffset := parser.endOffset(&yyS[yypt-1])
+			parser.setLastSelectFieldText(sel, endOffset)
+		}
+		switch x := setOprList2[0].(type) {
+		case *ast.SelectStmt:
+			x.AfterSetOperator = $2.(*ast.SetOprType)
+		case *ast.SetOprSelectList:
+			x.AfterSetOperator = $2.(*ast.SetOprType)
+		}
+		$$ = append(setOprList1, setOprList2...)
+	}
+
+SetOprClause:
+	SelectStmt
+	{
+		$$ = []ast.Node{$1.(*ast.SelectStmt)}
+	}
+|	'(' SetOprClauseList ')'
+	{
+		setList := $2.([]ast.Node)
+		if sel, isSelect := setList[0].(*ast.SelectStmt); isSelect && len(setList) == 1 {
+			endOffset := parser.endOffset(&yyS[yypt])
+			parser.setLastSelectFieldText(sel, endOffset)
+			sel.IsInBraces = true
+		} else {
+			setList = []ast.Node{&ast.SetOprSelectList{Selects: $2.([]ast.Node)}}
+		}
+		$$ = setList
+	}
+
+SetOpr:
+	"UNION" SetOprOpt
+	{
+		var tp ast.SetOprType
+		tp = ast.Union
+		if $2 == false {
+			tp = ast.UnionAll
+		}
+		$$ = &tp
+	}
+|	"EXCEPT" SetOprOpt
+	{
+		var tp ast.SetOprType
+		tp = ast.Except
+		if $2 == false {
+			tp = ast.ExceptAll
+		}
+		$$ = &tp
+	}
+|	"INTERSECT" SetOprOpt
+	{
+		var tp ast.SetOprType
+		tp = ast.Intersect
+		if $2 == false {
+			tp = ast.IntersectAll
+		}
+		$$ = &tp
+	}
+
+SetOprOpt:
+	DefaultTrueDistinctOpt
+
+/********************Change Statement*******************************/
+ChangeStmt:
+	"CHANGE" "PUMP" "TO" "NODE_STATE" eq stringLit forKwd "NODE_ID" stringLit
+	{
+		$$ = &ast.ChangeStmt{
+			NodeType: ast.PumpType,
+			State:    $6,
+			NodeID:   $9,
+		}
+	}
+|	"CHANGE" "DRAINER" "TO" "NODE_STATE" eq stringLit forKwd "NODE_ID" stringLit
+	{
+		$$ = &ast.ChangeStmt{
+			NodeType: ast.DrainerType,
+			State:    $6,
+			NodeID:   $9,
+		}
+	}
+
+/* See https://dev.mysql.com/doc/refman/8.0/en/change-replication-source-to.html */
+ChangeReplicationSourceStmt:
+	"CHANGE" "MASTER" "TO" ReplicationSourceOptionList ChannelOpt
+	{
+		$$ = &ast.ChangeReplicationSourceStmt{
+			Legacy:  true,
+			Options: $4.([]*ast.ReplicationOption),
+			Channel: $5,
+		}
+	}
+|	"CHANGE" "REPLICATION" "SOURCE" "TO" ReplicationSourceOptionList ChannelOpt
+	{
+		$$ = &ast.ChangeReplicationSourceStmt{
+			Options: $5.([]*ast.ReplicationOption),
+			Channel: $6,
+		}
+	}
+
+ReplicationSourceOptionList:
+	ReplicationSourceOption
+	{
+		$$ = []*ast.ReplicationOption{$1.(*ast.ReplicationOption)}
+	}
+|	ReplicationSourceOptionList ',' ReplicationSourceOption
+	{
+		$$ = append($1.([]*ast.ReplicationOption), $3.(*ast.ReplicationOption))
+	}
+
+ReplicationSourceOption:
+	Identifier eq ReplicationOptionValue
+	{
+		opt, errMsg := newReplicationOption(replicationSourceOptions, $1, $3.(*ast.ReplicationOption))
+		if len(errMsg) != 0 {
+			yylex.AppendError(yylex.Errorf(errMsg))
+			return 1
+		}
+		$$ = opt
+	}
+
+ReplicationOptionValue:
+	stringLit
+	{
+		$$ = &ast.ReplicationOption{Tp: ast.ReplicationOptionString, StrValue: $1}
+	}
+|	LengthNum
+	{
+		$$ = &ast.ReplicationOption{Tp: ast.ReplicationOptionUint, UintValue: $1.(uint64)}
+	}
+|	decLit
+	{
+		$$ = &ast.ReplicationOption{Tp: ast.ReplicationOptionDecimal, StrValue: $<ident>1}
+	}
+|	"ON"
+	{
+		$$ = &ast.ReplicationOption{Tp: ast.ReplicationOptionKeyword, StrValue: "ON"}
+	}
+|	"NULL"
+	{
+		$$ = &ast.ReplicationOption{Tp: ast.ReplicationOptionKeyword, StrValue: "NULL"}
+	}
+|	Identifier
+	{
+		$$ = &ast.ReplicationOption{Tp: ast.ReplicationOptionKeyword, StrValue: strings.ToUpper($1)}
+	}
+|	'(' ')'
+	{
+		$$ = &ast.ReplicationOption{Tp: ast.ReplicationOptionIDList}
+	}
+|	'(' ReplicationIDList ')'
+	{
+		$$ = &ast.ReplicationOption{Tp: ast.ReplicationOptionIDList, IDs: $2.([]uint64)}
+	}
+
+ReplicationIDList:
+	LengthNum
+	{
+		$$ = []uint64{$1.(uint64)}
+	}
+|	ReplicationIDList ',' LengthNum
+	{
+		$$ = append($1.([]uint64), $3.(uint64))
+	}
+
+ChannelOpt:
+	{
+		$$ = ""
+	}
+|	"FOR" "CHANNEL" stringLit
+	{
+		$$ = $3
+	}
+
+SlaveOrReplica:
+	"SLAVE"
+	{
+		$$ = true
+	}
+|	"REPLICA"
+	{
+		$$ = false
+	}
+
+/* See https://dev.mysql.com/doc/refman/8.0/en/start-replica.html */
+StartReplicaStmt:
+	"START" SlaveOrReplica ReplicaThreadListOpt ReplicaUntilOpt ReplicaConnectionOptionListOpt ChannelOpt
+	{
+		$$ = &ast.StartReplicaStmt{
+			Legacy:     $2.(bool),
+			Threads:    $3.([]ast.ReplicaThread),
+			Until:      $4.([]*ast.ReplicationOption),
+			Connection: $5.([]*ast.ReplicationOption),
+			Channel:    $6,
+		}
+	}
+
+/* See https://dev.mysql.com/doc/refman/8.0/en/stop-replica.html */
+StopReplicaStmt:
+	"STOP" SlaveOrReplica ReplicaThreadListOpt ChannelOpt
+	{
+		$$ = &ast.StopReplicaStmt{
+			Legacy:  $2.(bool),
+			Threads: $3.([]ast.ReplicaThread),
+			Channel: $4,
+		}
+	}
+
+ReplicaThreadListOpt:
+	{
+		$$ = []ast.ReplicaThread(nil)
+	}
+|	ReplicaThreadList
+
+ReplicaThreadList:
+	ReplicaThread
+	{
+		$$ = []ast.ReplicaThread{$1.(ast.ReplicaThread)}
+	}
+|	ReplicaThreadList ',' ReplicaThread
+	{
+		$$ = append($1.([]ast.ReplicaThread), $3.(ast.ReplicaThread))
+	}
+
+ReplicaThread:
+	"IO_THREAD"
+	{
+		$$ = ast.ReplicaIOThread
+	}
+|	"SQL_THREAD"
 	{
-		setOprList := $2.([]ast.Node)
-		if sel, isSelect := setOprList[0].(*ast.SelectStmt); isSelect && !sel.IsInBraces && len(setOprList) == 1 {
-			endOffset := parser.endOffset(&yyS[yypt-1])
-			parser.setLastSelectFieldText(sel, endOffset)
-		}
-		setOpr := &ast.SetOprStmt{SelectList: &ast.SetOprSelectList{Selects: []ast.Node{&ast.SetOprSelectList{Selects: $2.([]ast.Node)}}}}
-		setOpr.OrderBy = $4.(*ast.OrderByClause)
-		$$ = setOpr
+		$$ = ast.ReplicaSQLThread
 	}
-|	'(' SetOprClauseList ')' SelectStmtLimit
+
+ReplicaUntilOpt:
 	{
-		setOprList := $2.([]ast.Node)
-		if sel, isSelect := setOprList[0].(*ast.SelectStmt); isSelect && !sel.IsInBraces && len(setOprList) == 1 {
-			endOffset := parser.endOffset(&yyS[yypt-1])
-			parser.setLastSelectFieldText(sel, endOffset)
-		}
-		setOpr := &ast.SetOprStmt{SelectList: &ast.SetOprSelectList{Selects: []ast.Node{&ast.SetOprSelectList{Selects: setOprList}}}}
-		setOpr.Limit = $4.(*ast.Limit)
-		$$ = setOpr
+		$$ = []*ast.ReplicationOption(nil)
 	}
-|	'(' SetOprClauseList ')' OrderBy SelectStmtLimit
+|	"UNTIL" ReplicaUntilOptionList
 	{
-		setOprList := $2.([]ast.Node)
-		if sel, isSelect := setOprList[0].(*ast.SelectStmt); isSelect && !sel.IsInBraces && len(setOprList) == 1 {
-			endOffset := parser.endOffset(&yyS[yypt-2])
-			parser.setLastSelectFieldText(sel, endOffset)
-		}
-		setOpr := &ast.SetOprStmt{SelectList: &ast.SetOprSelectList{Selects: []ast.Node{&ast.SetOprSelectList{Selects: setOprList}}}}
-		setOpr.OrderBy = $4.(*ast.OrderByClause)
-		setOpr.Limit = $5.(*ast.Limit)
-		$$ = setOpr
+		$$ = $2
 	}
 
-SetOprClauseList:
-	SetOprClause
-|	SetOprClauseList SetOpr SetOprClause
+ReplicaUntilOptionList:
+	ReplicaUntilOption
 	{
-		setOprList1 := $1.([]ast.Node)
-		setOprList2 := $3.([]ast.Node)
-		if sel, isSelect := setOprList1[len(setOprList1)-1].(*ast.SelectStmt); isSelect && !sel.IsInBraces {
-			endOffset := parser.endOffset(&yyS[yypt-1])
-			parser.setLastSelectFieldText(sel, endOffset)
+		$$ = []*ast.ReplicationOption{$1.(*ast.ReplicationOption)}
+	}
+|	ReplicaUntilOptionList ',' ReplicaUntilOption
+	{
+		$$ = append($1.([]*ast.ReplicationOption), $3.(*ast.ReplicationOption))
+	}
+
+ReplicaUntilOption:
+	Identifier
+	{
+		opt, errMsg := newReplicationOption(replicaUntilOptions, $1, &ast.ReplicationOption{Tp: ast.ReplicationOptionNone})
+		if len(errMsg) != 0 {
+			yylex.AppendError(yylex.Errorf(errMsg))
+			return 1
 		}
-		switch x := setOprList2[0].(type) {
-		case *ast.SelectStmt:
-			x.AfterSetOperator = $2.(*ast.SetOprType)
-		case *ast.SetOprSelectList:
-			x.AfterSetOperator = $2.(*ast.SetOprType)
+		$$ = opt
+	}
+|	Identifier eq ReplicationOptionValue
+	{
+		opt, errMsg := newReplicationOption(replicaUntilOptions, $1, $3.(*ast.ReplicationOption))
+		if len(errMsg) != 0 {
+			yylex.AppendError(yylex.Errorf(errMsg))
+			return 1
 		}
-		$$ = append(setOprList1, setOprList2...)
+		$$ = opt
 	}
 
-SetOprClause:
-	SelectStmt
+ReplicaConnectionOptionListOpt:
 	{
-		$$ = []ast.Node{$1.(*ast.SelectStmt)}
+		$$ = []*ast.ReplicationOption(nil)
 	}
-|	'(' SetOprClauseList ')'
+|	ReplicaConnectionOptionListOpt ReplicaConnectionOption
 	{
-		setList := $2.([]ast.Node)
-		if sel, isSelect := setList[0].(*ast.SelectStmt); isSelect && len(setList) == 1 {
-			endOffset := parser.endOffset(&yyS[yypt])
-			parser.setLastSelectFieldText(sel, endOffset)
-			sel.IsInBraces = true
-		} else {
-			setList = []ast.Node{&ast.SetOprSelectList{Selects: $2.([]ast.Node)}}
-		}
-		$$ = setList
+		$$ = append($1.([]*ast.ReplicationOption), $2.(*ast.ReplicationOption))
 	}
 
-SetOpr:
-	"UNION" SetOprOpt
+ReplicaConnectionOption:
+	"USER" eq stringLit
 	{
-		var tp ast.SetOprType
-		tp = ast.Union
-		if $2 == false {
-			tp = ast.UnionAll
-		}
-		$$ = &tp
+		$$ = &ast.ReplicationOption{Name: "USER", Tp: ast.ReplicationOptionString, StrValue: $3}
 	}
-|	"EXCEPT" SetOprOpt
+|	"PASSWORD" eq stringLit
 	{
-		var tp ast.SetOprType
-		tp = ast.Except
-		if $2 == false {
-			tp = ast.ExceptAll
-		}
-		$$ = &tp
+		$$ = &ast.ReplicationOption{Name: "PASSWORD", Tp: ast.ReplicationOptionString, StrValue: $3}
 	}
-|	"INTERSECT" SetOprOpt
+|	"DEFAULT_AUTH" eq stringLit
 	{
-		var tp ast.SetOprType
-		tp = ast.Intersect
-		if $2 == false {
-			tp = ast.IntersectAll
-		}
-		$$ = &tp
+		$$ = &ast.ReplicationOption{Name: "DEFAULT_AUTH", Tp: ast.ReplicationOptionString, StrValue: $3}
+	}
+|	"PLUGIN_DIR" eq stringLit
+	{
+		$$ = &ast.ReplicationOption{Name: "PLUGIN_DIR", Tp: ast.ReplicationOptionString, StrValue: $3}
 	}
 
-SetOprOpt:
-	DefaultTrueDistinctOpt
+/* See https://dev.mysql.com/doc/refman/8.0/en/reset-master.html */
+ResetMasterStmt:
+	"RESET" "MASTER" ResetMasterToOpt
+	{
+		$$ = &ast.ResetMasterStmt{To: $3.(uint64)}
+	}
 
-/********************Change Statement*******************************/
-ChangeStmt:
-	"CHANGE" "PUMP" "TO" "NODE_STATE" eq stringLit forKwd "NODE_ID" stringLit
+ResetMasterToOpt:
 	{
-		$$ = &ast.ChangeStmt{
-			NodeType: ast.PumpType,
-			State:    $6,
-			NodeID:   $9,
-		}
+		$$ = uint64(0)
 	}
-|	"CHANGE" "DRAINER" "TO" "NODE_STATE" eq stringLit forKwd "NODE_ID" stringLit
+|	"TO" LengthNum
 	{
-		$$ = &ast.ChangeStmt{
-			NodeType: ast.DrainerType,
-			State:    $6,
-			NodeID:   $9,
-		}
+		$$ = $2
+	}
+
+/* See https://dev.mysql.com/doc/refman/8.0/en/reset-replica.html */
+ResetReplicaStmt:
+	"RESET" SlaveOrReplica ChannelOpt
+	{
+		$$ = &ast.ResetReplicaStmt{Legacy: $2.(bool), Channel: $3}
+	}
+|	"RESET" SlaveOrReplica "ALL" ChannelOpt
+	{
+		$$ = &ast.ResetReplicaStmt{Legacy: $2.(bool), All: true, Channel: $4}
+	}
+
+/* See https://dev.mysql.com/doc/refman/8.0/en/purge-binary-logs.html */
+PurgeBinaryLogsStmt:
+	"PURGE" BinaryOrMaster "LOGS" "TO" stringLit
+	{
+		$$ = &ast.PurgeBinaryLogsStmt{Legacy: $2.(bool), To: $5}
+	}
+|	"PURGE" BinaryOrMaster "LOGS" "BEFORE" Expression
+	{
+		$$ = &ast.PurgeBinaryLogsStmt{Legacy: $2.(bool), Before: $5}
+	}
+
+BinaryOrMaster:
+	"BINARY"
+	{
+		$$ = false
+	}
+|	"MASTER"
+	{
+		$$ = true
 	}
 
 /********************Set Statement*******************************/
@@ -10031,6 +11815,8 @@ Statement:
 |	AlterUserStmt
 |	AlterInstanceStmt
 |	AlterSequenceStmt
+|	AlterRoutineStmt
+|	AlterEventStmt
 |	AnalyzeTableStmt
 |	BeginTransactionStmt
 |	BinlogStmt
@@ -10045,6 +11831,9 @@ Statement:
 |	CreateIndexStmt
 |	CreateTableStmt
 |	CreateViewStmt
+|	CreateRoutineStmt
+|	CreateTriggerStmt
+|	CreateEventStmt
 |	CreateUserStmt
 |	CreateRoleStmt
 |	CreateBindingStmt
@@ -10056,6 +11845,9 @@ Statement:
 |	DropTableStmt
 |	DropSequenceStmt
 |	DropViewStmt
+|	DropRoutineStmt
+|	DropTriggerStmt
+|	DropEventStmt
 |	DropUserStmt
 |	DropRoleStmt
 |	DropStatisticsStmt
@@ -10075,11 +11867,13 @@ Statement:
 |	PreparedStmt
 |	PurgeImportStmt
 |	RollbackStmt
+|	ReleaseSavepointStmt
 |	RenameTableStmt
 |	ReplaceIntoStmt
 |	RecoverTableStmt
 |	RevokeStmt
 |	RevokeRoleStmt
+|	SavepointStmt
 |	SetOprStmt1
 |	SetStmt
 |	SetRoleStmt
@@ -10099,6 +11893,22 @@ Statement:
 |	UnlockTablesStmt
 |	LockTablesStmt
 |	ShutdownStmt
+|	XAStmt
+|	AlterResourceGroupStmt
+|	ChangeReplicationSourceStmt
+|	CloneStmt
+|	CreateResourceGroupStmt
+|	DropResourceGroupStmt
+|	HelpStmt
+|	InstallComponentStmt
+|	InstallPluginStmt
+|	PurgeBinaryLogsStmt
+|	ResetMasterStmt
+|	ResetReplicaStmt
+|	StartReplicaStmt
+|	StopReplicaStmt
+|	UninstallComponentStmt
+|	UninstallPluginStmt
 
 TraceableStmt:
 	DeleteFromStmt
@@ -10463,6 +12273,45 @@ Type:
 	NumericType
 |	StringType
 |	DateAndTimeType
+|	SpatialType
+
+SpatialType:
+	"GEOMETRY"
+	{
+		$$ = newGeometryFieldType(mysql.GeometryTypeGeometry)
+	}
+|	"POINT"
+	{
+		$$ = newGeometryFieldType(mysql.GeometryTypePoint)
+	}
+|	"LINESTRING"
+	{
+		$$ = newGeometryFieldType(mysql.GeometryTypeLineString)
+	}
+|	"POLYGON"
+	{
+		$$ = newGeometryFieldType(mysql.GeometryTypePolygon)
+	}
+|	"MULTIPOINT"
+	{
+		$$ = newGeometryFieldType(mysql.GeometryTypeMultiPoint)
+	}
+|	"MULTILINESTRING"
+	{
+		$$ = newGeometryFieldType(mysql.GeometryTypeMultiLineString)
+	}
+|	"MULTIPOLYGON"
+	{
+		$$ = newGeometryFieldType(mysql.GeometryTypeMultiPolygon)
+	}
+|	"GEOMETRYCOLLECTION"
+	{
+		$$ = newGeometryFieldType(mysql.GeometryTypeGeometryCollection)
+	}
+|	"GEOMCOLLECTION"
+	{
+		$$ = newGeometryFieldType(mysql.GeometryTypeGeometryCollection)
+	}
 
 NumericType:
 	IntegerType OptFieldLen FieldOpts
@@ -10977,6 +12826,7 @@ FieldLen:
 	}
 
 OptFieldLen:
+	%prec lowerThanParenthese
 	{
 		$$ = types.UnspecifiedLength
 	}
@@ -11006,6 +12856,7 @@ FieldOpts:
 	}
 
 FloatOpt:
+	%prec lowerThanParenthese
 	{
 		$$ = &ast.FloatOpt{Flen: types.UnspecifiedLength, Decimal: types.UnspecifiedLength}
 	}
@@ -11031,6 +12882,7 @@ OptBinMod:
 	}
 
 OptBinary:
+	%prec lowerThanParenthese
 	{
 		$$ = &ast.OptBinary{
 			IsBinary: false,
@@ -11120,6 +12972,15 @@ StringNameOrBRIEOptionKeyword:
  * See https://dev.mysql.com/doc/refman/5.7/en/update.html
  ***********************************************************************************/
 UpdateStmt:
+	UpdateStmtNoWith
+|	WithClause UpdateStmtNoWith
+	{
+		u := $2.(*ast.UpdateStmt)
+		u.With = $1.(*ast.WithClause)
+		$$ = u
+	}
+
+UpdateStmtNoWith:
 	"UPDATE" TableOptimizerHintsOpt PriorityOpt IgnoreOptional TableRef "SET" AssignmentList WhereClauseOptional OrderByOptional LimitClause
 	{
 		var refs *ast.Join
@@ -11263,6 +13124,194 @@ InstanceOption:
 		}
 	}
 
+/* See https://dev.mysql.com/doc/refman/8.0/en/install-plugin.html */
+InstallPluginStmt:
+	"INSTALL" "PLUGIN" Identifier "SONAME" stringLit
+	{
+		$$ = &ast.InstallPluginStmt{Name: $3, Library: $5}
+	}
+
+UninstallPluginStmt:
+	"UNINSTALL" "PLUGIN" Identifier
+	{
+		$$ = &ast.UninstallPluginStmt{Name: $3}
+	}
+
+/* See https://dev.mysql.com/doc/refman/8.0/en/install-component.html */
+InstallComponentStmt:
+	"INSTALL" "COMPONENT" StringList
+	{
+		$$ = &ast.InstallComponentStmt{Components: $3.([]string)}
+	}
+
+UninstallComponentStmt:
+	"UNINSTALL" "COMPONENT" StringList
+	{
+		$$ = &ast.UninstallComponentStmt{Components: $3.([]string)}
+	}
+
+/* See https://dev.mysql.com/doc/refman/8.0/en/clone.html */
+CloneStmt:
+	"CLONE" "LOCAL" "DATA" "DIRECTORY" EqOpt stringLit
+	{
+		$$ = &ast.CloneStmt{Local: true, DataDirectory: $6}
+	}
+|	"CLONE" "INSTANCE" "FROM" Username ':' LengthNum "IDENTIFIED" "BY" stringLit CloneDataDirectoryOpt CloneRequireSSLOpt
+	{
+		$$ = &ast.CloneStmt{
+			User:          $4.(*auth.UserIdentity),
+			Port:          $6.(uint64),
+			Password:      $9,
+			DataDirectory: $10,
+			RequireSSL:    $11.(ast.CloneSSL),
+		}
+	}
+
+CloneDataDirectoryOpt:
+	{
+		$$ = ""
+	}
+|	"DATA" "DIRECTORY" EqOpt stringLit
+	{
+		$$ = $4
+	}
+
+CloneRequireSSLOpt:
+	{
+		$$ = ast.CloneSSLDefault
+	}
+|	"REQUIRE" "SSL"
+	{
+		$$ = ast.CloneSSLRequired
+	}
+|	"REQUIRE" "NO" "SSL"
+	{
+		$$ = ast.CloneSSLNotRequired
+	}
+
+/* See https://dev.mysql.com/doc/refman/8.0/en/help.html */
+HelpStmt:
+	"HELP" stringLit
+	{
+		$$ = &ast.HelpStmt{Topic: $2}
+	}
+
+/* See https://dev.mysql.com/doc/refman/8.0/en/create-resource-group.html */
+CreateResourceGroupStmt:
+	"CREATE" "RESOURCE" "GROUP" Identifier "TYPE" EqOpt ResourceGroupType ResourceGroupOptions
+	{
+		$$ = &ast.CreateResourceGroupStmt{
+			Name:                 model.NewCIStr($4),
+			Type:                 $7.(ast.ResourceGroupType),
+			ResourceGroupOptions: *$8.(*ast.ResourceGroupOptions),
+		}
+	}
+
+AlterResourceGroupStmt:
+	"ALTER" "RESOURCE" "GROUP" Identifier ResourceGroupOptions ForceOpt
+	{
+		opts := $5.(*ast.ResourceGroupOptions)
+		if $6.(bool) && opts.State == ast.ResourceGroupStateDefault {
+			yylex.AppendError(yylex.Errorf("FORCE requires ENABLE or DISABLE"))
+			return 1
+		}
+		$$ = &ast.AlterResourceGroupStmt{
+			Name:                 model.NewCIStr($4),
+			ResourceGroupOptions: *opts,
+			Force:                $6.(bool),
+		}
+	}
+
+DropResourceGroupStmt:
+	"DROP" "RESOURCE" "GROUP" Identifier ForceOpt
+	{
+		$$ = &ast.DropResourceGroupStmt{Name: model.NewCIStr($4), Force: $5.(bool)}
+	}
+
+ResourceGroupType:
+	"SYSTEM"
+	{
+		$$ = ast.ResourceGroupSystem
+	}
+|	"USER"
+	{
+		$$ = ast.ResourceGroupUser
+	}
+
+ResourceGroupOptions:
+	ResourceGroupVCPUOpt ResourceGroupPriorityOpt ResourceGroupStateOpt
+	{
+		opts := &ast.ResourceGroupOptions{
+			VCPUs: $1.([]ast.ResourceGroupVCPU),
+			State: $3.(ast.ResourceGroupState),
+		}
+		if $2 != nil {
+			priority := $2.(int64)
+			opts.ThreadPriority = &priority
+		}
+		$$ = opts
+	}
+
+ResourceGroupVCPUOpt:
+	{
+		$$ = []ast.ResourceGroupVCPU(nil)
+	}
+|	"VCPU" EqOpt ResourceGroupVCPUList
+	{
+		$$ = $3
+	}
+
+ResourceGroupVCPUList:
+	ResourceGroupVCPU
+	{
+		$$ = []ast.ResourceGroupVCPU{$1.(ast.ResourceGroupVCPU)}
+	}
+|	ResourceGroupVCPUList ',' ResourceGroupVCPU
+	{
+		$$ = append($1.([]ast.ResourceGroupVCPU), $3.(ast.ResourceGroupVCPU))
+	}
+
+ResourceGroupVCPU:
+	LengthNum
+	{
+		$$ = ast.ResourceGroupVCPU{Start: $1.(uint64), End: $1.(uint64)}
+	}
+|	LengthNum '-' LengthNum
+	{
+		$$ = ast.ResourceGroupVCPU{Start: $1.(uint64), End: $3.(uint64)}
+	}
+
+ResourceGroupPriorityOpt:
+	{
+		$$ = nil
+	}
+|	"THREAD_PRIORITY" EqOpt SignedNum
+	{
+		$$ = $3
+	}
+
+ResourceGroupStateOpt:
+	{
+		$$ = ast.ResourceGroupStateDefault
+	}
+|	"ENABLE"
+	{
+		$$ = ast.ResourceGroupEnable
+	}
+|	"DISABLE"
+	{
+		$$ = ast.ResourceGroupDisable
+	}
+
+ForceOpt:
+	{
+		$$ = false
+	}
+|	"FORCE"
+	{
+		$$ = true
+	}
+
 UserSpec:
 	Username AuthOption
 	{
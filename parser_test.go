@@ -15,7 +15,9 @@ package parser_test
 
 import (
 	"bytes"
+	goerrors "errors"
 	"fmt"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -57,11 +59,11 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"exists", "explain", "false", "float", "fetch", "for", "force", "foreign", "from",
 		"fulltext", "grant", "group", "having", "hour_microsecond", "hour_minute",
 		"hour_second", "if", "ignore", "in", "index", "infile", "inner", "insert", "int", "into", "integer",
-		"interval", "is", "join", "key", "keys", "kill", "leading", "left", "like", "limit", "lines", "load",
+		"interval", "is", "join", "json_table", "key", "keys", "kill", "lateral", "leading", "left", "like", "limit", "lines", "load",
 		"localtime", "localtimestamp", "lock", "longblob", "longtext", "mediumblob", "maxvalue", "mediumint", "mediumtext",
 		"minute_microsecond", "minute_second", "mod", "not", "no_write_to_binlog", "null", "numeric",
-		"on", "option", "optionally", "or", "order", "outer", "partition", "precision", "primary", "procedure", "range", "read", "real",
-		"references", "regexp", "rename", "repeat", "replace", "revoke", "restrict", "right", "rlike",
+		"of", "on", "option", "optionally", "or", "order", "outer", "partition", "precision", "primary", "procedure", "range", "read", "real",
+		"recursive", "references", "regexp", "rename", "repeat", "replace", "revoke", "restrict", "right", "rlike",
 		"schema", "schemas", "second_microsecond", "select", "set", "show", "smallint",
 		"starting", "table", "terminated", "then", "tinyblob", "tinyint", "tinytext", "to",
 		"trailing", "true", "union", "unique", "unlock", "unsigned",
@@ -72,6 +74,9 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"cumeDist", "denseRank", "firstValue", "lag", "lastValue", "lead", "nthValue", "ntile",
 		"over", "percentRank", "rank", "row", "rows", "rowNumber", "window", "linear",
 		"match", "until", "placement", "tablesample",
+		"condition", "continue", "cursor", "declare", "deterministic", "elseif", "exit", "inout", "iterate",
+		"leave", "loop", "modifies", "out", "reads", "resignal", "return", "signal", "sqlexception",
+		"sqlstate", "sqlwarning", "undo", "while", "before", "each",
 		// TODO: support the following keywords
 		// "with",
 	}
@@ -108,7 +113,14 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"max_connections_per_hour", "max_queries_per_hour", "max_updates_per_hour", "max_user_connections", "event", "reload", "routine", "temporary",
 		"following", "preceding", "unbounded", "respect", "nulls", "current", "last", "against", "expansion",
 		"chain", "error", "general", "nvarchar", "pack_keys", "parser", "shard_row_id_bits", "pre_split_regions",
-		"constraints", "role", "replicas", "policy", "s3",
+		"constraints", "role", "replicas", "policy", "s3", "close", "contains", "found", "handler", "returns",
+		"at", "completion", "ends", "every", "follows", "precedes", "preserve", "schedule", "starts",
+		"geometry", "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon",
+		"geometrycollection", "geomcollection", "srid",
+		"migrate", "one", "phase", "savepoint", "suspend", "work", "xa", "xid",
+		"empty", "nested", "ordinality", "path",
+		"channel", "clone", "component", "default_auth", "help", "install", "io_thread", "plugin", "plugin_dir",
+		"resource", "soname", "sql_thread", "stop", "thread_priority", "uninstall", "vcpu",
 	}
 	for _, kw := range unreservedKws {
 		src := fmt.Sprintf("SELECT %s FROM tbl;", kw)
@@ -536,6 +548,32 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"ROLLBACK AND NO CHAIN RELEASE", true, "ROLLBACK RELEASE"},
 		{"ROLLBACK AND CHAIN NO RELEASE", true, "ROLLBACK AND CHAIN"},
 		{"ROLLBACK AND CHAIN RELEASE", false, ""},
+		{"BEGIN WORK", true, "START TRANSACTION"},
+		{"COMMIT WORK AND CHAIN", true, "COMMIT AND CHAIN"},
+		{"ROLLBACK WORK NO RELEASE", true, "ROLLBACK"},
+		{"SAVEPOINT sp1", true, "SAVEPOINT `sp1`"},
+		{"SAVEPOINT", false, ""},
+		{"ROLLBACK TO sp1", true, "ROLLBACK TO SAVEPOINT `sp1`"},
+		{"ROLLBACK WORK TO SAVEPOINT sp1", true, "ROLLBACK TO SAVEPOINT `sp1`"},
+		{"ROLLBACK TO SAVEPOINT", true, "ROLLBACK TO SAVEPOINT `SAVEPOINT`"},
+		{"ROLLBACK TO SAVEPOINT sp1 AND CHAIN", false, ""},
+		{"RELEASE SAVEPOINT sp1", true, "RELEASE SAVEPOINT `sp1`"},
+		{"RELEASE sp1", false, ""},
+		{"XA START 'x'", true, "XA START 'x'"},
+		{"XA BEGIN 'x', 'b' JOIN", true, "XA START 'x','b' JOIN"},
+		{"XA START 'x', 'b', 1 RESUME", true, "XA START 'x','b' RESUME"},
+		{"XA START X'7801', '', 3", true, "XA START 0x7801,'',3"},
+		{"XA START 'x' JOIN RESUME", false, ""},
+		{"XA END 'x'", true, "XA END 'x'"},
+		{"XA END 'x' SUSPEND", true, "XA END 'x' SUSPEND"},
+		{"XA END 'x' SUSPEND FOR MIGRATE", true, "XA END 'x' SUSPEND FOR MIGRATE"},
+		{"XA PREPARE 'x'", true, "XA PREPARE 'x'"},
+		{"XA COMMIT 'x'", true, "XA COMMIT 'x'"},
+		{"XA COMMIT 'x' ONE PHASE", true, "XA COMMIT 'x' ONE PHASE"},
+		{"XA ROLLBACK 'x', 'b', 7", true, "XA ROLLBACK 'x','b',7"},
+		{"XA ROLLBACK x", false, ""},
+		{"XA RECOVER", true, "XA RECOVER"},
+		{"XA RECOVER CONVERT XID", true, "XA RECOVER CONVERT XID"},
 		{`BEGIN;
 			INSERT INTO foo VALUES (42, 3.14);
 			INSERT INTO foo VALUES (-1, 2.78);
@@ -566,6 +604,9 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"CREATE VIEW v AS TABLE t", true, "CREATE ALGORITHM = UNDEFINED DEFINER = CURRENT_USER SQL SECURITY DEFINER VIEW `v` AS TABLE `t`"},
 		{"CREATE VIEW v AS (TABLE t)", true, "CREATE ALGORITHM = UNDEFINED DEFINER = CURRENT_USER SQL SECURITY DEFINER VIEW `v` AS (TABLE `t`)"},
 		{"SELECT * FROM t1 WHERE a IN (TABLE t2)", true, "SELECT * FROM `t1` WHERE `a` IN (TABLE `t2`)"},
+		{"SELECT * FROM (TABLE t) AS d", true, "SELECT * FROM (TABLE `t`) AS `d`"},
+		{"SELECT * FROM t1 JOIN (TABLE t2) AS d", true, "SELECT * FROM `t1` JOIN (TABLE `t2`) AS `d`"},
+		{"SELECT * FROM t1 UNION TABLE t2 EXCEPT VALUES ROW(1)", true, "SELECT * FROM `t1` UNION TABLE `t2` EXCEPT VALUES ROW(1)"},
 
 		// values statement
 		{"VALUES ROW(1)", true, "VALUES ROW(1)"},
@@ -583,6 +624,8 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"CREATE TABLE ta VALUES ROW(1)", true, "CREATE TABLE `ta` AS VALUES ROW(1)"},
 		{"CREATE TABLE ta AS VALUES ROW(1)", true, "CREATE TABLE `ta` AS VALUES ROW(1)"},
 		{"CREATE VIEW a AS VALUES ROW(1)", true, "CREATE ALGORITHM = UNDEFINED DEFINER = CURRENT_USER SQL SECURITY DEFINER VIEW `a` AS VALUES ROW(1)"},
+		{"SELECT * FROM (VALUES ROW(1,2), ROW(3,4)) AS v", true, "SELECT * FROM (VALUES ROW(1,2), ROW(3,4)) AS `v`"},
+		{"VALUES ROW(1) INTERSECT (VALUES ROW(2) UNION TABLE t)", true, "VALUES ROW(1) INTERSECT (VALUES ROW(2) UNION TABLE `t`)"},
 
 		// qualified select
 		{"SELECT a.b.c FROM t", true, "SELECT `a`.`b`.`c` FROM `t`"},
@@ -665,6 +708,15 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"select * from t for update skip locked", true, "SELECT * FROM `t` FOR UPDATE SKIP LOCKED"},
 		{"select * from t for share skip locked", true, "SELECT * FROM `t` FOR SHARE SKIP LOCKED"},
 		{"select * from t lock in share mode", true, "SELECT * FROM `t` FOR SHARE"},
+		{"select * from t1, t2 for update of t1", true, "SELECT * FROM (`t1`) JOIN `t2` FOR UPDATE OF `t1`"},
+		{"select * from t1 a, t2 b for update of a, b skip locked", true, "SELECT * FROM (`t1` AS `a`) JOIN `t2` AS `b` FOR UPDATE OF `a`, `b` SKIP LOCKED"},
+		{"select * from t1, t2 for update of t1 nowait for share of test.t2 skip locked", true, "SELECT * FROM (`t1`) JOIN `t2` FOR UPDATE OF `t1` NOWAIT FOR SHARE OF `test`.`t2` SKIP LOCKED"},
+		{"select * from t for update of t wait 3", true, "SELECT * FROM `t` FOR UPDATE OF `t` WAIT 3"},
+		{"select * from t for share of t nowait", true, "SELECT * FROM `t` FOR SHARE OF `t` NOWAIT"},
+		{"select * from t for update for share", true, "SELECT * FROM `t` FOR UPDATE FOR SHARE"},
+		{"select * from t for update of", false, ""},
+		{"select * from t for update skip locked of t", false, ""},
+		{"select * from t lock in share mode for update", false, ""},
 		{"select * from t lock in share mode nowait", false, ""},
 		{"select * from t lock in share mode skip locked", false, ""},
 
@@ -681,6 +733,15 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"select a,b,a+b from t into outfile '/tmp/result.txt' fields terminated BY ',' enclosed BY '\"' lines terminated BY '\r'", true, "SELECT `a`,`b`,`a`+`b` FROM `t` INTO OUTFILE '/tmp/result.txt' FIELDS TERMINATED BY ',' ENCLOSED BY '\"' LINES TERMINATED BY '\r'"},
 		{"select a,b,a+b from t into outfile '/tmp/result.txt' fields terminated BY ',' optionally enclosed BY '\"' lines starting by 'xy' terminated BY '\r'", true, "SELECT `a`,`b`,`a`+`b` FROM `t` INTO OUTFILE '/tmp/result.txt' FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '\"' LINES STARTING BY 'xy' TERMINATED BY '\r'"},
 		{"select a,b,a+b from t into outfile '/tmp/result.txt' fields terminated BY ',' enclosed BY '\"' lines starting by 'xy' terminated BY '\r'", true, "SELECT `a`,`b`,`a`+`b` FROM `t` INTO OUTFILE '/tmp/result.txt' FIELDS TERMINATED BY ',' ENCLOSED BY '\"' LINES STARTING BY 'xy' TERMINATED BY '\r'"},
+		{"select a into outfile '/tmp/result.txt' from t", true, "SELECT `a` FROM `t` INTO OUTFILE '/tmp/result.txt'"},
+
+		// select into variables
+		{"select a, b into @x, @y from t where c = 1", true, "SELECT `a`,`b` FROM `t` WHERE `c`=1 INTO @`x`, @`y`"},
+		{"select a, b from t where c = 1 into @x, v", true, "SELECT `a`,`b` FROM `t` WHERE `c`=1 INTO @`x`, `v`"},
+		{"select 1 into @x", true, "SELECT 1 INTO @`x`"},
+		{"select a into @x from t for update", true, "SELECT `a` FROM `t` FOR UPDATE INTO @`x`"},
+		{"select a into @x from t into @y", false, ""},
+		{"select a into from t", false, ""},
 
 		// from join
 		{"SELECT * from t1, t2, t3", true, "SELECT * FROM ((`t1`) JOIN `t2`) JOIN `t3`"},
@@ -2752,6 +2813,12 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"CREATE UNIQUE INDEX ident USING BTREE ON d_n.t_n ( ident , ident ASC )", true, "CREATE UNIQUE INDEX `ident` ON `d_n`.`t_n` (`ident`, `ident`) USING BTREE"},
 		{"CREATE SPATIAL INDEX idx ON t (a)", true, "CREATE SPATIAL INDEX `idx` ON `t` (`a`)"},
 		{"CREATE SPATIAL INDEX IF NOT EXISTS idx ON t (a)", true, "CREATE SPATIAL INDEX IF NOT EXISTS `idx` ON `t` (`a`)"},
+		{"create table t (g geometry, p point not null srid 4326, l linestring, pg polygon srid 0, mp multipoint, ml multilinestring, mg multipolygon, gc geometrycollection, gc2 geomcollection, spatial key idx (p), spatial (g))", true, "CREATE TABLE `t` (`g` GEOMETRY,`p` POINT NOT NULL SRID 4326,`l` LINESTRING,`pg` POLYGON SRID 0,`mp` MULTIPOINT,`ml` MULTILINESTRING,`mg` MULTIPOLYGON,`gc` GEOMETRYCOLLECTION,`gc2` GEOMETRYCOLLECTION,SPATIAL `idx`(`p`),SPATIAL(`g`))"},
+		{"alter table t add column p point srid 3857", true, "ALTER TABLE `t` ADD COLUMN `p` POINT SRID 3857"},
+		{"create table t (p point srid 4294967296)", false, ""},
+		{"create table t (p point srid -1)", false, ""},
+		{"create table t (point point, polygon int)", true, "CREATE TABLE `t` (`point` POINT,`polygon` INT)"},
+		{"select point(1, 2), polygon(linestring(point(0, 0), point(1, 1), point(0, 0))), multipoint(point(1, 1)), geometrycollection(point(1, 1))", true, "SELECT POINT(1, 2),POLYGON(LINESTRING(POINT(0, 0), POINT(1, 1), POINT(0, 0))),MULTIPOINT(POINT(1, 1)),GEOMETRYCOLLECTION(POINT(1, 1))"},
 		{"CREATE FULLTEXT INDEX idx ON t (a)", true, "CREATE FULLTEXT INDEX `idx` ON `t` (`a`)"},
 		{"CREATE FULLTEXT INDEX IF NOT EXISTS idx ON t (a)", true, "CREATE FULLTEXT INDEX IF NOT EXISTS `idx` ON `t` (`a`)"},
 		{"CREATE FULLTEXT INDEX idx ON t (a) WITH PARSER ident", true, "CREATE FULLTEXT INDEX `idx` ON `t` (`a`) WITH PARSER `ident`"},
@@ -4634,6 +4701,313 @@ func (s *testParserSuite) TestFuncCallExprOffset(c *C) {
 	}
 }
 
+func (s *testParserSuite) TestNodeSpan(c *C) {
+	src := "select a, (b+1)*2 as c\nfrom t1 join t2 on t1.id = t2.id\nwhere x in (select y from u) order by a desc;\n" +
+		"insert into t values (1, 'é'), (2, 'b')"
+	p := parser.New()
+	// The spans are only kept on demand.
+	stmts, _, err := p.Parse(src, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts[0].Span().IsEmpty(), IsTrue)
+	c.Assert(stmts[0].(*ast.SelectStmt).Fields.Span().IsEmpty(), IsTrue)
+	p.SetKeepSpans(true)
+	stmts, _, err = p.Parse(src, "", "")
+	c.Assert(err, IsNil)
+	text := func(n ast.Node) string {
+		span := n.Span()
+		return src[span.Start.Offset:span.End.Offset]
+	}
+
+	sel := stmts[0].(*ast.SelectStmt)
+	c.Assert(sel.Span(), Equals, ast.Span{Start: ast.Pos{Line: 1, Col: 1, Offset: 0}, End: ast.Pos{Line: 3, Col: 45, Offset: 100}})
+	c.Assert(text(sel.Fields), Equals, "a, (b+1)*2 as c")
+	c.Assert(text(sel.Fields.Fields[1]), Equals, "(b+1)*2 as c")
+	c.Assert(text(sel.Fields.Fields[1].Expr), Equals, "(b+1)*2")
+	c.Assert(text(sel.Fields.Fields[1].Expr.(*ast.BinaryOperationExpr).L), Equals, "(b+1)")
+	join := sel.From.TableRefs
+	c.Assert(text(join), Equals, "t1 join t2 on t1.id = t2.id")
+	c.Assert(text(join.Right), Equals, "t2")
+	c.Assert(join.Right.Span().Start, Equals, ast.Pos{Line: 2, Col: 14, Offset: 36})
+	c.Assert(text(join.On.Expr), Equals, "t1.id = t2.id")
+	c.Assert(text(join.On.Expr.(*ast.BinaryOperationExpr).L.(*ast.ColumnNameExpr).Name), Equals, "t1.id")
+	in := sel.Where.(*ast.PatternInExpr)
+	c.Assert(text(in), Equals, "x in (select y from u)")
+	c.Assert(text(in.Sel), Equals, "(select y from u)")
+	c.Assert(text(in.Sel.(*ast.SubqueryExpr).Query), Equals, "select y from u")
+	c.Assert(text(sel.OrderBy), Equals, "order by a desc")
+	c.Assert(text(sel.OrderBy.Items[0]), Equals, "a desc")
+
+	// Columns are counted in characters.
+	ins := stmts[1].(*ast.InsertStmt)
+	c.Assert(text(ins), Equals, "insert into t values (1, 'é'), (2, 'b')")
+	c.Assert(ins.Lists[0][1].Span(), Equals, ast.Span{Start: ast.Pos{Line: 4, Col: 26, Offset: 127}, End: ast.Pos{Line: 4, Col: 29, Offset: 131}})
+	c.Assert(ins.Lists[1][0].Span().Start, Equals, ast.Pos{Line: 4, Col: 33, Offset: 135})
+}
+
+func (s *testParserSuite) TestParseWithRecovery(c *C) {
+	p := parser.New()
+	p.SetKeepComments(true)
+	src := "select 1; selec 2;\n/* skipped */ select 'a;b' frm x y; -- three\nselect 3 + ;\nselect 4 from t; create table t (a int, b)"
+	stmts, _, errs := p.ParseWithRecovery(src, "", "")
+	c.Assert(stmts, HasLen, 2)
+	c.Assert(stmts[0].Text(), Equals, "select 1;")
+	c.Assert(stmts[1].(*ast.SelectStmt).From, NotNil)
+	c.Assert(stmts[1].Comments(), HasLen, 0)
+	c.Assert(errs, HasLen, 4)
+	texts := []string{"selec 2", "select 'a;b' frm x y", "select 3 +", "create table t (a int, b)"}
+	indexes := []int{1, 2, 3, 5}
+	for i, e := range errs {
+		c.Assert(e.Index, Equals, indexes[i])
+		c.Assert(src[e.Start:e.End], Equals, texts[i])
+		c.Assert(e.Cause(), NotNil)
+	}
+
+	// The parser works as usual after a recovery.
+	stmts, _, errs = p.ParseWithRecovery("select 1; select 2", "", "")
+	c.Assert(stmts, HasLen, 2)
+	c.Assert(errs, HasLen, 0)
+	_, _, err := p.Parse("select 1; selec 2; select 3", "", "")
+	c.Assert(err, NotNil)
+}
+
+func (s *testParserSuite) TestSplitScript(c *C) {
+	src := "select 1; select ';' /* ; */ -- ;\n, \"\\\";\", `a;``b`;;\n" +
+		"-- routines\nDELIMITER $$\ncreate procedure p() begin select 1; select 2; end$$\n" +
+		"delimiter ;\nselect 3 # ;\n;select 4"
+	stmts, err := parser.SplitScript(src)
+	c.Assert(err, IsNil)
+	texts := []string{
+		"select 1",
+		"select ';' /* ; */ -- ;\n, \"\\\";\", `a;``b`",
+		"create procedure p() begin select 1; select 2; end",
+		"select 3 # ;",
+		"select 4",
+	}
+	delimiters := []string{";", ";", "$$", ";", ";"}
+	c.Assert(stmts, HasLen, len(texts))
+	for i, stmt := range stmts {
+		c.Assert(stmt.Text, Equals, texts[i])
+		c.Assert(src[stmt.Start:stmt.End], Equals, texts[i])
+		c.Assert(stmt.Delimiter, Equals, delimiters[i])
+	}
+
+	stmts, err = parser.SplitScript("select 1;\ndelimiter\nselect 2")
+	c.Assert(err, NotNil)
+	c.Assert(stmts, HasLen, 1)
+	_, err = parser.SplitScript("delimiter \\\nselect 2")
+	c.Assert(terror.ErrorEqual(err, parser.ErrDelimiterBackslash), IsTrue)
+	stmts, err = parser.SplitScript("DELIMITER '//' x\nselect 1//select 2//")
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 2)
+	c.Assert(stmts[1].Delimiter, Equals, "//")
+}
+
+func (s *testParserSuite) TestParseScript(c *C) {
+	p := parser.New()
+	p.SetKeepSpans(true)
+	src := "select 1;\nDELIMITER //\ncreate trigger tr before insert on t for each row begin set new.a = 1; end//\n" +
+		"selec 2//\ndelimiter\n  select a from t//"
+	stmts, _, errs := p.ParseScript(src, "", "")
+	c.Assert(stmts, HasLen, 3)
+	c.Assert(stmts[0].Text(), Equals, "select 1")
+	c.Assert(stmts[1], FitsTypeOf, &ast.CreateTriggerStmt{})
+	c.Assert(errs, HasLen, 2)
+	c.Assert(errs[0].Index, Equals, 2)
+	c.Assert(src[errs[0].Start:errs[0].End], Equals, "selec 2")
+	serr, ok := errors.Cause(errs[0].Err).(*parser.SyntaxError)
+	c.Assert(ok, IsTrue)
+	c.Assert(serr.Line, Equals, 4)
+	c.Assert(serr.Offset, Equals, errs[0].Start)
+	// The message locates the error in the script too.
+	c.Assert(serr.Error(), Equals, `line 4 column 5 near "selec 2" `)
+	c.Assert(errs[0].Error(), Equals, `statement 2: line 4 column 5 near "selec 2" `)
+	_, _, errs2 := p.ParseScript("select 1; select 2 frm t", "", "")
+	c.Assert(errs2, HasLen, 1)
+	c.Assert(errs2[0].Cause().Error(), Equals, `line 1 column 24 near "t" `)
+	c.Assert(errs[1].Index, Equals, 3)
+	c.Assert(src[errs[1].Start:errs[1].End], Equals, "delimiter")
+
+	// The positions are relative to the script.
+	field := stmts[2].(*ast.SelectStmt).Fields.Fields[0]
+	span := field.Span()
+	c.Assert(src[span.Start.Offset:span.End.Offset], Equals, "a")
+	c.Assert(span.Start.Line, Equals, 6)
+	c.Assert(span.Start.Col, Equals, 10)
+}
+
+func (s *testParserSuite) TestSyntaxError(c *C) {
+	p := parser.New()
+	_, _, err := p.Parse("select 1 from t\ngroup a", "", "")
+	e, ok := errors.Cause(err).(*parser.SyntaxError)
+	c.Assert(ok, IsTrue)
+	c.Assert(e.Error(), Equals, `line 2 column 8 near "a" `)
+	c.Assert(e.Line, Equals, 2)
+	c.Assert(e.Col, Equals, 7)
+	c.Assert(e.Offset, Equals, 22)
+	c.Assert(e.Token, Equals, "a")
+	c.Assert(e.Expected, DeepEquals, []string{"BY"})
+
+	_, _, err = p.Parse("select a frm t where", "", "")
+	e = errors.Cause(err).(*parser.SyntaxError)
+	c.Assert(e.Token, Equals, "t")
+	c.Assert(e.Expected, DeepEquals, []string{",", ";", "EOF", "EXCEPT", "FETCH", "FOR", "FROM", "INTERSECT", "INTO", "LIMIT", "LOCK", "ORDER", "UNION", "WHERE"})
+
+	// Only the tokens which can follow are expected, not all the tokens with
+	// an action in the state of the parser.
+	_, _, err = p.Parse("select 1 from t limit 1 1", "", "")
+	e = errors.Cause(err).(*parser.SyntaxError)
+	c.Assert(e.Expected, DeepEquals, []string{",", ";", "EOF", "EXCEPT", "FOR", "INTERSECT", "INTO", "LOCK", "OFFSET", "UNION"})
+
+	// The error isn't wrapped, so errors.As finds it.
+	c.Assert(goerrors.As(err, &e), IsTrue)
+	_, err = p.ParseOneStmt("select 1 frm t", "", "")
+	c.Assert(goerrors.As(err, &e), IsTrue)
+	c.Assert(e.Token, Equals, "t")
+	_, _, errs := p.ParseWithRecovery("select 1; selec 2", "", "")
+	c.Assert(goerrors.As(errs[0], &e), IsTrue)
+	c.Assert(e.Token, Equals, "selec")
+
+	// The end of the text is located too.
+	_, _, err = p.Parse("select 1 from t where é = (", "", "")
+	e = errors.Cause(err).(*parser.SyntaxError)
+	c.Assert(e.Col, Equals, 28)
+	c.Assert(e.Offset, Equals, 28)
+	c.Assert(e.Token, Equals, "")
+	c.Assert(e.Expected, Not(HasLen), 0)
+	// The tokens are named with their text.
+	expected := strings.Join(e.Expected, " ")
+	c.Assert(expected, Matches, ".*user variable.*")
+	c.Assert(expected, Not(Matches), ".*(builtin|not2|leading at|optimizer hint).*")
+}
+
+func (s *testParserSuite) TestCommonTableExpression(c *C) {
+	table := []testCase{
+		{"with cte as (select 1) select * from cte", true, "WITH `cte` AS (SELECT 1) SELECT * FROM `cte`"},
+		{"with recursive cte (n) as (select 1 union all select n + 1 from cte where n < 5) select * from cte", true, "WITH RECURSIVE `cte` (`n`) AS (SELECT 1 UNION ALL SELECT `n`+1 FROM `cte` WHERE `n`<5) SELECT * FROM `cte`"},
+		{"with a as (select 1), b (x) as (select 2) select * from a union select * from b", true, "WITH `a` AS (SELECT 1), `b` (`x`) AS (SELECT 2) SELECT * FROM `a` UNION SELECT * FROM `b`"},
+		{"with a as (select 1) (select * from a) union (select 2) order by 1", true, "WITH `a` AS (SELECT 1) (SELECT * FROM `a`) UNION (SELECT 2) ORDER BY 1"},
+		{"with a as (select 1) update t set x = 1 where x in (select * from a)", true, "WITH `a` AS (SELECT 1) UPDATE `t` SET `x`=1 WHERE `x` IN (SELECT * FROM `a`)"},
+		{"with a as (select 1) delete from t where x in (select * from a)", true, "WITH `a` AS (SELECT 1) DELETE FROM `t` WHERE `x` IN (SELECT * FROM `a`)"},
+		{"insert into t with a as (select 1) select * from a", true, "INSERT INTO `t` WITH `a` AS (SELECT 1) SELECT * FROM `a`"},
+		{"select * from t where x in (with a as (select 1) select * from a)", true, "SELECT * FROM `t` WHERE `x` IN (WITH `a` AS (SELECT 1) SELECT * FROM `a`)"},
+		{"explain with a as (select 1) select * from a", true, "EXPLAIN FORMAT = 'row' WITH `a` AS (SELECT 1) SELECT * FROM `a`"},
+		{"with a as (select 1) with b as (select 2) select 1", false, ""},
+		{"with a (select 1) select 1", false, ""},
+		{"with recursive select 1", false, ""},
+		{"select recursive from t", false, ""},
+	}
+	s.RunTest(c, table)
+
+	stmt, err := parser.New().ParseOneStmt("with recursive a as (select 1), b as (select 2) select * from a, b", "", "")
+	c.Assert(err, IsNil)
+	with := stmt.(*ast.SelectStmt).With
+	c.Assert(with.IsRecursive, IsTrue)
+	c.Assert(with.CTEs, HasLen, 2)
+	c.Assert(with.CTEs[1].Name.L, Equals, "b")
+	c.Assert(with.CTEs[1].IsRecursive, IsTrue)
+}
+
+func (s *testParserSuite) TestStoredRoutine(c *C) {
+	table := []testCase{
+		{"create procedure p() select 1", true, "CREATE PROCEDURE `p`() SELECT 1"},
+		{"create definer = 'root'@'%' procedure if not exists db.p(a int, out b varchar(10), inout c int) comment 'x' deterministic sql security invoker begin end", true, "CREATE DEFINER = `root`@`%` PROCEDURE IF NOT EXISTS `db`.`p`(`a` INT, OUT `b` VARCHAR(10), INOUT `c` INT) COMMENT 'x' DETERMINISTIC SQL SECURITY INVOKER BEGIN END"},
+		{"create function f(a int) returns int no sql return a + 1", true, "CREATE FUNCTION `f`(`a` INT) RETURNS INT NO SQL RETURN `a`+1"},
+		{"create function f() returns varchar(10) reads sql data not deterministic language sql contains sql modifies sql data return 'a'", true, "CREATE FUNCTION `f`() RETURNS VARCHAR(10) READS SQL DATA NOT DETERMINISTIC LANGUAGE SQL CONTAINS SQL MODIFIES SQL DATA RETURN _UTF8MB4'a'"},
+		{"create function f(in a int) returns int return 1", false, ""},
+		{"create procedure p(a int) begin declare x, y int default 0; set x = a, @u = 1, y = x; select x; end", true, "CREATE PROCEDURE `p`(`a` INT) BEGIN DECLARE `x`, `y` INT DEFAULT 0; SET `x`=`a`, @`u`=1, `y`=`x`; SELECT `x`; END"},
+		{"create procedure p() begin declare c cursor for select a from t; declare continue handler for not found, sqlstate '02000', 1062 set done = 1; open c; fetch next from c into a, b; close c; end", true, "CREATE PROCEDURE `p`() BEGIN DECLARE `c` CURSOR FOR SELECT `a` FROM `t`; DECLARE CONTINUE HANDLER FOR NOT FOUND, SQLSTATE '02000', 1062 SET @@SESSION.`done`=1; OPEN `c`; FETCH `c` INTO `a`, `b`; CLOSE `c`; END"},
+		{"create procedure p() begin declare e condition for sqlstate value '45000'; declare exit handler for e, sqlwarning, sqlexception resignal; signal e set message_text = 'oops', mysql_errno = 1001; end", true, "CREATE PROCEDURE `p`() BEGIN DECLARE `e` CONDITION FOR SQLSTATE '45000'; DECLARE EXIT HANDLER FOR `e`, SQLWARNING, SQLEXCEPTION RESIGNAL; SIGNAL `e` SET MESSAGE_TEXT = _UTF8MB4'oops', MYSQL_ERRNO = 1001; END"},
+		{"create procedure p() signal sqlstate '45000' set foo = 1", false, ""},
+		{"create procedure p() resignal sqlstate '45000'", true, "CREATE PROCEDURE `p`() RESIGNAL SQLSTATE '45000'"},
+		{"create procedure p(n int) begin if n > 1 then select 1; elseif n > 0 then select 2; select 3; else select 4; end if; end", true, "CREATE PROCEDURE `p`(`n` INT) BEGIN IF `n`>1 THEN SELECT 1; ELSEIF `n`>0 THEN SELECT 2; SELECT 3; ELSE SELECT 4; END IF; END"},
+		{"create procedure p(n int) case n when 1 then select 1; else begin end; end case", true, "CREATE PROCEDURE `p`(`n` INT) CASE `n` WHEN 1 THEN SELECT 1; ELSE BEGIN END; END CASE"},
+		{"create procedure p(n int) case when n > 1 then select 1; when n > 0 then select 2; end case", true, "CREATE PROCEDURE `p`(`n` INT) CASE WHEN `n`>1 THEN SELECT 1; WHEN `n`>0 THEN SELECT 2; END CASE"},
+		{"create procedure p(n int) l: loop set n = n - 1; if n = 0 then leave l; end if; iterate l; end loop l", true, "CREATE PROCEDURE `p`(`n` INT) `l`: LOOP SET `n`=`n`-1; IF `n`=0 THEN LEAVE `l`; END IF; ITERATE `l`; END LOOP `l`"},
+		{"create procedure p(n int) l: while n > 0 do set n = n - 1; end while", true, "CREATE PROCEDURE `p`(`n` INT) `l`: WHILE `n`>0 DO SET `n`=`n`-1; END WHILE `l`"},
+		{"create procedure p(n int) repeat set n = n - 1; until n = 0 end repeat", true, "CREATE PROCEDURE `p`(`n` INT) REPEAT SET `n`=`n`-1; UNTIL `n`=0 END REPEAT"},
+		{"create procedure p() a: begin end b", false, ""},
+		{"create procedure p() begin select 1; declare x int; end", false, ""},
+		{"create procedure p() begin end; call p()", true, "CREATE PROCEDURE `p`() BEGIN END; CALL `p`()"},
+		{"create procedure p() begin declare v int; select a into v from t; end", true, "CREATE PROCEDURE `p`() BEGIN DECLARE `v` INT; SELECT `a` FROM `t` INTO `v`; END"},
+		{"create procedure p() begin select a, b into @x, @y from t; select c from t into @z; end", true, "CREATE PROCEDURE `p`() BEGIN SELECT `a`,`b` FROM `t` INTO @`x`, @`y`; SELECT `c` FROM `t` INTO @`z`; END"},
+		{"create procedure p() begin start transaction; update t set a = 1; commit; end", true, "CREATE PROCEDURE `p`() BEGIN START TRANSACTION; UPDATE `t` SET `a`=1; COMMIT; END"},
+		{"create procedure p() begin begin work; rollback; end", true, "CREATE PROCEDURE `p`() BEGIN START TRANSACTION; ROLLBACK; END"},
+		{"create procedure p() begin create view v as select 1; drop view v; end", true, "CREATE PROCEDURE `p`() BEGIN CREATE ALGORITHM = UNDEFINED DEFINER = CURRENT_USER SQL SECURITY DEFINER VIEW `v` AS SELECT 1; DROP VIEW `v`; END"},
+		{"create procedure p() begin create user u; grant select on t to u; revoke select on t from u; drop user u; end", true, "CREATE PROCEDURE `p`() BEGIN CREATE USER `u`@`%`; GRANT SELECT ON `t` TO `u`@`%`; REVOKE SELECT ON `t` FROM `u`@`%`; DROP USER `u`@`%`; END"},
+		{"create procedure p() begin create database d; drop database d; flush tables; kill 1; end", true, "CREATE PROCEDURE `p`() BEGIN CREATE DATABASE `d`; DROP DATABASE `d`; FLUSH TABLES; KILL 1; END"},
+		{"create procedure p() begin use d; end", false, ""},
+		{"drop procedure p", true, "DROP PROCEDURE `p`"},
+		{"drop function if exists db.f", true, "DROP FUNCTION IF EXISTS `db`.`f`"},
+		{"alter procedure p comment 'x' sql security definer", true, "ALTER PROCEDURE `p` COMMENT 'x' SQL SECURITY DEFINER"},
+		{"alter function f no sql", true, "ALTER FUNCTION `f` NO SQL"},
+		{"create algorithm = merge definer = current_user view v as select 1", true, "CREATE ALGORITHM = MERGE DEFINER = CURRENT_USER SQL SECURITY DEFINER VIEW `v` AS SELECT 1"},
+	}
+	s.RunTest(c, table)
+
+	stmt, err := parser.New().ParseOneStmt("create procedure p(a int) begin declare b int; set a = 1; begin declare c int; set c = b; end; set c = 2; end", "", "")
+	c.Assert(err, IsNil)
+	body := stmt.(*ast.CreateRoutineStmt).Body.(*ast.BlockStmt)
+	c.Assert(body.Stmts, HasLen, 4)
+	c.Assert(body.Stmts[1].(*ast.SetStmt).Variables[0].IsLocal, IsTrue)
+	inner := body.Stmts[2].(*ast.BlockStmt)
+	c.Assert(inner.Stmts[1].(*ast.SetStmt).Variables[0].IsLocal, IsTrue)
+	assign := body.Stmts[3].(*ast.SetStmt).Variables[0]
+	c.Assert(assign.IsLocal, IsFalse)
+	c.Assert(assign.IsSystem, IsTrue)
+
+	// RETURN is only allowed in the body of a function.
+	s.RunErrMsgTest(c, []testErrMsgCase{
+		{"create function f(n int) returns int begin if n > 0 then return 1; end if; return 0; end", nil},
+		{"create procedure p() return 1", parser.ErrSpBadReturn},
+		{"create procedure p(n int) begin if n > 0 then return n; end if; end", parser.ErrSpBadReturn},
+	})
+	_, err = parser.New().ParseOneStmt("create procedure p() return 1", "", "")
+	c.Assert(err, ErrorMatches, ".*RETURN is only allowed in a FUNCTION")
+}
+
+func (s *testParserSuite) TestTriggerAndEvent(c *C) {
+	table := []testCase{
+		{"create trigger tr before insert on t for each row set new.a = new.a + 1", true, "CREATE TRIGGER `tr` BEFORE INSERT ON `t` FOR EACH ROW SET NEW.`a`=NEW.`a`+1"},
+		{"create definer = current_user trigger if not exists db.tr after update on db.t for each row follows tr0 begin declare x int; set x = old.a; insert into log values (x, NEW.b); end", true, "CREATE DEFINER = CURRENT_USER TRIGGER IF NOT EXISTS `db`.`tr` AFTER UPDATE ON `db`.`t` FOR EACH ROW FOLLOWS `tr0` BEGIN DECLARE `x` INT; SET `x`=OLD.`a`; INSERT INTO `log` VALUES (`x`,NEW.`b`); END"},
+		{"create trigger tr after delete on t for each row precedes tr0 delete from t2 where id = old.id", true, "CREATE TRIGGER `tr` AFTER DELETE ON `t` FOR EACH ROW PRECEDES `tr0` DELETE FROM `t2` WHERE `id`=OLD.`id`"},
+		{"create trigger tr after delete on t for each row select db.new.a", true, "CREATE TRIGGER `tr` AFTER DELETE ON `t` FOR EACH ROW SELECT `db`.`new`.`a`"},
+		{"create trigger tr before insert on t for each statement set new.a = 1", false, ""},
+		{"create trigger tr before select on t for each row set new.a = 1", false, ""},
+		{"drop trigger tr", true, "DROP TRIGGER `tr`"},
+		{"drop trigger if exists db.tr", true, "DROP TRIGGER IF EXISTS `db`.`tr`"},
+		{"create event e on schedule at '2021-01-01 00:00:00' do delete from t", true, "CREATE EVENT `e` ON SCHEDULE AT _UTF8MB4'2021-01-01 00:00:00' DO DELETE FROM `t`"},
+		{"create definer = 'root'@'%' event if not exists db.e on schedule every 1 day starts now() ends now() + interval 1 month on completion not preserve disable on slave comment 'x' do begin select 1; end", true, "CREATE DEFINER = `root`@`%` EVENT IF NOT EXISTS `db`.`e` ON SCHEDULE EVERY 1 DAY STARTS NOW() ENDS DATE_ADD(NOW(), INTERVAL 1 MONTH) ON COMPLETION NOT PRESERVE DISABLE ON SLAVE COMMENT 'x' DO BEGIN SELECT 1; END"},
+		{"create event e on schedule every 2 hour on completion preserve enable do select 1", true, "CREATE EVENT `e` ON SCHEDULE EVERY 2 HOUR ON COMPLETION PRESERVE ENABLE DO SELECT 1"},
+		{"create event e on schedule every 1 day", false, ""},
+		{"alter event e disable", true, "ALTER EVENT `e` DISABLE"},
+		{"alter event e on completion preserve rename to e2", true, "ALTER EVENT `e` ON COMPLETION PRESERVE RENAME TO `e2`"},
+		{"alter definer = current_user event e on schedule at now() comment 'y' do select 2", true, "ALTER DEFINER = CURRENT_USER EVENT `e` ON SCHEDULE AT NOW() COMMENT 'y' DO SELECT 2"},
+		{"drop event e", true, "DROP EVENT `e`"},
+		{"drop event if exists db.e", true, "DROP EVENT IF EXISTS `db`.`e`"},
+	}
+	s.RunTest(c, table)
+	s.RunErrMsgTest(c, []testErrMsgCase{
+		{"create trigger tr before insert on t for each row return 1", parser.ErrSpBadReturn},
+		{"create event e on schedule every 1 day do begin return 1; end", parser.ErrSpBadReturn},
+		{"alter event e do return 1", parser.ErrSpBadReturn},
+	})
+
+	stmt, err := parser.New().ParseOneStmt("create trigger tr before update on t for each row begin set new.a = old.a; set @x = new.b; end", "", "")
+	c.Assert(err, IsNil)
+	body := stmt.(*ast.CreateTriggerStmt).BodyStmts()
+	c.Assert(body, HasLen, 2)
+	assign := body[0].(*ast.SetStmt).Variables[0]
+	c.Assert(assign.TriggerRow, Equals, ast.TriggerRowNew)
+	c.Assert(assign.Name, Equals, "a")
+	c.Assert(assign.IsSystem, IsFalse)
+	field := assign.Value.(*ast.TriggerFieldExpr)
+	c.Assert(field.Row, Equals, ast.TriggerRowOld)
+	c.Assert(field.Name.L, Equals, "a")
+	field = body[1].(*ast.SetStmt).Variables[0].Value.(*ast.TriggerFieldExpr)
+	c.Assert(field.Row, Equals, ast.TriggerRowNew)
+	c.Assert(field.Name.L, Equals, "b")
+}
+
 func (s *testParserSuite) TestSessionManage(c *C) {
 	table := []testCase{
 		// Kill statement.
@@ -4840,6 +5214,76 @@ func (s *testParserSuite) TestTableSample(c *C) {
 	}
 }
 
+func (s *testParserSuite) TestJSONTableAndLateral(c *C) {
+	table := []testCase{
+		{"select * from json_table('[1,2]', '$[*]' columns (a int path '$')) as jt", true, "SELECT * FROM JSON_TABLE(_UTF8MB4'[1,2]', '$[*]' COLUMNS(`a` INT PATH '$')) AS `jt`"},
+		{"select * from t, json_table(t.doc, '$.items[*]' columns (id for ordinality, name varchar(20) path '$.name' default '\"x\"' on empty error on error, has_tag int exists path '$.tag')) jt", true,
+			"SELECT * FROM (`t`) JOIN JSON_TABLE(`t`.`doc`, '$.items[*]' COLUMNS(`id` FOR ORDINALITY, `name` VARCHAR(20) PATH '$.name' DEFAULT '\"x\"' ON EMPTY ERROR ON ERROR, `has_tag` INT EXISTS PATH '$.tag')) AS `jt`"},
+		{"select * from json_table(@j, '$' columns (a json path '$.a' null on error, nested path '$.b[*]' columns (b int path '$', nested '$.c' columns (c text path '$')))) as jt", true,
+			"SELECT * FROM JSON_TABLE(@`j`, '$' COLUMNS(`a` JSON PATH '$.a' NULL ON ERROR, NESTED PATH '$.b[*]' COLUMNS(`b` INT PATH '$', NESTED PATH '$.c' COLUMNS(`c` TEXT PATH '$')))) AS `jt`"},
+		{"select * from json_table('[]', '$' columns (nested int path '$')) jt", true, "SELECT * FROM JSON_TABLE(_UTF8MB4'[]', '$' COLUMNS(`nested` INT PATH '$')) AS `jt`"},
+		{"select * from json_table('[]', '$' columns (a int path '$' error on error null on empty)) jt", false, ""},
+		{"select * from json_table('[]', '$' columns (a int path '$'))", false, ""},
+		{"select * from json_table('[]', '$' columns ()) jt", false, ""},
+		{"select * from json_table('[]', '$' columns (a for ordinality)) jt", true, "SELECT * FROM JSON_TABLE(_UTF8MB4'[]', '$' COLUMNS(`a` FOR ORDINALITY)) AS `jt`"},
+		{"select * from t1, lateral (select * from t2 where t2.a = t1.a) as dt", true, "SELECT * FROM (`t1`) JOIN LATERAL (SELECT * FROM (`t2`) WHERE `t2`.`a`=`t1`.`a`) AS `dt`"},
+		{"select * from t1 join lateral (select 1 union select t1.a) dt on true", true, "SELECT * FROM `t1` JOIN LATERAL (SELECT 1 UNION SELECT `t1`.`a`) AS `dt` ON TRUE"},
+		{"select * from t1, lateral (select 1)", false, ""},
+		{"select * from t1, lateral t2", false, ""},
+	}
+	s.RunTest(c, table)
+}
+
+func (s *testParserSuite) TestReplicationAndAdminStmt(c *C) {
+	table := []testCase{
+		{"change master to master_host='h1', master_port=3306, master_user='repl', master_password='secret', master_auto_position=1", true,
+			"CHANGE MASTER TO MASTER_HOST = 'h1', MASTER_PORT = 3306, MASTER_USER = 'repl', MASTER_PASSWORD = 'secret', MASTER_AUTO_POSITION = 1"},
+		{"change replication source to source_host = 'h1', source_heartbeat_period = 0.5, ignore_server_ids = (1, 2), require_table_primary_key_check = on, privilege_checks_user = null for channel 'c1'", true,
+			"CHANGE REPLICATION SOURCE TO SOURCE_HOST = 'h1', SOURCE_HEARTBEAT_PERIOD = 0.5, IGNORE_SERVER_IDS = (1, 2), REQUIRE_TABLE_PRIMARY_KEY_CHECK = ON, PRIVILEGE_CHECKS_USER = NULL FOR CHANNEL 'c1'"},
+		{"change replication source to ignore_server_ids = (), assign_gtids_to_anonymous_transactions = local", true,
+			"CHANGE REPLICATION SOURCE TO IGNORE_SERVER_IDS = (), ASSIGN_GTIDS_TO_ANONYMOUS_TRANSACTIONS = LOCAL"},
+		{"change master to no_such_option = 1", false, ""},
+		{"change master to sql_after_mts_gaps", false, ""},
+		{"change replication source to", false, ""},
+		{"start slave", true, "START SLAVE"},
+		{"start replica io_thread, sql_thread until source_log_file = 'binlog.000002', source_log_pos = 4 user = 'u' password = 'p' for channel 'c1'", true,
+			"START REPLICA IO_THREAD, SQL_THREAD UNTIL SOURCE_LOG_FILE = 'binlog.000002', SOURCE_LOG_POS = 4 USER = 'u' PASSWORD = 'p' FOR CHANNEL 'c1'"},
+		{"start replica sql_thread until sql_after_mts_gaps", true, "START REPLICA SQL_THREAD UNTIL SQL_AFTER_MTS_GAPS"},
+		{"start replica until sql_before_gtids = '3e11fa47-71ca-11e1-9e33-c80aa9429562:11-56' default_auth = 'caching_sha2_password' plugin_dir = '/p'", true,
+			"START REPLICA UNTIL SQL_BEFORE_GTIDS = '3e11fa47-71ca-11e1-9e33-c80aa9429562:11-56' DEFAULT_AUTH = 'caching_sha2_password' PLUGIN_DIR = '/p'"},
+		{"start replica until source_host = 'h1'", false, ""},
+		{"stop slave io_thread", true, "STOP SLAVE IO_THREAD"},
+		{"stop replica for channel 'c1'", true, "STOP REPLICA FOR CHANNEL 'c1'"},
+		{"reset master", true, "RESET MASTER"},
+		{"reset master to 1234", true, "RESET MASTER TO 1234"},
+		{"reset slave all", true, "RESET SLAVE ALL"},
+		{"reset replica for channel 'c1'", true, "RESET REPLICA FOR CHANNEL 'c1'"},
+		{"purge binary logs to 'binlog.000010'", true, "PURGE BINARY LOGS TO 'binlog.000010'"},
+		{"purge master logs before now() - interval 3 day", true, "PURGE MASTER LOGS BEFORE DATE_SUB(NOW(), INTERVAL 3 DAY)"},
+		{"purge binary logs", false, ""},
+		{"install plugin rpl_semi_sync_source soname 'semisync_source.so'", true, "INSTALL PLUGIN `rpl_semi_sync_source` SONAME 'semisync_source.so'"},
+		{"uninstall plugin rpl_semi_sync_source", true, "UNINSTALL PLUGIN `rpl_semi_sync_source`"},
+		{"install component 'file://component_validate_password', 'file://component_log_sink_json'", true,
+			"INSTALL COMPONENT 'file://component_validate_password', 'file://component_log_sink_json'"},
+		{"uninstall component 'file://component_validate_password'", true, "UNINSTALL COMPONENT 'file://component_validate_password'"},
+		{"clone local data directory = '/var/lib/clone'", true, "CLONE LOCAL DATA DIRECTORY = '/var/lib/clone'"},
+		{"clone instance from 'clone_user'@'donor.example.com':3306 identified by 'pw' data directory '/d' require no ssl", true,
+			"CLONE INSTANCE FROM `clone_user`@`donor.example.com`:3306 IDENTIFIED BY 'pw' DATA DIRECTORY = '/d' REQUIRE NO SSL"},
+		{"clone instance from 'clone_user'@'donor':3306 identified by 'pw' require ssl", true, "CLONE INSTANCE FROM `clone_user`@`donor`:3306 IDENTIFIED BY 'pw' REQUIRE SSL"},
+		{"clone instance from 'clone_user'@'donor' identified by 'pw'", false, ""},
+		{"help 'contents'", true, "HELP 'contents'"},
+		{"help contents", false, ""},
+		{"create resource group batch type = user vcpu = 2-3, 5 thread_priority = 10 disable", true,
+			"CREATE RESOURCE GROUP `batch` TYPE = USER VCPU = 2-3, 5 THREAD_PRIORITY = 10 DISABLE"},
+		{"create resource group sys_io type system thread_priority -5", true, "CREATE RESOURCE GROUP `sys_io` TYPE = SYSTEM THREAD_PRIORITY = -5"},
+		{"create resource group g vcpu = 1", false, ""},
+		{"alter resource group batch vcpu = 0 enable force", true, "ALTER RESOURCE GROUP `batch` VCPU = 0 ENABLE FORCE"},
+		{"alter resource group batch force", false, ""},
+		{"drop resource group batch force", true, "DROP RESOURCE GROUP `batch` FORCE"},
+	}
+	s.RunTest(c, table)
+}
+
 func (s *testParserSuite) TestGeneratedColumn(c *C) {
 	tests := []struct {
 		input string
@@ -5502,6 +5946,45 @@ func (s *testParserSuite) TestSignedInt64OutOfRange(c *C) {
 func CleanNodeText(node ast.Node) {
 	var cleaner nodeTextCleaner
 	node.Accept(&cleaner)
+	cleanPositions(reflect.ValueOf(node), make(map[uintptr]bool))
+}
+
+// cleanPositions clears the spans and the origin text positions of the nodes
+// reachable from v, including the nodes that Accept doesn't visit.
+// For test only.
+func cleanPositions(v reflect.Value, seen map[uintptr]bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		if v.CanInterface() {
+			if n, ok := v.Interface().(ast.Node); ok {
+				n.SetSpan(ast.Span{})
+				n.SetOriginTextPosition(0)
+			}
+		}
+		cleanPositions(v.Elem(), seen)
+	case reflect.Interface:
+		if !v.IsNil() {
+			cleanPositions(v.Elem(), seen)
+		}
+	case reflect.Struct:
+		if v.CanAddr() && v.Addr().CanInterface() {
+			if n, ok := v.Addr().Interface().(ast.Node); ok {
+				n.SetSpan(ast.Span{})
+				n.SetOriginTextPosition(0)
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			cleanPositions(v.Field(i), seen)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			cleanPositions(v.Index(i), seen)
+		}
+	}
 }
 
 // nodeTextCleaner clean the text of a node and it's child node.
@@ -5819,3 +6302,91 @@ func (s *testParserSuite) TestHighNotPrecedenceMode(c *C) {
 	restoreSQL = sb.String()
 	c.Assert(restoreSQL, Equals, "SELECT !1 BETWEEN -5 AND 5")
 }
+
+func (s *testParserSuite) TestKeepComments(c *C) {
+	sql := "-- create the table\n" +
+		"/* users */ create table t (id int /* key */ primary key); # done\n" +
+		"\n" +
+		"# seed\n" +
+		"insert into t values (1); -- one\n" +
+		"select 1 /* inner */ from t -- last\n" +
+		"/* tail */"
+
+	p := parser.New()
+	stmts, _, err := p.Parse(sql, "", "")
+	c.Assert(err, IsNil)
+	for _, stmt := range stmts {
+		c.Assert(stmt.Comments(), HasLen, 0)
+	}
+
+	p.SetKeepComments(true)
+	stmts, _, err = p.Parse(sql, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 3)
+	c.Assert(stmts[0].Comments(), DeepEquals, []ast.Comment{
+		{Text: "-- create the table", Offset: 0},
+		{Text: "/* users */", Offset: 20},
+		{Text: "/* key */", Offset: 55, Node: stmts[0].(*ast.CreateTableStmt).Cols[0].Options[0]},
+		{Text: "# done", Offset: 79, Trailing: true},
+	})
+	c.Assert(stmts[1].Comments(), DeepEquals, []ast.Comment{
+		{Text: "# seed", Offset: 87},
+		{Text: "-- one", Offset: 120, Trailing: true},
+	})
+	c.Assert(stmts[2].Comments(), DeepEquals, []ast.Comment{
+		{Text: "/* inner */", Offset: 136, Node: stmts[2].(*ast.SelectStmt).Fields, Trailing: true},
+		{Text: "-- last", Offset: 155, Trailing: true},
+		{Text: "/* tail */", Offset: 163, Trailing: true, OwnLine: true},
+	})
+
+	var sb strings.Builder
+	var restored []string
+	for _, stmt := range stmts {
+		sb.Reset()
+		c.Assert(stmt.Restore(NewRestoreCtx(DefaultRestoreFlags|RestoreWithComments, &sb)), IsNil)
+		restored = append(restored, sb.String())
+	}
+	c.Assert(restored, DeepEquals, []string{
+		"-- create the table\n/* users */\nCREATE TABLE `t` (`id` INT /* key */ PRIMARY KEY) /* done */",
+		"# seed\nINSERT INTO `t` VALUES (1) /* one */",
+		"SELECT 1 /* inner */ FROM `t` /* last */\n/* tail */",
+	})
+
+	// The restored statements keep their comments when parsed again.
+	stmts2, _, err := p.Parse(strings.Join(restored, ";\n"), "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts2, HasLen, 3)
+	for i, stmt := range stmts2 {
+		c.Assert(len(stmt.Comments()), Equals, len(stmts[i].Comments()))
+	}
+
+	// The comments inside a statement stay next to their nodes.
+	stmts, _, err = p.Parse("select a /* first */, b -- second\nfrom t where /* cond */ x = 1 and /* y */ y", "", "")
+	c.Assert(err, IsNil)
+	sb.Reset()
+	c.Assert(stmts[0].Restore(NewRestoreCtx(DefaultRestoreFlags|RestoreWithComments, &sb)), IsNil)
+	c.Assert(sb.String(), Equals, "SELECT `a` /* first */,`b` /* second */ FROM `t` WHERE /* cond */ `x`=1 AND /* y */ `y`")
+
+	// A comment is written next to its own node, not next to the same text
+	// written earlier for another one.
+	stmts, _, err = p.Parse("select a = 1, b from t where /* the filter */ a = 1", "", "")
+	c.Assert(err, IsNil)
+	sb.Reset()
+	c.Assert(stmts[0].Restore(NewRestoreCtx(DefaultRestoreFlags|RestoreWithComments, &sb)), IsNil)
+	c.Assert(sb.String(), Equals, "SELECT `a`=1,`b` FROM `t` WHERE /* the filter */ `a`=1")
+
+	stmts, _, err = p.Parse("select a, /* again */ a, b /* last */ from t", "", "")
+	c.Assert(err, IsNil)
+	sb.Reset()
+	ctx := NewRestoreCtx(DefaultRestoreFlags|RestoreWithComments, &sb)
+	ctx.Layout = &Layout{Indent: "  ", Width: 80}
+	c.Assert(stmts[0].Restore(ctx), IsNil)
+	c.Assert(sb.String(), Equals, "SELECT `a`, /* again */ `a`, `b` /* last */\nFROM `t`")
+
+	// A line comment on its own line after the last statement stays there.
+	stmts, _, err = p.Parse("select 1; -- one\n-- end", "", "")
+	c.Assert(err, IsNil)
+	sb.Reset()
+	c.Assert(stmts[0].Restore(NewRestoreCtx(DefaultRestoreFlags|RestoreWithComments, &sb)), IsNil)
+	c.Assert(sb.String(), Equals, "SELECT 1 /* one */\n-- end\n")
+}
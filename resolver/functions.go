@@ -0,0 +1,454 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolver
+
+import (
+	"strings"
+
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/charset"
+	"github.com/kyleconroy/sqlparse/mysql"
+	"github.com/kyleconroy/sqlparse/types"
+)
+
+// funcKind is the result type of a builtin function which doesn't depend on
+// its arguments.
+type funcKind int
+
+const (
+	kindInt funcKind = iota + 1
+	kindUnsignedInt
+	kindBool
+	kindReal
+	kindString
+	kindBinaryString
+	kindJSON
+	kindDate
+	kindTime
+	kindDatetime
+)
+
+var funcKinds = map[string]funcKind{
+	ast.ASCII: kindInt, ast.BitLength: kindInt, ast.CharLength: kindInt, ast.CharacterLength: kindInt,
+	ast.DateDiff: kindInt, ast.Day: kindInt, ast.DayOfMonth: kindInt, ast.DayOfWeek: kindInt,
+	ast.DayOfYear: kindInt, ast.Extract: kindInt, ast.Field: kindInt, ast.FindInSet: kindInt,
+	ast.Hour: kindInt, ast.Instr: kindInt, ast.Interval: kindInt, ast.Length: kindInt,
+	ast.Locate: kindInt, ast.Position: kindInt, ast.MicroSecond: kindInt, ast.Minute: kindInt,
+	ast.Month: kindInt, ast.OctetLength: kindInt, ast.Ord: kindInt, ast.PeriodAdd: kindInt,
+	ast.PeriodDiff: kindInt, ast.Quarter: kindInt, ast.Second: kindInt, ast.Sign: kindInt,
+	ast.Strcmp: kindInt, ast.TimeToSec: kindInt, ast.TimestampDiff: kindInt, ast.ToDays: kindInt,
+	ast.ToSeconds: kindInt, ast.Week: kindInt, ast.Weekday: kindInt, ast.WeekOfYear: kindInt,
+	ast.Year: kindInt, ast.YearWeek: kindInt, ast.FoundRows: kindInt, ast.RowCount: kindInt,
+	ast.IsFreeLock: kindInt, ast.IsUsedLock: kindInt, ast.GetLock: kindInt, ast.ReleaseLock: kindInt,
+	ast.ReleaseAllLocks: kindInt, ast.Sleep: kindInt, ast.Benchmark: kindInt, ast.Coercibility: kindInt,
+	ast.JSONDepth: kindInt, ast.JSONLength: kindInt, ast.JSONStorageSize: kindInt,
+	ast.UncompressedLength: kindInt, ast.ValidatePasswordStrength: kindInt, ast.MasterPosWait: kindInt,
+	ast.NextVal: kindInt, ast.LastVal: kindInt, ast.SetVal: kindInt, ast.BitCount: kindInt,
+	ast.TiDBIsDDLOwner: kindInt,
+
+	ast.CRC32: kindUnsignedInt, ast.ConnectionID: kindUnsignedInt, ast.LastInsertId: kindUnsignedInt,
+	ast.InetAton: kindUnsignedInt, ast.UUIDShort: kindUnsignedInt, ast.VitessHash: kindUnsignedInt,
+
+	ast.LogicAnd: kindBool, ast.LogicOr: kindBool, ast.LogicXor: kindBool, ast.GE: kindBool,
+	ast.LE: kindBool, ast.EQ: kindBool, ast.NE: kindBool, ast.LT: kindBool, ast.GT: kindBool,
+	ast.NullEQ: kindBool, ast.UnaryNot: kindBool, ast.In: kindBool, ast.Like: kindBool,
+	ast.Regexp: kindBool, ast.IsNull: kindBool, ast.IsTruthWithoutNull: kindBool,
+	ast.IsTruthWithNull: kindBool, ast.IsFalsity: kindBool, ast.IsIPv4: kindBool,
+	ast.IsIPv4Compat: kindBool, ast.IsIPv4Mapped: kindBool, ast.IsIPv6: kindBool,
+	ast.JSONContains: kindBool, ast.JSONContainsPath: kindBool, ast.JSONValid: kindBool,
+
+	ast.Acos: kindReal, ast.Asin: kindReal, ast.Atan: kindReal, ast.Atan2: kindReal, ast.Cos: kindReal,
+	ast.Cot: kindReal, ast.Degrees: kindReal, ast.Exp: kindReal, ast.Ln: kindReal, ast.Log: kindReal,
+	ast.Log2: kindReal, ast.Log10: kindReal, ast.PI: kindReal, ast.Pow: kindReal, ast.Power: kindReal,
+	ast.Radians: kindReal, ast.Rand: kindReal, ast.Sin: kindReal, ast.Sqrt: kindReal, ast.Tan: kindReal,
+
+	ast.Bin: kindString, ast.Conv: kindString, ast.Elt: kindString, ast.ExportSet: kindString,
+	ast.Format: kindString, ast.InsertFunc: kindString, ast.MakeSet: kindString, ast.Oct: kindString,
+	ast.ToBase64: kindString, ast.Hex: kindString, ast.DayName: kindString, ast.MonthName: kindString,
+	ast.DateFormat: kindString, ast.TimeFormat: kindString, ast.GetFormat: kindString,
+	ast.Charset: kindString, ast.Collation: kindString, ast.CurrentUser: kindString,
+	ast.CurrentRole: kindString, ast.Database: kindString, ast.Schema: kindString,
+	ast.SessionUser: kindString, ast.SystemUser: kindString, ast.User: kindString,
+	ast.Version: kindString, ast.TiDBVersion: kindString, ast.TiDBDecodePlan: kindString,
+	ast.FormatBytes: kindString, ast.FormatNanoTime: kindString, ast.InetNtoa: kindString,
+	ast.Inet6Ntoa: kindString, ast.MD5: kindString, ast.SHA1: kindString, ast.SHA: kindString,
+	ast.SHA2: kindString, ast.PasswordFunc: kindString, ast.OldPassword: kindString,
+	ast.UUID: kindString, ast.BinToUUID: kindString, ast.JSONUnquote: kindString,
+	ast.JSONType: kindString, ast.JSONQuote: kindString, ast.JSONPretty: kindString,
+	ast.TiDBDecodeKey: kindString, ast.TiDBDecodeBase64Key: kindString, ast.GetMvccInfo: kindString,
+	ast.Replace: kindString, ast.Repeat: kindString,
+
+	ast.FromBase64: kindBinaryString, ast.Unhex: kindBinaryString, ast.CharFunc: kindBinaryString,
+	ast.WeightString: kindBinaryString, ast.Inet6Aton: kindBinaryString, ast.UUIDToBin: kindBinaryString,
+	ast.AesEncrypt: kindBinaryString, ast.AesDecrypt: kindBinaryString, ast.Compress: kindBinaryString,
+	ast.Uncompress: kindBinaryString, ast.DesEncrypt: kindBinaryString, ast.DesDecrypt: kindBinaryString,
+	ast.Encode: kindBinaryString, ast.Decode: kindBinaryString, ast.Encrypt: kindBinaryString,
+	ast.RandomBytes: kindBinaryString, ast.LoadFile: kindBinaryString,
+
+	ast.JSONExtract: kindJSON, ast.JSONArray: kindJSON, ast.JSONObject: kindJSON, ast.JSONMerge: kindJSON,
+	ast.JSONSet: kindJSON, ast.JSONInsert: kindJSON, ast.JSONReplace: kindJSON, ast.JSONRemove: kindJSON,
+	ast.JSONArrayAppend: kindJSON, ast.JSONArrayInsert: kindJSON, ast.JSONMergePatch: kindJSON,
+	ast.JSONMergePreserve: kindJSON, ast.JSONKeys: kindJSON, ast.JSONSearch: kindJSON,
+
+	ast.Curdate: kindDate, ast.CurrentDate: kindDate, ast.UTCDate: kindDate, ast.Date: kindDate,
+	ast.FromDays: kindDate, ast.MakeDate: kindDate, ast.LastDay: kindDate, ast.DateLiteral: kindDate,
+
+	ast.Curtime: kindTime, ast.CurrentTime: kindTime, ast.UTCTime: kindTime, ast.Time: kindTime,
+	ast.MakeTime: kindTime, ast.SecToTime: kindTime, ast.TimeDiff: kindTime, ast.TimeLiteral: kindTime,
+
+	ast.Now: kindDatetime, ast.CurrentTimestamp: kindDatetime, ast.LocalTime: kindDatetime,
+	ast.LocalTimestamp: kindDatetime, ast.Sysdate: kindDatetime, ast.UTCTimestamp: kindDatetime,
+	ast.FromUnixTime: kindDatetime, ast.Timestamp: kindDatetime, ast.ConvertTz: kindDatetime,
+	ast.StrToDate: kindDatetime, ast.TimestampAdd: kindDatetime, ast.TiDBParseTso: kindDatetime,
+	ast.TimestampLiteral: kindDatetime,
+}
+
+// nullableFuncs return NULL for some non-NULL arguments, such as invalid
+// dates or values out of their domain.
+var nullableFuncs = map[string]bool{
+	ast.FromBase64: true, ast.Unhex: true, ast.StrToDate: true, ast.MakeDate: true, ast.MakeTime: true,
+	ast.LastDay: true, ast.DateAdd: true, ast.DateSub: true, ast.AddDate: true, ast.SubDate: true,
+	ast.AddTime: true, ast.SubTime: true, ast.TimeDiff: true, ast.ConvertTz: true, ast.Conv: true,
+	ast.Elt: true, ast.InetAton: true, ast.InetNtoa: true, ast.Inet6Aton: true, ast.Inet6Ntoa: true,
+	ast.JSONExtract: true, ast.JSONSearch: true, ast.JSONKeys: true, ast.Database: true,
+	ast.Schema: true, ast.LoadFile: true, ast.Uncompress: true, ast.AesDecrypt: true,
+	ast.DesDecrypt: true, ast.Sqrt: true, ast.Ln: true, ast.Log: true, ast.Log2: true, ast.Log10: true,
+	ast.Acos: true, ast.Asin: true, ast.Date: true, ast.Time: true, ast.Timestamp: true,
+	ast.FromUnixTime: true, ast.FromDays: true, ast.DayName: true, ast.MonthName: true,
+	ast.DateFormat: true, ast.TimeFormat: true, ast.DateDiff: true, ast.TimestampDiff: true,
+	ast.TimestampAdd: true, ast.ToDays: true, ast.ToSeconds: true, ast.Day: true,
+	ast.DayOfMonth: true, ast.DayOfWeek: true, ast.DayOfYear: true, ast.Month: true, ast.Year: true,
+	ast.Quarter: true, ast.Week: true, ast.Weekday: true, ast.WeekOfYear: true, ast.YearWeek: true,
+	ast.Hour: true, ast.Minute: true, ast.Second: true, ast.MicroSecond: true, ast.Extract: true,
+	ast.TimeToSec: true, ast.UUIDToBin: true, ast.BinToUUID: true, ast.GetLock: true,
+	ast.ReleaseLock: true, ast.IsFreeLock: true, ast.IsUsedLock: true, ast.MasterPosWait: true,
+	ast.GetFormat: true, ast.TiDBParseTso: true, ast.Nullif: true,
+}
+
+// notNullFuncs never return NULL.
+var notNullFuncs = map[string]bool{
+	ast.PI: true, ast.Rand: true, ast.Now: true, ast.CurrentTimestamp: true, ast.LocalTime: true,
+	ast.LocalTimestamp: true, ast.Sysdate: true, ast.UTCTimestamp: true, ast.Curdate: true,
+	ast.CurrentDate: true, ast.UTCDate: true, ast.Curtime: true, ast.CurrentTime: true,
+	ast.UTCTime: true, ast.ConnectionID: true, ast.CurrentUser: true, ast.CurrentRole: true,
+	ast.SessionUser: true, ast.SystemUser: true, ast.User: true, ast.Version: true,
+	ast.TiDBVersion: true, ast.FoundRows: true, ast.RowCount: true, ast.LastInsertId: true,
+	ast.UUID: true, ast.UUIDShort: true, ast.Charset: true, ast.Collation: true,
+	ast.Coercibility: true, ast.IsNull: true, ast.IsTruthWithoutNull: true, ast.IsFalsity: true,
+	ast.NullEQ: true, ast.Field: true, ast.Interval: true, ast.DateLiteral: true,
+	ast.TimeLiteral: true, ast.TimestampLiteral: true,
+}
+
+// constInt returns the value of an integer literal.
+func constInt(expr ast.ExprNode) (int, bool) {
+	if _, ok := expr.(ast.ParamMarkerExpr); ok {
+		return 0, false
+	}
+	v, ok := expr.(ast.ValueExpr)
+	if !ok {
+		return 0, false
+	}
+	switch x := v.GetValue().(type) {
+	case int64:
+		return int(x), true
+	case uint64:
+		return int(x), true
+	case int:
+		return x, true
+	}
+	return 0, false
+}
+
+// fspArg returns the fractional seconds precision given by the i-th
+// argument, or the precision of the i-th argument when it is a temporal value.
+func fspArg(args []ast.ExprNode, i int) int {
+	if i >= len(args) {
+		return 0
+	}
+	if fsp, ok := constInt(args[i]); ok {
+		return fsp
+	}
+	return 0
+}
+
+// temporalFsp returns the fractional seconds precision of a temporal or
+// string argument.
+func temporalFsp(ft *types.FieldType) int {
+	switch ft.EvalType() {
+	case types.ETDatetime, types.ETTimestamp, types.ETDuration:
+		return maxInt(ft.Decimal, 0)
+	case types.ETString:
+		// The precision of a string isn't known until it is parsed.
+		return 6
+	}
+	return 0
+}
+
+// stringArgType returns a string type of the charset of the string
+// arguments, with the given length.
+func stringArgType(args []*types.FieldType, flen int) *types.FieldType {
+	ft := newStringType(flen)
+	for _, arg := range args {
+		if arg.EvalType() != types.ETString || arg.Tp == mysql.TypeNull || arg.Tp == mysql.TypeUnspecified {
+			continue
+		}
+		if isBinaryString(arg) {
+			setBinary(ft)
+			break
+		}
+		if arg.Charset != "" && ft.Charset == mysql.DefaultCharset {
+			ft.Charset, ft.Collate = arg.Charset, arg.Collate
+		}
+	}
+	return ft
+}
+
+func funcCallType(x *ast.FuncCallExpr) *types.FieldType {
+	name := x.FnName.L
+	args := argTypes(x.Args)
+	ft, nullable := builtinType(name, x.Args, args)
+	if ft == nil {
+		// User defined functions and stored functions have an unknown type.
+		return nil
+	}
+	switch {
+	case nullable != nil:
+		setNullable(ft, *nullable)
+	case notNullFuncs[name]:
+		setNullable(ft, false)
+	case nullableFuncs[name]:
+		setNullable(ft, true)
+	default:
+		setNullable(ft, !allNotNull(args))
+	}
+	return ft
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// builtinType returns the type of a builtin function. When the nullability
+// of the result doesn't follow from the function name it is returned as well.
+func builtinType(name string, argExprs []ast.ExprNode, args []*types.FieldType) (*types.FieldType, *bool) {
+	arg := func(i int) *types.FieldType {
+		if i < len(args) {
+			return args[i]
+		}
+		return types.NewFieldType(mysql.TypeUnspecified)
+	}
+	switch name {
+	case ast.If:
+		if len(args) < 3 {
+			return nil, nil
+		}
+		return unifyTypes(args[1:3]), nil
+	case ast.Ifnull:
+		ft := unifyTypes(args)
+		return ft, boolPtr(!mysql.HasNotNullFlag(arg(1).Flag))
+	case ast.Nullif:
+		ft := arg(0).Clone()
+		return ft, boolPtr(true)
+	case ast.Coalesce:
+		ft := unifyTypes(args)
+		notNull := false
+		for _, a := range args {
+			notNull = notNull || mysql.HasNotNullFlag(a.Flag)
+		}
+		return ft, boolPtr(!notNull)
+	case ast.Greatest, ast.Least:
+		return unifyTypes(args), boolPtr(!allNotNull(args))
+	case ast.Abs:
+		ft := arg(0).Clone()
+		if kind, ok := numericKind(ft); ok && kind == types.ETReal {
+			ft = newRealType()
+		}
+		return ft, nil
+	case ast.Ceil, ast.Ceiling, ast.Floor:
+		switch kind, _ := numericKind(arg(0)); kind {
+		case types.ETInt:
+			return newIntType(isUnsigned(arg(0))), nil
+		case types.ETDecimal:
+			intDigits, _ := digits(arg(0))
+			if intDigits < mysql.MaxIntWidth-1 {
+				return newIntType(isUnsigned(arg(0))), nil
+			}
+			return newDecimalType(intDigits, 0), nil
+		}
+		return newRealType(), nil
+	case ast.Round, ast.Truncate:
+		switch kind, _ := numericKind(arg(0)); kind {
+		case types.ETInt:
+			return newIntType(isUnsigned(arg(0))), nil
+		case types.ETDecimal:
+			intDigits, scale := digits(arg(0))
+			if len(argExprs) > 1 {
+				if d, ok := constInt(argExprs[1]); ok {
+					scale = minInt(maxInt(d, 0), scale)
+				}
+			} else {
+				scale = 0
+			}
+			return newDecimalType(intDigits+1, scale), nil
+		}
+		return newRealType(), nil
+	case ast.Concat:
+		flen := 0
+		for _, a := range args {
+			flen += displayLength(a)
+		}
+		return stringArgType(args, flen), nil
+	case ast.ConcatWS:
+		flen := 0
+		for i, a := range args[1:] {
+			if i > 0 {
+				flen += displayLength(arg(0))
+			}
+			flen += displayLength(a)
+		}
+		// NULL arguments other than the separator are skipped.
+		return stringArgType(args, flen), boolPtr(!mysql.HasNotNullFlag(arg(0).Flag))
+	case ast.Lower, ast.Lcase, ast.Upper, ast.Ucase, ast.Reverse, ast.LTrim, ast.RTrim, ast.Trim,
+		ast.Left, ast.Right, ast.Substring, ast.Substr, ast.Mid, ast.SubstringIndex:
+		return stringArgType(args[:minInt(1, len(args))], displayLength(arg(0))), nil
+	case ast.Quote:
+		return stringArgType(args[:minInt(1, len(args))], 2*displayLength(arg(0))+2), nil
+	case ast.Lpad, ast.Rpad:
+		flen := -1
+		if len(argExprs) > 1 {
+			if n, ok := constInt(argExprs[1]); ok {
+				flen = n
+			}
+		}
+		// The result is NULL when the length is negative.
+		return stringArgType(args[:1], flen), boolPtr(true)
+	case ast.Space:
+		flen := -1
+		if len(argExprs) > 0 {
+			if n, ok := constInt(argExprs[0]); ok {
+				flen = n
+			}
+		}
+		return newStringType(flen), nil
+	case ast.Convert:
+		// CONVERT(expr USING charset) is parsed as a function of the charset name.
+		ft := newStringType(displayLength(arg(0)))
+		if len(argExprs) > 1 {
+			if v, ok := argExprs[1].(ast.ValueExpr); ok {
+				if cs, coll, err := charset.GetCharsetInfo(strings.ToLower(v.GetString())); err == nil {
+					ft.Charset, ft.Collate = cs, coll
+				}
+			}
+		}
+		return ft, nil
+	case ast.AnyValue, ast.NameConst, ast.Values, ast.DefaultFunc, ast.GetParam:
+		if len(args) == 0 {
+			return nil, nil
+		}
+		return args[len(args)-1].Clone(), nil
+	case ast.SetVar:
+		if len(args) < 2 {
+			return nil, nil
+		}
+		return args[1].Clone(), nil
+	case ast.DateAdd, ast.DateSub, ast.AddDate, ast.SubDate:
+		switch first := arg(0); first.EvalType() {
+		case types.ETDatetime, types.ETTimestamp:
+			if first.Tp == mysql.TypeDate && len(argExprs) > 2 && isDateUnit(argExprs[2]) {
+				return newTemporalType(mysql.TypeDate, 0), nil
+			}
+			return newTemporalType(mysql.TypeDatetime, maxInt(first.Decimal, 0)), nil
+		case types.ETDuration:
+			return newTemporalType(mysql.TypeDuration, maxInt(first.Decimal, 0)), nil
+		}
+		// The result of adding an interval to a string is a string.
+		return newStringType(mysql.MaxDatetimeFullWidth), nil
+	case ast.AddTime, ast.SubTime:
+		fsp := maxInt(temporalFsp(arg(0)), temporalFsp(arg(1)))
+		switch arg(0).EvalType() {
+		case types.ETDatetime, types.ETTimestamp:
+			return newTemporalType(mysql.TypeDatetime, fsp), nil
+		case types.ETDuration:
+			return newTemporalType(mysql.TypeDuration, fsp), nil
+		}
+		return newStringType(mysql.MaxDatetimeFullWidth), nil
+	case ast.UnixTimestamp:
+		if len(args) > 0 && temporalFsp(arg(0)) > 0 {
+			return newDecimalType(12, temporalFsp(arg(0))), nil
+		}
+		return newIntType(false), nil
+	}
+	kind, ok := funcKinds[name]
+	if !ok {
+		return nil, nil
+	}
+	switch kind {
+	case kindInt:
+		return newIntType(false), nil
+	case kindUnsignedInt:
+		return newIntType(true), nil
+	case kindBool:
+		return newBoolType(false), nil
+	case kindReal:
+		return newRealType(), nil
+	case kindString:
+		return newStringType(-1), nil
+	case kindBinaryString:
+		return newBinaryStringType(-1), nil
+	case kindJSON:
+		return newJSONType(), nil
+	case kindDate:
+		return newTemporalType(mysql.TypeDate, 0), nil
+	case kindTime:
+		switch name {
+		case ast.Curtime, ast.CurrentTime, ast.UTCTime:
+			return newTemporalType(mysql.TypeDuration, fspArg(argExprs, 0)), nil
+		case ast.MakeTime:
+			return newTemporalType(mysql.TypeDuration, temporalFsp(arg(2))), nil
+		case ast.TimeDiff:
+			return newTemporalType(mysql.TypeDuration, maxInt(temporalFsp(arg(0)), temporalFsp(arg(1)))), nil
+		}
+		return newTemporalType(mysql.TypeDuration, temporalFsp(arg(0))), nil
+	case kindDatetime:
+		switch name {
+		case ast.Now, ast.CurrentTimestamp, ast.LocalTime, ast.LocalTimestamp, ast.Sysdate, ast.UTCTimestamp:
+			return newTemporalType(mysql.TypeDatetime, fspArg(argExprs, 0)), nil
+		case ast.StrToDate, ast.FromUnixTime, ast.TimestampAdd:
+			return newTemporalType(mysql.TypeDatetime, 6), nil
+		}
+		return newTemporalType(mysql.TypeDatetime, temporalFsp(arg(0))), nil
+	}
+	return nil, nil
+}
+
+// isDateUnit reports whether a time unit argument only changes the date part.
+func isDateUnit(expr ast.ExprNode) bool {
+	unit, ok := expr.(*ast.TimeUnitExpr)
+	if !ok {
+		return false
+	}
+	switch unit.Unit {
+	case ast.TimeUnitDay, ast.TimeUnitWeek, ast.TimeUnitMonth, ast.TimeUnitQuarter,
+		ast.TimeUnitYear, ast.TimeUnitYearMonth:
+		return true
+	}
+	return false
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,768 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolver
+
+import (
+	"strings"
+
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/charset"
+	"github.com/kyleconroy/sqlparse/mysql"
+	"github.com/kyleconroy/sqlparse/opcode"
+	"github.com/kyleconroy/sqlparse/types"
+)
+
+// divPrecisionIncrement is the default value of the div_precision_increment
+// system variable, the number of digits added to the scale of a division.
+const divPrecisionIncrement = 4
+
+// InferTypes sets the type of every expression in node following the MySQL
+// type rules: flen, decimal, charset and the unsigned and not null flags.
+//
+// Column names should be resolved first, as a column takes the type of the
+// field it refers to; the type of an unresolved column is left unspecified.
+// Resolve already infers the types of the statements it resolves, so
+// InferTypes is meant for nodes which aren't resolved, such as expressions
+// outside of a statement.
+func InferTypes(node ast.Node) {
+	node.Accept(&typeVisitor{inf: newInferrer()})
+}
+
+type typeVisitor struct {
+	inf *inferrer
+}
+
+// Enter implements ast.Visitor interface.
+func (v *typeVisitor) Enter(in ast.Node) (ast.Node, bool) {
+	return in, false
+}
+
+// Leave implements ast.Visitor interface.
+func (v *typeVisitor) Leave(in ast.Node) (ast.Node, bool) {
+	if expr, ok := in.(ast.ExprNode); ok {
+		v.inf.infer(expr)
+	}
+	return in, true
+}
+
+// inferrer computes the type of an expression from the types of its
+// children, which must have been inferred first.
+type inferrer struct {
+	// outputs holds the output fields of the queries resolved so far, which
+	// give the type of subqueries.
+	outputs map[ast.Node][]*ast.ResultField
+	// nullable holds the fields of the tables on the inner side of an outer join.
+	nullable map[*ast.ResultField]bool
+	// columns holds the bindings of column names which aren't expressions,
+	// such as the argument of DEFAULT().
+	columns map[*ast.ColumnName]*ast.ResultField
+}
+
+func newInferrer() *inferrer {
+	return &inferrer{
+		outputs:  make(map[ast.Node][]*ast.ResultField),
+		nullable: make(map[*ast.ResultField]bool),
+		columns:  make(map[*ast.ColumnName]*ast.ResultField),
+	}
+}
+
+func (inf *inferrer) infer(expr ast.ExprNode) {
+	switch x := expr.(type) {
+	case ast.ParamMarkerExpr:
+		// The type of a parameter depends on its context, see Resolver.Params.
+		return
+	case ast.ValueExpr:
+		ft := x.GetType()
+		if ft.Tp != mysql.TypeNull {
+			ft.Flag |= mysql.NotNullFlag
+		}
+		return
+	}
+	if ft := inf.typeOf(expr); ft != nil {
+		expr.SetType(ft)
+	}
+}
+
+// typeOfField returns the type of the values of a result field.
+func (inf *inferrer) typeOfField(rf *ast.ResultField) *types.FieldType {
+	var ft *types.FieldType
+	if rf.Expr != nil {
+		ft = rf.Expr.GetType().Clone()
+	} else {
+		ft = rf.Column.FieldType.Clone()
+	}
+	if inf.nullable[rf] {
+		ft.Flag &^= mysql.NotNullFlag
+	}
+	return ft
+}
+
+// typeOfQuery returns the type of the first column of a query, which is the
+// type of a scalar subquery.
+func (inf *inferrer) typeOfQuery(query ast.Node) *types.FieldType {
+	if fields := inf.outputs[query]; len(fields) > 0 {
+		return inf.typeOfField(fields[0])
+	}
+	for {
+		switch x := query.(type) {
+		case *ast.SetOprStmt:
+			query = x.SelectList
+			continue
+		case *ast.SetOprSelectList:
+			if len(x.Selects) > 0 {
+				query = x.Selects[0]
+				continue
+			}
+		case *ast.SelectStmt:
+			if x.Fields != nil && len(x.Fields.Fields) > 0 && x.Fields.Fields[0].Expr != nil {
+				return x.Fields.Fields[0].Expr.GetType().Clone()
+			}
+		}
+		return types.NewFieldType(mysql.TypeUnspecified)
+	}
+}
+
+func (inf *inferrer) typeOf(expr ast.ExprNode) *types.FieldType {
+	switch x := expr.(type) {
+	case *ast.ColumnNameExpr:
+		if x.Refer != nil {
+			return inf.typeOfField(x.Refer)
+		}
+	case *ast.PositionExpr:
+		if x.Refer != nil {
+			return inf.typeOfField(x.Refer)
+		}
+	case *ast.DefaultExpr:
+		if rf := inf.columns[x.Name]; x.Name != nil && rf != nil {
+			return inf.typeOfField(rf)
+		}
+	case *ast.ValuesExpr:
+		ft := x.Column.GetType().Clone()
+		setNullable(ft, true)
+		return ft
+	case *ast.ParenthesesExpr:
+		return x.Expr.GetType().Clone()
+	case *ast.SetCollationExpr:
+		ft := x.Expr.GetType().Clone()
+		if coll, err := charset.GetCollationByName(x.Collate); err == nil {
+			ft.Charset, ft.Collate = coll.CharsetName, coll.Name
+		}
+		return ft
+	case *ast.BinaryOperationExpr:
+		return binaryOperationType(x.Op, x.L.GetType(), x.R.GetType())
+	case *ast.UnaryOperationExpr:
+		return unaryOperationType(x.Op, x.V.GetType())
+	case *ast.BetweenExpr:
+		return newBoolType(isNotNull(x.Expr, x.Left, x.Right))
+	case *ast.PatternInExpr:
+		args := append([]ast.ExprNode{x.Expr}, x.List...)
+		if x.Sel != nil {
+			args = append(args, x.Sel)
+		}
+		return newBoolType(isNotNull(args...))
+	case *ast.PatternLikeExpr:
+		return newBoolType(isNotNull(x.Expr, x.Pattern))
+	case *ast.PatternRegexpExpr:
+		return newBoolType(isNotNull(x.Expr, x.Pattern))
+	case *ast.IsNullExpr, *ast.IsTruthExpr, *ast.ExistsSubqueryExpr:
+		return newBoolType(true)
+	case *ast.CompareSubqueryExpr:
+		return newBoolType(false)
+	case *ast.MatchAgainst:
+		return newRealType()
+	case *ast.SubqueryExpr:
+		ft := inf.typeOfQuery(x.Query)
+		if !x.Exists {
+			// A scalar subquery returns NULL when it has no row.
+			setNullable(ft, true)
+		}
+		return ft
+	case *ast.CaseExpr:
+		var results []*types.FieldType
+		for _, w := range x.WhenClauses {
+			results = append(results, w.Result.GetType())
+		}
+		if x.ElseClause != nil {
+			results = append(results, x.ElseClause.GetType())
+		}
+		ft := unifyTypes(results)
+		if x.ElseClause == nil {
+			setNullable(ft, true)
+		}
+		return ft
+	case *ast.VariableExpr:
+		if x.Value != nil {
+			ft := x.Value.GetType().Clone()
+			setNullable(ft, true)
+			return ft
+		}
+	case *ast.FuncCastExpr:
+		return castType(x)
+	case *ast.AggregateFuncExpr:
+		return aggregateType(x.F, argTypes(x.Args))
+	case *ast.WindowFuncExpr:
+		return windowType(x.F, argTypes(x.Args))
+	case *ast.FuncCallExpr:
+		return funcCallType(x)
+	}
+	return nil
+}
+
+func argTypes(args []ast.ExprNode) []*types.FieldType {
+	fts := make([]*types.FieldType, len(args))
+	for i, arg := range args {
+		fts[i] = arg.GetType()
+	}
+	return fts
+}
+
+func isNotNull(exprs ...ast.ExprNode) bool {
+	for _, expr := range exprs {
+		if !mysql.HasNotNullFlag(expr.GetType().Flag) {
+			return false
+		}
+	}
+	return true
+}
+
+func allNotNull(fts []*types.FieldType) bool {
+	for _, ft := range fts {
+		if !mysql.HasNotNullFlag(ft.Flag) {
+			return false
+		}
+	}
+	return true
+}
+
+func setNullable(ft *types.FieldType, nullable bool) {
+	if nullable {
+		ft.Flag &^= mysql.NotNullFlag
+	} else {
+		ft.Flag |= mysql.NotNullFlag
+	}
+}
+
+// newType returns a type with the default flen and decimal of tp. Types
+// which aren't strings have the binary charset.
+func newType(tp byte) *types.FieldType {
+	ft := types.NewFieldType(tp)
+	ft.Flen, ft.Decimal = mysql.GetDefaultFieldLengthAndDecimal(tp)
+	if ft.EvalType() != types.ETString || tp == mysql.TypeNull {
+		setBinary(ft)
+	}
+	return ft
+}
+
+func setBinary(ft *types.FieldType) {
+	ft.Charset = charset.CharsetBin
+	ft.Collate = charset.CollationBin
+	ft.Flag |= mysql.BinaryFlag
+}
+
+func newIntType(unsigned bool) *types.FieldType {
+	ft := newType(mysql.TypeLonglong)
+	if unsigned {
+		ft.Flag |= mysql.UnsignedFlag
+	}
+	return ft
+}
+
+func newBoolType(notNull bool) *types.FieldType {
+	ft := newType(mysql.TypeLonglong)
+	ft.Flen = 1
+	ft.Flag |= mysql.IsBooleanFlag
+	setNullable(ft, !notNull)
+	return ft
+}
+
+func newRealType() *types.FieldType {
+	ft := newType(mysql.TypeDouble)
+	ft.Flen = mysql.MaxRealWidth
+	return ft
+}
+
+func newDecimalType(intDigits, scale int) *types.FieldType {
+	ft := newType(mysql.TypeNewDecimal)
+	if scale > mysql.MaxDecimalScale {
+		scale = mysql.MaxDecimalScale
+	}
+	if scale < 0 {
+		scale = 0
+	}
+	flen := intDigits + scale
+	if flen > mysql.MaxDecimalWidth {
+		flen = mysql.MaxDecimalWidth
+	}
+	if flen < scale {
+		flen = scale
+	}
+	ft.Flen, ft.Decimal = flen, scale
+	return ft
+}
+
+func newStringType(flen int) *types.FieldType {
+	ft := newType(mysql.TypeVarString)
+	ft.Charset, ft.Collate = mysql.DefaultCharset, mysql.DefaultCollationName
+	if flen >= 0 {
+		ft.Flen = flen
+	}
+	if ft.Flen > mysql.MaxFieldVarCharLength {
+		ft.Tp = mysql.TypeLongBlob
+	}
+	return ft
+}
+
+func newBinaryStringType(flen int) *types.FieldType {
+	ft := newStringType(flen)
+	setBinary(ft)
+	return ft
+}
+
+// newTemporalType returns a date, datetime, timestamp or time type with the
+// given fractional seconds precision.
+func newTemporalType(tp byte, fsp int) *types.FieldType {
+	ft := newType(tp)
+	if tp == mysql.TypeDate || fsp < 0 {
+		fsp = 0
+	}
+	ft.Decimal = fsp
+	if fsp > 0 {
+		ft.Flen += fsp + 1
+	}
+	return ft
+}
+
+func newJSONType() *types.FieldType {
+	ft := newType(mysql.TypeJSON)
+	ft.Charset, ft.Collate = mysql.DefaultCharset, mysql.DefaultCollationName
+	ft.Flag &^= mysql.BinaryFlag
+	return ft
+}
+
+// isBinaryString reports whether ft is a string of the binary charset.
+func isBinaryString(ft *types.FieldType) bool {
+	return ft.EvalType() == types.ETString && ft.Charset == charset.CharsetBin &&
+		ft.Tp != mysql.TypeNull && ft.Tp != mysql.TypeUnspecified
+}
+
+// displayLength returns the number of characters needed to show a value of
+// type ft as a string.
+func displayLength(ft *types.FieldType) int {
+	if ft.Flen >= 0 {
+		return ft.Flen
+	}
+	flen, _ := mysql.GetDefaultFieldLengthAndDecimal(ft.Tp)
+	return flen
+}
+
+// numericKind returns the evaluation type an operand of an arithmetic
+// operation is converted to. ok is false when the type isn't known.
+func numericKind(ft *types.FieldType) (kind types.EvalType, ok bool) {
+	switch ft.Tp {
+	case mysql.TypeUnspecified:
+		return 0, false
+	case mysql.TypeNull:
+		return types.ETInt, true
+	}
+	switch et := ft.EvalType(); et {
+	case types.ETInt, types.ETReal, types.ETDecimal:
+		return et, true
+	case types.ETDatetime, types.ETTimestamp, types.ETDuration:
+		if ft.Decimal > 0 {
+			return types.ETDecimal, true
+		}
+		return types.ETInt, true
+	}
+	// Strings and JSON documents are converted to double.
+	return types.ETReal, true
+}
+
+// digits returns the number of integer digits and the scale of a numeric type.
+func digits(ft *types.FieldType) (intDigits, scale int) {
+	flen := displayLength(ft)
+	switch ft.Tp {
+	case mysql.TypeDate:
+		return 8, 0
+	case mysql.TypeDatetime, mysql.TypeTimestamp:
+		return 14, ft.Decimal
+	case mysql.TypeDuration:
+		return 7, ft.Decimal
+	}
+	scale = ft.Decimal
+	if scale < 0 || ft.EvalType() == types.ETInt {
+		scale = 0
+	}
+	intDigits = flen - scale
+	if intDigits < 1 {
+		intDigits = 1
+	}
+	return intDigits, scale
+}
+
+func isUnsigned(ft *types.FieldType) bool {
+	return mysql.HasUnsignedFlag(ft.Flag)
+}
+
+func binaryOperationType(op opcode.Op, l, r *types.FieldType) *types.FieldType {
+	notNull := mysql.HasNotNullFlag(l.Flag) && mysql.HasNotNullFlag(r.Flag)
+	switch op {
+	case opcode.LogicAnd, opcode.LogicOr, opcode.LogicXor,
+		opcode.EQ, opcode.NE, opcode.LT, opcode.LE, opcode.GT, opcode.GE,
+		opcode.Like, opcode.Regexp, opcode.In:
+		return newBoolType(notNull)
+	case opcode.NullEQ:
+		return newBoolType(true)
+	case opcode.And, opcode.Or, opcode.Xor, opcode.LeftShift, opcode.RightShift:
+		ft := newIntType(true)
+		ft.Flen = mysql.MaxIntWidth + 1
+		setNullable(ft, !notNull)
+		return ft
+	}
+	lk, lok := numericKind(l)
+	rk, rok := numericKind(r)
+	switch {
+	case !lok && !rok:
+		return types.NewFieldType(mysql.TypeUnspecified)
+	case !lok:
+		lk, l = rk, r
+	case !rok:
+		rk, r = lk, l
+	}
+	li, ls := digits(l)
+	ri, rs := digits(r)
+	var ft *types.FieldType
+	switch {
+	case lk == types.ETReal || rk == types.ETReal:
+		ft = newRealType()
+	case op == opcode.IntDiv:
+		ft = newIntType(isUnsigned(l) || isUnsigned(r))
+	case op == opcode.Div:
+		ft = newDecimalType(li+rs, ls+divPrecisionIncrement)
+	case lk == types.ETDecimal || rk == types.ETDecimal:
+		switch op {
+		case opcode.Mul:
+			ft = newDecimalType(li+ri, ls+rs)
+		case opcode.Mod:
+			ft = newDecimalType(maxInt(li, ri), maxInt(ls, rs))
+		default:
+			ft = newDecimalType(maxInt(li, ri)+1, maxInt(ls, rs))
+		}
+	case op == opcode.Mod:
+		ft = newIntType(isUnsigned(l))
+	default:
+		ft = newIntType(isUnsigned(l) || isUnsigned(r))
+	}
+	// Division and modulo by zero return NULL.
+	if op == opcode.Div || op == opcode.IntDiv || op == opcode.Mod {
+		notNull = false
+	}
+	setNullable(ft, !notNull)
+	return ft
+}
+
+func unaryOperationType(op opcode.Op, v *types.FieldType) *types.FieldType {
+	notNull := mysql.HasNotNullFlag(v.Flag)
+	var ft *types.FieldType
+	switch op {
+	case opcode.Not, opcode.Not2:
+		return newBoolType(notNull)
+	case opcode.BitNeg:
+		ft = newIntType(true)
+		ft.Flen = mysql.MaxIntWidth + 1
+	case opcode.Plus:
+		return v.Clone()
+	case opcode.Minus:
+		kind, ok := numericKind(v)
+		switch {
+		case !ok:
+			return types.NewFieldType(mysql.TypeUnspecified)
+		case kind == types.ETInt:
+			ft = newIntType(false)
+		case kind == types.ETDecimal:
+			intDigits, scale := digits(v)
+			ft = newDecimalType(intDigits+1, scale)
+		default:
+			ft = newRealType()
+		}
+	default:
+		return nil
+	}
+	setNullable(ft, !notNull)
+	return ft
+}
+
+// intRank orders the integer types by size.
+var intRank = map[byte]int{
+	mysql.TypeBit:      0,
+	mysql.TypeTiny:     1,
+	mysql.TypeYear:     2,
+	mysql.TypeShort:    2,
+	mysql.TypeInt24:    3,
+	mysql.TypeLong:     4,
+	mysql.TypeLonglong: 5,
+}
+
+// blobRank orders the string types by their maximum length.
+var blobRank = map[byte]int{
+	mysql.TypeString:     0,
+	mysql.TypeVarchar:    1,
+	mysql.TypeVarString:  1,
+	mysql.TypeTinyBlob:   2,
+	mysql.TypeBlob:       3,
+	mysql.TypeMediumBlob: 4,
+	mysql.TypeLongBlob:   5,
+}
+
+// unifyTypes returns the type of an expression whose value is one of
+// several expressions, such as CASE, IF or COALESCE. NULL arguments don't
+// contribute to the type. The result is nullable if any argument is.
+func unifyTypes(fts []*types.FieldType) *types.FieldType {
+	var (
+		known   []*types.FieldType
+		hasNull bool
+	)
+	for _, ft := range fts {
+		switch ft.Tp {
+		case mysql.TypeNull:
+			hasNull = true
+		case mysql.TypeUnspecified:
+		default:
+			known = append(known, ft)
+		}
+	}
+	if len(known) == 0 {
+		if hasNull {
+			return newType(mysql.TypeNull)
+		}
+		return types.NewFieldType(mysql.TypeUnspecified)
+	}
+	ft := mergeTypes(known)
+	setNullable(ft, !allNotNull(fts))
+	return ft
+}
+
+func mergeTypes(fts []*types.FieldType) *types.FieldType {
+	sameTp, allInt, allNumeric, allDate, allTemporal := true, true, true, true, true
+	allUnsigned, anyReal, anyBinary, anyJSON := true, false, false, false
+	for _, ft := range fts {
+		sameTp = sameTp && ft.Tp == fts[0].Tp
+		et := ft.EvalType()
+		allInt = allInt && et == types.ETInt
+		allNumeric = allNumeric && (et == types.ETInt || et == types.ETReal || et == types.ETDecimal)
+		allDate = allDate && (et == types.ETDatetime || et == types.ETTimestamp)
+		allTemporal = allTemporal && (et == types.ETDatetime || et == types.ETTimestamp || et == types.ETDuration)
+		allUnsigned = allUnsigned && isUnsigned(ft)
+		anyReal = anyReal || et == types.ETReal
+		anyBinary = anyBinary || isBinaryString(ft)
+		anyJSON = anyJSON || et == types.ETJson
+	}
+	var ft *types.FieldType
+	switch {
+	case sameTp && fts[0].EvalType() != types.ETString:
+		ft = fts[0].Clone()
+		ft.Flag &^= mysql.NotNullFlag | mysql.PriKeyFlag | mysql.UniqueKeyFlag | mysql.MultipleKeyFlag |
+			mysql.AutoIncrementFlag | mysql.OnUpdateNowFlag | mysql.NoDefaultValueFlag
+		for _, other := range fts[1:] {
+			ft.Flen = maxInt(ft.Flen, other.Flen)
+			ft.Decimal = maxInt(ft.Decimal, other.Decimal)
+		}
+		if !allUnsigned {
+			ft.Flag &^= mysql.UnsignedFlag
+		}
+		return ft
+	case allInt:
+		ft = newIntType(allUnsigned)
+		tp := fts[0].Tp
+		for _, other := range fts[1:] {
+			if intRank[other.Tp] > intRank[tp] {
+				tp = other.Tp
+			}
+		}
+		if tp != mysql.TypeBit && tp != mysql.TypeYear {
+			ft.Tp = tp
+			ft.Flen, _ = mysql.GetDefaultFieldLengthAndDecimal(tp)
+		}
+		return ft
+	case allNumeric && anyReal:
+		return newRealType()
+	case allNumeric:
+		var intDigits, scale int
+		for _, other := range fts {
+			i, s := digits(other)
+			intDigits, scale = maxInt(intDigits, i), maxInt(scale, s)
+		}
+		return newDecimalType(intDigits, scale)
+	case allDate:
+		fsp := 0
+		for _, other := range fts {
+			fsp = maxInt(fsp, other.Decimal)
+		}
+		return newTemporalType(mysql.TypeDatetime, fsp)
+	case allTemporal:
+		fsp := 0
+		for _, other := range fts {
+			fsp = maxInt(fsp, other.Decimal)
+		}
+		return newTemporalType(mysql.TypeDatetime, fsp)
+	case anyJSON && sameTp:
+		return newJSONType()
+	}
+	// Anything else is converted to a string.
+	flen, tp := 0, mysql.TypeVarString
+	for _, other := range fts {
+		flen = maxInt(flen, displayLength(other))
+		if rank, ok := blobRank[other.Tp]; ok && rank > blobRank[tp] {
+			tp = other.Tp
+		}
+	}
+	ft = newStringType(flen)
+	if tp != mysql.TypeString && blobRank[tp] > blobRank[ft.Tp] {
+		ft.Tp = tp
+	}
+	for _, other := range fts {
+		if other.EvalType() == types.ETString && other.Charset != "" && other.Charset != charset.CharsetBin {
+			ft.Charset, ft.Collate = other.Charset, other.Collate
+			break
+		}
+	}
+	if anyBinary {
+		setBinary(ft)
+	}
+	return ft
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func castType(x *ast.FuncCastExpr) *types.FieldType {
+	ft := x.Tp.Clone()
+	flen, decimal := mysql.GetDefaultFieldLengthAndDecimalForCast(ft.Tp)
+	if ft.Flen == types.UnspecifiedLength {
+		ft.Flen = flen
+	}
+	if ft.Decimal == types.UnspecifiedLength {
+		ft.Decimal = decimal
+	}
+	switch ft.EvalType() {
+	case types.ETString:
+		if ft.Flen <= 0 {
+			ft.Flen = displayLength(x.Expr.GetType())
+		}
+		if ft.Charset == "" {
+			ft.Charset, ft.Collate = mysql.DefaultCharset, mysql.DefaultCollationName
+		}
+		if ft.Charset == charset.CharsetBin {
+			ft.Flag |= mysql.BinaryFlag
+		}
+	case types.ETJson:
+	default:
+		setBinary(ft)
+	}
+	nullable := !mysql.HasNotNullFlag(x.Expr.GetType().Flag)
+	// Casting a string to a temporal type returns NULL for invalid values.
+	switch ft.EvalType() {
+	case types.ETDatetime, types.ETTimestamp, types.ETDuration, types.ETJson:
+		nullable = true
+	}
+	setNullable(ft, nullable)
+	return ft
+}
+
+func aggregateType(name string, args []*types.FieldType) *types.FieldType {
+	var arg *types.FieldType
+	if len(args) > 0 {
+		arg = args[0]
+	} else {
+		arg = types.NewFieldType(mysql.TypeUnspecified)
+	}
+	var ft *types.FieldType
+	switch strings.ToLower(name) {
+	case ast.AggFuncCount, ast.AggFuncApproxCountDistinct:
+		ft = newIntType(false)
+		ft.Flen = mysql.MaxIntWidth + 1
+		return setNotNull(ft)
+	case ast.AggFuncBitAnd, ast.AggFuncBitOr, ast.AggFuncBitXor:
+		ft = newIntType(true)
+		ft.Flen = mysql.MaxIntWidth + 1
+		return setNotNull(ft)
+	case ast.AggFuncSum:
+		switch kind, _ := numericKind(arg); kind {
+		case types.ETInt, types.ETDecimal:
+			_, scale := digits(arg)
+			ft = newDecimalType(mysql.MaxDecimalWidth, scale)
+		default:
+			ft = newRealType()
+		}
+	case ast.AggFuncAvg:
+		switch kind, _ := numericKind(arg); kind {
+		case types.ETInt, types.ETDecimal:
+			intDigits, scale := digits(arg)
+			ft = newDecimalType(intDigits, scale+divPrecisionIncrement)
+		default:
+			ft = newRealType()
+		}
+	case ast.AggFuncVarPop, ast.AggFuncVarSamp, ast.AggFuncStddevPop, ast.AggFuncStddevSamp,
+		"variance", "std", "stddev":
+		ft = newRealType()
+	case ast.AggFuncGroupConcat:
+		ft = newStringType(1024)
+		for _, a := range args {
+			if isBinaryString(a) {
+				setBinary(ft)
+			}
+		}
+	case ast.AggFuncJsonArrayagg, ast.AggFuncJsonObjectAgg:
+		ft = newJSONType()
+	default:
+		// MAX, MIN, FIRSTROW, APPROX_PERCENTILE and the like keep the type of
+		// their argument.
+		ft = arg.Clone()
+		ft.Flag &^= mysql.PriKeyFlag | mysql.UniqueKeyFlag | mysql.MultipleKeyFlag | mysql.AutoIncrementFlag
+	}
+	// Aggregating no row returns NULL.
+	setNullable(ft, true)
+	return ft
+}
+
+func setNotNull(ft *types.FieldType) *types.FieldType {
+	setNullable(ft, false)
+	return ft
+}
+
+func windowType(name string, args []*types.FieldType) *types.FieldType {
+	name = strings.ToLower(name)
+	switch name {
+	case ast.WindowFuncRowNumber, ast.WindowFuncRank, ast.WindowFuncDenseRank, ast.WindowFuncNtile:
+		ft := newIntType(true)
+		ft.Flen = mysql.MaxIntWidth + 1
+		return setNotNull(ft)
+	case ast.WindowFuncCumeDist, ast.WindowFuncPercentRank:
+		return setNotNull(newRealType())
+	case ast.WindowFuncLead, ast.WindowFuncLag, ast.WindowFuncFirstValue, ast.WindowFuncLastValue, ast.WindowFuncNthValue:
+		if len(args) == 0 {
+			return nil
+		}
+		ft := unifyTypes(args[:1])
+		if len(args) == 3 && (name == ast.WindowFuncLead || name == ast.WindowFuncLag) {
+			ft = unifyTypes([]*types.FieldType{args[0], args[2]})
+		}
+		setNullable(ft, true)
+		return ft
+	}
+	return aggregateType(name, args)
+}
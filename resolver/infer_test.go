@@ -0,0 +1,161 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolver_test
+
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/kyleconroy/sqlparse"
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/catalog"
+	"github.com/kyleconroy/sqlparse/mysql"
+	. "github.com/kyleconroy/sqlparse/resolver"
+	"github.com/kyleconroy/sqlparse/types"
+)
+
+var _ = Suite(&testInferSuite{})
+
+type testInferSuite struct {
+	cat *catalog.Catalog
+}
+
+func (s *testInferSuite) SetUpSuite(c *C) {
+	s.cat = catalog.New("test")
+	stmts, _, err := parser.New().Parse(`
+		create table n (
+			i int not null, u int unsigned, d decimal(10, 2), f double,
+			s varchar(10), dt datetime(3), j json)`, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(s.cat.ApplyAll(stmts), IsNil)
+}
+
+// describeType renders a field type as `type(flen,decimal)`, followed by
+// `unsigned` and `not null` when the flags are set.
+func describeType(ft *types.FieldType) string {
+	desc := fmt.Sprintf("%s(%d,%d)", types.TypeToStr(ft.Tp, ft.Charset), ft.Flen, ft.Decimal)
+	if mysql.HasUnsignedFlag(ft.Flag) {
+		desc += " unsigned"
+	}
+	if mysql.HasNotNullFlag(ft.Flag) {
+		desc += " not null"
+	}
+	return desc
+}
+
+// fieldTypes resolves a query and describes the types of its select fields.
+func (s *testInferSuite) fieldTypes(c *C, sql string) []string {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(New(s.cat).Resolve(stmt), IsNil, Commentf("sql: %s", sql))
+	var got []string
+	for _, field := range stmt.(*ast.SelectStmt).Fields.Fields {
+		got = append(got, describeType(field.Expr.GetType()))
+	}
+	return got
+}
+
+func (s *testInferSuite) TestExpressions(c *C) {
+	cases := []struct {
+		expr   string
+		expect string
+	}{
+		{"i + 1", "bigint(20,0) not null"},
+		{"u + i", "bigint(20,0) unsigned"},
+		{"i + d", "decimal(14,2)"},
+		{"d * d", "decimal(20,4)"},
+		{"i / 2", "decimal(15,4)"},
+		{"i div 2", "bigint(20,0)"},
+		{"f - i", "double(23,-1)"},
+		{"s + 1", "double(23,-1)"},
+		{"-u", "bigint(20,0)"},
+		{"i > 0", "bigint(1,0) not null"},
+		{"s like 'a%'", "bigint(1,0)"},
+		{"s is null", "bigint(1,0) not null"},
+		{"cast(s as signed)", "bigint(22,0)"},
+		{"cast(i as decimal(8, 3))", "decimal(8,3) not null"},
+		{"cast(i as char(5))", "var_string(5,-1) not null"},
+		{"case when i > 0 then i else d end", "decimal(13,2)"},
+		{"case i when 1 then 'a' when 2 then 'bcd' end", "var_string(3,0)"},
+		{"case when i > 0 then 1 else 2 end", "bigint(1,0) not null"},
+		{"concat(s, 'xy')", "var_string(12,0)"},
+		{"concat_ws(',', s, s)", "var_string(21,0) not null"},
+		{"ifnull(s, 'a')", "var_string(10,0) not null"},
+		{"coalesce(u, i)", "int(11,0) not null"},
+		{"nullif(i, 1)", "int(11,0)"},
+		{"now(3)", "datetime(23,3) not null"},
+		{"date_add(dt, interval 1 day)", "datetime(23,3)"},
+		{"length(s)", "bigint(20,0)"},
+		{"json_extract(j, '$.a')", "json(4294967295,0)"},
+		{"exists (select 1)", "bigint(1,0) not null"},
+		{"(select max(d) from n)", "decimal(10,2)"},
+	}
+	for _, ca := range cases {
+		got := s.fieldTypes(c, "select "+ca.expr+" from n")
+		c.Assert(got, DeepEquals, []string{ca.expect}, Commentf("expr: %s", ca.expr))
+	}
+}
+
+func (s *testInferSuite) TestAggregates(c *C) {
+	got := s.fieldTypes(c, "select count(*), sum(i), sum(f), avg(d), min(s), group_concat(s), bit_or(i) from n")
+	c.Assert(got, DeepEquals, []string{
+		"bigint(21,0) not null",
+		"decimal(65,0)",
+		"double(23,-1)",
+		"decimal(14,6)",
+		"varchar(10,0)",
+		"var_string(1024,0)",
+		"bigint(21,0) unsigned not null",
+	})
+}
+
+func (s *testInferSuite) TestSources(c *C) {
+	// The inner side of an outer join may be NULL.
+	got := s.fieldTypes(c, "select n.i, x.i from n left join n as x on n.i = x.i")
+	c.Assert(got, DeepEquals, []string{"int(11,0) not null", "int(11,0)"})
+
+	got = s.fieldTypes(c, "select y, z from (select i + 1 as y, d as z from n) t")
+	c.Assert(got, DeepEquals, []string{"bigint(20,0) not null", "decimal(10,2)"})
+
+	got = s.fieldTypes(c, "select t.i from (select x.i from n right join n as x on true) t right join n on true")
+	c.Assert(got, DeepEquals, []string{"int(11,0)"})
+
+	got = s.fieldTypes(c, "select a from (select i as a from n union select s from n) t")
+	c.Assert(got, DeepEquals, []string{"var_string(11,0)"})
+
+	// The columns of VALUES are unified across the rows.
+	got = s.fieldTypes(c, "select column_0, column_1 from (values row(1, 'a'), row(2.5, null)) t")
+	c.Assert(got, DeepEquals, []string{"decimal(3,1) not null", "var_string(1,0)"})
+
+	got = s.fieldTypes(c, "select t.i from (table n) t")
+	c.Assert(got, DeepEquals, []string{"int(11,0) not null"})
+
+	// The columns of JSON_TABLE have the types they are defined with.
+	got = s.fieldTypes(c, "select jt.n, jt.x, jt.s from n, json_table(n.j, '$[*]' columns (n for ordinality, x decimal(5, 2) path '$.x', s varchar(3) exists path '$.s')) jt")
+	c.Assert(got, DeepEquals, []string{"int(10,0) unsigned not null", "decimal(5,2)", "varchar(3,0)"})
+
+	got = s.fieldTypes(c, "select l.y from n, lateral (select n.i + 1 as y) l")
+	c.Assert(got, DeepEquals, []string{"bigint(20,0) not null"})
+}
+
+func (s *testInferSuite) TestInferTypes(c *C) {
+	stmt, err := parser.New().ParseOneStmt("select 1.5 * 2, 'abc' + 1, null", "", "")
+	c.Assert(err, IsNil)
+	InferTypes(stmt)
+	var got []string
+	for _, field := range stmt.(*ast.SelectStmt).Fields.Fields {
+		got = append(got, describeType(field.Expr.GetType()))
+	}
+	c.Assert(got, DeepEquals, []string{"decimal(4,1) not null", "double(23,-1) not null", "null(0,0)"})
+}
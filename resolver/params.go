@@ -0,0 +1,246 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolver
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/mysql"
+	"github.com/kyleconroy/sqlparse/opcode"
+	"github.com/kyleconroy/sqlparse/types"
+)
+
+// Param describes a parameter marker of a prepared statement.
+type Param struct {
+	Marker ast.ParamMarkerExpr
+	// Order is the position of the marker among the markers of the
+	// statement, starting from 0.
+	Order int
+	// Offset is the byte offset of the marker in the statement text.
+	Offset int
+	// Type is the type the context of the marker expects, such as the type
+	// of the column it's compared to. It's nil when the context doesn't tell.
+	Type *types.FieldType
+	// Name is a name suggested for the parameter, derived from the column it
+	// stands for. Names are unique within a statement; a name is empty when
+	// no column is related to the marker.
+	Name string
+}
+
+// Params returns the parameter markers of a statement in the order they
+// appear in the text, and sets their order. The statement must have been
+// resolved by r.
+func (r *Resolver) Params(node ast.Node) []*Param {
+	v := &paramCollector{r: r, params: make(map[ast.ParamMarkerExpr]*Param)}
+	node.Accept(v)
+	sort.SliceStable(v.markers, func(i, j int) bool {
+		return v.markers[i].OriginTextPosition() < v.markers[j].OriginTextPosition()
+	})
+	params := make([]*Param, 0, len(v.markers))
+	used := make(map[string]int)
+	for i, marker := range v.markers {
+		p := v.params[marker]
+		p.Order = i
+		p.Offset = marker.OriginTextPosition()
+		if p.Name != "" {
+			used[p.Name]++
+			if n := used[p.Name]; n > 1 {
+				p.Name = fmt.Sprintf("%s_%d", p.Name, n)
+			}
+		}
+		marker.SetOrder(i)
+		params = append(params, p)
+	}
+	return params
+}
+
+// paramCollector collects the parameter markers of a statement. Nodes set
+// the context of the markers among their children when entered, so a marker
+// takes the context of its innermost enclosing node.
+type paramCollector struct {
+	r       *Resolver
+	markers []ast.ParamMarkerExpr
+	params  map[ast.ParamMarkerExpr]*Param
+}
+
+// Enter implements ast.Visitor interface.
+func (v *paramCollector) Enter(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case ast.ParamMarkerExpr:
+		v.markers = append(v.markers, x)
+		v.param(x)
+	case *ast.BinaryOperationExpr:
+		switch x.Op {
+		case opcode.EQ, opcode.NE, opcode.LT, opcode.LE, opcode.GT, opcode.GE, opcode.NullEQ:
+			v.pair(x.L, x.R)
+		case opcode.Plus, opcode.Minus, opcode.Mul, opcode.Div, opcode.IntDiv, opcode.Mod:
+			// Operands of an arithmetic operation take the type but not the
+			// name of each other.
+			v.setType(x.L, v.typeOf(x.R))
+			v.setType(x.R, v.typeOf(x.L))
+		}
+	case *ast.BetweenExpr:
+		v.pair(x.Left, x.Expr)
+		v.pair(x.Right, x.Expr)
+		v.prefixName(x.Left, "from_")
+		v.prefixName(x.Right, "to_")
+	case *ast.PatternInExpr:
+		for _, item := range x.List {
+			v.pair(x.Expr, item)
+		}
+	case *ast.PatternLikeExpr:
+		v.pair(x.Pattern, x.Expr)
+	case *ast.CaseExpr:
+		if x.Value != nil {
+			for _, w := range x.WhenClauses {
+				v.pair(x.Value, w.Expr)
+			}
+		}
+	case *ast.Limit:
+		v.set(x.Count, newLimitType(), "limit")
+		v.set(x.Offset, newLimitType(), "offset")
+	case *ast.FrameBound:
+		if x.Unit == ast.TimeUnitInvalid {
+			v.set(x.Expr, newLimitType(), "")
+		}
+	case *ast.Assignment:
+		if rf := v.r.columns[x.Column]; rf != nil {
+			v.set(x.Expr, v.r.inf.typeOfField(rf), rf.Column.Name.O)
+		}
+	case *ast.InsertStmt:
+		columns := v.r.targets[x]
+		for _, list := range x.Lists {
+			for i, expr := range list {
+				if i < len(columns) {
+					v.set(expr, v.r.inf.typeOfField(columns[i]), columns[i].Column.Name.O)
+				}
+			}
+		}
+		if sel, ok := x.Select.(*ast.SelectStmt); ok {
+			for i, field := range sel.Fields.Fields {
+				if i < len(columns) && field.Expr != nil {
+					v.set(field.Expr, v.r.inf.typeOfField(columns[i]), columns[i].Column.Name.O)
+				}
+			}
+		}
+	}
+	return in, false
+}
+
+// Leave implements ast.Visitor interface.
+func (v *paramCollector) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+func (v *paramCollector) param(marker ast.ParamMarkerExpr) *Param {
+	p, ok := v.params[marker]
+	if !ok {
+		p = &Param{Marker: marker}
+		v.params[marker] = p
+	}
+	return p
+}
+
+// marker returns the parameter marker expr is, looking through parentheses.
+func (v *paramCollector) marker(expr ast.ExprNode) ast.ParamMarkerExpr {
+	marker, _ := unparen(expr).(ast.ParamMarkerExpr)
+	return marker
+}
+
+// pair gives the operands of a comparison the type and the name of each
+// other. The elements of row constructors are paired one by one.
+func (v *paramCollector) pair(l, r ast.ExprNode) {
+	l, r = unparen(l), unparen(r)
+	lrow, lok := l.(*ast.RowExpr)
+	rrow, rok := r.(*ast.RowExpr)
+	if lok && rok {
+		for i := 0; i < len(lrow.Values) && i < len(rrow.Values); i++ {
+			v.pair(lrow.Values[i], rrow.Values[i])
+		}
+		return
+	}
+	v.set(l, v.typeOf(r), v.nameOf(r))
+	v.set(r, v.typeOf(l), v.nameOf(l))
+}
+
+func unparen(expr ast.ExprNode) ast.ExprNode {
+	for {
+		p, ok := expr.(*ast.ParenthesesExpr)
+		if !ok {
+			return expr
+		}
+		expr = p.Expr
+	}
+}
+
+// set sets the type and the name of expr if it's a parameter marker.
+func (v *paramCollector) set(expr ast.ExprNode, tp *types.FieldType, name string) {
+	if expr == nil {
+		return
+	}
+	if marker := v.marker(expr); marker != nil {
+		p := v.param(marker)
+		if tp != nil {
+			p.Type = tp.Clone()
+		}
+		if name != "" {
+			p.Name = name
+		}
+	}
+}
+
+func (v *paramCollector) setType(expr ast.ExprNode, tp *types.FieldType) {
+	v.set(expr, tp, "")
+}
+
+func (v *paramCollector) prefixName(expr ast.ExprNode, prefix string) {
+	if marker := v.marker(expr); marker != nil {
+		if p := v.param(marker); p.Name != "" {
+			p.Name = prefix + p.Name
+		}
+	}
+}
+
+// typeOf returns the type of an operand, or nil if it's a parameter marker
+// or its type is unknown.
+func (v *paramCollector) typeOf(expr ast.ExprNode) *types.FieldType {
+	if v.marker(expr) != nil {
+		return nil
+	}
+	if cn, ok := expr.(*ast.ColumnNameExpr); ok && cn.Refer != nil {
+		return v.r.inf.typeOfField(cn.Refer)
+	}
+	if tp := expr.GetType(); tp.Tp != mysql.TypeUnspecified {
+		return tp
+	}
+	return nil
+}
+
+// nameOf returns the name of the column an operand refers to.
+func (v *paramCollector) nameOf(expr ast.ExprNode) string {
+	if cn, ok := expr.(*ast.ColumnNameExpr); ok {
+		return cn.Name.Name.O
+	}
+	return ""
+}
+
+// newLimitType returns the type of the row counts of LIMIT clauses and
+// window frames.
+func newLimitType() *types.FieldType {
+	ft := newIntType(true)
+	ft.Flag |= mysql.NotNullFlag
+	return ft
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolver_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/kyleconroy/sqlparse"
+	"github.com/kyleconroy/sqlparse/catalog"
+	. "github.com/kyleconroy/sqlparse/resolver"
+	"github.com/kyleconroy/sqlparse/types"
+	"github.com/kyleconroy/sqlparse/test_driver"
+)
+
+var _ = Suite(&testParamsSuite{})
+
+type testParamsSuite struct {
+	cat *catalog.Catalog
+}
+
+func (s *testParamsSuite) SetUpSuite(c *C) {
+	s.cat = catalog.New("test")
+	stmts, _, err := parser.New().Parse(`
+		create table p (id bigint unsigned not null, name varchar(20), born date, score decimal(5, 1))`, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(s.cat.ApplyAll(stmts), IsNil)
+}
+
+// describeParams renders the parameters of a statement as
+// `offset:name:type`, with `-` standing for an unknown type.
+func (s *testParamsSuite) describeParams(c *C, sql string) string {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil)
+	r := New(s.cat)
+	c.Assert(r.Resolve(stmt), IsNil, Commentf("sql: %s", sql))
+	var parts []string
+	for i, p := range r.Params(stmt) {
+		c.Assert(p.Order, Equals, i)
+		c.Assert(p.Marker.(*test_driver.ParamMarkerExpr).Order, Equals, i)
+		c.Assert(p.Marker.(*test_driver.ParamMarkerExpr).Offset, Equals, p.Offset)
+		tp := "-"
+		if p.Type != nil {
+			tp = types.TypeToStr(p.Type.Tp, p.Type.Charset)
+		}
+		parts = append(parts, fmt.Sprintf("%d:%s:%s", p.Offset, p.Name, tp))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (s *testParamsSuite) TestParams(c *C) {
+	cases := []struct {
+		sql    string
+		expect string
+	}{
+		{"select * from p where id = ? and ? < score", "27:id:bigint 33:score:decimal"},
+		{"select name from p where born between ? and ? limit ?, ?", "38:from_born:date 44:to_born:date 52:offset:bigint 55:limit:bigint"},
+		{"select * from p where (id, name) in ((?, ?), (?, ?))", "38:id:bigint 41:name:varchar 46:id_2:bigint 49:name_2:varchar"},
+		{"select * from p where (id, (name)) = (?, (?))", "38:id:bigint 42:name:varchar"},
+		{"select * from p where name like ? or score + ? > 1", "32:name:varchar 45::decimal"},
+		{"select ?, count(*) over (rows ? preceding) from p", "7::- 30::bigint"},
+		{"insert into p values (?, ?, ?, ?)", "22:id:bigint 25:name:varchar 28:born:date 31:score:decimal"},
+		{"insert into p (score, id) values (?, 1), (2, ?) on duplicate key update name = ?", "34:score:decimal 45:id:bigint 79:name:varchar"},
+		{"insert into p (name) select ? from p where id = ?", "28:name:varchar 48:id:bigint"},
+		{"update p set name = ?, score = score * ? where id in (select id from p where born > ?)", "20:name:varchar 39::decimal 84:born:date"},
+		{"delete from p where ? = id order by born limit ?", "20:id:bigint 47:limit:bigint"},
+	}
+	for _, ca := range cases {
+		c.Assert(s.describeParams(c, ca.sql), Equals, ca.expect, Commentf("sql: %s", ca.sql))
+	}
+}
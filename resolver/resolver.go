@@ -0,0 +1,971 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resolver binds the column names of DML statements to the tables
+// they refer to.
+//
+// After a statement is resolved, every ast.ColumnNameExpr has its Refer set
+// to the ast.ResultField describing the column, and every ast.TableName has
+// its DBInfo and TableInfo set. The types of the expressions are inferred
+// along the way, see InferTypes.
+package resolver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/format"
+	"github.com/kyleconroy/sqlparse/model"
+	"github.com/kyleconroy/sqlparse/mysql"
+	"github.com/kyleconroy/sqlparse/terror"
+	"github.com/kyleconroy/sqlparse/types"
+)
+
+var (
+	// ErrUnknownColumn is returned when a column name can't be found in any visible table.
+	ErrUnknownColumn = terror.ClassOptimizer.NewStd(mysql.ErrBadField)
+	// ErrAmbiguousColumn is returned when an unqualified column name matches several columns.
+	ErrAmbiguousColumn = terror.ClassOptimizer.NewStd(mysql.ErrNonUniq)
+	// ErrUnknownTable is returned when a qualified wildcard refers to an unknown table.
+	ErrUnknownTable = terror.ClassOptimizer.NewStd(mysql.ErrBadTable)
+	// ErrUnknownDeleteTable is returned when a multiple-table DELETE targets an unknown table.
+	ErrUnknownDeleteTable = terror.ClassOptimizer.NewStd(mysql.ErrUnknownTable)
+	// ErrNonUniqTable is returned when two tables of a FROM clause have the same name.
+	ErrNonUniqTable = terror.ClassOptimizer.NewStd(mysql.ErrNonuniqTable)
+	// ErrViewWrongList is returned when the column list of a common table
+	// expression doesn't match the number of columns of its query.
+	ErrViewWrongList = terror.ClassOptimizer.NewStd(mysql.ErrViewWrongList)
+	// ErrWrongValueCountOnRow is returned when the rows of a VALUES statement
+	// don't have the same number of columns.
+	ErrWrongValueCountOnRow = terror.ClassOptimizer.NewStd(mysql.ErrWrongValueCountOnRow)
+)
+
+// Error is a resolution error located in the source text.
+type Error struct {
+	// Offset is the byte offset in the source text of the node the error is about.
+	Offset int
+	// Err is the underlying error, such as ErrUnknownColumn.
+	Err error
+}
+
+// Error implements error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.Err.Error(), e.Offset)
+}
+
+// Cause returns the underlying error, so that terror.ErrorEqual and
+// errors.Cause see through the location.
+func (e *Error) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError locates err at node. Only expressions have an origin text
+// position, other nodes are located by the start of their span.
+func newError(node ast.Node, err error) error {
+	offset := node.OriginTextPosition()
+	if span := node.Span(); offset == 0 && !span.IsEmpty() {
+		offset = span.Start.Offset
+	}
+	return &Error{Offset: offset, Err: err}
+}
+
+// Schema provides the databases and tables names are resolved against.
+// It is implemented by *catalog.Catalog.
+type Schema interface {
+	// CurrentSchema returns the database unqualified table names belong to.
+	CurrentSchema() model.CIStr
+	// SchemaByName returns the database with the given name.
+	SchemaByName(schema model.CIStr) (*model.DBInfo, bool)
+	// TableByName returns the table with the given name. An empty schema
+	// refers to the current database.
+	TableByName(schema, table model.CIStr) (*model.TableInfo, error)
+}
+
+// Resolver binds the names of statements to a schema.
+type Resolver struct {
+	schema Schema
+	// columns holds the bindings of column names which aren't expressions,
+	// such as INSERT column lists and UPDATE assignment targets.
+	columns map[*ast.ColumnName]*ast.ResultField
+	// insert is the scope of the target table of the INSERT statement being
+	// resolved, which VALUES() functions refer to.
+	insert *scope
+	// targets holds the columns the values of INSERT statements are assigned to.
+	targets map[*ast.InsertStmt][]*ast.ResultField
+	// recursive holds the output fields of the queries of the recursive CTEs
+	// being resolved. They are the fields of the first query block, which
+	// is resolved before the recursive ones.
+	recursive map[*ast.SetOprSelectList][]*ast.ResultField
+	inf       *inferrer
+}
+
+// New returns a Resolver for the given schema.
+func New(schema Schema) *Resolver {
+	inf := newInferrer()
+	return &Resolver{
+		schema:    schema,
+		columns:   inf.columns,
+		targets:   make(map[*ast.InsertStmt][]*ast.ResultField),
+		recursive: make(map[*ast.SetOprSelectList][]*ast.ResultField),
+		inf:       inf,
+	}
+}
+
+// Resolve binds the names used in a SELECT, UPDATE, DELETE or INSERT
+// statement or in a set operation, and infers the types of its expressions
+// as InferTypes does. Other statements are left untouched. The first
+// unknown or ambiguous name is reported as an *Error.
+func (r *Resolver) Resolve(node ast.Node) error {
+	r.insert = nil
+	switch x := node.(type) {
+	case *ast.InsertStmt:
+		return r.resolveInsert(x)
+	case *ast.UpdateStmt:
+		return r.resolveUpdate(x)
+	case *ast.DeleteStmt:
+		return r.resolveDelete(x)
+	case *ast.SelectStmt, *ast.SetOprStmt:
+		_, err := r.resolveQuery(x, nil, nil)
+		return err
+	}
+	return nil
+}
+
+// ColumnRefer returns the field a column name which isn't an expression,
+// such as an INSERT column or the target of an UPDATE assignment, refers to.
+// It returns nil if the name hasn't been resolved.
+func (r *Resolver) ColumnRefer(name *ast.ColumnName) *ast.ResultField {
+	return r.columns[name]
+}
+
+// resolveQuery resolves a query in a scope enclosed by parent, and returns
+// its output fields.
+func (r *Resolver) resolveQuery(node ast.Node, parent *scope, subquery *ast.SubqueryExpr) ([]*ast.ResultField, error) {
+	fields, err := r.resolveQueryFields(node, parent, subquery)
+	if err != nil {
+		return nil, err
+	}
+	r.inf.outputs[node] = fields
+	return fields, nil
+}
+
+func (r *Resolver) resolveQueryFields(node ast.Node, parent *scope, subquery *ast.SubqueryExpr) ([]*ast.ResultField, error) {
+	switch x := node.(type) {
+	case *ast.SelectStmt:
+		return r.resolveSelect(x, parent, subquery)
+	case *ast.SetOprStmt:
+		parent, err := r.resolveWith(x.With, parent, subquery)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := r.resolveQuery(x.SelectList, parent, subquery)
+		if err != nil {
+			return nil, err
+		}
+		// The ORDER BY clause of a set operation can only refer to its output.
+		s := &scope{parent: parent, subquery: subquery, fields: fields}
+		if x.OrderBy != nil {
+			if err := r.resolveOrderBy(s, x.OrderBy); err != nil {
+				return nil, err
+			}
+		}
+		return fields, nil
+	case *ast.SetOprSelectList:
+		// The columns of a recursive CTE get their types from its first
+		// query block only, which has already been resolved.
+		if fields, ok := r.recursive[x]; ok {
+			for _, sel := range x.Selects[1:] {
+				if _, err := r.resolveQuery(sel, parent, subquery); err != nil {
+					return nil, err
+				}
+			}
+			return fields, nil
+		}
+		var selects [][]*ast.ResultField
+		for _, sel := range x.Selects {
+			selFields, err := r.resolveQuery(sel, parent, subquery)
+			if err != nil {
+				return nil, err
+			}
+			selects = append(selects, selFields)
+		}
+		return r.unifyFields(selects), nil
+	}
+	return nil, nil
+}
+
+// unifyFields returns the output fields of a set operation. The first
+// query block names the columns, whose types are unified across all blocks.
+func (r *Resolver) unifyFields(selects [][]*ast.ResultField) []*ast.ResultField {
+	if len(selects) == 0 {
+		return nil
+	}
+	if len(selects) == 1 {
+		return selects[0]
+	}
+	fields := make([]*ast.ResultField, len(selects[0]))
+	for i, first := range selects[0] {
+		var fts []*types.FieldType
+		for _, sel := range selects {
+			if i < len(sel) {
+				fts = append(fts, r.inf.typeOfField(sel[i]))
+			}
+		}
+		col := *first.Column
+		col.Name = fieldName(first)
+		col.FieldType = *unifyTypes(fts)
+		rf := *first
+		rf.Column = &col
+		rf.ColumnAsName = col.Name
+		rf.Expr = nil
+		fields[i] = &rf
+	}
+	return fields
+}
+
+// resolveWith resolves the common table expressions of a WITH clause, and
+// returns the scope they are visible in. It returns parent when there is no
+// WITH clause.
+func (r *Resolver) resolveWith(with *ast.WithClause, parent *scope, subquery *ast.SubqueryExpr) (*scope, error) {
+	if with == nil {
+		return parent, nil
+	}
+	s := &scope{parent: parent, subquery: subquery}
+	for _, cte := range with.CTEs {
+		src := &source{name: cte.Name}
+		query := cte.Query.Query
+		// The recursive part of a recursive CTE refers to the columns of
+		// the CTE, which are named by the first query block.
+		setOpr, self := query.(*ast.SetOprStmt)
+		self = self && cte.IsRecursive && len(setOpr.SelectList.Selects) > 1
+		if self {
+			fields, err := r.resolveQuery(setOpr.SelectList.Selects[0], s, cte.Query)
+			if err != nil {
+				return nil, err
+			}
+			if src.fields, err = r.cteFields(cte, fields); err != nil {
+				return nil, err
+			}
+			s.ctes = append(s.ctes, src)
+			r.recursive[setOpr.SelectList] = fields
+		}
+		fields, err := r.resolveQuery(query, s, cte.Query)
+		if self {
+			delete(r.recursive, setOpr.SelectList)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !self {
+			if src.fields, err = r.cteFields(cte, fields); err != nil {
+				return nil, err
+			}
+			s.ctes = append(s.ctes, src)
+		}
+	}
+	return s, nil
+}
+
+// cteFields returns the columns of a common table expression, named by its
+// column list if it has one.
+func (r *Resolver) cteFields(cte *ast.CommonTableExpression, fields []*ast.ResultField) ([]*ast.ResultField, error) {
+	if len(cte.ColNameList) == 0 {
+		return fields, nil
+	}
+	if len(cte.ColNameList) != len(fields) {
+		return nil, newError(cte, ErrViewWrongList)
+	}
+	renamed := make([]*ast.ResultField, len(fields))
+	for i, field := range fields {
+		col := *field.Column
+		col.Name = cte.ColNameList[i]
+		rf := *field
+		rf.Column = &col
+		rf.ColumnAsName = col.Name
+		r.inf.nullable[&rf] = r.inf.nullable[field]
+		renamed[i] = &rf
+	}
+	return renamed, nil
+}
+
+func (r *Resolver) resolveSelect(sel *ast.SelectStmt, parent *scope, subquery *ast.SubqueryExpr) ([]*ast.ResultField, error) {
+	parent, err := r.resolveWith(sel.With, parent, subquery)
+	if err != nil {
+		return nil, err
+	}
+	s := &scope{parent: parent, subquery: subquery}
+	if err := r.resolveFrom(s, sel.From); err != nil {
+		return nil, err
+	}
+	if sel.Where != nil {
+		if err := r.resolveExpr(s, sel.Where, clauseWhere); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case sel.Kind == ast.SelectStmtKindValues:
+		fields, err := r.resolveValues(s, sel.Lists)
+		if err != nil {
+			return nil, err
+		}
+		s.fields = fields
+	case sel.Fields != nil:
+		// TABLE t is parsed as SELECT * FROM t.
+		fields, err := r.resolveFields(s, sel.Fields.Fields)
+		if err != nil {
+			return nil, err
+		}
+		s.fields = fields
+	}
+	for i := range sel.WindowSpecs {
+		if err := r.resolveExpr(s, &sel.WindowSpecs[i], clauseWindow); err != nil {
+			return nil, err
+		}
+	}
+	if sel.GroupBy != nil {
+		for _, item := range sel.GroupBy.Items {
+			if err := r.resolveByItem(s, item, clauseGroupBy); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if sel.Having != nil {
+		if err := r.resolveExpr(s, sel.Having.Expr, clauseHaving); err != nil {
+			return nil, err
+		}
+	}
+	if sel.OrderBy != nil {
+		if err := r.resolveOrderBy(s, sel.OrderBy); err != nil {
+			return nil, err
+		}
+	}
+	return s.fields, nil
+}
+
+// resolveFields resolves the select fields of a query block and returns its
+// output fields. Wildcards are expanded to the columns of the tables they
+// refer to.
+func (r *Resolver) resolveFields(s *scope, selFields []*ast.SelectField) ([]*ast.ResultField, error) {
+	var fields []*ast.ResultField
+	for _, field := range selFields {
+		if field.WildCard != nil {
+			expanded, err := expandWildCard(s, field.WildCard)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, expanded...)
+			continue
+		}
+		if err := r.resolveExpr(s, field.Expr, clauseFieldList); err != nil {
+			return nil, err
+		}
+		fields = append(fields, selectField(field, len(fields)))
+	}
+	return fields, nil
+}
+
+// resolveValues resolves the rows of a VALUES statement and returns its
+// output fields, which MySQL names column_0, column_1 and so on. The type of
+// a column is unified across the rows.
+func (r *Resolver) resolveValues(s *scope, rows []*ast.RowExpr) ([]*ast.ResultField, error) {
+	for i, row := range rows {
+		if len(row.Values) != len(rows[0].Values) {
+			return nil, newError(row, ErrWrongValueCountOnRow.GenWithStackByArgs(i+1))
+		}
+		if err := r.resolveExpr(s, row, clauseFieldList); err != nil {
+			return nil, err
+		}
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	fields := make([]*ast.ResultField, len(rows[0].Values))
+	for i := range fields {
+		fts := make([]*types.FieldType, 0, len(rows))
+		for _, row := range rows {
+			fts = append(fts, row.Values[i].GetType())
+		}
+		name := model.NewCIStr(fmt.Sprintf("column_%d", i))
+		fields[i] = &ast.ResultField{
+			Column: &model.ColumnInfo{
+				Name:      name,
+				Offset:    i,
+				FieldType: *unifyTypes(fts),
+				State:     model.StatePublic,
+			},
+			ColumnAsName: name,
+		}
+	}
+	return fields, nil
+}
+
+func expandWildCard(s *scope, wildCard *ast.WildCardField) ([]*ast.ResultField, error) {
+	var (
+		fields []*ast.ResultField
+		found  bool
+	)
+	for _, src := range s.sources {
+		if wildCard.Table.L != "" {
+			if wildCard.Table.L != src.name.L || (wildCard.Schema.L != "" && wildCard.Schema.L != src.schema.L) {
+				continue
+			}
+		}
+		found = true
+		for _, rf := range src.fields {
+			// A coalesced column only appears once in the expansion of `*`.
+			if wildCard.Table.L == "" && src.coalesced[fieldName(rf).L] {
+				continue
+			}
+			fields = append(fields, rf)
+		}
+	}
+	if wildCard.Table.L != "" && !found {
+		name := wildCard.Table.O
+		if wildCard.Schema.O != "" {
+			name = wildCard.Schema.O + "." + name
+		}
+		return nil, newError(wildCard, ErrUnknownTable.GenWithStackByArgs(name))
+	}
+	return fields, nil
+}
+
+// selectField returns the output field of a select field which isn't a wildcard.
+func selectField(field *ast.SelectField, offset int) *ast.ResultField {
+	name := field.AsName
+	if name.L == "" {
+		switch {
+		case isColumnName(field.Expr):
+			name = field.Expr.(*ast.ColumnNameExpr).Name.Name
+		case field.Text() != "":
+			name = model.NewCIStr(field.Text())
+		default:
+			var sb strings.Builder
+			if err := field.Expr.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err == nil {
+				name = model.NewCIStr(sb.String())
+			}
+		}
+	}
+	if cn, ok := field.Expr.(*ast.ColumnNameExpr); ok && cn.Refer != nil {
+		rf := *cn.Refer
+		rf.ColumnAsName = name
+		rf.Expr = field.Expr
+		return &rf
+	}
+	return &ast.ResultField{
+		Column: &model.ColumnInfo{
+			Name:      name,
+			Offset:    offset,
+			FieldType: *field.Expr.GetType(),
+			State:     model.StatePublic,
+		},
+		ColumnAsName: name,
+		Expr:         field.Expr,
+	}
+}
+
+func isColumnName(expr ast.ExprNode) bool {
+	_, ok := expr.(*ast.ColumnNameExpr)
+	return ok
+}
+
+// resolveFrom resolves the FROM clause of a query block and sets the sources of s.
+func (r *Resolver) resolveFrom(s *scope, from *ast.TableRefsClause) error {
+	if from == nil || from.TableRefs == nil {
+		return nil
+	}
+	sources, err := r.resolveJoin(s, from.TableRefs, nil)
+	if err != nil {
+		return err
+	}
+	for i, src := range sources {
+		for _, other := range sources[:i] {
+			if src.name.L == other.name.L && src.schema.L == other.schema.L {
+				return newError(src.node, ErrNonUniqTable.GenWithStackByArgs(src.name.O))
+			}
+		}
+	}
+	s.sources = sources
+	return nil
+}
+
+// resolveJoin resolves a table reference and returns the sources it introduces.
+// prev are the sources before it in the FROM clause, which JSON_TABLE and
+// LATERAL derived tables can refer to.
+func (r *Resolver) resolveJoin(s *scope, node ast.ResultSetNode, prev []*source) ([]*source, error) {
+	switch x := node.(type) {
+	case *ast.Join:
+		if x.Left == nil {
+			return nil, nil
+		}
+		left, err := r.resolveJoin(s, x.Left, prev)
+		if err != nil || x.Right == nil {
+			return left, err
+		}
+		right, err := r.resolveJoin(s, x.Right, append(prev[:len(prev):len(prev)], left...))
+		if err != nil {
+			return nil, err
+		}
+		sources := make([]*source, 0, len(left)+len(right))
+		sources = append(append(sources, left...), right...)
+		switch {
+		case x.NaturalJoin:
+			err = naturalJoin(left, right, x.Tp)
+		case len(x.Using) > 0:
+			err = r.usingJoin(left, right, x.Using, x.Tp)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if x.On != nil {
+			if err := r.resolveExpr(s.blockScope(sources), x.On.Expr, clauseOn); err != nil {
+				return nil, err
+			}
+		}
+		// The columns of the inner side of an outer join are NULL when no row matches.
+		switch x.Tp {
+		case ast.LeftJoin:
+			r.markNullable(right)
+		case ast.RightJoin:
+			r.markNullable(left)
+		}
+		return sources, nil
+	case *ast.TableSource:
+		return r.resolveTableSource(s, x, prev)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) markNullable(sources []*source) {
+	for _, src := range sources {
+		for _, rf := range src.fields {
+			r.inf.nullable[rf] = true
+		}
+	}
+}
+
+func (r *Resolver) resolveTableSource(s *scope, ts *ast.TableSource, prev []*source) ([]*source, error) {
+	switch x := ts.Source.(type) {
+	case *ast.TableName:
+		if x.Schema.L == "" {
+			if cte := s.findCTE(x.Name); cte != nil {
+				name := ts.AsName
+				if name.L == "" {
+					name = x.Name
+				}
+				src := r.derivedSource(name, cte.fields)
+				src.node = x
+				return []*source{src}, nil
+			}
+		}
+		src, err := r.resolveTableName(x)
+		if err != nil {
+			return nil, err
+		}
+		src.node = x
+		if ts.AsName.L != "" {
+			src.name = ts.AsName
+			src.schema = model.CIStr{}
+			for _, rf := range src.fields {
+				rf.TableAsName = ts.AsName
+			}
+		}
+		return []*source{src}, nil
+	case *ast.Join:
+		return r.resolveJoin(s, x, prev)
+	case *ast.JSONTable:
+		if err := r.resolveExpr(s.blockScope(prev), x.Expr, clauseFrom); err != nil {
+			return nil, err
+		}
+		src := r.derivedSource(ts.AsName, jsonTableFields(x.Columns, nil))
+		src.node = ts
+		return []*source{src}, nil
+	case *ast.LateralTable:
+		fields, err := r.resolveQuery(x.Query, s.blockScope(prev), s.subquery)
+		if err != nil {
+			return nil, err
+		}
+		src := r.derivedSource(ts.AsName, fields)
+		src.node = ts
+		return []*source{src}, nil
+	}
+	// A derived table can't refer to the other tables of the FROM clause.
+	fields, err := r.resolveQuery(ts.Source, s.blockScope(nil), s.subquery)
+	if err != nil {
+		return nil, err
+	}
+	src := r.derivedSource(ts.AsName, fields)
+	src.node = ts
+	return []*source{src}, nil
+}
+
+// jsonTableFields appends the fields of the columns of a JSON_TABLE, those of
+// its nested paths included, to fields. They have the types they are defined
+// with, and an ordinality column is an INT UNSIGNED.
+func jsonTableFields(cols []*ast.JSONTableColumn, fields []*ast.ResultField) []*ast.ResultField {
+	for _, col := range cols {
+		var ft *types.FieldType
+		switch col.Tp {
+		case ast.JSONTableColumnNested:
+			fields = jsonTableFields(col.Columns, fields)
+			continue
+		case ast.JSONTableColumnOrdinality:
+			ft = types.NewFieldType(mysql.TypeLong)
+			ft.Flen, ft.Decimal = 10, 0
+			ft.Flag = mysql.UnsignedFlag | mysql.NotNullFlag
+		default:
+			ft = col.FieldType.Clone()
+			flen, decimal := mysql.GetDefaultFieldLengthAndDecimal(ft.Tp)
+			if ft.Flen == types.UnspecifiedLength {
+				ft.Flen = flen
+			}
+			if ft.Decimal == types.UnspecifiedLength {
+				ft.Decimal = decimal
+			}
+		}
+		fields = append(fields, &ast.ResultField{
+			Column: &model.ColumnInfo{
+				Name:      col.Name,
+				Offset:    len(fields),
+				FieldType: *ft,
+				State:     model.StatePublic,
+			},
+			ColumnAsName: col.Name,
+		})
+	}
+	return fields
+}
+
+// derivedSource returns the source of a derived table or a reference to a
+// common table expression, whose columns are the given fields.
+func (r *Resolver) derivedSource(name model.CIStr, fields []*ast.ResultField) *source {
+	src := &source{name: name}
+	for _, field := range fields {
+		rf := *field
+		rf.ColumnAsName = fieldName(field)
+		rf.TableAsName = name
+		r.inf.nullable[&rf] = r.inf.nullable[field]
+		src.fields = append(src.fields, &rf)
+	}
+	return src
+}
+
+// resolveTableName looks up a base table or view in the schema.
+func (r *Resolver) resolveTableName(tn *ast.TableName) (*source, error) {
+	tbl, err := r.schema.TableByName(tn.Schema, tn.Name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	dbName := tn.Schema
+	if dbName.L == "" {
+		dbName = r.schema.CurrentSchema()
+	}
+	tn.TableInfo = tbl
+	if db, ok := r.schema.SchemaByName(dbName); ok {
+		tn.DBInfo = db
+		dbName = db.Name
+	}
+	src := &source{name: tbl.Name, schema: dbName}
+	for _, col := range tbl.Columns {
+		if col.Hidden || col.State != model.StatePublic {
+			continue
+		}
+		src.fields = append(src.fields, &ast.ResultField{
+			Column:    col,
+			Table:     tbl,
+			DBName:    dbName,
+			TableName: tn,
+		})
+	}
+	return src, nil
+}
+
+// usingJoin coalesces the columns of a USING clause. The coalesced column
+// refers to the left table, or to the right table of a RIGHT JOIN.
+func (r *Resolver) usingJoin(left, right []*source, using []*ast.ColumnName, tp ast.JoinType) error {
+	for _, name := range using {
+		unqualified := &ast.ColumnName{Name: name.Name}
+		leftSrc, lf, err := matchSources(left, unqualified, clauseFrom)
+		if err != nil {
+			return newError(name, err)
+		}
+		rightSrc, rf, err := matchSources(right, unqualified, clauseFrom)
+		if err != nil {
+			return newError(name, err)
+		}
+		if lf == nil || rf == nil {
+			return newError(name, ErrUnknownColumn.GenWithStackByArgs(name.Name.O, clauseFrom.String()))
+		}
+		r.columns[name] = lf
+		if tp == ast.RightJoin {
+			r.columns[name] = rf
+			leftSrc.coalesce(name.Name.L)
+		} else {
+			rightSrc.coalesce(name.Name.L)
+		}
+	}
+	return nil
+}
+
+// naturalJoin coalesces the columns with the same name in both sides of a NATURAL join.
+func naturalJoin(left, right []*source, tp ast.JoinType) error {
+	for _, leftSrc := range left {
+		for _, lf := range leftSrc.fields {
+			name := fieldName(lf)
+			if leftSrc.coalesced[name.L] {
+				continue
+			}
+			rightSrc, rf, err := matchSources(right, &ast.ColumnName{Name: name}, clauseFrom)
+			if err != nil {
+				return err
+			}
+			if rf == nil {
+				continue
+			}
+			if tp == ast.RightJoin {
+				leftSrc.coalesce(name.L)
+			} else {
+				rightSrc.coalesce(name.L)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveByItem resolves a GROUP BY item. Positions refer to the output fields.
+func (r *Resolver) resolveByItem(s *scope, item *ast.ByItem, c clause) error {
+	if pos, ok := item.Expr.(*ast.PositionExpr); ok {
+		if pos.P != nil {
+			return nil
+		}
+		if pos.N < 1 || pos.N > len(s.fields) {
+			return newError(pos, ErrUnknownColumn.GenWithStackByArgs(fmt.Sprintf("%d", pos.N), c.String()))
+		}
+		pos.Refer = s.fields[pos.N-1]
+		return nil
+	}
+	return r.resolveExpr(s, item.Expr, c)
+}
+
+func (r *Resolver) resolveOrderBy(s *scope, orderBy *ast.OrderByClause) error {
+	for _, item := range orderBy.Items {
+		if err := r.resolveByItem(s, item, clauseOrderBy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveColumnName binds a column name which isn't an expression.
+func (r *Resolver) resolveColumnName(s *scope, name *ast.ColumnName, c clause) (*ast.ResultField, error) {
+	rf, err := s.lookup(name, c)
+	if err != nil {
+		return nil, newError(name, err)
+	}
+	if rf == nil {
+		return nil, newError(name, ErrUnknownColumn.GenWithStackByArgs(name.OrigColName(), c.String()))
+	}
+	r.columns[name] = rf
+	return rf, nil
+}
+
+func (r *Resolver) resolveInsert(stmt *ast.InsertStmt) error {
+	target := &scope{}
+	if err := r.resolveFrom(target, stmt.Table); err != nil {
+		return err
+	}
+	r.insert = target
+	var columns []*ast.ResultField
+	for _, name := range stmt.Columns {
+		rf, err := r.resolveColumnName(target, name, clauseFieldList)
+		if err != nil {
+			return err
+		}
+		columns = append(columns, rf)
+	}
+	if len(stmt.Columns) == 0 && len(stmt.Setlist) == 0 {
+		for _, src := range target.sources {
+			columns = append(columns, src.fields...)
+		}
+	}
+	r.targets[stmt] = columns
+	for _, list := range stmt.Lists {
+		for _, expr := range list {
+			if err := r.resolveExpr(target, expr, clauseFieldList); err != nil {
+				return err
+			}
+		}
+	}
+	if err := r.resolveAssignments(target, stmt.Setlist); err != nil {
+		return err
+	}
+	dup := target
+	if stmt.Select != nil {
+		fields, err := r.resolveQuery(stmt.Select, nil, nil)
+		if err != nil {
+			return err
+		}
+		// ON DUPLICATE KEY UPDATE may refer to the columns of the query when
+		// they aren't shadowed by the target table.
+		dup = &scope{sources: target.sources, parent: &scope{sources: []*source{{fields: fields}}}}
+	}
+	return r.resolveAssignments(dup, stmt.OnDuplicate)
+}
+
+func (r *Resolver) resolveUpdate(stmt *ast.UpdateStmt) error {
+	parent, err := r.resolveWith(stmt.With, nil, nil)
+	if err != nil {
+		return err
+	}
+	s := &scope{parent: parent}
+	if err := r.resolveFrom(s, stmt.TableRefs); err != nil {
+		return err
+	}
+	if err := r.resolveAssignments(s, stmt.List); err != nil {
+		return err
+	}
+	if stmt.Where != nil {
+		if err := r.resolveExpr(s, stmt.Where, clauseWhere); err != nil {
+			return err
+		}
+	}
+	if stmt.Order != nil {
+		return r.resolveOrderBy(s, stmt.Order)
+	}
+	return nil
+}
+
+func (r *Resolver) resolveAssignments(s *scope, list []*ast.Assignment) error {
+	for _, a := range list {
+		if _, err := r.resolveColumnName(s, a.Column, clauseFieldList); err != nil {
+			return err
+		}
+		if err := r.resolveExpr(s, a.Expr, clauseFieldList); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Resolver) resolveDelete(stmt *ast.DeleteStmt) error {
+	parent, err := r.resolveWith(stmt.With, nil, nil)
+	if err != nil {
+		return err
+	}
+	s := &scope{parent: parent}
+	if err := r.resolveFrom(s, stmt.TableRefs); err != nil {
+		return err
+	}
+	if stmt.Tables != nil {
+		for _, tn := range stmt.Tables.Tables {
+			if err := resolveDeleteTable(s, tn); err != nil {
+				return err
+			}
+		}
+	}
+	if stmt.Where != nil {
+		if err := r.resolveExpr(s, stmt.Where, clauseWhere); err != nil {
+			return err
+		}
+	}
+	if stmt.Order != nil {
+		return r.resolveOrderBy(s, stmt.Order)
+	}
+	return nil
+}
+
+// resolveDeleteTable binds a target of a multiple-table DELETE to the table
+// of the FROM clause it names.
+func resolveDeleteTable(s *scope, tn *ast.TableName) error {
+	for _, src := range s.sources {
+		if src.name.L != tn.Name.L || (tn.Schema.L != "" && tn.Schema.L != src.schema.L) {
+			continue
+		}
+		if len(src.fields) > 0 && src.fields[0].TableName != nil {
+			tn.DBInfo = src.fields[0].TableName.DBInfo
+			tn.TableInfo = src.fields[0].TableName.TableInfo
+		}
+		return nil
+	}
+	return newError(tn, ErrUnknownDeleteTable.GenWithStackByArgs(tn.Name.O, "MULTI DELETE"))
+}
+
+// resolveExpr binds the column names of an expression, resolving its
+// subqueries in nested scopes.
+func (r *Resolver) resolveExpr(s *scope, node ast.Node, c clause) error {
+	v := &exprResolver{r: r, s: s, clause: c}
+	node.Accept(v)
+	return v.err
+}
+
+type exprResolver struct {
+	r      *Resolver
+	s      *scope
+	clause clause
+	err    error
+}
+
+// Enter implements ast.Visitor interface.
+func (v *exprResolver) Enter(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.ColumnNameExpr:
+		rf, err := v.s.lookup(x.Name, v.clause)
+		switch {
+		case err != nil:
+			v.err = newError(x, err)
+		case rf == nil:
+			v.err = newError(x, ErrUnknownColumn.GenWithStackByArgs(x.Name.OrigColName(), v.clause.String()))
+		default:
+			x.Refer = rf
+		}
+		return in, true
+	case *ast.SubqueryExpr:
+		_, v.err = v.r.resolveQuery(x.Query, v.s, x)
+		return in, true
+	case *ast.ValuesExpr:
+		if v.r.insert != nil {
+			v.err = v.r.resolveExpr(v.r.insert, x.Column, v.clause)
+			return in, true
+		}
+	case *ast.DefaultExpr:
+		if x.Name != nil {
+			_, v.err = v.r.resolveColumnName(v.s, x.Name, v.clause)
+		}
+		return in, true
+	case *ast.MatchAgainst:
+		for _, name := range x.ColumnNames {
+			if _, v.err = v.r.resolveColumnName(v.s, name, v.clause); v.err != nil {
+				break
+			}
+		}
+		if v.err == nil {
+			v.err = v.r.resolveExpr(v.s, x.Against, v.clause)
+		}
+		return in, true
+	}
+	return in, false
+}
+
+// Leave implements ast.Visitor interface.
+func (v *exprResolver) Leave(in ast.Node) (ast.Node, bool) {
+	if expr, ok := in.(ast.ExprNode); ok && v.err == nil {
+		v.r.inf.infer(expr)
+	}
+	return in, v.err == nil
+}
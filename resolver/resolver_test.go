@@ -0,0 +1,290 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolver_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/kyleconroy/sqlparse"
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/catalog"
+	"github.com/kyleconroy/sqlparse/mysql"
+	. "github.com/kyleconroy/sqlparse/resolver"
+	"github.com/kyleconroy/sqlparse/terror"
+	_ "github.com/kyleconroy/sqlparse/test_driver"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testResolverSuite{})
+
+type testResolverSuite struct {
+	cat *catalog.Catalog
+}
+
+func (s *testResolverSuite) SetUpSuite(c *C) {
+	s.cat = catalog.New("test")
+	stmts, _, err := parser.New().Parse(`
+		create table t1 (a int, b int, c int);
+		create table t2 (a int, b int, d int);
+		create table t3 (a int, e int);
+		create table t4 (a int, j json);
+		create database other;
+		create table other.t1 (x int);
+		create view v as select a, b as vb from t1`, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(s.cat.ApplyAll(stmts), IsNil)
+}
+
+// columnRefs collects the column name expressions of a statement in the
+// order they are visited.
+type columnRefs struct {
+	refs       []*ast.ColumnNameExpr
+	subqueries []*ast.SubqueryExpr
+}
+
+func (v *columnRefs) Enter(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.ColumnNameExpr:
+		v.refs = append(v.refs, x)
+	case *ast.SubqueryExpr:
+		v.subqueries = append(v.subqueries, x)
+	}
+	return in, false
+}
+
+func (v *columnRefs) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+func (s *testResolverSuite) resolve(c *C, sql string) (ast.StmtNode, *Resolver, error) {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil)
+	r := New(s.cat)
+	return stmt, r, r.Resolve(stmt)
+}
+
+// describe renders the binding of every column name of a statement as
+// `name=table.column`, where table is the alias or the name of the table.
+func (s *testResolverSuite) describe(c *C, sql string) (string, []*ast.SubqueryExpr) {
+	stmt, _, err := s.resolve(c, sql)
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	v := &columnRefs{}
+	stmt.Accept(v)
+	var parts []string
+	for _, ref := range v.refs {
+		c.Assert(ref.Refer, NotNil, Commentf("sql: %s, column: %s", sql, ref.Name))
+		table := ref.Refer.TableAsName.L
+		if table == "" && ref.Refer.Table != nil {
+			table = ref.Refer.Table.Name.L
+		}
+		parts = append(parts, fmt.Sprintf("%s=%s.%s", ref.Name.OrigColName(), table, ref.Refer.Column.Name.L))
+	}
+	return strings.Join(parts, " "), v.subqueries
+}
+
+func (s *testResolverSuite) TestSelect(c *C) {
+	cases := []struct {
+		sql    string
+		expect string
+	}{
+		{"select a, t1.b from t1 where c > 0", "a=t1.a t1.b=t1.b c=t1.c"},
+		{"select x.a from t1 as x join t2 y on x.a = y.d", "x.a=x.a x.a=x.a y.d=y.d"},
+		{"select test.t1.a, other.t1.x from t1, other.t1", "test.t1.a=t1.a other.t1.x=t1.x"},
+		{"select a, b from t1 join t2 using (a, b)", "a=t1.a b=t1.b"},
+		{"select a, t2.a from t1 right join t2 using (a)", "a=t2.a t2.a=t2.a"},
+		{"select a, d, e from t1 natural join t2 natural join t3", "a=t1.a d=t2.d e=t3.e"},
+		{"select a, vb from v", "a=v.a vb=v.vb"},
+		{"select d.x, y from (select a as x, b + 1 as y from t1) d where d.x > 1", "d.x=d.a y=d.y a=t1.a b=t1.b d.x=d.a"},
+		{"select b as a from t1 order by a", "b=t1.b a=t1.b"},
+		{"select b as x from t1 group by x having x > 0 order by c", "b=t1.b x=t1.b x=t1.b c=t1.c"},
+		{"select a as b from t1 group by b", "a=t1.a b=t1.b"},
+		{"select a, sum(b) over w from t1 window w as (partition by c order by a)", "a=t1.a b=t1.b c=t1.c a=t1.a"},
+		{"select a from t1 union select d from t2 order by a", "a=t1.a d=t2.d a=t1.a"},
+		{"select a from t1 where a in (select a from t2 where t2.b = t1.b)", "a=t1.a a=t1.a a=t2.a t2.b=t2.b t1.b=t1.b"},
+	}
+	for _, ca := range cases {
+		got, _ := s.describe(c, ca.sql)
+		c.Assert(got, Equals, ca.expect, Commentf("sql: %s", ca.sql))
+	}
+}
+
+func (s *testResolverSuite) TestCorrelation(c *C) {
+	_, subqueries := s.describe(c, "select a from t1 where exists (select 1 from t2 where t2.a = t1.a) and b in (select b from t3 join t2 using (a))")
+	c.Assert(subqueries, HasLen, 2)
+	c.Assert(subqueries[0].Correlated, IsTrue)
+	c.Assert(subqueries[1].Correlated, IsFalse)
+
+	_, subqueries = s.describe(c, "select (select (select t1.c from t3) from t2) from t1")
+	c.Assert(subqueries, HasLen, 2)
+	c.Assert(subqueries[0].Correlated, IsTrue)
+	c.Assert(subqueries[1].Correlated, IsTrue)
+
+	// A derived table can't see the other tables of its FROM clause.
+	_, _, err := s.resolve(c, "select * from t1, (select t1.a from t2) d")
+	c.Assert(terror.ErrorEqual(err, ErrUnknownColumn), IsTrue)
+}
+
+func (s *testResolverSuite) TestLateralSources(c *C) {
+	cases := []struct {
+		sql    string
+		expect string
+	}{
+		{"select jt.x from t4, json_table(t4.j, '$[*]' columns (x int path '$')) jt", "jt.x=jt.x t4.j=t4.j"},
+		{"select n, y from t4 join json_table(j, '$' columns (n for ordinality, nested path '$.a[*]' columns (y int path '$'))) jt on a = n", "n=jt.n y=jt.y j=t4.j a=t4.a n=jt.n"},
+		{"select d.b from t1, lateral (select t1.a b) d", "d.b=d.a t1.a=t1.a"},
+		{"select d.b from t1 join t2 using (a) left join lateral (select d + c as b) d on true", "d.b=d.b d=t2.d c=t1.c"},
+	}
+	for _, ca := range cases {
+		got, _ := s.describe(c, ca.sql)
+		c.Assert(got, Equals, ca.expect, Commentf("sql: %s", ca.sql))
+	}
+
+	// They can't see the tables after them.
+	_, _, err := s.resolve(c, "select 1 from json_table(t4.j, '$' columns (x int path '$')) jt, t4")
+	c.Assert(terror.ErrorEqual(err, ErrUnknownColumn), IsTrue)
+	c.Assert(errors.Cause(err).Error(), Matches, ".*Unknown column 't4.j' in 'from clause'")
+	_, _, err = s.resolve(c, "select 1 from lateral (select t1.a) d, t1")
+	c.Assert(terror.ErrorEqual(err, ErrUnknownColumn), IsTrue)
+}
+
+func (s *testResolverSuite) TestDML(c *C) {
+	stmt, r, err := s.resolve(c, "insert into t1 (a, b) select a, d from t2 on duplicate key update c = d + values(a)")
+	c.Assert(err, IsNil)
+	insert := stmt.(*ast.InsertStmt)
+	c.Assert(r.ColumnRefer(insert.Columns[1]).Column.Name.L, Equals, "b")
+	c.Assert(r.ColumnRefer(insert.OnDuplicate[0].Column).Table.Name.L, Equals, "t1")
+	got, _ := s.describe(c, "insert into t1 (a, b) select a, d from t2 on duplicate key update c = d + values(a)")
+	c.Assert(got, Equals, "a=t2.a d=t2.d d=t2.d a=t1.a")
+
+	got, _ = s.describe(c, "insert into t1 values (1, 2, a + 1)")
+	c.Assert(got, Equals, "a=t1.a")
+
+	stmt, r, err = s.resolve(c, "update t1 x, t2 set x.a = d where x.b = t2.b")
+	c.Assert(err, IsNil)
+	c.Assert(r.ColumnRefer(stmt.(*ast.UpdateStmt).List[0].Column).TableAsName.L, Equals, "x")
+
+	stmt, _, err = s.resolve(c, "delete t from t1 as t join t2 using (a) where d > 0")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.DeleteStmt).Tables.Tables[0].TableInfo.Name.L, Equals, "t1")
+
+	got, _ = s.describe(c, "delete from t2 where d > 0 order by b")
+	c.Assert(got, Equals, "d=t2.d b=t2.b")
+}
+
+func (s *testResolverSuite) TestCTE(c *C) {
+	cases := []struct {
+		sql    string
+		expect string
+	}{
+		{"with x as (select a, b from t1) select x.a, b from x", "a=t1.a b=t1.b x.a=x.a b=x.b"},
+		{"with x (m, n) as (select a, b from t1) select m, y.n from x as y", "a=t1.a b=t1.b m=y.m y.n=y.n"},
+		{"with x as (select a from t1), y as (select a from x) select a from y", "a=t1.a a=x.a a=y.a"},
+		{"with recursive x (n) as (select 1 union all select n + 1 from x where n < 3) select n from x", "n=x.n n=x.n n=x.n"},
+		{"select a from t1 where a in (with x as (select d from t2) select d from x)", "a=t1.a a=t1.a d=t2.d d=x.d"},
+		{"with x as (select d from t2) select * from (select d from x) y", "d=t2.d d=x.d"},
+		{"with t1 as (select d from t2) select d from t1", "d=t2.d d=t1.d"},
+		{"with x as (select a from t1) update t2 set d = 1 where a in (select a from x)", "a=t1.a a=t2.a a=x.a"},
+		{"with x as (select a from t1) delete from t2 where a in (select a from x)", "a=t1.a a=t2.a a=x.a"},
+		{"with x as (select a from t1) select a from x union select d from t2", "a=t1.a a=x.a d=t2.d"},
+	}
+	for _, ca := range cases {
+		got, _ := s.describe(c, ca.sql)
+		c.Assert(got, Equals, ca.expect, Commentf("sql: %s", ca.sql))
+	}
+
+	// The columns of a recursive CTE have the types of its first query block,
+	// in the recursive query block as well as outside of the CTE.
+	stmt, _, err := s.resolve(c, "with recursive x (n) as (select 1 union all select n + 1 from x where n < 3) select n from x")
+	c.Assert(err, IsNil)
+	v := &columnRefs{}
+	stmt.Accept(v)
+	c.Assert(v.refs, HasLen, 3)
+	for _, ref := range v.refs {
+		c.Assert(ref.GetType().Tp, Equals, mysql.TypeLonglong)
+		c.Assert(ref.GetType().Flen, Equals, 1)
+	}
+
+	// A CTE isn't visible outside of the query it belongs to.
+	_, _, err = s.resolve(c, "select * from (with x as (select 1) select * from x) y, x")
+	c.Assert(terror.ErrorEqual(err, catalog.ErrTableNotExists), IsTrue)
+	_, _, err = s.resolve(c, "with x (m, n) as (select a from t1) select m from x")
+	c.Assert(terror.ErrorEqual(err, ErrViewWrongList), IsTrue)
+}
+
+func (s *testResolverSuite) TestTableValueConstructor(c *C) {
+	cases := []struct {
+		sql    string
+		expect string
+	}{
+		{"table t1 order by c", "c=t1.c"},
+		{"select x.a from (table t1) x where x.c > 0", "x.a=x.a x.c=x.c"},
+		{"select v.column_1 from (values row(1, 'a'), row(2, 'b')) v", "v.column_1=v.column_1"},
+		{"select a from t1 where (a, b) in (values row(1, 2))", "a=t1.a a=t1.a b=t1.b"},
+		{"values row(1) union table t3 order by column_0", "column_0=.column_0"},
+		{"insert into t3 values row(1, 2)", ""},
+		{"insert into t1 table t2", ""},
+	}
+	for _, ca := range cases {
+		got, _ := s.describe(c, ca.sql)
+		c.Assert(got, Equals, ca.expect, Commentf("sql: %s", ca.sql))
+	}
+
+	_, _, err := s.resolve(c, "values row(1, 2), row(3)")
+	c.Assert(terror.ErrorEqual(err, ErrWrongValueCountOnRow), IsTrue)
+	c.Assert(errors.Cause(err).Error(), Matches, ".*Column count doesn't match value count at row 2")
+}
+
+func (s *testResolverSuite) TestErrors(c *C) {
+	cases := []struct {
+		sql    string
+		err    *terror.Error
+		msg    string
+		offset int
+	}{
+		{"select a from t1, t2", ErrAmbiguousColumn, "Column 'a' in field list is ambiguous", 7},
+		{"select b from t1 where zz = 1", ErrUnknownColumn, "Unknown column 'zz' in 'where clause'", 23},
+		{"select t1.a from t1 x", ErrUnknownColumn, "Unknown column 't1.a' in 'field list'", 7},
+		{"select 1 from t1 join t2 on t1.a = t3.a", ErrUnknownColumn, "Unknown column 't3.a' in 'on clause'", 35},
+		{"select a as x, b as x from t1 order by x", ErrAmbiguousColumn, "Column 'x' in order clause is ambiguous", 39},
+		{"select a from t1 group by 3", ErrUnknownColumn, "Unknown column '3' in 'group statement'", -1},
+		{"select a from t1 union select a from t2 order by t1.a", ErrUnknownColumn, "Unknown column 't1.a' in 'order clause'", 49},
+		{"select zz.* from t1", ErrUnknownTable, "Unknown table 'zz'", -1},
+		{"update t1 set zz = 1", ErrUnknownColumn, "Unknown column 'zz' in 'field list'", -1},
+		{"delete zz from t1", ErrUnknownDeleteTable, "Unknown table 'zz' in MULTI DELETE", -1},
+		{"select 1 from t1, t1", ErrNonUniqTable, "Not unique table/alias: 't1'", 18},
+		{"select 1 from t1 x join t2 x", ErrNonUniqTable, "Not unique table/alias: 'x'", 24},
+		{"select 1 from (select 1) x, (select 2) x", ErrNonUniqTable, "Not unique table/alias: 'x'", 28},
+	}
+	for _, ca := range cases {
+		_, _, err := s.resolve(c, ca.sql)
+		c.Assert(terror.ErrorEqual(err, ca.err), IsTrue, Commentf("sql: %s, err: %v", ca.sql, err))
+		c.Assert(errors.Cause(err).Error(), Matches, ".*"+ca.msg, Commentf("sql: %s", ca.sql))
+		e, ok := err.(*Error)
+		c.Assert(ok, IsTrue, Commentf("sql: %s", ca.sql))
+		if ca.offset >= 0 {
+			c.Assert(e.Offset, Equals, ca.offset, Commentf("sql: %s", ca.sql))
+		}
+	}
+
+	_, _, err := s.resolve(c, "select 1 from missing")
+	c.Assert(terror.ErrorEqual(err, catalog.ErrTableNotExists), IsTrue)
+}
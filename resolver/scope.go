@@ -0,0 +1,218 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolver
+
+import (
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/model"
+)
+
+// clause is the part of a query block a column name appears in. It decides
+// which names are visible and is reported in error messages.
+type clause int
+
+const (
+	clauseFieldList clause = iota
+	clauseFrom
+	clauseOn
+	clauseWhere
+	clauseGroupBy
+	clauseHaving
+	clauseWindow
+	clauseOrderBy
+)
+
+var clauseNames = map[clause]string{
+	clauseFieldList: "field list",
+	clauseFrom:      "from clause",
+	clauseOn:        "on clause",
+	clauseWhere:     "where clause",
+	clauseGroupBy:   "group statement",
+	clauseHaving:    "having clause",
+	clauseWindow:    "window clause",
+	clauseOrderBy:   "order clause",
+}
+
+// String implements fmt.Stringer interface.
+func (c clause) String() string {
+	return clauseNames[c]
+}
+
+// source is a table visible in the FROM clause of a query block: a base
+// table, a view or a derived table.
+type source struct {
+	name   model.CIStr
+	schema model.CIStr
+	fields []*ast.ResultField
+	// node is the table reference the source comes from, which errors
+	// about the source are located at.
+	node ast.Node
+	// coalesced holds the columns merged into the other side of a USING or
+	// NATURAL join. They are only visible when qualified by the table name.
+	coalesced map[string]bool
+}
+
+func (src *source) coalesce(name string) {
+	if src.coalesced == nil {
+		src.coalesced = make(map[string]bool)
+	}
+	src.coalesced[name] = true
+}
+
+// scope holds the names visible in a query block.
+type scope struct {
+	parent *scope
+	// subquery is the subquery expression the query block belongs to. It is
+	// marked as correlated when a name is resolved in an enclosing scope.
+	subquery *ast.SubqueryExpr
+	sources  []*source
+	// fields are the output fields of the query block, which GROUP BY, HAVING
+	// and ORDER BY may refer to by name.
+	fields []*ast.ResultField
+	// ctes are the common table expressions defined by the WITH clause of
+	// the query block, in the order they are defined.
+	ctes []*source
+}
+
+// blockScope returns a scope which has no sources of its own but sees the
+// same enclosing scopes as s. Derived tables and ON conditions are resolved
+// in such scopes, as they can't refer to the sibling tables of the query block.
+func (s *scope) blockScope(sources []*source) *scope {
+	return &scope{parent: s.parent, subquery: s.subquery, sources: sources}
+}
+
+// findCTE looks up a common table expression by name in the scope and its
+// enclosing scopes. The innermost definition wins.
+func (s *scope) findCTE(name model.CIStr) *source {
+	for cur := s; cur != nil; cur = cur.parent {
+		for i := len(cur.ctes) - 1; i >= 0; i-- {
+			if cur.ctes[i].name.L == name.L {
+				return cur.ctes[i]
+			}
+		}
+	}
+	return nil
+}
+
+// fieldName returns the name a result field is referred to by.
+func fieldName(rf *ast.ResultField) model.CIStr {
+	if rf.ColumnAsName.L != "" {
+		return rf.ColumnAsName
+	}
+	return rf.Column.Name
+}
+
+// matchSources looks up name in the sources. An unqualified name must match
+// exactly one visible column.
+func matchSources(sources []*source, name *ast.ColumnName, c clause) (*source, *ast.ResultField, error) {
+	var (
+		foundSrc *source
+		found    *ast.ResultField
+	)
+	for _, src := range sources {
+		if name.Table.L != "" {
+			if name.Table.L != src.name.L || (name.Schema.L != "" && name.Schema.L != src.schema.L) {
+				continue
+			}
+		} else if src.coalesced[name.Name.L] {
+			continue
+		}
+		for _, rf := range src.fields {
+			if fieldName(rf).L != name.Name.L {
+				continue
+			}
+			if found != nil {
+				return nil, nil, ErrAmbiguousColumn.GenWithStackByArgs(name.OrigColName(), c.String())
+			}
+			foundSrc, found = src, rf
+		}
+	}
+	return foundSrc, found, nil
+}
+
+// matchFields looks up an unqualified name among the output fields of a
+// query block. Several fields may share the name as long as they refer to
+// the same column.
+func matchFields(fields []*ast.ResultField, name *ast.ColumnName, c clause) (*ast.ResultField, error) {
+	if name.Table.L != "" {
+		return nil, nil
+	}
+	var found *ast.ResultField
+	for _, rf := range fields {
+		if fieldName(rf).L != name.Name.L {
+			continue
+		}
+		if found != nil && !sameColumn(found, rf) {
+			return nil, ErrAmbiguousColumn.GenWithStackByArgs(name.OrigColName(), c.String())
+		}
+		if found == nil {
+			found = rf
+		}
+	}
+	return found, nil
+}
+
+func sameColumn(a, b *ast.ResultField) bool {
+	if a == b {
+		return true
+	}
+	if a.Table == nil || b.Table == nil {
+		return false
+	}
+	return a.Column == b.Column && a.TableName == b.TableName
+}
+
+// find looks up name in the scope alone. GROUP BY and HAVING prefer the
+// columns of the FROM clause over the output fields, ORDER BY prefers the
+// output fields. Enclosing scopes only expose the FROM clause.
+func (s *scope) find(name *ast.ColumnName, c clause, outer bool) (*ast.ResultField, error) {
+	if outer {
+		_, rf, err := matchSources(s.sources, name, c)
+		return rf, err
+	}
+	if c == clauseOrderBy {
+		if rf, err := matchFields(s.fields, name, c); rf != nil || err != nil {
+			return rf, err
+		}
+	}
+	_, rf, err := matchSources(s.sources, name, c)
+	if rf != nil || err != nil {
+		return rf, err
+	}
+	if c == clauseGroupBy || c == clauseHaving {
+		return matchFields(s.fields, name, c)
+	}
+	return nil, nil
+}
+
+// lookup looks up name in the scope and its enclosing scopes. Subqueries
+// between the scope and the one name is found in are marked as correlated.
+func (s *scope) lookup(name *ast.ColumnName, c clause) (*ast.ResultField, error) {
+	for cur := s; cur != nil; cur = cur.parent {
+		rf, err := cur.find(name, c, cur != s)
+		if err != nil {
+			return nil, err
+		}
+		if rf == nil {
+			continue
+		}
+		for inner := s; inner != cur; inner = inner.parent {
+			if inner.subquery != nil {
+				inner.subquery.Correlated = true
+			}
+		}
+		return rf, nil
+	}
+	return nil, nil
+}
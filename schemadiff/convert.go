@@ -0,0 +1,254 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schemadiff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/kyleconroy/sqlparse"
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/format"
+	"github.com/kyleconroy/sqlparse/model"
+	"github.com/kyleconroy/sqlparse/mysql"
+	"github.com/kyleconroy/sqlparse/types"
+)
+
+// converter turns the parts of a model.TableInfo back into AST nodes.
+// Expressions are stored as text in the model, so they are parsed again.
+type converter struct {
+	parser *parser.Parser
+}
+
+func restoreText(n interface {
+	Restore(*format.RestoreCtx) error
+}) (string, error) {
+	var sb strings.Builder
+	if err := n.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		return "", errors.Trace(err)
+	}
+	return sb.String(), nil
+}
+
+func (c *converter) parseExpr(text string) (ast.ExprNode, error) {
+	if strings.EqualFold(text, "MAXVALUE") {
+		return &ast.MaxValueExpr{}, nil
+	}
+	stmt, err := c.parser.ParseOneStmt("SELECT "+text, "", "")
+	if err != nil {
+		return nil, errors.Annotatef(err, "parse expression %q", text)
+	}
+	return stmt.(*ast.SelectStmt).Fields.Fields[0].Expr, nil
+}
+
+func (c *converter) parseExprs(texts []string) ([]ast.ExprNode, error) {
+	exprs := make([]ast.ExprNode, 0, len(texts))
+	for _, text := range texts {
+		expr, err := c.parseExpr(text)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	return exprs, nil
+}
+
+// columnDef returns the definition of a column. Key options are left out,
+// as keys are compared as table constraints.
+func (c *converter) columnDef(col *model.ColumnInfo) (*ast.ColumnDef, error) {
+	tp := col.FieldType.Clone()
+	tp.Flag &= mysql.UnsignedFlag | mysql.ZerofillFlag | mysql.BinaryFlag
+	def := &ast.ColumnDef{Name: &ast.ColumnName{Name: col.Name}, Tp: tp}
+	if col.IsGenerated() {
+		expr, err := c.parseExpr(col.GeneratedExprString)
+		if err != nil {
+			return nil, err
+		}
+		def.Options = append(def.Options, &ast.ColumnOption{Tp: ast.ColumnOptionGenerated, Expr: expr, Stored: col.GeneratedStored})
+	}
+	if mysql.HasNotNullFlag(col.Flag) {
+		def.Options = append(def.Options, &ast.ColumnOption{Tp: ast.ColumnOptionNotNull})
+	}
+	if value := col.GetDefaultValue(); value != nil {
+		expr, err := c.defaultValue(col, value)
+		if err != nil {
+			return nil, err
+		}
+		def.Options = append(def.Options, &ast.ColumnOption{Tp: ast.ColumnOptionDefaultValue, Expr: expr})
+	}
+	if mysql.HasAutoIncrementFlag(col.Flag) {
+		def.Options = append(def.Options, &ast.ColumnOption{Tp: ast.ColumnOptionAutoIncrement})
+	}
+	if mysql.HasOnUpdateNowFlag(col.Flag) {
+		now := &ast.FuncCallExpr{FnName: model.NewCIStr(ast.CurrentTimestamp)}
+		if col.Decimal > 0 {
+			now.Args = []ast.ExprNode{ast.NewValueExpr(int64(col.Decimal), "", "")}
+		}
+		def.Options = append(def.Options, &ast.ColumnOption{Tp: ast.ColumnOptionOnUpdate, Expr: now})
+	}
+	if col.Comment != "" {
+		def.Options = append(def.Options, &ast.ColumnOption{Tp: ast.ColumnOptionComment, Expr: ast.NewValueExpr(col.Comment, "", "")})
+	}
+	return def, nil
+}
+
+// defaultValue returns the expression of a default value. String literals
+// are stored as their value, numbers and expressions as their text.
+func (c *converter) defaultValue(col *model.ColumnInfo, value interface{}) (ast.ExprNode, error) {
+	s, ok := value.(string)
+	if !ok {
+		return ast.NewValueExpr(value, "", ""), nil
+	}
+	if col.DefaultIsExpr {
+		return c.parseExpr(s)
+	}
+	switch col.EvalType() {
+	case types.ETInt, types.ETReal, types.ETDecimal:
+		if expr, err := c.parseExpr(s); err == nil {
+			return expr, nil
+		}
+	}
+	return ast.NewValueExpr(s, "", ""), nil
+}
+
+// definitionText returns the text of a column definition without its name.
+func definitionText(def *ast.ColumnDef) (string, error) {
+	return restoreText(&ast.ColumnDef{Name: &ast.ColumnName{}, Tp: def.Tp, Options: def.Options})
+}
+
+// indexConstraint returns the definition of an index. Column names are
+// mapped by rename, and the visibility of the index is left out.
+func (c *converter) indexConstraint(tbl *model.TableInfo, idx *model.IndexInfo, rename func(model.CIStr) model.CIStr) (*ast.Constraint, error) {
+	cst := &ast.Constraint{Tp: ast.ConstraintIndex, Name: idx.Name.O}
+	switch {
+	case idx.Primary:
+		cst.Tp, cst.Name = ast.ConstraintPrimaryKey, ""
+	case idx.Unique:
+		cst.Tp = ast.ConstraintUniqIndex
+	}
+	for _, ic := range idx.Columns {
+		key := &ast.IndexPartSpecification{}
+		if col := model.FindColumnInfo(tbl.Columns, ic.Name.L); col != nil && col.Hidden {
+			expr, err := c.parseExpr(col.GeneratedExprString)
+			if err != nil {
+				return nil, err
+			}
+			key.Expr = expr
+		} else {
+			key.Column = &ast.ColumnName{Name: rename(ic.Name)}
+			if ic.Length > 0 {
+				key.Length = ic.Length
+			}
+		}
+		cst.Keys = append(cst.Keys, key)
+	}
+	if idx.Tp != model.IndexTypeInvalid || idx.Comment != "" {
+		cst.Option = &ast.IndexOption{Tp: idx.Tp, Comment: idx.Comment}
+	}
+	return cst, nil
+}
+
+func foreignKeyConstraint(fk *model.FKInfo, rename func(model.CIStr) model.CIStr) *ast.Constraint {
+	cst := &ast.Constraint{
+		Tp:   ast.ConstraintForeignKey,
+		Name: fk.Name.O,
+		Refer: &ast.ReferenceDef{
+			Table:    &ast.TableName{Name: fk.RefTable},
+			OnDelete: &ast.OnDeleteOpt{ReferOpt: ast.ReferOptionType(fk.OnDelete)},
+			OnUpdate: &ast.OnUpdateOpt{ReferOpt: ast.ReferOptionType(fk.OnUpdate)},
+		},
+	}
+	for _, name := range fk.Cols {
+		cst.Keys = append(cst.Keys, &ast.IndexPartSpecification{Column: &ast.ColumnName{Name: rename(name)}})
+	}
+	for _, name := range fk.RefCols {
+		cst.Refer.IndexPartSpecifications = append(cst.Refer.IndexPartSpecifications, &ast.IndexPartSpecification{Column: &ast.ColumnName{Name: name}})
+	}
+	return cst
+}
+
+func (c *converter) checkConstraint(cst *model.ConstraintInfo) (*ast.Constraint, error) {
+	expr, err := c.parseExpr(cst.ExprString)
+	if err != nil {
+		return nil, err
+	}
+	return &ast.Constraint{Tp: ast.ConstraintCheck, Name: cst.Name.O, Expr: expr, Enforced: cst.Enforced}, nil
+}
+
+// partitionOptions returns the partitioning of a table. HASH and KEY
+// partitions with generated names are described by their number.
+func (c *converter) partitionOptions(pi *model.PartitionInfo) (*ast.PartitionOptions, error) {
+	opts := &ast.PartitionOptions{PartitionMethod: ast.PartitionMethod{Tp: pi.Type}}
+	if pi.Expr != "" {
+		expr, err := c.parseExpr(pi.Expr)
+		if err != nil {
+			return nil, err
+		}
+		opts.Expr = expr
+	}
+	for _, name := range pi.Columns {
+		opts.ColumnNames = append(opts.ColumnNames, &ast.ColumnName{Name: name})
+	}
+	if hasGeneratedPartitions(pi) {
+		opts.Num = uint64(len(pi.Definitions))
+		return opts, nil
+	}
+	for _, def := range pi.Definitions {
+		pd, err := c.partitionDefinition(def)
+		if err != nil {
+			return nil, err
+		}
+		opts.Definitions = append(opts.Definitions, pd)
+	}
+	return opts, nil
+}
+
+func hasGeneratedPartitions(pi *model.PartitionInfo) bool {
+	if pi.Type != model.PartitionTypeHash && pi.Type != model.PartitionTypeKey {
+		return false
+	}
+	for i, def := range pi.Definitions {
+		if def.Name.L != fmt.Sprintf("p%d", i) || def.Comment != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *converter) partitionDefinition(def model.PartitionDefinition) (*ast.PartitionDefinition, error) {
+	pd := &ast.PartitionDefinition{Name: def.Name, Clause: &ast.PartitionDefinitionClauseNone{}}
+	switch {
+	case len(def.LessThan) > 0:
+		exprs, err := c.parseExprs(def.LessThan)
+		if err != nil {
+			return nil, err
+		}
+		pd.Clause = &ast.PartitionDefinitionClauseLessThan{Exprs: exprs}
+	case len(def.InValues) > 0:
+		clause := &ast.PartitionDefinitionClauseIn{}
+		for _, values := range def.InValues {
+			exprs, err := c.parseExprs(values)
+			if err != nil {
+				return nil, err
+			}
+			clause.Values = append(clause.Values, exprs)
+		}
+		pd.Clause = clause
+	}
+	if def.Comment != "" {
+		pd.Options = append(pd.Options, &ast.TableOption{Tp: ast.TableOptionComment, StrValue: def.Comment})
+	}
+	return pd, nil
+}
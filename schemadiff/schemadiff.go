@@ -0,0 +1,166 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schemadiff computes the DDL statements migrating a schema to
+// another one.
+//
+// The statements are AST nodes, which can be turned into SQL text with
+// format.RestoreCtx. Applied in order, they turn the old tables into the new
+// ones: columns are dropped, renamed, changed, added and moved; indices,
+// foreign keys, check constraints, table options and partitions are
+// recreated when they differ.
+package schemadiff
+
+import (
+	"github.com/kyleconroy/sqlparse"
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/catalog"
+	"github.com/kyleconroy/sqlparse/model"
+)
+
+// defaultSchema is the database unqualified table names are created in by
+// Schemas.
+const defaultSchema = "test"
+
+// Tables returns the statements migrating table from into table to. The
+// statements refer to the table by the name of to.
+func Tables(from, to *model.TableInfo) ([]ast.StmtNode, error) {
+	c := &converter{parser: parser.New()}
+	return c.diffTable(&ast.TableName{Name: to.Name}, from, to)
+}
+
+// table is a table created by a `CREATE TABLE` statement.
+type table struct {
+	stmt *ast.CreateTableStmt
+	info *model.TableInfo
+}
+
+func (t *table) key() string {
+	return t.stmt.Table.Schema.L + "." + t.stmt.Table.Name.L
+}
+
+// Schemas returns the statements migrating the tables created by from into
+// the tables created by to. Tables are matched by name. A table only found
+// in from is dropped, or renamed if a table only found in to has the same
+// definition; a table only found in to is created by its statement of to.
+//
+// The statements are ordered as renames, creations, changes and drops.
+func Schemas(from, to []*ast.CreateTableStmt) ([]ast.StmtNode, error) {
+	c := &converter{parser: parser.New()}
+	fromTables, err := buildTables(from)
+	if err != nil {
+		return nil, err
+	}
+	toTables, err := buildTables(to)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*table, len(fromTables))
+	for _, t := range fromTables {
+		byKey[t.key()] = t
+	}
+	found := make(map[string]bool, len(toTables))
+	for _, t := range toTables {
+		found[t.key()] = true
+	}
+
+	var (
+		renames, creates, alters, drops []ast.StmtNode
+		renamed                         = make(map[*table]*table)
+	)
+	var dropped []*table
+	for _, t := range fromTables {
+		if !found[t.key()] {
+			dropped = append(dropped, t)
+		}
+	}
+	for _, t := range toTables {
+		old, ok := byKey[t.key()]
+		if !ok {
+			if old, err = c.findRenamed(dropped, renamed, t); err != nil {
+				return nil, err
+			}
+		}
+		if old == nil {
+			creates = append(creates, t.stmt)
+			continue
+		}
+		if !ok {
+			renamed[old] = t
+			renames = append(renames, &ast.RenameTableStmt{
+				TableToTables: []*ast.TableToTable{{OldTable: old.stmt.Table, NewTable: t.stmt.Table}},
+			})
+		}
+		stmts, err := c.diffTable(t.stmt.Table, old.info, t.info)
+		if err != nil {
+			return nil, err
+		}
+		alters = append(alters, stmts...)
+	}
+	for _, t := range dropped {
+		if _, ok := renamed[t]; !ok {
+			drops = append(drops, &ast.DropTableStmt{Tables: []*ast.TableName{t.stmt.Table}})
+		}
+	}
+
+	stmts := append(renames, creates...)
+	stmts = append(stmts, alters...)
+	return append(stmts, drops...), nil
+}
+
+// findRenamed returns the only dropped table with the same definition as t.
+func (c *converter) findRenamed(dropped []*table, renamed map[*table]*table, t *table) (*table, error) {
+	var match *table
+	for _, old := range dropped {
+		if _, ok := renamed[old]; ok {
+			continue
+		}
+		stmts, err := c.diffTable(t.stmt.Table, old.info, t.info)
+		if err != nil {
+			return nil, err
+		}
+		if len(stmts) > 0 {
+			continue
+		}
+		if match != nil {
+			return nil, nil
+		}
+		match = old
+	}
+	return match, nil
+}
+
+// buildTables builds the tables created by stmts in an empty catalog.
+func buildTables(stmts []*ast.CreateTableStmt) ([]*table, error) {
+	cat := catalog.New(defaultSchema)
+	tables := make([]*table, 0, len(stmts))
+	for _, stmt := range stmts {
+		schema := stmt.Table.Schema
+		if schema.L != "" {
+			if _, ok := cat.SchemaByName(schema); !ok {
+				if err := cat.Apply(&ast.CreateDatabaseStmt{Name: schema.O}); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := cat.Apply(stmt); err != nil {
+			return nil, err
+		}
+		info, err := cat.TableByName(schema, stmt.Table.Name)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, &table{stmt: stmt, info: info})
+	}
+	return tables, nil
+}
@@ -0,0 +1,228 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schemadiff_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/kyleconroy/sqlparse"
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/catalog"
+	"github.com/kyleconroy/sqlparse/format"
+	"github.com/kyleconroy/sqlparse/model"
+	. "github.com/kyleconroy/sqlparse/schemadiff"
+	_ "github.com/kyleconroy/sqlparse/test_driver"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testSchemaDiffSuite{})
+
+type testSchemaDiffSuite struct{}
+
+func (s *testSchemaDiffSuite) parse(c *C, sql string) []*ast.CreateTableStmt {
+	stmts, _, err := parser.New().Parse(sql, "", "")
+	c.Assert(err, IsNil)
+	var creates []*ast.CreateTableStmt
+	for _, stmt := range stmts {
+		creates = append(creates, stmt.(*ast.CreateTableStmt))
+	}
+	return creates
+}
+
+func restore(c *C, stmts []ast.StmtNode) []string {
+	var texts []string
+	for _, stmt := range stmts {
+		var sb strings.Builder
+		c.Assert(stmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)), IsNil)
+		texts = append(texts, sb.String())
+	}
+	return texts
+}
+
+// migrate diffs two schemas, checks that applying the statements to the
+// first schema gives the second one, and returns the statements as text.
+func (s *testSchemaDiffSuite) migrate(c *C, from, to string) []string {
+	stmts, err := Schemas(s.parse(c, from), s.parse(c, to))
+	c.Assert(err, IsNil, Commentf("from: %s\nto: %s", from, to))
+	texts := restore(c, stmts)
+
+	cat := catalog.New("test")
+	for _, stmt := range s.parse(c, from) {
+		c.Assert(cat.Apply(stmt), IsNil)
+	}
+	for _, text := range texts {
+		stmt, err := parser.New().ParseOneStmt(text, "", "")
+		c.Assert(err, IsNil, Commentf("sql: %s", text))
+		c.Assert(cat.Apply(stmt), IsNil, Commentf("sql: %s", text))
+	}
+	want := catalog.New("test")
+	for _, stmt := range s.parse(c, to) {
+		c.Assert(want.Apply(stmt), IsNil)
+	}
+	db, _ := want.SchemaByName(model.NewCIStr("test"))
+	got, _ := cat.SchemaByName(model.NewCIStr("test"))
+	c.Assert(got.Tables, HasLen, len(db.Tables))
+	for _, tbl := range db.Tables {
+		migrated, err := cat.TableByName(model.CIStr{}, tbl.Name)
+		c.Assert(err, IsNil)
+		rest, err := Tables(migrated, tbl)
+		c.Assert(err, IsNil)
+		c.Assert(restore(c, rest), HasLen, 0, Commentf("remaining: %v\nafter: %v", restore(c, rest), texts))
+	}
+	return texts
+}
+
+func (s *testSchemaDiffSuite) TestColumns(c *C) {
+	cases := []struct {
+		from   string
+		to     string
+		expect []string
+	}{
+		{
+			"create table t (a int, b int)",
+			"create table t (a int, b int)",
+			nil,
+		},
+		{
+			"create table t (a int, b int, c int)",
+			"create table t (a bigint not null, c int, d varchar(10) default 'x')",
+			[]string{"ALTER TABLE `t` DROP COLUMN `b`, MODIFY COLUMN `a` BIGINT(20) NOT NULL, ADD COLUMN `d` VARCHAR(10) DEFAULT 'x'"},
+		},
+		{
+			"create table t (a int, b int, c int, d int)",
+			"create table t (d int, a int, c int, b int)",
+			[]string{"ALTER TABLE `t` MODIFY COLUMN `d` INT(11) FIRST, MODIFY COLUMN `c` INT(11) AFTER `a`"},
+		},
+		{
+			"create table t (id int, name varchar(20), x int)",
+			"create table t (id int, y bigint, full_name varchar(20))",
+			[]string{"ALTER TABLE `t` DROP COLUMN `x`, ADD COLUMN `y` BIGINT(20) AFTER `id`, RENAME COLUMN `name` TO `full_name`"},
+		},
+		{
+			"create table t (id int, name varchar(20) comment 'n')",
+			"create table t (id int, title varchar(20) comment 'n')",
+			[]string{"ALTER TABLE `t` RENAME COLUMN `name` TO `title`"},
+		},
+		{
+			"create table t (a int, name varchar(20), b bigint)",
+			"create table t (title varchar(20), a int, b bigint)",
+			[]string{"ALTER TABLE `t` CHANGE COLUMN `name` `title` VARCHAR(20) FIRST"},
+		},
+		{
+			// Two candidates with the same definition can't be told apart.
+			"create table t (id int, a int, b int)",
+			"create table t (id int, c int, d int)",
+			[]string{"ALTER TABLE `t` DROP COLUMN `a`, DROP COLUMN `b`, ADD COLUMN `c` INT(11), ADD COLUMN `d` INT(11)"},
+		},
+		{
+			"create table t (a int) comment 'old'",
+			"create table t (a int, u timestamp(3) default current_timestamp(3) on update current_timestamp(3)) comment 'new' charset latin1",
+			[]string{"ALTER TABLE `t` ADD COLUMN `u` TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3), DEFAULT CHARACTER SET = LATIN1 DEFAULT COLLATE = LATIN1_BIN COMMENT = 'new'"},
+		},
+	}
+	for _, ca := range cases {
+		c.Assert(s.migrate(c, ca.from, ca.to), DeepEquals, ca.expect, Commentf("from: %s\nto: %s", ca.from, ca.to))
+	}
+}
+
+func (s *testSchemaDiffSuite) TestKeys(c *C) {
+	cases := []struct {
+		from   string
+		to     string
+		expect []string
+	}{
+		{
+			"create table t (a int, b int, key ia (a), key ib (b))",
+			"create table t (a int, b int primary key, key ia (a, b), unique key uc ((a + b)), key ib (b) invisible)",
+			[]string{
+				"DROP INDEX `ia` ON `t`",
+				"ALTER TABLE `t` MODIFY COLUMN `b` INT(11) NOT NULL, ALTER INDEX `ib` INVISIBLE, ADD PRIMARY KEY(`b`)",
+				"CREATE INDEX `ia` ON `t` (`a`, `b`)",
+				"CREATE UNIQUE INDEX `uc` ON `t` ((`a`+`b`))",
+			},
+		},
+		{
+			"create table t (a int, b int, key ia (a), constraint c1 check (a > 0), constraint c2 check (b > 0))",
+			"create table t (x int, b int, key ia (x), constraint c1 check (x > 0), constraint c2 check (b > 0) not enforced)",
+			[]string{"ALTER TABLE `t` DROP CHECK `c1`, RENAME COLUMN `a` TO `x`, ALTER CHECK `c2` NOT ENFORCED, ADD CONSTRAINT `c1` CHECK(`x`>0) ENFORCED"},
+		},
+		{
+			"create table p (id int primary key); create table t (a int, p int, constraint fk foreign key (p) references p (id))",
+			"create table p (id int primary key); create table t (a int, q int, constraint fk foreign key (q) references p (id) on delete cascade)",
+			[]string{
+				"ALTER TABLE `t` DROP FOREIGN KEY `fk`",
+				"ALTER TABLE `t` RENAME COLUMN `p` TO `q`",
+				"ALTER TABLE `t` ADD CONSTRAINT `fk` FOREIGN KEY (`q`) REFERENCES `p`(`id`) ON DELETE CASCADE",
+			},
+		},
+	}
+	for _, ca := range cases {
+		c.Assert(s.migrate(c, ca.from, ca.to), DeepEquals, ca.expect, Commentf("from: %s\nto: %s", ca.from, ca.to))
+	}
+}
+
+func (s *testSchemaDiffSuite) TestPartitions(c *C) {
+	cases := []struct {
+		from   string
+		to     string
+		expect []string
+	}{
+		{
+			"create table t (a int) partition by range (a) (partition p0 values less than (10))",
+			"create table t (a int) partition by range (a) (partition p0 values less than (10), partition p1 values less than (maxvalue))",
+			[]string{"ALTER TABLE `t` ADD PARTITION (PARTITION `p1` VALUES LESS THAN (MAXVALUE))"},
+		},
+		{
+			"create table t (a int) partition by list (a) (partition p0 values in (1, 2), partition p1 values in (3), partition p2 values in (4))",
+			"create table t (a int) partition by list (a) (partition p0 values in (1, 2), partition p2 values in (4))",
+			[]string{"ALTER TABLE `t` DROP PARTITION `p1`"},
+		},
+		{
+			"create table t (a int) partition by hash (a) partitions 4",
+			"create table t (a int) partition by hash (a) partitions 2",
+			[]string{"ALTER TABLE `t` COALESCE PARTITION 2"},
+		},
+		{
+			"create table t (a int) partition by hash (a) partitions 4",
+			"create table t (a int) partition by key (a) partitions 4",
+			[]string{"ALTER TABLE `t` PARTITION BY KEY (`a`) PARTITIONS 4"},
+		},
+		{
+			"create table t (a int) partition by hash (a) partitions 4",
+			"create table t (a int)",
+			[]string{"ALTER TABLE `t` REMOVE PARTITIONING"},
+		},
+	}
+	for _, ca := range cases {
+		c.Assert(s.migrate(c, ca.from, ca.to), DeepEquals, ca.expect, Commentf("from: %s\nto: %s", ca.from, ca.to))
+	}
+}
+
+func (s *testSchemaDiffSuite) TestTables(c *C) {
+	got := s.migrate(c,
+		"create table a (id int); create table b (id int, v text); create table c (x int)",
+		"create table a (id int, n int); create table b2 (id int, v text); create table d (y int)")
+	c.Assert(got, DeepEquals, []string{
+		"RENAME TABLE `b` TO `b2`",
+		"CREATE TABLE `d` (`y` INT)",
+		"ALTER TABLE `a` ADD COLUMN `n` INT(11)",
+		"DROP TABLE `c`",
+	})
+}
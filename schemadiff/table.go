@@ -0,0 +1,594 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schemadiff
+
+import (
+	"github.com/kyleconroy/sqlparse/ast"
+	"github.com/kyleconroy/sqlparse/model"
+)
+
+// tableDiff collects the changes between two versions of a table.
+type tableDiff struct {
+	*converter
+	name     *ast.TableName
+	from, to *model.TableInfo
+	// renames maps the lower case names of the renamed columns of from to
+	// their names in to.
+	renames map[string]model.CIStr
+
+	// dropForeignKeys, dropIndices, specs, createIndices and addForeignKeys
+	// are emitted in this order: keys are dropped before the columns they
+	// use are changed, and added after.
+	dropForeignKeys []*ast.AlterTableSpec
+	dropIndices     []ast.StmtNode
+	specs           []*ast.AlterTableSpec
+	createIndices   []ast.StmtNode
+	addForeignKeys  []*ast.AlterTableSpec
+	partition       *ast.AlterTableSpec
+}
+
+func (c *converter) diffTable(name *ast.TableName, from, to *model.TableInfo) ([]ast.StmtNode, error) {
+	d := &tableDiff{converter: c, name: name, from: from, to: to, renames: make(map[string]model.CIStr)}
+	if err := d.diffColumns(); err != nil {
+		return nil, err
+	}
+	if err := d.diffIndices(); err != nil {
+		return nil, err
+	}
+	if err := d.diffChecks(); err != nil {
+		return nil, err
+	}
+	if err := d.diffForeignKeys(); err != nil {
+		return nil, err
+	}
+	d.diffOptions()
+	if err := d.diffPartitions(); err != nil {
+		return nil, err
+	}
+
+	var stmts []ast.StmtNode
+	if len(d.dropForeignKeys) > 0 {
+		stmts = append(stmts, d.alter(d.dropForeignKeys...))
+	}
+	stmts = append(stmts, d.dropIndices...)
+	if len(d.specs) > 0 {
+		stmts = append(stmts, d.alter(d.specs...))
+	}
+	stmts = append(stmts, d.createIndices...)
+	if len(d.addForeignKeys) > 0 {
+		stmts = append(stmts, d.alter(d.addForeignKeys...))
+	}
+	if d.partition != nil {
+		stmts = append(stmts, d.alter(d.partition))
+	}
+	return stmts, nil
+}
+
+func (d *tableDiff) alter(specs ...*ast.AlterTableSpec) *ast.AlterTableStmt {
+	return &ast.AlterTableStmt{Table: d.name, Specs: specs}
+}
+
+// rename returns the name a column of from has in to.
+func (d *tableDiff) rename(name model.CIStr) model.CIStr {
+	if newName, ok := d.renames[name.L]; ok {
+		return newName
+	}
+	return name
+}
+
+func keepName(name model.CIStr) model.CIStr {
+	return name
+}
+
+func visibleColumns(tbl *model.TableInfo) []*model.ColumnInfo {
+	cols := make([]*model.ColumnInfo, 0, len(tbl.Columns))
+	for _, col := range tbl.Columns {
+		if !col.Hidden {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// column is a visible column with its definition.
+type column struct {
+	info *model.ColumnInfo
+	def  *ast.ColumnDef
+	text string
+}
+
+func (d *tableDiff) columns(tbl *model.TableInfo) ([]*column, error) {
+	var cols []*column
+	for _, info := range visibleColumns(tbl) {
+		def, err := d.columnDef(info)
+		if err != nil {
+			return nil, err
+		}
+		text, err := definitionText(def)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, &column{info: info, def: def, text: text})
+	}
+	return cols, nil
+}
+
+// diffColumns drops, renames, changes, adds and moves columns. A dropped
+// column and an added one are taken for a rename when their definitions are
+// the same, and no other dropped or added column has this definition.
+//
+// The columns which keep their relative order are the longest increasing
+// subsequence of their positions in to; the other ones are moved.
+func (d *tableDiff) diffColumns() error {
+	fromCols, err := d.columns(d.from)
+	if err != nil {
+		return err
+	}
+	toCols, err := d.columns(d.to)
+	if err != nil {
+		return err
+	}
+	toIndex := make(map[string]int, len(toCols))
+	for i, col := range toCols {
+		toIndex[col.info.Name.L] = i
+	}
+	// source maps the position of a column in to to its column in from.
+	source := make(map[int]*column, len(fromCols))
+	var removed []*column
+	for _, col := range fromCols {
+		if i, ok := toIndex[col.info.Name.L]; ok {
+			source[i] = col
+		} else {
+			removed = append(removed, col)
+		}
+	}
+	count := make(map[string]int)
+	for _, col := range removed {
+		count["-"+col.text]++
+	}
+	for i, col := range toCols {
+		if source[i] == nil {
+			count["+"+col.text]++
+		}
+	}
+	var dropped []*column
+	for _, col := range removed {
+		if count["-"+col.text] != 1 || count["+"+col.text] != 1 {
+			dropped = append(dropped, col)
+			continue
+		}
+		for i, newCol := range toCols {
+			if source[i] == nil && newCol.text == col.text {
+				source[i] = col
+				break
+			}
+		}
+	}
+	for i, col := range toCols {
+		if old := source[i]; old != nil && old.info.Name.O != col.info.Name.O {
+			d.renames[old.info.Name.L] = col.info.Name
+		}
+	}
+	for _, col := range dropped {
+		d.specs = append(d.specs, &ast.AlterTableSpec{
+			Tp:            ast.AlterTableDropColumn,
+			OldColumnName: &ast.ColumnName{Name: col.info.Name},
+		})
+	}
+
+	// Columns are added at the end of the table from the first column of
+	// the trailing run of new columns.
+	appendFrom := len(toCols)
+	for appendFrom > 0 && source[appendFrom-1] == nil {
+		appendFrom--
+	}
+	toPos := make(map[*column]int, len(source))
+	for i, col := range source {
+		toPos[col] = i
+	}
+	var order []int
+	for _, col := range fromCols {
+		if i, ok := toPos[col]; ok {
+			order = append(order, i)
+		}
+	}
+	kept := longestIncreasing(order)
+	for i, col := range toCols {
+		pos := &ast.ColumnPosition{Tp: ast.ColumnPositionNone}
+		old := source[i]
+		if (old == nil && i < appendFrom) || (old != nil && !kept[i]) {
+			pos = position(toCols, i)
+		}
+		switch {
+		case old == nil:
+			d.specs = append(d.specs, &ast.AlterTableSpec{
+				Tp:         ast.AlterTableAddColumns,
+				NewColumns: []*ast.ColumnDef{col.def},
+				Position:   pos,
+			})
+		case old.info.Name.O != col.info.Name.O && (old.text != col.text || pos.Tp != ast.ColumnPositionNone):
+			d.specs = append(d.specs, &ast.AlterTableSpec{
+				Tp:            ast.AlterTableChangeColumn,
+				OldColumnName: &ast.ColumnName{Name: old.info.Name},
+				NewColumns:    []*ast.ColumnDef{col.def},
+				Position:      pos,
+			})
+		case old.info.Name.O != col.info.Name.O:
+			d.specs = append(d.specs, &ast.AlterTableSpec{
+				Tp:            ast.AlterTableRenameColumn,
+				OldColumnName: &ast.ColumnName{Name: old.info.Name},
+				NewColumnName: &ast.ColumnName{Name: col.info.Name},
+			})
+		case old.text != col.text || pos.Tp != ast.ColumnPositionNone:
+			d.specs = append(d.specs, &ast.AlterTableSpec{
+				Tp:         ast.AlterTableModifyColumn,
+				NewColumns: []*ast.ColumnDef{col.def},
+				Position:   pos,
+			})
+		}
+	}
+	return nil
+}
+
+// longestIncreasing returns the elements of the longest increasing
+// subsequence of order.
+func longestIncreasing(order []int) map[int]bool {
+	// length[i] is the length of the longest increasing subsequence ending
+	// at order[i], and prev[i] the previous element of this subsequence.
+	length := make([]int, len(order))
+	prev := make([]int, len(order))
+	best := -1
+	for i := range order {
+		length[i], prev[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if order[j] < order[i] && length[j]+1 > length[i] {
+				length[i], prev[i] = length[j]+1, j
+			}
+		}
+		if best < 0 || length[i] > length[best] {
+			best = i
+		}
+	}
+	kept := make(map[int]bool, len(order))
+	for i := best; i >= 0; i = prev[i] {
+		kept[order[i]] = true
+	}
+	return kept
+}
+
+// position returns the position of the i-th column of to, relative to the
+// previous column.
+func position(toCols []*column, i int) *ast.ColumnPosition {
+	if i == 0 {
+		return &ast.ColumnPosition{Tp: ast.ColumnPositionFirst}
+	}
+	return &ast.ColumnPosition{
+		Tp:             ast.ColumnPositionAfter,
+		RelativeColumn: &ast.ColumnName{Name: toCols[i-1].info.Name},
+	}
+}
+
+// index is an index with its definition.
+type index struct {
+	info *model.IndexInfo
+	cst  *ast.Constraint
+	text string
+}
+
+func (d *tableDiff) indices(tbl *model.TableInfo, rename func(model.CIStr) model.CIStr) (map[string]*index, error) {
+	indices := make(map[string]*index, len(tbl.Indices))
+	for _, info := range tbl.Indices {
+		cst, err := d.indexConstraint(tbl, info, rename)
+		if err != nil {
+			return nil, err
+		}
+		text, err := restoreText(cst)
+		if err != nil {
+			return nil, err
+		}
+		indices[info.Name.L] = &index{info: info, cst: cst, text: text}
+	}
+	return indices, nil
+}
+
+// diffIndices recreates the indices which differ. An index which only
+// differs by its visibility is altered.
+func (d *tableDiff) diffIndices() error {
+	fromIndices, err := d.indices(d.from, d.rename)
+	if err != nil {
+		return err
+	}
+	toIndices, err := d.indices(d.to, keepName)
+	if err != nil {
+		return err
+	}
+	var drops []*ast.AlterTableSpec
+	for _, info := range d.from.Indices {
+		old := fromIndices[info.Name.L]
+		idx, ok := toIndices[info.Name.L]
+		if ok && idx.text == old.text {
+			if idx.info.Invisible != old.info.Invisible {
+				d.specs = append(d.specs, indexVisibility(idx.info))
+			}
+			continue
+		}
+		if info.Primary {
+			drops = append(drops, &ast.AlterTableSpec{Tp: ast.AlterTableDropPrimaryKey})
+			continue
+		}
+		d.dropIndices = append(d.dropIndices, &ast.DropIndexStmt{IndexName: info.Name.O, Table: d.name})
+	}
+	// The primary key is dropped before the columns are changed.
+	d.specs = append(drops, d.specs...)
+
+	for _, info := range d.to.Indices {
+		idx := toIndices[info.Name.L]
+		if old, ok := fromIndices[info.Name.L]; ok && old.text == idx.text {
+			continue
+		}
+		if info.Invisible {
+			if idx.cst.Option == nil {
+				idx.cst.Option = &ast.IndexOption{}
+			}
+			idx.cst.Option.Visibility = ast.IndexVisibilityInvisible
+		}
+		if info.Primary {
+			d.specs = append(d.specs, &ast.AlterTableSpec{Tp: ast.AlterTableAddConstraint, Constraint: idx.cst})
+			continue
+		}
+		stmt := &ast.CreateIndexStmt{
+			IndexName:               info.Name.O,
+			Table:                   d.name,
+			IndexPartSpecifications: idx.cst.Keys,
+			IndexOption:             idx.cst.Option,
+		}
+		if stmt.IndexOption == nil {
+			stmt.IndexOption = &ast.IndexOption{}
+		}
+		if info.Unique {
+			stmt.KeyType = ast.IndexKeyTypeUnique
+		}
+		d.createIndices = append(d.createIndices, stmt)
+	}
+	return nil
+}
+
+func indexVisibility(idx *model.IndexInfo) *ast.AlterTableSpec {
+	spec := &ast.AlterTableSpec{Tp: ast.AlterTableIndexInvisible, IndexName: idx.Name, Visibility: ast.IndexVisibilityVisible}
+	if idx.Invisible {
+		spec.Visibility = ast.IndexVisibilityInvisible
+	}
+	return spec
+}
+
+// diffChecks recreates the check constraints which differ. A constraint
+// which only differs by whether it's enforced is altered.
+func (d *tableDiff) diffChecks() error {
+	toChecks := make(map[string]*model.ConstraintInfo, len(d.to.Constraints))
+	for _, cst := range d.to.Constraints {
+		toChecks[cst.Name.L] = cst
+	}
+	fromChecks := make(map[string]*model.ConstraintInfo, len(d.from.Constraints))
+	var drops []*ast.AlterTableSpec
+	for _, old := range d.from.Constraints {
+		fromChecks[old.Name.L] = old
+		cst, ok := toChecks[old.Name.L]
+		if ok && cst.ExprString == old.ExprString {
+			if cst.Enforced != old.Enforced {
+				d.specs = append(d.specs, &ast.AlterTableSpec{
+					Tp:         ast.AlterTableAlterCheck,
+					Constraint: &ast.Constraint{Tp: ast.ConstraintCheck, Name: cst.Name.O, Enforced: cst.Enforced},
+				})
+			}
+			continue
+		}
+		drops = append(drops, &ast.AlterTableSpec{
+			Tp:         ast.AlterTableDropCheck,
+			Constraint: &ast.Constraint{Tp: ast.ConstraintCheck, Name: old.Name.O},
+		})
+	}
+	d.specs = append(drops, d.specs...)
+	for _, cst := range d.to.Constraints {
+		if old, ok := fromChecks[cst.Name.L]; ok && old.ExprString == cst.ExprString {
+			continue
+		}
+		check, err := d.checkConstraint(cst)
+		if err != nil {
+			return err
+		}
+		d.specs = append(d.specs, &ast.AlterTableSpec{Tp: ast.AlterTableAddConstraint, Constraint: check})
+	}
+	return nil
+}
+
+func foreignKeyTexts(fks []*model.FKInfo, rename func(model.CIStr) model.CIStr) (map[string]string, error) {
+	texts := make(map[string]string, len(fks))
+	for _, fk := range fks {
+		text, err := restoreText(foreignKeyConstraint(fk, rename))
+		if err != nil {
+			return nil, err
+		}
+		texts[fk.Name.L] = text
+	}
+	return texts, nil
+}
+
+// diffForeignKeys recreates the foreign keys which differ.
+func (d *tableDiff) diffForeignKeys() error {
+	fromTexts, err := foreignKeyTexts(d.from.ForeignKeys, d.rename)
+	if err != nil {
+		return err
+	}
+	toTexts, err := foreignKeyTexts(d.to.ForeignKeys, keepName)
+	if err != nil {
+		return err
+	}
+	for _, fk := range d.from.ForeignKeys {
+		if text, ok := toTexts[fk.Name.L]; !ok || text != fromTexts[fk.Name.L] {
+			d.dropForeignKeys = append(d.dropForeignKeys, &ast.AlterTableSpec{Tp: ast.AlterTableDropForeignKey, Name: fk.Name.O})
+		}
+	}
+	for _, fk := range d.to.ForeignKeys {
+		if text, ok := fromTexts[fk.Name.L]; !ok || text != toTexts[fk.Name.L] {
+			d.addForeignKeys = append(d.addForeignKeys, &ast.AlterTableSpec{
+				Tp:         ast.AlterTableAddConstraint,
+				Constraint: foreignKeyConstraint(fk, keepName),
+			})
+		}
+	}
+	return nil
+}
+
+// diffOptions changes the character set, collation, comment and compression
+// of the table.
+func (d *tableDiff) diffOptions() {
+	var options []*ast.TableOption
+	if d.from.Charset != d.to.Charset || d.from.Collate != d.to.Collate {
+		options = append(options,
+			&ast.TableOption{Tp: ast.TableOptionCharset, StrValue: d.to.Charset},
+			&ast.TableOption{Tp: ast.TableOptionCollate, StrValue: d.to.Collate})
+	}
+	if d.from.Comment != d.to.Comment {
+		options = append(options, &ast.TableOption{Tp: ast.TableOptionComment, StrValue: d.to.Comment})
+	}
+	if d.from.Compression != d.to.Compression {
+		options = append(options, &ast.TableOption{Tp: ast.TableOptionCompression, StrValue: d.to.Compression})
+	}
+	if len(options) > 0 {
+		d.specs = append(d.specs, &ast.AlterTableSpec{Tp: ast.AlterTableOption, Options: options})
+	}
+}
+
+// diffPartitions changes the partitioning of the table. Partitions added at
+// the end or dropped from RANGE and LIST partitioning, and the number of
+// HASH and KEY partitions are changed in place; other changes repartition
+// the table.
+func (d *tableDiff) diffPartitions() error {
+	from, to := d.from.Partition, d.to.Partition
+	switch {
+	case from == nil && to == nil:
+		return nil
+	case to == nil:
+		d.partition = &ast.AlterTableSpec{Tp: ast.AlterTableRemovePartitioning}
+		return nil
+	}
+	toOpts, err := d.partitionOptions(to)
+	if err != nil {
+		return err
+	}
+	repartition := &ast.AlterTableSpec{Tp: ast.AlterTablePartition, Partition: toOpts}
+	if from == nil {
+		d.partition = repartition
+		return nil
+	}
+	fromOpts, err := d.partitionOptions(from)
+	if err != nil {
+		return err
+	}
+	fromText, err := restoreText(fromOpts)
+	if err != nil {
+		return err
+	}
+	toText, err := restoreText(toOpts)
+	if err != nil {
+		return err
+	}
+	if fromText == toText {
+		return nil
+	}
+	fromMethod, err := methodText(fromOpts)
+	if err != nil {
+		return err
+	}
+	toMethod, err := methodText(toOpts)
+	if err != nil {
+		return err
+	}
+	d.partition = repartition
+	if fromMethod != toMethod {
+		return nil
+	}
+	if toOpts.Num > 0 && fromOpts.Num > 0 {
+		if toOpts.Num > fromOpts.Num {
+			d.partition = &ast.AlterTableSpec{Tp: ast.AlterTableAddPartitions, Num: toOpts.Num - fromOpts.Num}
+		} else {
+			d.partition = &ast.AlterTableSpec{Tp: ast.AlterTableCoalescePartitions, Num: fromOpts.Num - toOpts.Num}
+		}
+		return nil
+	}
+	if len(fromOpts.Definitions) == 0 || len(toOpts.Definitions) == 0 {
+		return nil
+	}
+	return d.diffPartitionDefinitions(fromOpts.Definitions, toOpts.Definitions)
+}
+
+func methodText(opts *ast.PartitionOptions) (string, error) {
+	return restoreText(&ast.PartitionOptions{PartitionMethod: ast.PartitionMethod{
+		Tp:          opts.Tp,
+		Expr:        opts.Expr,
+		ColumnNames: opts.ColumnNames,
+	}})
+}
+
+// diffPartitionDefinitions adds the partitions of to following the ones of
+// from, or drops the partitions of from missing in to.
+func (d *tableDiff) diffPartitionDefinitions(from, to []*ast.PartitionDefinition) error {
+	fromTexts, err := definitionTexts(from)
+	if err != nil {
+		return err
+	}
+	toTexts, err := definitionTexts(to)
+	if err != nil {
+		return err
+	}
+	if len(fromTexts) < len(toTexts) && isPrefix(fromTexts, toTexts) {
+		d.partition = &ast.AlterTableSpec{Tp: ast.AlterTableAddPartitions, PartDefinitions: to[len(from):]}
+		return nil
+	}
+	// Look for the partitions of to among the ones of from, in order.
+	var names []model.CIStr
+	j := 0
+	for i, text := range fromTexts {
+		if j < len(toTexts) && toTexts[j] == text {
+			j++
+			continue
+		}
+		names = append(names, from[i].Name)
+	}
+	if j == len(toTexts) {
+		d.partition = &ast.AlterTableSpec{Tp: ast.AlterTableDropPartition, PartitionNames: names}
+	}
+	return nil
+}
+
+func definitionTexts(defs []*ast.PartitionDefinition) ([]string, error) {
+	texts := make([]string, 0, len(defs))
+	for _, def := range defs {
+		text, err := restoreText(def)
+		if err != nil {
+			return nil, err
+		}
+		texts = append(texts, text)
+	}
+	return texts, nil
+}
+
+func isPrefix(prefix, texts []string) bool {
+	for i, text := range prefix {
+		if texts[i] != text {
+			return false
+		}
+	}
+	return true
+}
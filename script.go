@@ -0,0 +1,333 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pingcap/errors"
+	"github.com/kyleconroy/sqlparse/ast"
+)
+
+// DefaultDelimiter is the statement delimiter a script starts with.
+const DefaultDelimiter = ";"
+
+var (
+	// ErrDelimiterMissing is returned for a DELIMITER directive without argument.
+	ErrDelimiterMissing = errors.New("DELIMITER must be followed by a 'delimiter' character or string")
+	// ErrDelimiterBackslash is returned for a delimiter containing a backslash.
+	ErrDelimiterBackslash = errors.New("DELIMITER cannot contain a backslash character")
+)
+
+// ScriptStmt is a statement of a SQL script, as the mysql client would send
+// it to the server.
+type ScriptStmt struct {
+	// Text is the statement text, leaving out the surrounding spaces and the
+	// delimiter.
+	Text string
+	// Start and End are the byte offsets of Text in the script.
+	Start int
+	End   int
+	// Delimiter is the delimiter in effect for the statement.
+	Delimiter string
+}
+
+// SplitScript splits a SQL script into statements the way the mysql client
+// does. The statements end with the delimiter, which is ";" unless a
+// DELIMITER directive changes it. Delimiters inside strings, quoted
+// identifiers and comments don't count, and the directives are not returned
+// as statements. It fails at the first invalid DELIMITER directive.
+func SplitScript(script string) ([]*ScriptStmt, error) {
+	var stmts []*ScriptStmt
+	s := &scriptSplitter{src: script, delimiter: DefaultDelimiter}
+	for {
+		stmt, err := s.next()
+		if err != nil {
+			return stmts, errors.Trace(err)
+		}
+		if stmt == nil {
+			return stmts, nil
+		}
+		stmts = append(stmts, stmt)
+	}
+}
+
+// ParseScript parses a SQL script the way the mysql client runs it with
+// --force: it splits the script like SplitScript, parses the statements one
+// by one and goes on after a failure. It returns the statements which parsed,
+// and the errors of the ones which didn't and of the invalid DELIMITER
+// directives. The positions in the returned nodes, comments and syntax
+// errors are relative to the script.
+func (parser *Parser) ParseScript(script, charset, collation string) (stmts []ast.StmtNode, warns []error, errs []*StmtError) {
+	s := &scriptSplitter{
+		src:                script,
+		delimiter:          DefaultDelimiter,
+		noBackslashEscapes: parser.lexer.GetSQLMode().HasNoBackslashEscapesMode(),
+	}
+	lines := NewScanner(script)
+	for index := 0; ; index++ {
+		stmt, err := s.next()
+		if err != nil {
+			errs = append(errs, &StmtError{Index: index, Start: stmt.Start, End: stmt.End, Err: errors.Trace(err)})
+			continue
+		}
+		if stmt == nil {
+			break
+		}
+		nodes, w, err := parser.Parse(stmt.Text, charset, collation)
+		warns = append(warns, w...)
+		base := lines.position(stmt.Start)
+		if err != nil {
+			// The position of a SyntaxError and the one of its message
+			// follow the statement to the script.
+			if e, ok := errors.Cause(err).(*SyntaxError); ok {
+				pos := shiftPos(Pos{Line: e.Line, Col: e.Col, Offset: e.Offset}, base)
+				e.Line, e.Col, e.Offset = pos.Line, pos.Col, pos.Offset
+				e.msgPos = shiftPos(e.msgPos, base)
+			}
+			errs = append(errs, &StmtError{Index: index, Start: stmt.Start, End: stmt.End, Err: err})
+			continue
+		}
+		for _, node := range nodes {
+			shiftStmt(node, base)
+		}
+		stmts = append(stmts, nodes...)
+	}
+	return stmts, warns, errs
+}
+
+// shiftPos moves a position in the text of a statement starting at base to
+// the script.
+func shiftPos(pos, base Pos) Pos {
+	if pos.Line == 1 {
+		pos.Col += base.Col - 1
+	}
+	pos.Line += base.Line - 1
+	pos.Offset += base.Offset
+	return pos
+}
+
+// shiftStmt moves the spans and comments of a statement starting at base to
+// the script.
+func shiftStmt(stmt ast.StmtNode, base Pos) {
+	stmt.Accept(&spanShifter{base: base})
+	if comments := stmt.Comments(); len(comments) > 0 {
+		shifted := make([]ast.Comment, len(comments))
+		for i, c := range comments {
+			c.Offset += base.Offset
+			shifted[i] = c
+		}
+		stmt.SetComments(shifted)
+	}
+}
+
+type spanShifter struct {
+	base Pos
+}
+
+func (s *spanShifter) Enter(n ast.Node) (ast.Node, bool) {
+	span := n.Span()
+	if span.Start.Line == 0 {
+		return n, false
+	}
+	n.SetSpan(ast.Span{Start: s.shift(span.Start), End: s.shift(span.End)})
+	return n, false
+}
+
+func (s *spanShifter) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+func (s *spanShifter) shift(pos ast.Pos) ast.Pos {
+	return astPos(shiftPos(Pos{Line: pos.Line, Col: pos.Col, Offset: pos.Offset}, s.base))
+}
+
+// scriptSplitter cuts a script into statements.
+type scriptSplitter struct {
+	src                string
+	pos                int
+	delimiter          string
+	noBackslashEscapes bool
+}
+
+// next returns the next statement, or nil at the end of the script. For an
+// invalid DELIMITER directive, it returns the error with the directive as the
+// statement, and skips it.
+func (s *scriptSplitter) next() (*ScriptStmt, error) {
+	for {
+		s.pos = s.skipSpaces(s.pos)
+		if s.pos >= len(s.src) {
+			return nil, nil
+		}
+		if start, ok := s.directive(); ok {
+			end := strings.IndexByte(s.src[start:], '\n')
+			if end < 0 {
+				end = len(s.src)
+			} else {
+				end += start
+			}
+			stmt := s.stmt(start, end)
+			s.pos = end
+			if err := s.setDelimiter(s.src[start+len("DELIMITER") : end]); err != nil {
+				return stmt, err
+			}
+			continue
+		}
+		start := s.pos
+		end := s.scanStmt()
+		if stmt := s.stmt(start, end); stmt.Text != "" {
+			return stmt, nil
+		}
+	}
+}
+
+// stmt returns the statement between the offsets, trimmed.
+func (s *scriptSplitter) stmt(start, end int) *ScriptStmt {
+	text := strings.TrimRightFunc(s.src[start:end], unicode.IsSpace)
+	return &ScriptStmt{
+		Text:      text,
+		Start:     start,
+		End:       start + len(text),
+		Delimiter: s.delimiter,
+	}
+}
+
+func (s *scriptSplitter) skipSpaces(pos int) int {
+	for pos < len(s.src) && isSpace(s.src[pos]) {
+		pos++
+	}
+	return pos
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+// directive returns the offset of the DELIMITER directive at the start of the
+// statement, if any. Comments may precede the directive.
+func (s *scriptSplitter) directive() (int, bool) {
+	pos := s.pos
+	for pos < len(s.src) {
+		switch {
+		case strings.HasPrefix(s.src[pos:], "/*") && !strings.HasPrefix(s.src[pos:], "/*!"):
+			pos = s.skipBlockComment(pos)
+		case s.isLineComment(pos):
+			pos = s.skipLine(pos)
+		default:
+			const kw = "DELIMITER"
+			if len(s.src)-pos >= len(kw) && strings.EqualFold(s.src[pos:pos+len(kw)], kw) &&
+				(pos+len(kw) == len(s.src) || isSpace(s.src[pos+len(kw)])) {
+				return pos, true
+			}
+			return 0, false
+		}
+		pos = s.skipSpaces(pos)
+	}
+	return 0, false
+}
+
+// setDelimiter sets the delimiter from the argument of a DELIMITER directive,
+// which is its first word, or a quoted string.
+func (s *scriptSplitter) setDelimiter(arg string) error {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return ErrDelimiterMissing
+	}
+	if q := arg[0]; q == '\'' || q == '"' || q == '`' {
+		if end := strings.IndexByte(arg[1:], q); end >= 0 {
+			arg = arg[1 : end+1]
+		}
+	} else if end := strings.IndexFunc(arg, unicode.IsSpace); end >= 0 {
+		arg = arg[:end]
+	}
+	if arg == "" {
+		return ErrDelimiterMissing
+	}
+	if strings.ContainsRune(arg, '\\') {
+		return ErrDelimiterBackslash
+	}
+	s.delimiter = arg
+	return nil
+}
+
+// scanStmt scans the statement at the current position up to the delimiter.
+// It returns the offset where the statement ends, and moves past the
+// delimiter.
+func (s *scriptSplitter) scanStmt() int {
+	pos := s.pos
+	for pos < len(s.src) {
+		if strings.HasPrefix(s.src[pos:], s.delimiter) {
+			s.pos = pos + len(s.delimiter)
+			return pos
+		}
+		switch c := s.src[pos]; {
+		case c == '\'' || c == '"' || c == '`':
+			pos = s.skipQuoted(pos)
+		case strings.HasPrefix(s.src[pos:], "/*"):
+			pos = s.skipBlockComment(pos)
+		case s.isLineComment(pos):
+			pos = s.skipLine(pos)
+		default:
+			pos++
+		}
+	}
+	s.pos = pos
+	return pos
+}
+
+// skipQuoted skips a string or a quoted identifier. The quote is escaped by
+// doubling it, or by a backslash in a string.
+func (s *scriptSplitter) skipQuoted(pos int) int {
+	quote := s.src[pos]
+	for pos++; pos < len(s.src); pos++ {
+		switch c := s.src[pos]; {
+		case c == '\\' && quote != '`' && !s.noBackslashEscapes:
+			pos++
+		case c == quote:
+			if pos+1 < len(s.src) && s.src[pos+1] == quote {
+				pos++
+				continue
+			}
+			return pos + 1
+		}
+	}
+	return pos
+}
+
+func (s *scriptSplitter) skipBlockComment(pos int) int {
+	if end := strings.Index(s.src[pos+2:], "*/"); end >= 0 {
+		return pos + 2 + end + 2
+	}
+	return len(s.src)
+}
+
+// isLineComment checks for a comment running to the end of the line. Like
+// MySQL, "--" starts a comment only if a space or a control character follows.
+func (s *scriptSplitter) isLineComment(pos int) bool {
+	if s.src[pos] == '#' {
+		return true
+	}
+	if !strings.HasPrefix(s.src[pos:], "--") {
+		return false
+	}
+	return pos+2 == len(s.src) || s.src[pos+2] <= ' '
+}
+
+func (s *scriptSplitter) skipLine(pos int) int {
+	if end := strings.IndexByte(s.src[pos:], '\n'); end >= 0 {
+		return pos + end + 1
+	}
+	return len(s.src)
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"github.com/kyleconroy/sqlparse/ast"
+)
+
+// span returns the span of the text between the offsets.
+func (parser *Parser) span(start, end int) ast.Span {
+	return ast.Span{
+		Start: astPos(parser.lexer.position(start)),
+		End:   astPos(parser.lexer.position(end)),
+	}
+}
+
+func astPos(pos Pos) ast.Pos {
+	return ast.Pos{Line: pos.Line, Col: pos.Col, Offset: pos.Offset}
+}
+
+func unionSpan(a, b ast.Span) ast.Span {
+	switch {
+	case b.IsEmpty():
+		return a
+	case a.IsEmpty():
+		return b
+	}
+	if b.Start.Offset < a.Start.Offset {
+		a.Start = b.Start
+	}
+	if b.End.Offset > a.End.Offset {
+		a.End = b.End
+	}
+	return a
+}
+
+// spanFiller sets the spans of the nodes which are not the result of a
+// grammar rule, such as the nodes built inside the action of a rule. Such a
+// node gets the span covering its children, and a single child without span
+// gets the span of its parent.
+type spanFiller struct {
+	children [][]ast.Node
+}
+
+func (f *spanFiller) Enter(n ast.Node) (ast.Node, bool) {
+	f.children = append(f.children, nil)
+	return n, false
+}
+
+func (f *spanFiller) Leave(n ast.Node) (ast.Node, bool) {
+	children := f.children[len(f.children)-1]
+	f.children = f.children[:len(f.children)-1]
+	span := n.Span()
+	if span.IsEmpty() {
+		for _, child := range children {
+			span = unionSpan(span, child.Span())
+		}
+		n.SetSpan(span)
+	}
+	if len(children) == 1 && children[0].Span().IsEmpty() {
+		children[0].SetSpan(span)
+	}
+	if len(f.children) > 0 {
+		f.children[len(f.children)-1] = append(f.children[len(f.children)-1], n)
+	}
+	return n, true
+}
@@ -58,6 +58,7 @@ type ValueExpr struct {
 
 // Restore implements Node interface.
 func (n *ValueExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	switch n.Kind() {
 	case KindNull:
 		ctx.WriteKeyWord("NULL")
@@ -78,7 +79,7 @@ func (n *ValueExpr) Restore(ctx *format.RestoreCtx) error {
 	case KindFloat64:
 		ctx.WritePlain(strconv.FormatFloat(n.GetFloat64(), 'e', -1, 64))
 	case KindString:
-		if n.Type.Charset != "" {
+		if n.Type.Charset != "" && !(ctx.Flags.HasStringWithoutDefaultCharset() && n.Type.Charset == mysql.DefaultCharset) {
 			ctx.WritePlain("_")
 			ctx.WriteKeyWord(n.Type.Charset)
 		}
@@ -196,6 +197,7 @@ type ParamMarkerExpr struct {
 
 // Restore implements Node interface.
 func (n *ParamMarkerExpr) Restore(ctx *format.RestoreCtx) error {
+	defer ctx.Track(n)()
 	ctx.WritePlain("?")
 	return nil
 }
@@ -70,6 +70,22 @@ var type2Str = map[byte]string{
 	mysql.TypeYear:        "year",
 }
 
+var geometryType2Str = map[byte]string{
+	mysql.GeometryTypeGeometry:           "geometry",
+	mysql.GeometryTypePoint:              "point",
+	mysql.GeometryTypeLineString:         "linestring",
+	mysql.GeometryTypePolygon:            "polygon",
+	mysql.GeometryTypeMultiPoint:         "multipoint",
+	mysql.GeometryTypeMultiLineString:    "multilinestring",
+	mysql.GeometryTypeMultiPolygon:       "multipolygon",
+	mysql.GeometryTypeGeometryCollection: "geometrycollection",
+}
+
+// GeometryTypeStr converts the geometry type of a TypeGeometry field to a string.
+func GeometryTypeStr(tp byte) string {
+	return geometryType2Str[tp]
+}
+
 // TypeStr converts tp to a string.
 func TypeStr(tp byte) (r string) {
 	return type2Str[tp]
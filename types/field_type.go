@@ -46,6 +46,8 @@ type FieldType struct {
 	Collate string
 	// Elems is the element list for enum and set type.
 	Elems []string
+	// GeometryType is the kind of geometry of TypeGeometry, see mysql.GeometryTypeGeometry.
+	GeometryType byte
 }
 
 // NewFieldType returns a FieldType,
@@ -79,6 +81,7 @@ func (ft *FieldType) Equal(other *FieldType) bool {
 		ft.Charset == other.Charset &&
 		ft.Collate == other.Collate &&
 		flenEqual &&
+		ft.GeometryType == other.GeometryType &&
 		mysql.HasUnsignedFlag(ft.Flag) == mysql.HasUnsignedFlag(other.Flag)
 	if !partialEqual || len(ft.Elems) != len(other.Elems) {
 		return false
@@ -128,7 +131,7 @@ func (ft *FieldType) Init(tp byte) {
 // CompactStr only considers Tp/CharsetBin/Flen/Deimal.
 // This is used for showing column type in infoschema.
 func (ft *FieldType) CompactStr() string {
-	ts := TypeToStr(ft.Tp, ft.Charset)
+	ts := ft.typeStr()
 	suffix := ""
 
 	defaultFlen, defaultDecimal := mysql.GetDefaultFieldLengthAndDecimal(ft.Tp)
@@ -180,6 +183,15 @@ func (ft *FieldType) CompactStr() string {
 	return ts + suffix
 }
 
+// typeStr returns the name of the type, which is the kind of geometry for
+// TypeGeometry.
+func (ft *FieldType) typeStr() string {
+	if ft.Tp == mysql.TypeGeometry {
+		return GeometryTypeStr(ft.GeometryType)
+	}
+	return TypeToStr(ft.Tp, ft.Charset)
+}
+
 // InfoSchemaStr joins the CompactStr with unsigned flag and
 // returns a string.
 func (ft *FieldType) InfoSchemaStr() string {
@@ -218,7 +230,7 @@ func (ft *FieldType) String() string {
 
 // Restore implements Node interface.
 func (ft *FieldType) Restore(ctx *format.RestoreCtx) error {
-	ctx.WriteKeyWord(TypeToStr(ft.Tp, ft.Charset))
+	ctx.WriteKeyWord(ft.typeStr())
 
 	precision := UnspecifiedLength
 	scale := UnspecifiedLength
@@ -203,6 +203,12 @@ func (s *testFieldTypeSuite) TestFieldType(c *C) {
 	ft.Decimal = 0
 	c.Assert(ft.String(), Equals, "char(0)")
 	c.Assert(HasCharset(ft), IsTrue)
+
+	ft = NewFieldType(mysql.TypeGeometry)
+	c.Assert(ft.String(), Equals, "geometry")
+	c.Assert(HasCharset(ft), IsFalse)
+	ft.GeometryType = mysql.GeometryTypeMultiPolygon
+	c.Assert(ft.String(), Equals, "multipolygon")
 }
 
 func (s *testFieldTypeSuite) TestHasCharsetFromStmt(c *C) {
@@ -311,4 +317,10 @@ func (s *testFieldTypeSuite) TestFieldTypeEqual(c *C) {
 	ft2.Decimal = -1
 	ft1.Flen = 23
 	c.Assert(ft1.Equal(ft2), Equals, true)
+
+	// Geometry type not equal
+	ft1 = NewFieldType(mysql.TypeGeometry)
+	ft2 = NewFieldType(mysql.TypeGeometry)
+	ft2.GeometryType = mysql.GeometryTypePoint
+	c.Assert(ft1.Equal(ft2), Equals, false)
 }
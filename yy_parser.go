@@ -16,15 +16,19 @@ package parser
 import (
 	"fmt"
 	"math"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"unicode"
 
-	"github.com/pingcap/errors"
 	"github.com/kyleconroy/sqlparse/ast"
 	"github.com/kyleconroy/sqlparse/auth"
+	"github.com/kyleconroy/sqlparse/charset"
 	"github.com/kyleconroy/sqlparse/mysql"
 	"github.com/kyleconroy/sqlparse/terror"
+	"github.com/kyleconroy/sqlparse/types"
 )
 
 var (
@@ -56,6 +60,8 @@ var (
 	ErrWarnDeprecatedIntegerDisplayWidth = terror.ClassParser.NewStdErr(mysql.ErrWarnDeprecatedSyntaxNoReplacement, mysql.Message("Integer display width is deprecated and will be removed in a future release.", nil))
 	// ErrWrongUsage returns for incorrect usages.
 	ErrWrongUsage = terror.ClassParser.NewStd(mysql.ErrWrongUsage)
+	// ErrSpBadReturn returns for a RETURN statement outside of a stored function.
+	ErrSpBadReturn = terror.ClassParser.NewStd(mysql.ErrSpBadreturn)
 	// SpecFieldPattern special result field pattern
 	SpecFieldPattern = regexp.MustCompile(`(\/\*!(M?[0-9]{5,6})?|\*\/)`)
 	specCodeStart    = regexp.MustCompile(`^\/\*!(M?[0-9]{5,6})?[ \t]*`)
@@ -71,6 +77,8 @@ func TrimComment(txt string) string {
 type ParserConfig struct {
 	EnableWindowFunction        bool
 	EnableStrictDoubleTypeCheck bool
+	KeepComments                bool
+	KeepSpans                   bool
 }
 
 // Parser represents a parser instance. Some temporary objects are stored in it to reduce object allocation during Parse function.
@@ -84,6 +92,10 @@ type Parser struct {
 
 	explicitCharset       bool
 	strictDoubleFieldType bool
+	keepComments          bool
+	keepSpans             bool
+	// spans is set when the statements being parsed get their spans.
+	spans bool
 
 	// the following fields are used by yyParse to reduce allocation.
 	cache  []yySymType
@@ -100,6 +112,106 @@ func yySetOffset(yyVAL *yySymType, offset int) {
 func yyhintSetOffset(_ *yyhintSymType, _ int) {
 }
 
+// yySetSpan sets the span of yyVAL reduced from syms[1:] when the parser keeps
+// the spans. It is small enough to be inlined in yyParse.
+func yySetSpan(parser *Parser, syms []yySymType, tag string) {
+	if parser.spans {
+		yyUpdateSpan(parser, syms, tag)
+	}
+}
+
+// yyUpdateSpan sets the start and end offsets of yyVAL reduced from syms[1:],
+// syms[0] is the symbol before them. The node of yyVAL gets the span if it has
+// none yet, or extends its span if it starts at the same offset, so a node
+// built by a rule and completed by the following rules covers all of them.
+func yyUpdateSpan(parser *Parser, syms []yySymType, tag string) {
+	yyVAL := parser.yyVAL
+	start, end := -1, syms[0].endOffset
+	for i := 1; i < len(syms); i++ {
+		if syms[i].endOffset > syms[i].startOffset {
+			if start < 0 {
+				start = syms[i].startOffset
+			}
+			end = syms[i].endOffset
+		}
+	}
+	if start < 0 {
+		yyVAL.startOffset, yyVAL.endOffset = end, end
+		return
+	}
+	yyVAL.startOffset, yyVAL.endOffset = start, end
+
+	var node ast.Node
+	switch tag {
+	case "expr":
+		node = yyVAL.expr
+	case "statement":
+		node = yyVAL.statement
+	case "item":
+		node, _ = yyVAL.item.(ast.Node)
+	}
+	if node == nil {
+		return
+	}
+	if v := reflect.ValueOf(node); v.Kind() == reflect.Ptr && v.IsNil() {
+		return
+	}
+	if span := node.Span(); !span.IsEmpty() {
+		// A statement may be built by the rule of its last part, like the
+		// values of an insert statement, so it always takes the whole text.
+		if tag != "statement" && span.Start.Offset != start {
+			return
+		}
+		if span.Start.Offset < start {
+			start = span.Start.Offset
+		}
+		if span.End.Offset > end {
+			end = span.End.Offset
+		}
+		if span.Start.Offset == start && span.End.Offset == end {
+			return
+		}
+	}
+	node.SetSpan(parser.span(start, end))
+}
+
+func yyhintSetSpan(_ *hintParser, _ []yyhintSymType, _ string) {
+}
+
+// yySyntaxError reports a syntax error at the lookahead token, expected holds
+// the tokens accepted in its place.
+func yySyntaxError(yylex yyLexer, expected []string) {
+	err := yylex.Errorf("")
+	if e, ok := err.(*SyntaxError); ok {
+		e.Expected = make([]string, 0, len(expected))
+		for _, tok := range expected {
+			if text, ok := expectedTokenText[tok]; ok {
+				tok = text
+			}
+			if tok != "" {
+				e.Expected = append(e.Expected, tok)
+			}
+		}
+		sort.Strings(e.Expected)
+	}
+	yylex.AppendError(err)
+}
+
+// expectedTokenText maps the names of the tokens which are described rather
+// than spelled in the grammar to the text of SyntaxError.Expected, "" leaves
+// them out.
+var expectedTokenText = map[string]string{
+	"UNDERSCORE_CHARSET":                "character set introducer",
+	"identifier with single leading at": "user variable",
+	"identifier with double leading at": "system variable",
+	"an optimizer hint":                 "",
+	"a special token never used by parser, used by lexer to indicate error": "",
+}
+
+func yyhintSyntaxError(yylex yyhintLexer, _ []string) {
+	yylex.AppendError(yylex.Errorf(""))
+}
+
 type stmtTexter interface {
 	stmtText() string
 }
@@ -127,14 +239,145 @@ func (parser *Parser) SetStrictDoubleTypeCheck(val bool) {
 	parser.strictDoubleFieldType = val
 }
 
+// SetKeepComments controls whether the parser attaches the comments of the
+// SQL text to the parsed statements. See ast.StmtNode.Comments. The comments
+// are placed with the spans of the nodes, so the spans are kept as well.
+func (parser *Parser) SetKeepComments(val bool) {
+	parser.keepComments = val
+}
+
+// SetKeepSpans controls whether the parser sets the spans of the parsed
+// nodes. See ast.Node.Span. It is off by default, as it slows down parsing.
+func (parser *Parser) SetKeepSpans(val bool) {
+	parser.keepSpans = val
+}
+
 func (parser *Parser) SetParserConfig(config ParserConfig) {
 	parser.EnableWindowFunc(config.EnableWindowFunction)
 	parser.SetStrictDoubleTypeCheck(config.EnableStrictDoubleTypeCheck)
+	parser.SetKeepComments(config.KeepComments)
+	parser.SetKeepSpans(config.KeepSpans)
 }
 
 // Parse parses a query string to raw ast.StmtNode.
 // If charset or collation is "", default charset and collation will be used.
 func (parser *Parser) Parse(sql, charset, collation string) (stmt []ast.StmtNode, warns []error, err error) {
+	parser.reset(sql, charset, collation)
+	var l yyLexer = &parser.lexer
+	yyParse(l, parser)
+
+	warns, errs := l.Errors()
+	if len(warns) > 0 {
+		warns = append([]error(nil), warns...)
+	} else {
+		warns = nil
+	}
+	if len(errs) != 0 {
+		// The error is returned as is, so that errors.As finds a *SyntaxError.
+		return nil, warns, errs[0]
+	}
+	parser.finish()
+	return parser.result, warns, nil
+}
+
+// StmtError is the error of a statement which failed to parse in
+// ParseWithRecovery.
+type StmtError struct {
+	// Index is the index of the statement in the query string, counting the
+	// statements which failed to parse.
+	Index int
+	// Start and End are the byte offsets of the statement text, leaving out
+	// the surrounding spaces, comments and semicolons.
+	Start int
+	End   int
+	// Err is the syntax error.
+	Err error
+}
+
+// Error implements error interface.
+func (e *StmtError) Error() string {
+	return fmt.Sprintf("statement %d: %s", e.Index, e.Err.Error())
+}
+
+// Cause returns the syntax error, so that terror.ErrorEqual and errors.Cause
+// see through the statement.
+func (e *StmtError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the syntax error.
+func (e *StmtError) Unwrap() error {
+	return e.Err
+}
+
+// ParseWithRecovery parses a query string like Parse, but doesn't give up at
+// the first error. When a statement fails to parse, the parser skips the text
+// up to the next ';' and goes on with the following statement. It returns the
+// statements which parsed, and the errors of the ones which didn't.
+func (parser *Parser) ParseWithRecovery(sql, charset, collation string) (stmts []ast.StmtNode, warns []error, errs []*StmtError) {
+	parser.reset(sql, charset, collation)
+	// The comments are needed to trim the text of the failed statements.
+	parser.lexer.keepComments = true
+	for {
+		yyParse(&parser.lexer, parser)
+		_, lexErrs := parser.lexer.Errors()
+		if len(lexErrs) == 0 {
+			break
+		}
+		start := parser.lexer.stmtStartPos
+		end, more := parser.lexer.skipStmt()
+		errs = append(errs, &StmtError{
+			Index: len(parser.result) + len(errs),
+			Start: start,
+			End:   end,
+			Err:   lexErrs[0],
+		})
+		parser.lexer.errs = parser.lexer.errs[:0]
+		if !more {
+			break
+		}
+	}
+
+	raw := make([][2]int, len(errs))
+	trimmer := newStmtTrimmer(sql, parser.lexer.comments)
+	for i, e := range errs {
+		raw[i] = [2]int{e.Start, e.End}
+		e.Start, e.End = trimmer.trim(e.Start, e.End)
+	}
+	if parser.keepComments && len(errs) > 0 {
+		parser.dropComments(errs, raw)
+	}
+	if w, _ := parser.lexer.Errors(); len(w) > 0 {
+		warns = append([]error(nil), w...)
+	}
+	parser.finish()
+	return parser.result, warns, errs
+}
+
+// dropComments drops the comments of the failed statements, they belong to
+// no statement. raw holds the offsets of the failed statements before
+// trimming. A comment on the line where the previous statement ends belongs
+// to the previous statement, unless it failed too.
+func (parser *Parser) dropComments(errs []*StmtError, raw [][2]int) {
+	comments := parser.lexer.comments[:0]
+	i := 0
+	for _, c := range parser.lexer.comments {
+		for i < len(errs) && errs[i].End <= c.Offset {
+			i++
+		}
+		if i < len(errs) && raw[i][0] <= c.Offset {
+			start := raw[i][0]
+			prevFailed := start == 0 || i > 0 && start == raw[i-1][1]+1
+			if errs[i].Start <= c.Offset || prevFailed || strings.Contains(parser.src[start:c.Offset], "\n") {
+				continue
+			}
+		}
+		comments = append(comments, c)
+	}
+	parser.lexer.comments = comments
+}
+
+func (parser *Parser) reset(sql, charset, collation string) {
 	if charset == "" {
 		charset = mysql.DefaultCharset
 	}
@@ -145,25 +388,22 @@ func (parser *Parser) Parse(sql, charset, collation string) (stmt []ast.StmtNode
 	parser.collation = collation
 	parser.src = sql
 	parser.result = parser.result[:0]
-
-	var l yyLexer
 	parser.lexer.reset(sql)
-	l = &parser.lexer
-	yyParse(l, parser)
+	parser.lexer.keepComments = parser.keepComments
+	parser.spans = parser.keepSpans || parser.keepComments
+}
 
-	warns, errs := l.Errors()
-	if len(warns) > 0 {
-		warns = append([]error(nil), warns...)
-	} else {
-		warns = nil
-	}
-	if len(errs) != 0 {
-		return nil, warns, errors.Trace(errs[0])
-	}
+// finish completes the parsed statements.
+func (parser *Parser) finish() {
 	for _, stmt := range parser.result {
 		ast.SetFlag(stmt)
+		if parser.spans {
+			stmt.Accept(&spanFiller{})
+		}
+	}
+	if parser.keepComments {
+		parser.attachComments()
 	}
-	return parser.result, warns, nil
 }
 
 func (parser *Parser) lastErrorAsWarn() {
@@ -175,7 +415,7 @@ func (parser *Parser) lastErrorAsWarn() {
 func (parser *Parser) ParseOneStmt(sql, charset, collation string) (ast.StmtNode, error) {
 	stmts, _, err := parser.Parse(sql, charset, collation)
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, err
 	}
 	if len(stmts) != 1 {
 		return nil, ErrSyntax
@@ -215,6 +455,223 @@ func (parser *Parser) setLastSelectFieldText(st *ast.SelectStmt, lastEnd int) {
 	}
 }
 
+// setWithClause attaches the WITH clause to the query it precedes.
+func setWithClause(query ast.StmtNode, with *ast.WithClause) {
+	switch x := query.(type) {
+	case *ast.SelectStmt:
+		x.With = with
+	case *ast.SetOprStmt:
+		x.With = with
+	}
+}
+
+// newGeometryFieldType returns the field type of a spatial column storing
+// the geometry type.
+func newGeometryFieldType(geometryType byte) *types.FieldType {
+	x := types.NewFieldType(mysql.TypeGeometry)
+	x.GeometryType = geometryType
+	x.Charset = charset.CharsetBin
+	x.Collate = charset.CollationBin
+	return x
+}
+
+// replicationSourceOptions are the options of CHANGE REPLICATION SOURCE TO,
+// with the SOURCE spelling.
+var replicationSourceOptions = map[string]bool{
+	"ASSIGN_GTIDS_TO_ANONYMOUS_TRANSACTIONS": true,
+	"GET_SOURCE_PUBLIC_KEY":                  true,
+	"GTID_ONLY":                              true,
+	"IGNORE_SERVER_IDS":                      true,
+	"NETWORK_NAMESPACE":                      true,
+	"PRIVILEGE_CHECKS_USER":                  true,
+	"RELAY_LOG_FILE":                         true,
+	"RELAY_LOG_POS":                          true,
+	"REQUIRE_ROW_FORMAT":                     true,
+	"REQUIRE_TABLE_PRIMARY_KEY_CHECK":        true,
+	"SOURCE_AUTO_POSITION":                   true,
+	"SOURCE_BIND":                            true,
+	"SOURCE_COMPRESSION_ALGORITHMS":          true,
+	"SOURCE_CONNECT_RETRY":                   true,
+	"SOURCE_CONNECTION_AUTO_FAILOVER":        true,
+	"SOURCE_DELAY":                           true,
+	"SOURCE_HEARTBEAT_PERIOD":                true,
+	"SOURCE_HOST":                            true,
+	"SOURCE_LOG_FILE":                        true,
+	"SOURCE_LOG_POS":                         true,
+	"SOURCE_PASSWORD":                        true,
+	"SOURCE_PORT":                            true,
+	"SOURCE_PUBLIC_KEY_PATH":                 true,
+	"SOURCE_RETRY_COUNT":                     true,
+	"SOURCE_SSL":                             true,
+	"SOURCE_SSL_CA":                          true,
+	"SOURCE_SSL_CAPATH":                      true,
+	"SOURCE_SSL_CERT":                        true,
+	"SOURCE_SSL_CIPHER":                      true,
+	"SOURCE_SSL_CRL":                         true,
+	"SOURCE_SSL_CRLPATH":                     true,
+	"SOURCE_SSL_KEY":                         true,
+	"SOURCE_SSL_VERIFY_SERVER_CERT":          true,
+	"SOURCE_TLS_CIPHERSUITES":                true,
+	"SOURCE_TLS_VERSION":                     true,
+	"SOURCE_USER":                            true,
+	"SOURCE_ZSTD_COMPRESSION_LEVEL":          true,
+}
+
+// replicaUntilOptions are the options of the UNTIL clause of START REPLICA,
+// with the SOURCE spelling.
+var replicaUntilOptions = map[string]bool{
+	"RELAY_LOG_FILE":     true,
+	"RELAY_LOG_POS":      true,
+	"SOURCE_LOG_FILE":    true,
+	"SOURCE_LOG_POS":     true,
+	"SQL_AFTER_GTIDS":    true,
+	"SQL_AFTER_MTS_GAPS": true,
+	"SQL_BEFORE_GTIDS":   true,
+}
+
+// newReplicationOption checks the name of a replication option against the
+// valid names, which accept both the MASTER and the SOURCE spelling. It
+// returns the error message if the option is invalid.
+func newReplicationOption(valid map[string]bool, name string, value *ast.ReplicationOption) (*ast.ReplicationOption, string) {
+	name = strings.ToUpper(name)
+	if !valid[strings.Replace(name, "MASTER_", "SOURCE_", 1)] {
+		return nil, fmt.Sprintf("Unknown replication option %s", name)
+	}
+	if (value.Tp == ast.ReplicationOptionNone) != (name == "SQL_AFTER_MTS_GAPS") {
+		return nil, fmt.Sprintf("Invalid value for replication option %s", name)
+	}
+	value.Name = name
+	return value, ""
+}
+
+// markLocalVariables marks the assignments in the body of a stored program
+// which set one of the routine parameters or a local variable declared in an
+// enclosing block. SET uses the same syntax for them and for the system
+// variables, so the grammar cannot tell them apart.
+func markLocalVariables(body ast.StmtNode, params []*ast.RoutineParam) {
+	names := make(map[string]bool, len(params))
+	for _, param := range params {
+		names[param.Name.L] = true
+	}
+	body.Accept(&localVarMarker{scopes: []map[string]bool{names}})
+}
+
+// localVarMarker keeps the names of the local variables in scope, one set
+// per enclosing block.
+type localVarMarker struct {
+	scopes []map[string]bool
+}
+
+func (m *localVarMarker) Enter(n ast.Node) (ast.Node, bool) {
+	switch x := n.(type) {
+	case *ast.BlockStmt:
+		m.scopes = append(m.scopes, make(map[string]bool))
+	case *ast.DeclareVarStmt:
+		for _, name := range x.Names {
+			m.scopes[len(m.scopes)-1][name.L] = true
+		}
+	case *ast.SetStmt:
+		for _, v := range x.Variables {
+			if v.IsSystem && !v.IsGlobal && m.inScope(strings.ToLower(v.Name)) {
+				v.IsSystem = false
+				v.IsLocal = true
+			}
+		}
+	}
+	return n, false
+}
+
+func (m *localVarMarker) Leave(n ast.Node) (ast.Node, bool) {
+	if _, ok := n.(*ast.BlockStmt); ok {
+		m.scopes = m.scopes[:len(m.scopes)-1]
+	}
+	return n, true
+}
+
+func (m *localVarMarker) inScope(name string) bool {
+	for _, scope := range m.scopes {
+		if scope[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasReturn reports whether the body of a stored program has a RETURN
+// statement, which only a stored function may have.
+func hasReturn(body ast.StmtNode) bool {
+	finder := &returnFinder{}
+	body.Accept(finder)
+	return finder.found
+}
+
+type returnFinder struct {
+	found bool
+}
+
+func (f *returnFinder) Enter(n ast.Node) (ast.Node, bool) {
+	if _, ok := n.(*ast.ReturnStmt); ok {
+		f.found = true
+	}
+	return n, f.found
+}
+
+func (f *returnFinder) Leave(n ast.Node) (ast.Node, bool) {
+	return n, !f.found
+}
+
+// markTriggerFields replaces the NEW.col and OLD.col references in the body of
+// a trigger, which the grammar parses as column names and system variables,
+// with references to the row the trigger is activated for.
+func markTriggerFields(body ast.StmtNode) {
+	body.Accept(&triggerFieldMarker{})
+}
+
+// triggerFieldMarker rewrites NEW.col and OLD.col references.
+type triggerFieldMarker struct{}
+
+func triggerRowOf(name string) ast.TriggerRow {
+	switch strings.ToLower(name) {
+	case "new":
+		return ast.TriggerRowNew
+	case "old":
+		return ast.TriggerRowOld
+	}
+	return ast.TriggerRowNone
+}
+
+func (m *triggerFieldMarker) Enter(n ast.Node) (ast.Node, bool) {
+	if x, ok := n.(*ast.SetStmt); ok {
+		for _, v := range x.Variables {
+			i := strings.IndexByte(v.Name, '.')
+			if !v.IsSystem || v.IsGlobal || i < 0 {
+				continue
+			}
+			if row := triggerRowOf(v.Name[:i]); row != ast.TriggerRowNone {
+				v.Name = v.Name[i+1:]
+				v.IsSystem = false
+				v.TriggerRow = row
+			}
+		}
+	}
+	return n, false
+}
+
+func (m *triggerFieldMarker) Leave(n ast.Node) (ast.Node, bool) {
+	col, ok := n.(*ast.ColumnNameExpr)
+	if !ok || col.Name.Schema.L != "" {
+		return n, true
+	}
+	row := triggerRowOf(col.Name.Table.L)
+	if row == ast.TriggerRowNone {
+		return n, true
+	}
+	field := &ast.TriggerFieldExpr{Row: row, Name: col.Name.Name}
+	field.SetText(col.Text())
+	field.SetSpan(col.Span())
+	return field, true
+}
+
 func (parser *Parser) startOffset(v *yySymType) int {
 	return v.offset
 }